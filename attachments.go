@@ -0,0 +1,474 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// allowedAttachmentTypes is the allowlist of sniffed MIME types that may be
+// uploaded. The type is always sniffed from the content, never taken from the
+// client's Content-Type or the filename.
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":                true,
+	"image/png":                 true,
+	"image/gif":                 true,
+	"image/webp":                true,
+	"application/pdf":           true,
+	"text/plain; charset=utf-8": true,
+}
+
+// sniffAttachmentType returns the MIME type of data and whether it may be uploaded.
+func sniffAttachmentType(data []byte) (string, bool) {
+	contentType := http.DetectContentType(data)
+	return contentType, allowedAttachmentTypes[contentType]
+}
+
+// isImageType reports whether contentType is an image that can be shown inline.
+func isImageType(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/")
+}
+
+var errMalformedImage = errors.New("malformed image")
+
+// stripMetadata removes EXIF, XMP and similar metadata (camera details, GPS
+// coordinates, comments) from images without re-encoding them. Other types
+// are returned unchanged.
+func stripMetadata(contentType string, data []byte) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEGMetadata(data)
+	case "image/png":
+		return stripPNGMetadata(data)
+	case "image/webp":
+		return stripWebPMetadata(data)
+	case "image/gif":
+		return stripGIFMetadata(data)
+	default:
+		return data, nil
+	}
+}
+
+// stripJPEGMetadata drops APP1 (EXIF/XMP), APP13 (IPTC) and COM segments.
+// APP0 (JFIF), APP2 (ICC profile) and APP14 (Adobe) are kept since they
+// affect how the image is decoded and displayed.
+func stripJPEGMetadata(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errMalformedImage
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+
+	i := 2
+	for {
+		if i+4 > len(data) || data[i] != 0xFF {
+			return nil, errMalformedImage
+		}
+		marker := data[i+1]
+
+		// Start of scan: the rest is entropy-coded image data
+		if marker == 0xDA {
+			return append(out, data[i:]...), nil
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, errMalformedImage
+		}
+
+		switch marker {
+		case 0xE1, 0xED, 0xFE: // APP1, APP13, COM
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+}
+
+// pngMetadataChunks are the ancillary PNG chunks that carry metadata.
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	const signature = "\x89PNG\r\n\x1a\n"
+	if !bytes.HasPrefix(data, []byte(signature)) {
+		return nil, errMalformedImage
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, signature...)
+
+	for i := len(signature); i < len(data); {
+		if i+8 > len(data) {
+			return nil, errMalformedImage
+		}
+		length := int(binary.BigEndian.Uint32(data[i : i+4]))
+		chunkType := string(data[i+4 : i+8])
+		// length, type, data, crc
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, errMalformedImage
+		}
+
+		if !pngMetadataChunks[chunkType] {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+
+		if chunkType == "IEND" {
+			break
+		}
+	}
+
+	return out, nil
+}
+
+// stripWebPMetadata drops the EXIF and XMP chunks of an extended WebP and
+// clears their flags in the VP8X header.
+func stripWebPMetadata(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errMalformedImage
+	}
+
+	const (
+		vp8xXMPFlag  = 0x04
+		vp8xEXIFFlag = 0x08
+	)
+
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, errMalformedImage
+		}
+		fourCC := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4 : i+8]))
+		// Chunks are padded to an even size
+		end := i + 8 + size + size%2
+		if size < 0 || end > len(data) {
+			return nil, errMalformedImage
+		}
+
+		switch fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			start := len(out)
+			out = append(out, data[i:end]...)
+			if size > 0 {
+				out[start+8] &^= vp8xEXIFFlag | vp8xXMPFlag
+			}
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out, nil
+}
+
+// gifKeptApplications are the GIF application extensions that affect how the
+// image plays: animation looping, and the ICC colour profile.
+var gifKeptApplications = map[string]bool{
+	"NETSCAPE2.0": true,
+	"ANIMEXTS1.0": true,
+	"ICCRGBG1012": true,
+}
+
+// stripGIFMetadata drops comment extensions and application extensions other
+// than those in gifKeptApplications, such as XMP.
+func stripGIFMetadata(data []byte) ([]byte, error) {
+	const headerSize = 13 // signature, version and logical screen descriptor
+	if len(data) < headerSize || (string(data[0:6]) != "GIF87a" && string(data[0:6]) != "GIF89a") {
+		return nil, errMalformedImage
+	}
+
+	// colorTableSize returns the size of the colour table a packed field
+	// announces
+	colorTableSize := func(packed byte) int {
+		if packed&0x80 == 0 {
+			return 0
+		}
+		return 3 << (packed&0x07 + 1)
+	}
+
+	// subBlocksEnd returns the end of the data sub-blocks starting at i
+	subBlocksEnd := func(i int) (int, error) {
+		for {
+			if i >= len(data) {
+				return 0, errMalformedImage
+			}
+			size := int(data[i])
+			i++
+			if size == 0 {
+				return i, nil
+			}
+			i += size
+		}
+	}
+
+	i := headerSize + colorTableSize(data[10])
+	if i > len(data) {
+		return nil, errMalformedImage
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:i]...)
+
+	for {
+		if i >= len(data) {
+			return nil, errMalformedImage
+		}
+
+		switch data[i] {
+		case 0x3B: // trailer
+			return append(out, data[i]), nil
+
+		case 0x21: // extension
+			if i+2 > len(data) {
+				return nil, errMalformedImage
+			}
+			end, err := subBlocksEnd(i + 2)
+			if err != nil {
+				return nil, err
+			}
+
+			keep := true
+			switch data[i+1] {
+			case 0xFE: // comment
+				keep = false
+			case 0xFF: // application
+				// The first sub-block holds the 11 byte application identifier
+				keep = i+3+11 <= end && data[i+2] == 11 && gifKeptApplications[string(data[i+3:i+3+11])]
+			}
+			if keep {
+				out = append(out, data[i:end]...)
+			}
+			i = end
+
+		case 0x2C: // image descriptor, local colour table, then image data
+			if i+10 > len(data) {
+				return nil, errMalformedImage
+			}
+			// Skip the LZW minimum code size before the data sub-blocks
+			start := i + 10 + colorTableSize(data[i+9]) + 1
+			if start > len(data) {
+				return nil, errMalformedImage
+			}
+			end, err := subBlocksEnd(start)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, data[i:end]...)
+			i = end
+
+		default:
+			return nil, errMalformedImage
+		}
+	}
+}
+
+// attachmentLinkPattern matches links to uploaded files in a rendered post
+var attachmentLinkPattern = regexp.MustCompile(`/file/([0-9a-f]{64})\b`)
+
+// attachmentHashes returns the hashes of the uploaded files a rendered post
+// links or embeds. Posts are stored with them so the files are served to
+// whoever can see the post.
+func attachmentHashes(body string) []string {
+	var hashes []string
+	for _, match := range attachmentLinkPattern.FindAllStringSubmatch(body, -1) {
+		if !slices.Contains(hashes, match[1]) {
+			hashes = append(hashes, match[1])
+		}
+	}
+	return hashes
+}
+
+// attachmentMarkdown is the Markdown inserted into a post for an upload:
+// images are embedded and everything else is linked.
+func attachmentMarkdown(filename, contentType, url string) string {
+	label := strings.NewReplacer("[", "", "]", "", "\n", " ").Replace(filename)
+	if label == "" {
+		label = "attachment"
+	}
+	if isImageType(contentType) {
+		return fmt.Sprintf("![%s](%s)", label, url)
+	}
+	return fmt.Sprintf("[%s](%s)", label, url)
+}
+
+// UploadAttachment stores an uploaded file and responds with JSON containing
+// its URL and the Markdown to insert into a post. The body size is capped by
+// the upload chain's requestSizeLimitMiddleware.
+func (s *DiscussService) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "UploadAttachment")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	user, err := GetUser(r)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "GetUser", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			s.renderError(w, http.StatusRequestEntityTooLarge)
+			return
+		}
+		s.logger.DebugContext(r.Context(), "error reading upload", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error reading upload", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	contentType, ok := sniffAttachmentType(data)
+	if !ok {
+		s.logger.DebugContext(r.Context(), "upload type not allowed", slog.String("content_type", contentType))
+		s.renderError(w, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	data, err = stripMetadata(contentType, data)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error stripping metadata", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	span.AddEvent("blobs.Put")
+	if err := s.blobs.Put(r.Context(), hash, data, contentType); err != nil {
+		s.logger.ErrorContext(r.Context(), "error storing upload", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	filename := filepath.Base(header.Filename)
+	if utf8.RuneCountInString(filename) > MaxNameLength {
+		filename = string([]rune(filename)[:MaxNameLength])
+	}
+
+	span.AddEvent("queries.CreateAttachment")
+	if err := s.queries.CreateAttachment(r.Context(), CreateAttachmentParams{
+		Hash:        hash,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Filename:    filename,
+		MemberID:    user.ID,
+	}); err != nil {
+		s.logger.ErrorContext(r.Context(), "CreateAttachment", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	url := "/file/" + hash
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{
+		"url":      url,
+		"markdown": attachmentMarkdown(filename, contentType, url),
+	}); err != nil {
+		s.logger.ErrorContext(r.Context(), "error writing upload response", slog.String("error", err.Error()))
+	}
+}
+
+// ServeAttachment serves an uploaded file to the members who uploaded it and
+// to members who can see a post linking it; to anyone else it doesn't exist.
+// Images are shown inline; everything else is served as a download.
+func (s *DiscussService) ServeAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "ServeAttachment")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	hash := r.PathValue("hash")
+	if !blobHashPattern.MatchString(hash) {
+		s.renderError(w, http.StatusNotFound)
+		return
+	}
+
+	span.AddEvent("queries.GetAttachment")
+	attachment, err := s.queries.GetAttachment(r.Context(), hash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "GetAttachment", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	etag := `"` + hash + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	span.AddEvent("blobs.Open")
+	blob, err := s.blobs.Open(r.Context(), hash)
+	if err != nil {
+		if errors.Is(err, ErrBlobNotFound) {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "error opening blob", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+	defer blob.Close()
+
+	disposition := "attachment"
+	if isImageType(attachment.ContentType) {
+		disposition = "inline"
+	}
+	if attachment.Filename != "" {
+		disposition = mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename})
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", attachment.Size))
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	w.Header().Set("ETag", etag)
+
+	if _, err := io.Copy(w, blob); err != nil {
+		s.logger.ErrorContext(r.Context(), "error serving attachment", slog.String("error", err.Error()))
+	}
+}
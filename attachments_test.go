@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 60), G: uint8(y * 60), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func TestSniffAttachmentType(t *testing.T) {
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, testImage()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		allowed bool
+	}{
+		{"PNG", pngData.Bytes(), "image/png", true},
+		{"PDF", []byte("%PDF-1.7\n"), "application/pdf", true},
+		{"Plain text", []byte("hello world"), "text/plain; charset=utf-8", true},
+		{"HTML", []byte("<!DOCTYPE html><script>alert(1)</script>"), "text/html; charset=utf-8", false},
+		{"ZIP", []byte("PK\x03\x04rest"), "application/zip", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, allowed := sniffAttachmentType(tt.data)
+			if got != tt.want || allowed != tt.allowed {
+				t.Errorf("sniffAttachmentType() = %q, %v, want %q, %v", got, allowed, tt.want, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestStripJPEGMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+	clean := buf.Bytes()
+
+	exif := []byte("Exif\x00\x00GPS 37.7749 N 122.4194 W")
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(exif)+2))
+	segment = append(segment, exif...)
+
+	tagged := append([]byte{}, clean[:2]...)
+	tagged = append(tagged, segment...)
+	tagged = append(tagged, clean[2:]...)
+
+	stripped, err := stripMetadata("image/jpeg", tagged)
+	if err != nil {
+		t.Fatalf("stripMetadata() error = %v", err)
+	}
+	if bytes.Contains(stripped, []byte("GPS")) {
+		t.Error("EXIF segment was not removed")
+	}
+	if !bytes.Equal(stripped, clean) {
+		t.Error("stripping changed more than the EXIF segment")
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Errorf("stripped JPEG does not decode: %v", err)
+	}
+}
+
+func TestStripPNGMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	clean := buf.Bytes()
+
+	text := []byte("Comment\x00taken at home")
+	chunk := make([]byte, 8, 12+len(text))
+	binary.BigEndian.PutUint32(chunk[0:4], uint32(len(text)))
+	copy(chunk[4:8], "tEXt")
+	chunk = append(chunk, text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	// Insert the text chunk right after the IHDR chunk
+	ihdrEnd := 8 + 12 + 13
+	tagged := append([]byte{}, clean[:ihdrEnd]...)
+	tagged = append(tagged, chunk...)
+	tagged = append(tagged, clean[ihdrEnd:]...)
+
+	if _, err := png.Decode(bytes.NewReader(tagged)); err != nil {
+		t.Fatalf("tagged PNG does not decode: %v", err)
+	}
+
+	stripped, err := stripMetadata("image/png", tagged)
+	if err != nil {
+		t.Fatalf("stripMetadata() error = %v", err)
+	}
+	if !bytes.Equal(stripped, clean) {
+		t.Error("tEXt chunk was not removed cleanly")
+	}
+}
+
+func TestStripWebPMetadata(t *testing.T) {
+	chunk := func(fourCC string, data []byte) []byte {
+		out := []byte(fourCC)
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(data)))
+		out = append(out, data...)
+		if len(data)%2 == 1 {
+			out = append(out, 0)
+		}
+		return out
+	}
+	riff := func(chunks ...[]byte) []byte {
+		body := []byte("WEBP")
+		for _, c := range chunks {
+			body = append(body, c...)
+		}
+		out := []byte("RIFF")
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(body)))
+		return append(out, body...)
+	}
+
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x08 | 0x04 | 0x10 // EXIF, XMP and alpha
+	bitstream := chunk("VP8L", []byte{1, 2, 3})
+
+	tagged := riff(chunk("VP8X", vp8x), bitstream, chunk("EXIF", []byte("GPS data")), chunk("XMP ", []byte("<x/>")))
+
+	cleanVP8X := make([]byte, 10)
+	cleanVP8X[0] = 0x10
+	want := riff(chunk("VP8X", cleanVP8X), bitstream)
+
+	got, err := stripMetadata("image/webp", tagged)
+	if err != nil {
+		t.Fatalf("stripMetadata() error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("stripMetadata() = %q, want %q", got, want)
+	}
+}
+
+func TestStripGIFMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	frame := image.NewPaletted(image.Rect(0, 0, 4, 4), palette.Plan9)
+	// Two frames, so the encoder writes the NETSCAPE2.0 loop extension
+	if err := gif.EncodeAll(&buf, &gif.GIF{
+		Image: []*image.Paletted{frame, frame},
+		Delay: []int{10, 10},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	clean := buf.Bytes()
+
+	subBlocks := func(data string) []byte {
+		var out []byte
+		for len(data) > 0 {
+			n := min(len(data), 255)
+			out = append(out, byte(n))
+			out = append(out, data[:n]...)
+			data = data[n:]
+		}
+		return append(out, 0)
+	}
+	comment := append([]byte{0x21, 0xFE}, subBlocks("taken at home")...)
+	xmp := append([]byte{0x21, 0xFF, 11}, "XMP DataXMP"...)
+	xmp = append(xmp, subBlocks(`<x:xmpmeta xmlns:x="adobe:ns:meta/"/>`)...)
+
+	// Insert the extensions right after the header and global colour table,
+	// ahead of the NETSCAPE2.0 loop extension, which is kept
+	headerEnd := 13
+	if clean[10]&0x80 != 0 {
+		headerEnd += 3 << (clean[10]&0x07 + 1)
+	}
+	if !bytes.Contains(clean[headerEnd:], []byte("NETSCAPE2.0")) {
+		t.Fatal("encoded GIF has no loop extension")
+	}
+	tagged := append([]byte{}, clean[:headerEnd]...)
+	tagged = append(tagged, comment...)
+	tagged = append(tagged, xmp...)
+	tagged = append(tagged, clean[headerEnd:]...)
+
+	if _, err := gif.DecodeAll(bytes.NewReader(tagged)); err != nil {
+		t.Fatalf("tagged GIF does not decode: %v", err)
+	}
+
+	stripped, err := stripMetadata("image/gif", tagged)
+	if err != nil {
+		t.Fatalf("stripMetadata() error = %v", err)
+	}
+	if !bytes.Equal(stripped, clean) {
+		t.Error("comment and XMP extensions were not removed cleanly")
+	}
+}
+
+func TestStripMetadataMalformed(t *testing.T) {
+	for _, contentType := range []string{"image/jpeg", "image/png", "image/webp", "image/gif"} {
+		if _, err := stripMetadata(contentType, []byte("not an image")); err == nil {
+			t.Errorf("stripMetadata(%q) accepted malformed data", contentType)
+		}
+	}
+
+	text := []byte("plain text is left alone")
+	got, err := stripMetadata("text/plain; charset=utf-8", text)
+	if err != nil || !bytes.Equal(got, text) {
+		t.Errorf("stripMetadata() changed a text attachment")
+	}
+}
+
+func TestAttachmentMarkdown(t *testing.T) {
+	tests := []struct {
+		filename    string
+		contentType string
+		want        string
+	}{
+		{"cat.png", "image/png", "![cat.png](/file/abc)"},
+		{"notes.pdf", "application/pdf", "[notes.pdf](/file/abc)"},
+		{"[evil](x).txt", "text/plain; charset=utf-8", "[evil(x).txt](/file/abc)"},
+		{"", "image/gif", "![attachment](/file/abc)"},
+	}
+
+	for _, tt := range tests {
+		if got := attachmentMarkdown(tt.filename, tt.contentType, "/file/abc"); got != tt.want {
+			t.Errorf("attachmentMarkdown(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestAttachmentHashes(t *testing.T) {
+	a := strings.Repeat("a", 64)
+	b := strings.Repeat("b", 64)
+	body := `<p><img src="/file/` + a + `" alt="cat.png"> <a href="/file/` + b + `">notes.pdf</a> <a href="/file/` + a + `">again</a> /file/` + strings.Repeat("c", 63) + `</p>`
+
+	if got := attachmentHashes(body); !slices.Equal(got, []string{a, b}) {
+		t.Errorf("attachmentHashes() = %q, want %q", got, []string{a, b})
+	}
+	if got := attachmentHashes("<p>no files</p>"); got != nil {
+		t.Errorf("attachmentHashes() = %q, want none", got)
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imeyer/tdiscuss/middleware"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Actions recorded in the audit log.
+const (
+	auditBlockMember      = "block_member"
+	auditCreateBoard      = "create_board"
+	auditUpdateConfig     = "update_config"
+	auditUpdateRateLimits = "update_rate_limits"
+	auditApprovePost      = "approve_post"
+	auditRejectPost       = "reject_post"
+	auditDismissReports   = "dismiss_reports"
+	auditHidePost         = "hide_post"
+	auditDeletePost       = "delete_post"
+	auditLockThread       = "lock_thread"
+	auditRenameTag        = "rename_tag"
+	auditMergeTag         = "merge_tag"
+)
+
+// Kinds of thing an audited action can target.
+const (
+	auditTargetMember     = "member"
+	auditTargetThread     = "thread"
+	auditTargetPost       = "post"
+	auditTargetBoard      = "board"
+	auditTargetRateLimits = "rate_limits"
+	auditTargetTag        = "tag"
+)
+
+const (
+	// auditPageSize is how many entries /admin/audit shows at a time
+	auditPageSize = 100
+
+	// auditExportBatch is how many entries the JSONL export reads per query
+	auditExportBatch = 1000
+)
+
+// auditEntry is one admin action to record. Before and After are marshalled
+// to JSON; either may be nil.
+type auditEntry struct {
+	Action     string
+	TargetType string
+	TargetID   int64
+	Before     any
+	After      any
+}
+
+// recordAudit writes an admin action to the audit log along with the
+// request and trace it came from. The action has already happened, so a
+// failure to record it is logged rather than failing the request.
+func (s *DiscussService) recordAudit(r *http.Request, actor User, e auditEntry) {
+	arg := CreateAuditLogParams{
+		ActorID:    actor.ID,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		RequestID:  middleware.GetRequestID(r.Context()),
+		TraceID:    middleware.GetTraceID(r.Context()),
+	}
+	if e.TargetID != 0 {
+		arg.TargetID = strconv.FormatInt(e.TargetID, 10)
+	}
+	if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+		arg.TraceID = sc.TraceID().String()
+	}
+
+	var err error
+	if arg.Before, err = auditJSON(e.Before); err == nil {
+		arg.After, err = auditJSON(e.After)
+	}
+	if err == nil {
+		err = s.queries.CreateAuditLog(r.Context(), arg)
+	}
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "failed to record audit log",
+			slog.String("action", e.Action),
+			slog.Int64("admin_id", actor.ID),
+			slog.String("error", err.Error()))
+	}
+}
+
+// auditJSON marshals a before or after value, leaving nil as SQL NULL.
+func auditJSON(v any) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// auditFlag is the before or after value of an action that sets one flag.
+func auditFlag(name string, value bool) map[string]bool {
+	return map[string]bool{name: value}
+}
+
+// boardConfigSnapshot is the board configuration recorded around
+// create_board and update_config.
+type boardConfigSnapshot struct {
+	Slug          string   `json:"slug"`
+	Title         string   `json:"title"`
+	Description   string   `json:"description"`
+	EditWindow    int32    `json:"edit_window"`
+	ReactionEmoji []string `json:"reaction_emoji"`
+	HideEmails    bool     `json:"hide_emails"`
+	ACL           []string `json:"acl,omitempty"`
+}
+
+func newBoardConfigSnapshot(board GetBoardDataRow) boardConfigSnapshot {
+	return boardConfigSnapshot{
+		Slug:          board.Slug,
+		Title:         board.Title,
+		Description:   board.Description,
+		EditWindow:    board.EditWindow.Int32,
+		ReactionEmoji: board.ReactionEmoji,
+		HideEmails:    board.HideEmails,
+		ACL:           board.Acl,
+	}
+}
+
+// tagSnapshot is a tag recorded around rename_tag and merge_tag.
+type tagSnapshot struct {
+	Name string `json:"name"`
+}
+
+// rateLimitSnapshot is a role's limit recorded around update_rate_limits.
+type rateLimitSnapshot struct {
+	Requests      int32 `json:"requests"`
+	WindowSeconds int32 `json:"window_seconds"`
+}
+
+func newRateLimitSnapshots(rows []RateLimit) map[string]rateLimitSnapshot {
+	limits := make(map[string]rateLimitSnapshot, len(rows))
+	for _, row := range rows {
+		limits[row.Role] = rateLimitSnapshot{Requests: row.Requests, WindowSeconds: row.WindowSeconds}
+	}
+	return limits
+}
+
+// AuditLogEntry is an audit log row as shown on /admin/audit and written by
+// the JSONL export.
+type AuditLogEntry struct {
+	ID         int64           `json:"id"`
+	Date       time.Time       `json:"date"`
+	ActorID    int64           `json:"actor_id"`
+	ActorEmail string          `json:"actor_email"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type,omitempty"`
+	TargetID   string          `json:"target_id,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	TraceID    string          `json:"trace_id,omitempty"`
+}
+
+func newAuditLogEntry(row ListAuditLogRow) AuditLogEntry {
+	return AuditLogEntry{
+		ID:         row.ID,
+		Date:       row.DateCreated.Time.UTC(),
+		ActorID:    row.ActorID,
+		ActorEmail: row.ActorEmail.String,
+		Action:     row.Action,
+		TargetType: row.TargetType,
+		TargetID:   row.TargetID,
+		Before:     row.Before,
+		After:      row.After,
+		RequestID:  row.RequestID,
+		TraceID:    row.TraceID,
+	}
+}
+
+// parseAuditFilter reads the /admin/audit filters from a query string. Dates
+// are whole days: since is inclusive, and so is until, which covers the
+// whole of that day.
+func parseAuditFilter(query url.Values) (ListAuditLogParams, error) {
+	var arg ListAuditLogParams
+
+	if v := strings.TrimSpace(query.Get("actor")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return arg, fmt.Errorf("invalid actor %q", v)
+		}
+		arg.ActorID = pgtype.Int8{Int64: id, Valid: true}
+	}
+
+	if v := strings.TrimSpace(query.Get("action")); v != "" {
+		arg.Action = pgtype.Text{String: v, Valid: true}
+	}
+	if v := strings.TrimSpace(query.Get("target_type")); v != "" {
+		arg.TargetType = pgtype.Text{String: v, Valid: true}
+	}
+	if v := strings.TrimSpace(query.Get("target_id")); v != "" {
+		arg.TargetID = pgtype.Text{String: v, Valid: true}
+	}
+
+	if v := strings.TrimSpace(query.Get("since")); v != "" {
+		since, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return arg, fmt.Errorf("invalid since date %q", v)
+		}
+		arg.Since = pgtype.Timestamptz{Time: since, Valid: true}
+	}
+	if v := strings.TrimSpace(query.Get("until")); v != "" {
+		until, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return arg, fmt.Errorf("invalid until date %q", v)
+		}
+		arg.Until = pgtype.Timestamptz{Time: until.AddDate(0, 0, 1), Valid: true}
+	}
+
+	if v := strings.TrimSpace(query.Get("before")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return arg, fmt.Errorf("invalid before %q", v)
+		}
+		arg.BeforeID = pgtype.Int8{Int64: id, Valid: true}
+	}
+
+	return arg, nil
+}
+
+// AdminAudit shows the audit log, newest first, filtered by the query string.
+func (s *DiscussService) AdminAudit(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "AdminAudit")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	user, err := GetUser(r)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "GetUser", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !user.IsAdmin {
+		s.renderError(w, http.StatusForbidden)
+		return
+	}
+
+	arg, err := parseAuditFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	arg.RowLimit = auditPageSize
+
+	span.AddEvent("queries.ListAuditLog")
+	rows, err := s.queries.ListAuditLog(r.Context(), arg)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "ListAuditLog", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	entries := make([]AuditLogEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, newAuditLogEntry(row))
+	}
+
+	// The export covers every matching entry, not just this page
+	filter := r.URL.Query()
+	filter.Del("before")
+
+	var olderURL string
+	if len(entries) == auditPageSize {
+		older := r.URL.Query()
+		older.Set("before", strconv.FormatInt(entries[len(entries)-1].ID, 10))
+		olderURL = "/admin/audit?" + older.Encode()
+	}
+
+	s.renderTemplate(w, r, "audit.html", map[string]interface{}{
+		"Title":            GetBoardTitle(r),
+		"User":             user,
+		"CurrentUserEmail": user.Email,
+		"Version":          s.version,
+		"GitSha":           s.gitSha,
+		"Entries":          entries,
+		"Filter":           r.URL.Query(),
+		"ExportURL":        "/admin/audit/export.jsonl?" + filter.Encode(),
+		"OlderURL":         olderURL,
+	})
+}
+
+// AdminAuditExport streams every audit log entry matching the query string
+// as JSON lines, newest first.
+func (s *DiscussService) AdminAuditExport(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "AdminAuditExport")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	user, err := GetUser(r)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "GetUser", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !user.IsAdmin {
+		s.renderError(w, http.StatusForbidden)
+		return
+	}
+
+	arg, err := parseAuditFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	arg.RowLimit = auditExportBatch
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-log.jsonl"`)
+
+	enc := json.NewEncoder(w)
+	for {
+		rows, err := s.queries.ListAuditLog(r.Context(), arg)
+		if err != nil {
+			// Headers may already be sent, so all we can do is stop
+			s.logger.ErrorContext(r.Context(), "ListAuditLog", slog.String("error", err.Error()))
+			return
+		}
+
+		for _, row := range rows {
+			if err := enc.Encode(newAuditLogEntry(row)); err != nil {
+				s.logger.ErrorContext(r.Context(), "error writing audit log export", slog.String("error", err.Error()))
+				return
+			}
+		}
+
+		if len(rows) < auditExportBatch {
+			return
+		}
+		arg.BeforeID = pgtype.Int8{Int64: rows[len(rows)-1].ID, Valid: true}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuditFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    ListAuditLogParams
+		wantErr bool
+	}{
+		{
+			name:  "no filters",
+			query: "",
+			want:  ListAuditLogParams{},
+		},
+		{
+			name:  "all filters",
+			query: "actor=3&action=hide_post&target_type=post&target_id=42&since=2025-01-02&until=2025-01-03&before=100",
+			want: ListAuditLogParams{
+				ActorID:    pgtype.Int8{Int64: 3, Valid: true},
+				Action:     pgtype.Text{String: "hide_post", Valid: true},
+				TargetType: pgtype.Text{String: "post", Valid: true},
+				TargetID:   pgtype.Text{String: "42", Valid: true},
+				Since:      pgtype.Timestamptz{Time: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), Valid: true},
+				Until:      pgtype.Timestamptz{Time: time.Date(2025, 1, 4, 0, 0, 0, 0, time.UTC), Valid: true},
+				BeforeID:   pgtype.Int8{Int64: 100, Valid: true},
+			},
+		},
+		{
+			name:  "blank values are ignored",
+			query: "actor=+&action=&target_id=%20",
+			want:  ListAuditLogParams{},
+		},
+		{
+			name:    "invalid actor",
+			query:   "actor=alice",
+			wantErr: true,
+		},
+		{
+			name:    "invalid since",
+			query:   "since=yesterday",
+			wantErr: true,
+		},
+		{
+			name:    "invalid until",
+			query:   "until=2025-13-01",
+			wantErr: true,
+		},
+		{
+			name:    "invalid before",
+			query:   "before=x",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			got, err := parseAuditFilter(query)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAuditJSON(t *testing.T) {
+	got, err := auditJSON(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	got, err = auditJSON(auditFlag("hidden", true))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"hidden":true}`, string(got))
+
+	got, err = auditJSON(newBoardConfigSnapshot(GetBoardDataRow{
+		Slug:          "eng",
+		Title:         "tdiscuss",
+		Description:   "Engineering",
+		EditWindow:    pgtype.Int4{Int32: 900, Valid: true},
+		ReactionEmoji: []string{"👍", "🎉"},
+		HideEmails:    true,
+	}))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"slug":"eng","title":"tdiscuss","description":"Engineering","edit_window":900,"reaction_emoji":["👍","🎉"],"hide_emails":true}`, string(got))
+
+	got, err = auditJSON(newRateLimitSnapshots([]RateLimit{
+		{Role: "member", Requests: 60, WindowSeconds: 60},
+		{Role: "admin", Requests: 600, WindowSeconds: 60},
+	}))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"member":{"requests":60,"window_seconds":60},"admin":{"requests":600,"window_seconds":60}}`, string(got))
+}
+
+func TestNewAuditLogEntry(t *testing.T) {
+	date := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	entry := newAuditLogEntry(ListAuditLogRow{
+		ID:          7,
+		DateCreated: pgtype.Timestamptz{Time: date, Valid: true},
+		ActorID:     1,
+		ActorEmail:  pgtype.Text{String: "admin@example.com", Valid: true},
+		Action:      auditLockThread,
+		TargetType:  auditTargetThread,
+		TargetID:    "12",
+		Before:      []byte(`{"locked":false}`),
+		After:       []byte(`{"locked":true}`),
+		RequestID:   "req-1",
+		TraceID:     "abc",
+	})
+
+	got, err := json.Marshal(entry)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": 7,
+		"date": "2025-01-02T03:04:05Z",
+		"actor_id": 1,
+		"actor_email": "admin@example.com",
+		"action": "lock_thread",
+		"target_type": "thread",
+		"target_id": "12",
+		"before": {"locked": false},
+		"after": {"locked": true},
+		"request_id": "req-1",
+		"trace_id": "abc"
+	}`, string(got))
+
+	// Entries without before or after leave them out rather than writing null
+	got, err = json.Marshal(newAuditLogEntry(ListAuditLogRow{ID: 8, Action: auditBlockMember}))
+	require.NoError(t, err)
+	assert.NotContains(t, string(got), "before")
+	assert.NotContains(t, string(got), "after")
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// ErrBlobNotFound is returned by a BlobStore when no blob exists for a hash.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// blobHashPattern matches the hex sha256 hashes blobs are keyed by.
+var blobHashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// BlobStore stores uploaded files keyed by the hex sha256 of their content.
+// Since keys are derived from content, Put is idempotent.
+type BlobStore interface {
+	Put(ctx context.Context, hash string, data []byte, contentType string) error
+	Open(ctx context.Context, hash string) (io.ReadCloser, error)
+}
+
+// LocalBlobStore keeps blobs on disk, sharded by the first two characters of
+// the hash so no single directory grows too large.
+type LocalBlobStore struct {
+	dir string
+}
+
+// NewLocalBlobStore creates a blob store rooted at dir, creating it if needed.
+func NewLocalBlobStore(dir string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &LocalBlobStore{dir: dir}, nil
+}
+
+func (s *LocalBlobStore) path(hash string) (string, error) {
+	if !blobHashPattern.MatchString(hash) {
+		return "", fmt.Errorf("invalid blob hash %q", hash)
+	}
+	return filepath.Join(s.dir, hash[:2], hash), nil
+}
+
+// Put writes data to a temporary file and renames it into place so readers
+// never see a partially written blob.
+func (s *LocalBlobStore) Put(ctx context.Context, hash string, data []byte, contentType string) error {
+	path, err := s.path(hash)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, bytes.NewReader(data)); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store blob: %w", err)
+	}
+
+	return nil
+}
+
+// Open returns a reader for the blob, or ErrBlobNotFound.
+func (s *LocalBlobStore) Open(ctx context.Context, hash string) (io.ReadCloser, error) {
+	path, err := s.path(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrBlobNotFound
+		}
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// setupBlobStore creates the blob store selected by the -blob-store flag.
+// The S3 backend is configured from the environment so credentials stay out
+// of the process arguments.
+func setupBlobStore(kind, dataDir string) (BlobStore, error) {
+	switch kind {
+	case "local":
+		return NewLocalBlobStore(filepath.Join(dataDir, "blobs"))
+	case "s3":
+		return NewS3BlobStore(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          envOr("S3_REGION", "us-east-1"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			Prefix:          os.Getenv("S3_PREFIX"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", kind)
+	}
+}
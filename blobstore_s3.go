@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config configures an S3-compatible blob store. Requests use path-style
+// addressing (endpoint/bucket/key), which AWS, MinIO, R2 and friends accept.
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3BlobStore stores blobs in an S3-compatible bucket, signing requests with
+// AWS Signature Version 4.
+type S3BlobStore struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3BlobStore validates config and returns a store for it.
+func NewS3BlobStore(config S3Config) (*S3BlobStore, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required for the s3 blob store")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required for the s3 blob store")
+	}
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", config.Endpoint)
+	}
+
+	return &S3BlobStore{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+	}, nil
+}
+
+func (s *S3BlobStore) objectURL(hash string) (*url.URL, error) {
+	if !blobHashPattern.MatchString(hash) {
+		return nil, fmt.Errorf("invalid blob hash %q", hash)
+	}
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.config.Bucket + "/" + s.config.Prefix + hash
+	return &u, nil
+}
+
+// Put uploads the blob. Content addressing makes overwriting an existing
+// object harmless, so no existence check is made first.
+func (s *S3BlobStore) Put(ctx context.Context, hash string, data []byte, contentType string) error {
+	u, err := s.objectURL(hash)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, data)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 put failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 put failed: %s: %s", resp.Status, body)
+	}
+
+	return nil
+}
+
+// Open streams the blob from the bucket, or returns ErrBlobNotFound.
+func (s *S3BlobStore) Open(ctx context.Context, hash string) (io.ReadCloser, error) {
+	u, err := s.objectURL(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, nil)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 get failed: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrBlobNotFound
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 get failed: %s: %s", resp.Status, body)
+	}
+}
+
+// sign adds AWS Signature Version 4 headers to req.
+func (s *S3BlobStore) sign(req *http.Request, payload []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	payloadHash := sha256Hex(payload)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonicalHeaders, signedHeaders := canonicalS3Headers(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalS3Query(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+// canonicalS3Headers signs the host header plus every Content-Type, Range and
+// x-amz-* header set on the request.
+func canonicalS3Headers(req *http.Request) (canonical, signed string) {
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || lower == "range" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	return b.String(), strings.Join(names, ";")
+}
+
+func canonicalS3Query(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, url.QueryEscape(k)+"="+strings.ReplaceAll(url.QueryEscape(v), "+", "%20"))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/imeyer/tdiscuss/middleware"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// BoardAccessQuerier implements the ExtendedQuerier interface and keeps
+// private boards, and their threads and posts, from members who aren't on
+// the board's access list. Pages, feeds and anything else reading through
+// it see the same boards. A denied lookup fails with pgx.ErrNoRows, as if
+// there were nothing there, and a denied list leaves the rows out.
+type BoardAccessQuerier struct {
+	ExtendedQuerier
+}
+
+// NewBoardAccessQuerier creates a BoardAccessQuerier that checks the member
+// in each query's context against board access lists
+func NewBoardAccessQuerier(wrapped ExtendedQuerier) ExtendedQuerier {
+	return &BoardAccessQuerier{ExtendedQuerier: wrapped}
+}
+
+// WithTx creates a new BoardAccessQuerier with a transaction
+func (q *BoardAccessQuerier) WithTx(tx pgx.Tx) ExtendedQuerier {
+	return &BoardAccessQuerier{ExtendedQuerier: q.ExtendedQuerier.WithTx(tx)}
+}
+
+// canSeeBoard reports whether the member making the request may see a board
+// with the given access list. A board without one is open to every member.
+// Otherwise the member's email or one of the tags, capabilities and groups
+// their request carries must be on it. Admins see every board.
+func canSeeBoard(ctx context.Context, acl []string) bool {
+	if len(acl) == 0 {
+		return true
+	}
+
+	user, ok := middleware.GetUser(ctx)
+	if !ok || user == nil {
+		return false
+	}
+	if user.IsAdmin {
+		return true
+	}
+
+	for _, entry := range acl {
+		if strings.EqualFold(entry, user.Email) || slices.Contains(user.Principals, entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// boardViewer returns what the list queries match board access lists
+// against: the email and principals of the member making the request, and
+// whether they're an admin who sees every board. The queries apply the same
+// rules as canSeeBoard in their WHERE clause, so their LIMIT counts only
+// rows the member may see.
+func boardViewer(ctx context.Context) (email string, principals []string, admin bool) {
+	user, ok := middleware.GetUser(ctx)
+	if !ok || user == nil {
+		return "", nil, false
+	}
+	return user.Email, user.Principals, user.IsAdmin
+}
+
+// checkBoard fails with pgx.ErrNoRows unless the member may see the board
+func (q *BoardAccessQuerier) checkBoard(ctx context.Context, boardID int32) error {
+	acl, err := q.ExtendedQuerier.GetBoardAcl(ctx, boardID)
+	if err != nil {
+		return err
+	}
+	if !canSeeBoard(ctx, acl) {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// checkThread fails with pgx.ErrNoRows unless the member may see the
+// thread's board
+func (q *BoardAccessQuerier) checkThread(ctx context.Context, threadID int64) error {
+	_, err := q.GetThreadBoard(ctx, threadID)
+	return err
+}
+
+// checkPost fails with pgx.ErrNoRows unless the member may see the board of
+// the post's thread
+func (q *BoardAccessQuerier) checkPost(ctx context.Context, postID int64) error {
+	_, err := q.GetThreadPostBoard(ctx, postID)
+	return err
+}
+
+// GetBoardData implements the Querier interface, hiding private boards
+func (q *BoardAccessQuerier) GetBoardData(ctx context.Context, slug pgtype.Text) (GetBoardDataRow, error) {
+	row, err := q.ExtendedQuerier.GetBoardData(ctx, slug)
+	if err != nil {
+		return row, err
+	}
+	if !canSeeBoard(ctx, row.Acl) {
+		return GetBoardDataRow{}, pgx.ErrNoRows
+	}
+	return row, nil
+}
+
+// ListBoards implements the Querier interface, leaving out private boards
+func (q *BoardAccessQuerier) ListBoards(ctx context.Context) ([]ListBoardsRow, error) {
+	rows, err := q.ExtendedQuerier.ListBoards(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(rows, func(row ListBoardsRow) bool {
+		return !canSeeBoard(ctx, row.Acl)
+	}), nil
+}
+
+// GetThreadBoard implements the Querier interface, hiding threads on
+// private boards
+func (q *BoardAccessQuerier) GetThreadBoard(ctx context.Context, id int64) (GetThreadBoardRow, error) {
+	row, err := q.ExtendedQuerier.GetThreadBoard(ctx, id)
+	if err != nil {
+		return row, err
+	}
+	if !canSeeBoard(ctx, row.Acl) {
+		return GetThreadBoardRow{}, pgx.ErrNoRows
+	}
+	return row, nil
+}
+
+// GetThreadPostBoard implements the Querier interface, hiding posts on
+// private boards
+func (q *BoardAccessQuerier) GetThreadPostBoard(ctx context.Context, id int64) (GetThreadPostBoardRow, error) {
+	row, err := q.ExtendedQuerier.GetThreadPostBoard(ctx, id)
+	if err != nil {
+		return row, err
+	}
+	if !canSeeBoard(ctx, row.Acl) {
+		return GetThreadPostBoardRow{}, pgx.ErrNoRows
+	}
+	return row, nil
+}
+
+// ListThreads implements the Querier interface, leaving out threads on
+// private boards
+func (q *BoardAccessQuerier) ListThreads(ctx context.Context, arg ListThreadsParams) ([]ListThreadsRow, error) {
+	arg.ViewerEmail, arg.Principals, arg.SeeAllBoards = boardViewer(ctx)
+	return q.ExtendedQuerier.ListThreads(ctx, arg)
+}
+
+// ListMemberThreads implements the Querier interface, leaving out threads on
+// private boards
+func (q *BoardAccessQuerier) ListMemberThreads(ctx context.Context, arg ListMemberThreadsParams) ([]ListMemberThreadsRow, error) {
+	arg.ViewerEmail, arg.Principals, arg.SeeAllBoards = boardViewer(ctx)
+	return q.ExtendedQuerier.ListMemberThreads(ctx, arg)
+}
+
+// ListMemberDrafts implements the Querier interface, leaving out replies to
+// threads on private boards
+func (q *BoardAccessQuerier) ListMemberDrafts(ctx context.Context, arg ListMemberDraftsParams) ([]ListMemberDraftsRow, error) {
+	arg.ViewerEmail, arg.Principals, arg.SeeAllBoards = boardViewer(ctx)
+	return q.ExtendedQuerier.ListMemberDrafts(ctx, arg)
+}
+
+// ListThreadPosts implements the Querier interface, returning no posts for
+// threads on private boards
+func (q *BoardAccessQuerier) ListThreadPosts(ctx context.Context, arg ListThreadPostsParams) ([]ListThreadPostsRow, error) {
+	if err := q.checkThread(ctx, arg.ThreadID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return q.ExtendedQuerier.ListThreadPosts(ctx, arg)
+}
+
+// GetThreadSubjectById implements the Querier interface, hiding threads on
+// private boards
+func (q *BoardAccessQuerier) GetThreadSubjectById(ctx context.Context, id int64) (string, error) {
+	if err := q.checkThread(ctx, id); err != nil {
+		return "", err
+	}
+	return q.ExtendedQuerier.GetThreadSubjectById(ctx, id)
+}
+
+// GetThreadForEdit implements the Querier interface, hiding threads on
+// private boards
+func (q *BoardAccessQuerier) GetThreadForEdit(ctx context.Context, arg GetThreadForEditParams) (GetThreadForEditRow, error) {
+	if err := q.checkThread(ctx, arg.ID); err != nil {
+		return GetThreadForEditRow{}, err
+	}
+	return q.ExtendedQuerier.GetThreadForEdit(ctx, arg)
+}
+
+// GetThreadPoll implements the Querier interface, hiding polls on private
+// boards
+func (q *BoardAccessQuerier) GetThreadPoll(ctx context.Context, threadID int64) (Poll, error) {
+	if err := q.checkThread(ctx, threadID); err != nil {
+		return Poll{}, err
+	}
+	return q.ExtendedQuerier.GetThreadPoll(ctx, threadID)
+}
+
+// ListThreadLinkPreviews implements the Querier interface, returning no link
+// previews for threads on private boards
+func (q *BoardAccessQuerier) ListThreadLinkPreviews(ctx context.Context, threadID int64) ([]ListThreadLinkPreviewsRow, error) {
+	if err := q.checkThread(ctx, threadID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return q.ExtendedQuerier.ListThreadLinkPreviews(ctx, threadID)
+}
+
+// IsThreadLocked implements the Querier interface, hiding threads on
+// private boards
+func (q *BoardAccessQuerier) IsThreadLocked(ctx context.Context, id int64) (bool, error) {
+	if err := q.checkThread(ctx, id); err != nil {
+		return false, err
+	}
+	return q.ExtendedQuerier.IsThreadLocked(ctx, id)
+}
+
+// GetThreadPost implements the Querier interface, hiding posts on private
+// boards
+func (q *BoardAccessQuerier) GetThreadPost(ctx context.Context, id int64) (GetThreadPostRow, error) {
+	if err := q.checkPost(ctx, id); err != nil {
+		return GetThreadPostRow{}, err
+	}
+	return q.ExtendedQuerier.GetThreadPost(ctx, id)
+}
+
+// GetThreadPostForEdit implements the Querier interface, hiding posts on
+// private boards
+func (q *BoardAccessQuerier) GetThreadPostForEdit(ctx context.Context, arg GetThreadPostForEditParams) (GetThreadPostForEditRow, error) {
+	if err := q.checkPost(ctx, arg.ID); err != nil {
+		return GetThreadPostForEditRow{}, err
+	}
+	return q.ExtendedQuerier.GetThreadPostForEdit(ctx, arg)
+}
+
+// ListThreadPostRevisions implements the Querier interface, returning no
+// revisions for posts on private boards
+func (q *BoardAccessQuerier) ListThreadPostRevisions(ctx context.Context, threadPostID int64) ([]ListThreadPostRevisionsRow, error) {
+	if err := q.checkPost(ctx, threadPostID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return q.ExtendedQuerier.ListThreadPostRevisions(ctx, threadPostID)
+}
+
+// GetAttachment implements the Querier interface, hiding files the member
+// neither uploaded nor can see a post linking. Held and hidden posts only
+// count for their author.
+func (q *BoardAccessQuerier) GetAttachment(ctx context.Context, hash string) (Attachment, error) {
+	arg := CanSeeAttachmentParams{Hash: hash}
+	arg.ViewerEmail, arg.Principals, arg.SeeAllBoards = boardViewer(ctx)
+	if user, ok := middleware.GetUser(ctx); ok && user != nil {
+		arg.MemberID = user.ID
+	}
+
+	visible, err := q.ExtendedQuerier.CanSeeAttachment(ctx, arg)
+	if err != nil {
+		return Attachment{}, err
+	}
+	if !visible {
+		return Attachment{}, pgx.ErrNoRows
+	}
+	return q.ExtendedQuerier.GetAttachment(ctx, hash)
+}
+
+// LinkThreadPostAttachments implements the Querier interface, refusing
+// posts on private boards
+func (q *BoardAccessQuerier) LinkThreadPostAttachments(ctx context.Context, arg LinkThreadPostAttachmentsParams) error {
+	if err := q.checkPost(ctx, arg.ThreadPostID); err != nil {
+		return err
+	}
+	return q.ExtendedQuerier.LinkThreadPostAttachments(ctx, arg)
+}
+
+// CreateThread implements the Querier interface, refusing private boards
+func (q *BoardAccessQuerier) CreateThread(ctx context.Context, arg CreateThreadParams) error {
+	if err := q.checkBoard(ctx, arg.BoardID); err != nil {
+		return err
+	}
+	return q.ExtendedQuerier.CreateThread(ctx, arg)
+}
+
+// UpdateThread implements the Querier interface, refusing threads on
+// private boards
+func (q *BoardAccessQuerier) UpdateThread(ctx context.Context, arg UpdateThreadParams) error {
+	if err := q.checkThread(ctx, arg.ID); err != nil {
+		return err
+	}
+	return q.ExtendedQuerier.UpdateThread(ctx, arg)
+}
+
+// CreateThreadPost implements the Querier interface, refusing threads on
+// private boards
+func (q *BoardAccessQuerier) CreateThreadPost(ctx context.Context, arg CreateThreadPostParams) error {
+	if err := q.checkThread(ctx, arg.ThreadID); err != nil {
+		return err
+	}
+	return q.ExtendedQuerier.CreateThreadPost(ctx, arg)
+}
+
+// UpdateThreadPost implements the Querier interface, refusing posts on
+// private boards
+func (q *BoardAccessQuerier) UpdateThreadPost(ctx context.Context, arg UpdateThreadPostParams) error {
+	if err := q.checkPost(ctx, arg.ID); err != nil {
+		return err
+	}
+	return q.ExtendedQuerier.UpdateThreadPost(ctx, arg)
+}
+
+// CreateThreadPostRevision implements the Querier interface, refusing posts
+// on private boards
+func (q *BoardAccessQuerier) CreateThreadPostRevision(ctx context.Context, arg CreateThreadPostRevisionParams) error {
+	if err := q.checkPost(ctx, arg.ID); err != nil {
+		return err
+	}
+	return q.ExtendedQuerier.CreateThreadPostRevision(ctx, arg)
+}
+
+// CreatePostReaction implements the Querier interface, refusing posts on
+// private boards
+func (q *BoardAccessQuerier) CreatePostReaction(ctx context.Context, arg CreatePostReactionParams) error {
+	if err := q.checkPost(ctx, arg.ThreadPostID); err != nil {
+		return err
+	}
+	return q.ExtendedQuerier.CreatePostReaction(ctx, arg)
+}
+
+// DeletePostReaction implements the Querier interface, refusing posts on
+// private boards
+func (q *BoardAccessQuerier) DeletePostReaction(ctx context.Context, arg DeletePostReactionParams) (int64, error) {
+	if err := q.checkPost(ctx, arg.ThreadPostID); err != nil {
+		return 0, err
+	}
+	return q.ExtendedQuerier.DeletePostReaction(ctx, arg)
+}
+
+// CreateReport implements the Querier interface, refusing posts on private
+// boards
+func (q *BoardAccessQuerier) CreateReport(ctx context.Context, arg CreateReportParams) error {
+	if err := q.checkPost(ctx, arg.ThreadPostID); err != nil {
+		return err
+	}
+	return q.ExtendedQuerier.CreateReport(ctx, arg)
+}
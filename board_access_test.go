@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/imeyer/tdiscuss/middleware"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanSeeBoard(t *testing.T) {
+	member := &middleware.ContextUser{
+		ID:         2,
+		Email:      "alice@example.com",
+		Principals: []string{"tag:laptop", "cap:example.com/cap/oncall", "group:hr"},
+	}
+
+	tests := []struct {
+		name string
+		user *middleware.ContextUser
+		acl  []string
+		want bool
+	}{
+		{name: "public board", user: member, acl: nil, want: true},
+		{name: "public board without a member", user: nil, acl: []string{}, want: true},
+		{name: "private board without a member", user: nil, acl: []string{"group:hr"}, want: false},
+		{name: "group", user: member, acl: []string{"group:security", "group:hr"}, want: true},
+		{name: "tag", user: member, acl: []string{"tag:laptop"}, want: true},
+		{name: "capability", user: member, acl: []string{"cap:example.com/cap/oncall"}, want: true},
+		{name: "email ignores case", user: member, acl: []string{"Alice@Example.com"}, want: true},
+		{name: "not on the list", user: member, acl: []string{"group:security", "bob@example.com"}, want: false},
+		{name: "admin", user: &middleware.ContextUser{ID: 1, Email: "admin@example.com", IsAdmin: true}, acl: []string{"group:security"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.user != nil {
+				ctx = middleware.WithUser(ctx, tt.user)
+			}
+			assert.Equal(t, tt.want, canSeeBoard(ctx, tt.acl))
+		})
+	}
+}
+
+func TestBoardAccessQuerier(t *testing.T) {
+	private := []string{"group:hr"}
+	boards := []ListBoardsRow{
+		{ID: 1, Slug: "general", Title: "General"},
+		{ID: 2, Slug: "hr", Title: "HR", Acl: private},
+	}
+	// Thread 10 and post 100 are on the general board, thread 20 and post
+	// 200 on the private one
+	boardOf := map[int64]int32{10: 1, 20: 2, 100: 1, 200: 2}
+	aclOf := func(id int64) []string {
+		if boardOf[id] == 2 {
+			return private
+		}
+		return nil
+	}
+
+	inner := &MockQueries{
+		ListBoardsFunc: func(ctx context.Context) ([]ListBoardsRow, error) {
+			return append([]ListBoardsRow(nil), boards...), nil
+		},
+		GetBoardAclFunc: func(ctx context.Context, id int32) ([]string, error) {
+			for _, board := range boards {
+				if board.ID == id {
+					return board.Acl, nil
+				}
+			}
+			return nil, pgx.ErrNoRows
+		},
+		GetBoardDataFunc: func(ctx context.Context, slug pgtype.Text) (GetBoardDataRow, error) {
+			if slug.String == "hr" {
+				return GetBoardDataRow{ID: 2, Slug: "hr", Acl: private}, nil
+			}
+			return GetBoardDataRow{ID: 1, Slug: "general"}, nil
+		},
+		GetThreadBoardFunc: func(ctx context.Context, id int64) (GetThreadBoardRow, error) {
+			return GetThreadBoardRow{ID: boardOf[id], Acl: aclOf(id)}, nil
+		},
+		GetThreadPostBoardFunc: func(ctx context.Context, id int64) (GetThreadPostBoardRow, error) {
+			return GetThreadPostBoardRow{ID: boardOf[id], Acl: aclOf(id)}, nil
+		},
+		// The list queries match the access list in SQL; these stand in for
+		// it with the viewer they're given
+		ListThreadsFunc: func(ctx context.Context, arg ListThreadsParams) ([]ListThreadsRow, error) {
+			rows := []ListThreadsRow{{ThreadID: 10, BoardSlug: "general"}}
+			if arg.SeeAllBoards || slices.Contains(arg.Principals, "group:hr") {
+				rows = append(rows, ListThreadsRow{ThreadID: 20, BoardSlug: "hr"})
+			}
+			return rows, nil
+		},
+		ListMemberThreadsFunc: func(ctx context.Context, arg ListMemberThreadsParams) ([]ListMemberThreadsRow, error) {
+			rows := []ListMemberThreadsRow{{ThreadID: 10}}
+			if arg.SeeAllBoards || slices.Contains(arg.Principals, "group:hr") {
+				rows = append(rows, ListMemberThreadsRow{ThreadID: 20})
+			}
+			return rows, nil
+		},
+		ListThreadPostsFunc: func(ctx context.Context, arg ListThreadPostsParams) ([]ListThreadPostsRow, error) {
+			return []ListThreadPostsRow{{ID: 1}}, nil
+		},
+		GetThreadSubjectByIdFunc: func(ctx context.Context, id int64) (string, error) {
+			return "subject", nil
+		},
+		GetThreadPostFunc: func(ctx context.Context, id int64) (GetThreadPostRow, error) {
+			return GetThreadPostRow{ID: id}, nil
+		},
+		// Only members of group:hr, who can see the post linking it, or its
+		// uploader, member 4, can fetch the file
+		CanSeeAttachmentFunc: func(ctx context.Context, arg CanSeeAttachmentParams) (bool, error) {
+			return arg.SeeAllBoards || arg.MemberID == 4 || slices.Contains(arg.Principals, "group:hr"), nil
+		},
+		GetAttachmentFunc: func(ctx context.Context, hash string) (Attachment, error) {
+			return Attachment{Hash: hash}, nil
+		},
+	}
+	q := NewBoardAccessQuerier(inner)
+
+	outsider := middleware.WithUser(context.Background(), &middleware.ContextUser{ID: 2, Email: "bob@example.com"})
+	insider := middleware.WithUser(context.Background(), &middleware.ContextUser{ID: 3, Email: "carol@example.com", Principals: []string{"group:hr"}})
+
+	t.Run("lists leave out private boards", func(t *testing.T) {
+		got, err := q.ListBoards(outsider)
+		require.NoError(t, err)
+		assert.Len(t, got, 1)
+
+		threads, err := q.ListThreads(outsider, ListThreadsParams{})
+		require.NoError(t, err)
+		require.Len(t, threads, 1)
+		assert.Equal(t, int64(10), threads[0].ThreadID)
+
+		memberThreads, err := q.ListMemberThreads(outsider, ListMemberThreadsParams{MemberID: 1})
+		require.NoError(t, err)
+		assert.Len(t, memberThreads, 1)
+
+		got, err = q.ListBoards(insider)
+		require.NoError(t, err)
+		assert.Len(t, got, 2)
+
+		threads, err = q.ListThreads(insider, ListThreadsParams{})
+		require.NoError(t, err)
+		assert.Len(t, threads, 2)
+	})
+
+	t.Run("private board lookups find nothing", func(t *testing.T) {
+		_, err := q.GetBoardData(outsider, pgtype.Text{String: "hr", Valid: true})
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+		_, err = q.GetThreadSubjectById(outsider, 20)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+		_, err = q.GetThreadPost(outsider, 200)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+		posts, err := q.ListThreadPosts(outsider, ListThreadPostsParams{ThreadID: 20})
+		require.NoError(t, err)
+		assert.Empty(t, posts)
+
+		board, err := q.GetBoardData(insider, pgtype.Text{String: "hr", Valid: true})
+		require.NoError(t, err)
+		assert.Equal(t, "hr", board.Slug)
+
+		subject, err := q.GetThreadSubjectById(insider, 20)
+		require.NoError(t, err)
+		assert.Equal(t, "subject", subject)
+
+		posts, err = q.ListThreadPosts(insider, ListThreadPostsParams{ThreadID: 20})
+		require.NoError(t, err)
+		assert.Len(t, posts, 1)
+	})
+
+	t.Run("public board lookups pass through", func(t *testing.T) {
+		_, err := q.GetThreadSubjectById(outsider, 10)
+		assert.NoError(t, err)
+
+		_, err = q.GetThreadPost(outsider, 100)
+		assert.NoError(t, err)
+	})
+
+	t.Run("attachments are served to who can see them", func(t *testing.T) {
+		_, err := q.GetAttachment(outsider, "abc")
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+		_, err = q.GetAttachment(insider, "abc")
+		assert.NoError(t, err)
+
+		uploader := middleware.WithUser(context.Background(), &middleware.ContextUser{ID: 4, Email: "dave@example.com"})
+		_, err = q.GetAttachment(uploader, "abc")
+		assert.NoError(t, err)
+	})
+
+	t.Run("writes to private boards are refused", func(t *testing.T) {
+		assert.ErrorIs(t, q.CreateThread(outsider, CreateThreadParams{BoardID: 2}), pgx.ErrNoRows)
+		assert.ErrorIs(t, q.CreateThread(insider, CreateThreadParams{BoardID: 99}), pgx.ErrNoRows, "no such board")
+		assert.ErrorIs(t, q.CreateThreadPost(outsider, CreateThreadPostParams{ThreadID: 20}), pgx.ErrNoRows)
+		assert.ErrorIs(t, q.CreatePostReaction(outsider, CreatePostReactionParams{ThreadPostID: 200}), pgx.ErrNoRows)
+		assert.ErrorIs(t, q.CreateReport(outsider, CreateReportParams{ThreadPostID: 200}), pgx.ErrNoRows)
+
+		assert.NoError(t, q.CreateThread(outsider, CreateThreadParams{BoardID: 1}))
+		assert.NoError(t, q.CreateThread(insider, CreateThreadParams{BoardID: 2}))
+		assert.NoError(t, q.CreateThreadPost(insider, CreateThreadPostParams{ThreadID: 20}))
+	})
+}
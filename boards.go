@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/imeyer/tdiscuss/middleware"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// BoardTemplateData is a board as listed on the index and admin pages.
+type BoardTemplateData struct {
+	ID          int32
+	Slug        string
+	Title       string
+	Description string
+	Threads     int32
+	Posts       int32
+	// Private boards are only shown to the members on their access list
+	Private bool
+}
+
+func newBoardTemplateData(rows []ListBoardsRow) []BoardTemplateData {
+	boards := make([]BoardTemplateData, 0, len(rows))
+	for _, row := range rows {
+		boards = append(boards, BoardTemplateData{
+			ID:          row.ID,
+			Slug:        row.Slug,
+			Title:       row.Title,
+			Description: row.Description,
+			Threads:     row.TotalThreads.Int32,
+			Posts:       row.TotalThreadPosts.Int32,
+			Private:     len(row.Acl) > 0,
+		})
+	}
+	return boards
+}
+
+// boardPath is where a board's threads are listed.
+func boardPath(slug string) string {
+	return "/b/" + slug + "/"
+}
+
+// getBoard looks up a board by slug. An empty slug is the default board,
+// the first one created, which is also where board-wide settings are read
+// from.
+func (s *DiscussService) getBoard(ctx context.Context, slug string) (GetBoardDataRow, error) {
+	return s.queries.GetBoardData(ctx, pgtype.Text{String: slug, Valid: slug != ""})
+}
+
+// GetBoard returns the board the request is for: the board named by a
+// /b/{slug}/ route, otherwise the default board. It reports false for an
+// unknown slug.
+func GetBoard(r *http.Request) (GetBoardDataRow, bool) {
+	if r != nil && r.Context() != nil {
+		if boardData, ok := middleware.GetBoardData(r.Context()); ok && boardData != nil {
+			if bd, ok := boardData.(GetBoardDataRow); ok {
+				return bd, true
+			}
+			if bd, ok := boardData.(*GetBoardDataRow); ok && bd != nil {
+				return *bd, true
+			}
+		}
+	}
+	return GetBoardDataRow{}, false
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/imeyer/tdiscuss/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+type boardDataStub struct {
+	boards map[string]GetBoardDataRow
+}
+
+func (b boardDataStub) GetBoardData(ctx context.Context, slug string) (interface{}, error) {
+	if slug == "" {
+		slug = "general"
+	}
+	if board, ok := b.boards[slug]; ok {
+		return board, nil
+	}
+	return nil, assert.AnError
+}
+
+func TestBoardPath(t *testing.T) {
+	assert.Equal(t, "/b/general/", boardPath("general"))
+	assert.Equal(t, "/b/on-call/", boardPath("on-call"))
+}
+
+func TestGetBoard(t *testing.T) {
+	stub := boardDataStub{boards: map[string]GetBoardDataRow{
+		"general": {ID: 1, Slug: "general", Title: "My Board"},
+		"eng":     {ID: 2, Slug: "eng", Title: "Engineering"},
+	}}
+
+	tests := []struct {
+		name     string
+		pattern  string
+		path     string
+		wantOK   bool
+		wantSlug string
+	}{
+		{
+			name:     "default board",
+			pattern:  "GET /{$}",
+			path:     "/",
+			wantOK:   true,
+			wantSlug: "general",
+		},
+		{
+			name:     "board route",
+			pattern:  "GET /b/{slug}/{$}",
+			path:     "/b/eng/",
+			wantOK:   true,
+			wantSlug: "eng",
+		},
+		{
+			name:    "unknown board",
+			pattern: "GET /b/{slug}/{$}",
+			path:    "/b/nope/",
+			wantOK:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got GetBoardDataRow
+			var ok bool
+
+			mux := http.NewServeMux()
+			mux.Handle(tt.pattern, middleware.BoardDataMiddleware(stub)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, ok = GetBoard(r)
+			})))
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantSlug, got.Slug)
+		})
+	}
+
+	_, ok := GetBoard(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.False(t, ok, "no board data in context")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/imeyer/tdiscuss/middleware"
+)
+
+// DevUsers lists the fake users of local development mode and switches the
+// current user by setting the dev user cookie.
+func (s *DiscussService) DevUsers(w http.ResponseWriter, r *http.Request) {
+	provider, ok := s.authProvider.(*middleware.LocalAuthProvider)
+	if !ok {
+		s.renderError(w, http.StatusNotFound)
+		return
+	}
+
+	user, err := GetUser(r)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "GetUser", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			s.renderError(w, http.StatusBadRequest)
+			return
+		}
+
+		email := r.Form.Get("email")
+		if !slices.Contains(provider.Users(), email) {
+			s.renderError(w, http.StatusBadRequest)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     middleware.LocalUserCookie,
+			Value:    email,
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		s.logger.InfoContext(r.Context(), "switched local user", slog.String("email", email))
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	s.renderTemplate(w, r, "dev-users.html", map[string]interface{}{
+		"Title":            GetBoardTitle(r),
+		"User":             user,
+		"CurrentUserEmail": user.Email,
+		"Version":          s.version,
+		"GitSha":           s.gitSha,
+		"Users":            provider.Users(),
+	})
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Diagrams are written in Mermaid in a ```mermaid block. There's no Mermaid
+// renderer in Go, so with -mermaid-cli pointing at the Mermaid CLI (mmdc)
+// diagrams are rendered to SVG when the post is saved. Without it, or if a
+// diagram fails to render in time or every renderer is busy, its source is
+// shown as a code block. Previews, which are sent as the post is typed, only
+// draw diagrams already rendered.
+
+const (
+	// diagramBudget bounds rendering all the diagrams in a post, each of
+	// which starts a browser. The post is saved after, so it's kept well
+	// within the server's WriteTimeout.
+	diagramBudget = 8 * time.Second
+
+	// maxPostDiagrams is how many diagrams in one post are rendered
+	maxPostDiagrams = 5
+
+	// maxDiagramBytes is the largest rendered diagram kept in a post
+	maxDiagramBytes = 256 * 1024
+
+	// diagramCacheSize is how many rendered diagrams are kept in memory
+	diagramCacheSize = 100
+
+	// maxDiagramRenders is how many diagrams are rendered at once, across
+	// all posts, as each one starts a browser
+	maxDiagramRenders = 2
+)
+
+// mermaidConfig keeps Mermaid from running anything in a diagram and draws
+// labels as SVG text, as HTML labels in a <foreignObject> don't survive the
+// body policy. The colours come from the theme in style.css.
+const mermaidConfig = `{
+  "securityLevel": "strict",
+  "htmlLabels": false,
+  "flowchart": {"htmlLabels": false},
+  "theme": "neutral"
+}`
+
+// formattingDiagram is the example diagram in the formatting guide.
+const formattingDiagram = "flowchart LR\n    Draft --> Review --> Posted\n"
+
+var (
+	errNoDiagramRenderer  = errors.New("diagram: no renderer configured")
+	errDiagramNotRendered = errors.New("diagram: not rendered yet")
+	errDiagramsBusy       = errors.New("diagram: every renderer is busy")
+)
+
+var kindDiagram = ast.NewNodeKind("Diagram")
+
+// diagramBlock is a ```mermaid code block.
+type diagramBlock struct {
+	ast.BaseBlock
+	source string
+}
+
+func (n *diagramBlock) Kind() ast.NodeKind { return kindDiagram }
+
+func (n *diagramBlock) IsRaw() bool { return true }
+
+func (n *diagramBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Source": n.source}, nil)
+}
+
+// diagramTransformer turns ```mermaid code blocks into diagrams before the
+// code highlighter sees them.
+type diagramTransformer struct{}
+
+func (t *diagramTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	src := reader.Source()
+
+	var blocks []*ast.FencedCodeBlock
+	_ = ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if block, ok := node.(*ast.FencedCodeBlock); ok && entering && string(block.Language(src)) == "mermaid" {
+			blocks = append(blocks, block)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, block := range blocks {
+		var source bytes.Buffer
+		lines := block.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			segment := lines.At(i)
+			source.Write(segment.Value(src))
+		}
+		block.Parent().ReplaceChild(block.Parent(), block, &diagramBlock{source: source.String()})
+	}
+}
+
+// diagramRenderer writes diagrams as SVG, or as their source.
+type diagramRenderer struct {
+	diagrams *Diagrammer
+	preview  bool
+	// deadline is when the post's diagramBudget runs out
+	deadline time.Time
+	// rendered counts the diagrams rendered in this post
+	rendered int
+}
+
+func (r *diagramRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindDiagram, r.renderDiagram)
+}
+
+func (r *diagramRenderer) renderDiagram(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkSkipChildren, nil
+	}
+	n := node.(*diagramBlock)
+
+	if r.rendered < maxPostDiagrams {
+		r.rendered++
+		svg, err := r.render(n.source)
+		if err == nil {
+			_, _ = w.WriteString(`<div class="diagram">`)
+			_, _ = w.WriteString(svg)
+			_, _ = w.WriteString("</div>\n")
+			return ast.WalkSkipChildren, nil
+		}
+		if !errors.Is(err, errNoDiagramRenderer) && !errors.Is(err, errDiagramNotRendered) {
+			r.diagrams.logger.Warn("error rendering diagram", slog.String("error", err.Error()))
+		}
+	}
+
+	_, _ = w.WriteString(`<pre><code class="language-mermaid">`)
+	_, _ = w.Write(util.EscapeHTML([]byte(n.source)))
+	_, _ = w.WriteString("</code></pre>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *diagramRenderer) render(source string) (string, error) {
+	if r.preview {
+		return r.diagrams.Rendered(source)
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), r.deadline)
+	defer cancel()
+	return r.diagrams.Render(ctx, source)
+}
+
+// diagramExtension is a goldmark extension for Mermaid diagrams rendered to
+// SVG by diagrams, or shown as their source if it's nil. A preview only
+// draws the diagrams already rendered.
+type diagramExtension struct {
+	diagrams *Diagrammer
+	preview  bool
+}
+
+func (e *diagramExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithASTTransformers(
+			util.Prioritized(&diagramTransformer{}, 100),
+		),
+	)
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(
+			util.Prioritized(&diagramRenderer{
+				diagrams: e.diagrams,
+				preview:  e.preview,
+				deadline: time.Now().Add(diagramBudget),
+			}, 100),
+		),
+	)
+}
+
+// Diagrammer renders diagrams with the Mermaid CLI, a few at a time, and
+// keeps the recently rendered ones.
+type Diagrammer struct {
+	cli    string
+	logger *slog.Logger
+	// renders holds a slot for each diagram being rendered
+	renders chan struct{}
+	// cache keeps recently rendered diagrams by key, so previewing and
+	// editing a post doesn't render its diagrams again each time
+	cache *lruCache
+}
+
+// NewDiagrammer creates a Diagrammer running cli, or returns nil if cli is
+// empty.
+func NewDiagrammer(cli string, logger *slog.Logger) *Diagrammer {
+	if cli == "" {
+		return nil
+	}
+	return &Diagrammer{
+		cli:     cli,
+		logger:  logger,
+		renders: make(chan struct{}, maxDiagramRenders),
+		cache:   newLRUCache(diagramCacheSize),
+	}
+}
+
+// Render renders Mermaid source to SVG, unless it's been rendered recently.
+// If every renderer is busy it gives up rather than waiting for one.
+func (d *Diagrammer) Render(ctx context.Context, source string) (string, error) {
+	if d == nil {
+		return "", errNoDiagramRenderer
+	}
+
+	key, id := diagramKey(source)
+	if svg, ok := d.cache.get(key); ok {
+		return svg, nil
+	}
+
+	select {
+	case d.renders <- struct{}{}:
+		defer func() { <-d.renders }()
+	default:
+		return "", errDiagramsBusy
+	}
+
+	svg, err := runMermaidCLI(ctx, d.cli, id, source)
+	if err != nil {
+		return "", err
+	}
+	svg = scopeDiagramIDs(svg, id)
+
+	d.cache.put(key, svg)
+	return svg, nil
+}
+
+// Rendered returns a diagram that's been rendered recently.
+func (d *Diagrammer) Rendered(source string) (string, error) {
+	if d == nil {
+		return "", errNoDiagramRenderer
+	}
+
+	key, _ := diagramKey(source)
+	if svg, ok := d.cache.get(key); ok {
+		return svg, nil
+	}
+	return "", errDiagramNotRendered
+}
+
+// renderFormattingDiagram renders the formatting guide's example diagram.
+// The guide only previews it, so visiting the guide never starts a browser.
+func (s *DiscussService) renderFormattingDiagram(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, diagramBudget)
+	defer cancel()
+
+	if _, err := s.diagrams.Render(ctx, formattingDiagram); err != nil && !errors.Is(err, errNoDiagramRenderer) {
+		s.logger.WarnContext(ctx, "error rendering the formatting guide's diagram", slog.String("error", err.Error()))
+	}
+}
+
+// diagramKey identifies a diagram by the hash of its source. Its ID, for the
+// SVG's elements, is a prefix of the hash, so the same diagram always renders
+// the same and different diagrams on a page don't share element IDs.
+func diagramKey(source string) (key, id string) {
+	sum := sha256.Sum256([]byte(source))
+	key = hex.EncodeToString(sum[:])
+	return key, "diagram-" + key[:12]
+}
+
+func runMermaidCLI(ctx context.Context, cli, id, source string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("diagram: out of time: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "tdiscuss-diagram-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "diagram.mmd")
+	output := filepath.Join(dir, "diagram.svg")
+	config := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(input, []byte(source), 0o600); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(config, []byte(mermaidConfig), 0o600); err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, cli, "--input", input, "--output", output, "--configFile", config, "--svgId", id, "--quiet")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("diagram: %s: %w: %s", filepath.Base(cli), err, truncateRunes(string(bytes.TrimSpace(out)), 500))
+	}
+
+	svg, err := os.ReadFile(output)
+	if err != nil {
+		return "", err
+	}
+	if len(svg) > maxDiagramBytes {
+		return "", fmt.Errorf("diagram: %d bytes rendered, more than %d", len(svg), maxDiagramBytes)
+	}
+	return string(svg), nil
+}
+
+var (
+	svgIDAttribute = regexp.MustCompile(`\sid="([^"]*)"`)
+	svgIDReference = regexp.MustCompile(`url\(#([^)]*)\)`)
+)
+
+// scopeDiagramIDs prefixes the element IDs in a rendered diagram, and the
+// references to them, with the diagram's own ID. Mermaid doesn't prefix all
+// of them, and the body policy only allows IDs that can't clash with the
+// page's.
+func scopeDiagramIDs(svg, id string) string {
+	scope := func(name string) string {
+		if strings.HasPrefix(name, id) {
+			return name
+		}
+		return id + "-" + name
+	}
+
+	svg = svgIDAttribute.ReplaceAllStringFunc(svg, func(match string) string {
+		return ` id="` + scope(svgIDAttribute.FindStringSubmatch(match)[1]) + `"`
+	})
+	return svgIDReference.ReplaceAllStringFunc(svg, func(match string) string {
+		return `url(#` + scope(svgIDReference.FindStringSubmatch(match)[1]) + `)`
+	})
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// fakeMermaidCLI writes the SVG a Mermaid CLI would, with some of what a
+// hostile one might add, and returns a Diagrammer running it.
+func fakeMermaidCLI(t *testing.T) *Diagrammer {
+	t.Helper()
+	script := `#!/bin/sh
+while [ $# -gt 0 ]; do
+	case "$1" in
+	--output) out="$2"; shift ;;
+	--svgId) id="$2"; shift ;;
+	esac
+	shift
+done
+cat > "$out" <<SVG
+<svg id="$id" xmlns="http://www.w3.org/2000/svg" viewBox="0 0 200 100" style="max-width: 200px;"><style>#$id{fill:red}</style><marker id="${id}_pointEnd" viewBox="0 0 10 10"><path d="M 0 0 L 10 5 L 0 10 z"/></marker><marker id="arrowhead"><path d="M0,0 L10,5"/></marker><path d="M10,10L50,50" class="flowchart-link" marker-end="url(#${id}_pointEnd)"/><line x1="1" y1="2" x2="3" y2="4" marker-end="url(#arrowhead)"/><g class="node" transform="translate(50, 20)"><rect x="-20" y="-10" width="40" height="20"/><text>Start</text></g><foreignObject><div>html label</div></foreignObject><a href="javascript:alert(1)"><text>x</text></a><script>alert(1)</script></svg>
+SVG
+`
+	cli := filepath.Join(t.TempDir(), "mmdc")
+	require.NoError(t, os.WriteFile(cli, []byte(script), 0o755))
+	return NewDiagrammer(cli, discardLogger)
+}
+
+func TestRenderPostBody_DiagramWithoutRenderer(t *testing.T) {
+	assert.Nil(t, NewDiagrammer("", discardLogger))
+
+	result := renderPostBody(nil, "```mermaid\nflowchart LR\n    A --> B\n```")
+	assert.Equal(t, "<pre><code class=\"language-mermaid\">flowchart LR\n    A --&gt; B\n</code></pre>\n", result)
+}
+
+func TestRenderPostBody_Diagram(t *testing.T) {
+	d := fakeMermaidCLI(t)
+
+	result := renderPostBody(d, "```mermaid\nflowchart LR\n    A --> B\n```")
+
+	match := regexp.MustCompile(`<svg id="(diagram-[0-9a-f]{12})"`).FindStringSubmatch(result)
+	require.NotNil(t, match, result)
+	id := match[1]
+
+	assert.Contains(t, result, `<div class="diagram"><svg id="`+id+`"`)
+	assert.Contains(t, result, `<marker id="`+id+`_pointEnd"`)
+	assert.Contains(t, result, `marker-end="url(#`+id+`_pointEnd)"`)
+	assert.Contains(t, result, `<marker id="`+id+`-arrowhead"`, "unscoped IDs are scoped")
+	assert.Contains(t, result, `marker-end="url(#`+id+`-arrowhead)"`)
+	assert.Contains(t, result, `<rect x="-20" y="-10" width="40" height="20"`)
+	assert.Contains(t, result, `<text>Start</text>`)
+
+	for _, unwanted := range []string{"<style", "style=", "foreignobject", "html label", "javascript", "<script"} {
+		assert.NotContains(t, result, unwanted)
+	}
+}
+
+func TestRenderPostBody_DiagramLimit(t *testing.T) {
+	d := fakeMermaidCLI(t)
+
+	var input strings.Builder
+	for i := 0; i <= maxPostDiagrams; i++ {
+		input.WriteString("```mermaid\nflowchart LR\n    A --> B" + strings.Repeat("B", i) + "\n```\n\n")
+	}
+	result := renderPostBody(d, input.String())
+
+	assert.Equal(t, maxPostDiagrams, strings.Count(result, `<div class="diagram">`))
+	assert.Equal(t, 1, strings.Count(result, `<code class="language-mermaid">`))
+}
+
+func TestRenderPostBody_DiagramError(t *testing.T) {
+	cli := filepath.Join(t.TempDir(), "mmdc")
+	require.NoError(t, os.WriteFile(cli, []byte("#!/bin/sh\necho 'Parse error on line 1' >&2\nexit 1\n"), 0o755))
+
+	var logs bytes.Buffer
+	d := NewDiagrammer(cli, slog.New(slog.NewTextHandler(&logs, nil)))
+	result := renderPostBody(d, "```mermaid\nnot a diagram\n```")
+	assert.Equal(t, "<pre><code class=\"language-mermaid\">not a diagram\n</code></pre>\n", result)
+	assert.Contains(t, logs.String(), "Parse error on line 1")
+}
+
+func TestRenderPostBody_DiagramRenderersBusy(t *testing.T) {
+	d := fakeMermaidCLI(t)
+	for i := 0; i < maxDiagramRenders; i++ {
+		d.renders <- struct{}{}
+	}
+
+	result := renderPostBody(d, "```mermaid\nflowchart LR\n    A --> B\n```")
+	assert.Equal(t, "<pre><code class=\"language-mermaid\">flowchart LR\n    A --&gt; B\n</code></pre>\n", result, "a busy renderer isn't waited for")
+}
+
+func TestRenderPreviewBody_Diagram(t *testing.T) {
+	d := fakeMermaidCLI(t)
+	source := "```mermaid\nflowchart LR\n    A --> B\n```"
+
+	result := renderPreviewBody(d, source)
+	assert.Equal(t, "<pre><code class=\"language-mermaid\">flowchart LR\n    A --&gt; B\n</code></pre>\n", result, "previews don't render diagrams")
+
+	rendered := renderPostBody(d, source)
+	assert.Equal(t, rendered, renderPreviewBody(d, source), "previews show diagrams already rendered")
+}
+
+func TestDiagramKey(t *testing.T) {
+	key, id := diagramKey("flowchart LR\n")
+	assert.Len(t, key, 64, "diagrams are cached by their whole hash")
+	assert.Equal(t, "diagram-"+key[:12], id)
+}
+
+func TestRenderPostBody_HandWrittenSVG(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Inline", `see <svg id="diagram-0123456789ab"><text>forged</text></svg> here`},
+		{"Block", "<div class=\"diagram\">\n<svg id=\"diagram-0123456789ab\"><rect width=\"10\" height=\"10\"/>\n<text>forged</text></svg>\n</div>"},
+		{"Tag across lines", "<div>\n<svg\nid=\"diagram-0123456789ab\">forged</svg>\n</div>"},
+		{"Upper case", `<SVG ID="diagram-0123456789ab"><TEXT>forged</TEXT></SVG>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderPostBody(nil, tt.input)
+			assert.Contains(t, result, "forged")
+			for _, unwanted := range []string{"<svg", "<text", "<rect", "diagram-0123456789ab"} {
+				assert.NotContains(t, result, unwanted)
+			}
+		})
+	}
+
+	assert.Equal(t, "<p><code>&lt;svg&gt;</code> <b>bold</b></p>\n", renderPostBody(nil, "`<svg>` <b>bold</b>"), "other HTML and code are kept")
+}
+
+func TestScopeDiagramIDs(t *testing.T) {
+	svg := `<svg id="diagram-0123456789ab"><marker id="diagram-0123456789ab_end"/><marker id="end"/><path marker-end="url(#end)"/><path marker-start="url(#diagram-0123456789ab_end)"/></svg>`
+	assert.Equal(t,
+		`<svg id="diagram-0123456789ab"><marker id="diagram-0123456789ab_end"/><marker id="diagram-0123456789ab-end"/><path marker-end="url(#diagram-0123456789ab-end)"/><path marker-start="url(#diagram-0123456789ab_end)"/></svg>`,
+		scopeDiagramIDs(svg, "diagram-0123456789ab"))
+}
+
+func TestParseHTMLLessStrict_DiagramIDs(t *testing.T) {
+	// IDs that could clash with the page's are dropped
+	result := parseHTMLLessStrict(`<div class="diagram"><svg id="main"><marker id="diagram-0123456789ab-end"/><path marker-end="url(#main)"/></svg></div>`)
+	assert.Equal(t, `<div class="diagram"><svg><marker id="diagram-0123456789ab-end"/><path/></svg></div>`, result)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+)
+
+// Diff segment operations, compared as strings in templates.
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// maxDiffCells bounds the size of the LCS table. Larger inputs fall back to a
+// whole-text replacement rather than allocating an unbounded table.
+const maxDiffCells = 4_000_000
+
+// DiffSegment is a run of consecutive words that share the same operation.
+type DiffSegment struct {
+	Op   string
+	Text string
+}
+
+// diffWords computes a word-level diff between two plain-text strings.
+// Whitespace is normalised; the result is suitable for display, not patching.
+func diffWords(before, after string) []DiffSegment {
+	a := strings.Fields(before)
+	b := strings.Fields(after)
+
+	// Trim the common prefix and suffix so the LCS only covers the changed region
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var segments []DiffSegment
+	segments = appendDiff(segments, DiffEqual, a[:prefix]...)
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+	if (len(midA)+1)*(len(midB)+1) > maxDiffCells {
+		segments = appendDiff(segments, DiffDelete, midA...)
+		segments = appendDiff(segments, DiffInsert, midB...)
+	} else {
+		segments = appendLCSDiff(segments, midA, midB)
+	}
+
+	return appendDiff(segments, DiffEqual, a[len(a)-suffix:]...)
+}
+
+// appendLCSDiff appends the diff of a and b using a longest common subsequence table.
+func appendLCSDiff(segments []DiffSegment, a, b []string) []DiffSegment {
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			segments = appendDiff(segments, DiffEqual, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			segments = appendDiff(segments, DiffDelete, a[i])
+			i++
+		default:
+			segments = appendDiff(segments, DiffInsert, b[j])
+			j++
+		}
+	}
+	segments = appendDiff(segments, DiffDelete, a[i:]...)
+	return appendDiff(segments, DiffInsert, b[j:]...)
+}
+
+// appendDiff appends words to segments, merging with the last segment when
+// the operation matches.
+func appendDiff(segments []DiffSegment, op string, words ...string) []DiffSegment {
+	if len(words) == 0 {
+		return segments
+	}
+	text := strings.Join(words, " ")
+	if n := len(segments); n > 0 && segments[n-1].Op == op {
+		segments[n-1].Text += " " + text
+		return segments
+	}
+	return append(segments, DiffSegment{Op: op, Text: text})
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestDiffWords(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   []DiffSegment
+	}{
+		{
+			name:   "Identical",
+			before: "the quick fox",
+			after:  "the quick fox",
+			want:   []DiffSegment{{DiffEqual, "the quick fox"}},
+		},
+		{
+			name:   "Word replaced",
+			before: "the quick brown fox",
+			after:  "the slow brown fox",
+			want: []DiffSegment{
+				{DiffEqual, "the"},
+				{DiffDelete, "quick"},
+				{DiffInsert, "slow"},
+				{DiffEqual, "brown fox"},
+			},
+		},
+		{
+			name:   "Words appended",
+			before: "hello",
+			after:  "hello there world",
+			want: []DiffSegment{
+				{DiffEqual, "hello"},
+				{DiffInsert, "there world"},
+			},
+		},
+		{
+			name:   "Everything removed",
+			before: "gone now",
+			after:  "",
+			want:   []DiffSegment{{DiffDelete, "gone now"}},
+		},
+		{
+			name:   "Whitespace only changes",
+			before: "a  b\nc",
+			after:  "a b c",
+			want:   []DiffSegment{{DiffEqual, "a b c"}},
+		},
+		{
+			name:   "Both empty",
+			before: "",
+			after:  "",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffWords(tt.before, tt.after)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffWords() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffWordsLargeInputFallsBack(t *testing.T) {
+	before := strings.Repeat("a ", 3000)
+	after := strings.Repeat("b ", 3000)
+
+	got := diffWords(before, after)
+	if len(got) != 2 || got[0].Op != DiffDelete || got[1].Op != DiffInsert {
+		t.Fatalf("expected a delete and an insert segment, got %d segments", len(got))
+	}
+}
+
+func TestBuildPostRevisions(t *testing.T) {
+	posted := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	edited := posted.Add(5 * time.Minute)
+
+	post := GetThreadPostRow{
+		ID:          10,
+		ThreadID:    1,
+		MemberID:    2,
+		DatePosted:  pgtype.Timestamptz{Time: posted, Valid: true},
+		Body:        pgtype.Text{String: "<p>hello brave world</p>", Valid: true},
+		Subject:     pgtype.Text{String: "New subject", Valid: true},
+		IsFirstPost: true,
+	}
+	author := pgtype.Text{String: "alice@example.com", Valid: true}
+	revisions := []ListThreadPostRevisionsRow{
+		{
+			ThreadPostID: 10,
+			Email:        author,
+			DateRevised:  pgtype.Timestamptz{Time: edited, Valid: true},
+			Subject:      pgtype.Text{String: "Old subject", Valid: true},
+			Body:         pgtype.Text{String: "<p>hello world</p>", Valid: true},
+		},
+	}
+
+	got := buildPostRevisions(post, author, revisions)
+	if len(got) != 2 {
+		t.Fatalf("got %d versions, want 2", len(got))
+	}
+
+	latest := got[0]
+	if !latest.Current || latest.Number != 1 {
+		t.Errorf("expected newest version first, got %+v", latest)
+	}
+	if !latest.Date.Time.Equal(edited) {
+		t.Errorf("latest version date = %v, want %v", latest.Date.Time, edited)
+	}
+	wantBody := []DiffSegment{{DiffEqual, "hello"}, {DiffInsert, "brave"}, {DiffEqual, "world"}}
+	if !reflect.DeepEqual(latest.BodyDiff, wantBody) {
+		t.Errorf("body diff = %v, want %v", latest.BodyDiff, wantBody)
+	}
+	wantSubject := []DiffSegment{{DiffDelete, "Old"}, {DiffInsert, "New"}, {DiffEqual, "subject"}}
+	if !reflect.DeepEqual(latest.SubjectDiff, wantSubject) {
+		t.Errorf("subject diff = %v, want %v", latest.SubjectDiff, wantSubject)
+	}
+
+	original := got[1]
+	if original.Current || original.Number != 0 || !original.Date.Time.Equal(posted) {
+		t.Errorf("unexpected original version %+v", original)
+	}
+}
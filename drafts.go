@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// defaultDraftTTL is how long a draft is kept after it was last saved
+	// when -draft-ttl isn't set
+	defaultDraftTTL = 30 * 24 * time.Hour
+
+	// draftPruneInterval is how often expired drafts are deleted
+	draftPruneInterval = time.Hour
+)
+
+// DraftTemplateData is one of a member's drafts as listed on their profile.
+type DraftTemplateData struct {
+	// ThreadID is the thread being replied to, zero for a new thread
+	ThreadID  int64
+	Title     string
+	Body      string
+	DateSaved pgtype.Timestamptz
+	// URL is the form the draft is restored into
+	URL string
+}
+
+// buildDraftTemplateData names each draft after the thread it starts or the
+// thread it replies to.
+func buildDraftTemplateData(rows []ListMemberDraftsRow) []DraftTemplateData {
+	drafts := make([]DraftTemplateData, 0, len(rows))
+	for _, row := range rows {
+		d := DraftTemplateData{
+			Body:      row.Body,
+			DateSaved: row.DateSaved,
+		}
+		if row.ThreadID.Valid {
+			d.ThreadID = row.ThreadID.Int64
+			d.Title = "Re: " + row.ThreadSubject.String
+			d.URL = fmt.Sprintf("/thread/%d#reply", row.ThreadID.Int64)
+		} else {
+			d.Title = row.Subject
+			if d.Title == "" {
+				d.Title = "(no subject)"
+			}
+			d.URL = "/thread/new"
+		}
+		drafts = append(drafts, d)
+	}
+	return drafts
+}
+
+// draftThreadID is the thread a draft belongs to, null for a new thread.
+func draftThreadID(threadID int64) pgtype.Int8 {
+	return pgtype.Int8{Int64: threadID, Valid: threadID != 0}
+}
+
+// draftsSavedAfter is the oldest save time of a draft that hasn't expired.
+func (s *DiscussService) draftsSavedAfter(now time.Time) pgtype.Timestamptz {
+	ttl := s.draftTTL
+	if ttl <= 0 {
+		ttl = defaultDraftTTL
+	}
+	return pgtype.Timestamptz{Time: now.Add(-ttl), Valid: true}
+}
+
+// memberDraft loads the member's draft of a new thread, or of a reply when
+// threadID is set. It returns nil if there's none; a draft failing to load
+// is logged rather than failing the page.
+func (s *DiscussService) memberDraft(r *http.Request, user User, threadID int64) *Draft {
+	draft, err := s.queries.GetDraft(r.Context(), GetDraftParams{
+		MemberID:   user.ID,
+		ThreadID:   draftThreadID(threadID),
+		SavedAfter: s.draftsSavedAfter(time.Now()),
+	})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			s.logger.WarnContext(r.Context(), "error getting draft", slog.Int64("thread_id", threadID), slog.String("error", err.Error()))
+		}
+		return nil
+	}
+	return &draft
+}
+
+// discardDraft deletes the member's draft once what it was a draft of has
+// been posted. Failing to is only logged, the draft expires in time anyway.
+func (s *DiscussService) discardDraft(r *http.Request, user User, threadID int64) {
+	if err := s.queries.DeleteDraft(r.Context(), DeleteDraftParams{
+		MemberID: user.ID,
+		ThreadID: draftThreadID(threadID),
+	}); err != nil {
+		s.logger.WarnContext(r.Context(), "error discarding draft", slog.Int64("thread_id", threadID), slog.String("error", err.Error()))
+	}
+}
+
+// pruneDrafts deletes drafts that haven't been saved within the draft TTL.
+func (s *DiscussService) pruneDrafts(ctx context.Context) error {
+	deleted, err := s.queries.DeleteExpiredDrafts(ctx, s.draftsSavedAfter(time.Now()))
+	if err != nil {
+		return err
+	}
+
+	s.logger.DebugContext(ctx, "pruned drafts", slog.Int64("deleted", deleted))
+	return nil
+}
+
+// SaveDraft autosaves the current member's draft of a new thread or, with
+// thread_id set, of a reply. Saving an empty draft deletes it.
+func (s *DiscussService) SaveDraft(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "SaveDraft")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	user, err := GetUser(r)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "GetUser", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	var threadID int64
+	if value := r.Form.Get("thread_id"); value != "" {
+		if threadID, err = strconv.ParseInt(value, 10, 64); err != nil || threadID <= 0 {
+			http.Error(w, "thread_id: invalid thread", http.StatusBadRequest)
+			return
+		}
+	}
+
+	subject := r.Form.Get("subject")
+	body := r.Form.Get("body")
+	if threadID != 0 {
+		// Replies have no subject of their own
+		subject = ""
+	}
+
+	if errors := ValidateDraftForm(subject, body); len(errors) > 0 {
+		http.Error(w, errors.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if threadID != 0 {
+		span.AddEvent("queries.GetThreadBoard")
+		if _, err := s.queries.GetThreadBoard(r.Context(), threadID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				s.renderError(w, http.StatusNotFound)
+				return
+			}
+			s.logger.ErrorContext(r.Context(), "GetThreadBoard", slog.String("error", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if strings.TrimSpace(subject) == "" && strings.TrimSpace(body) == "" {
+		span.AddEvent("queries.DeleteDraft")
+		err = s.queries.DeleteDraft(r.Context(), DeleteDraftParams{
+			MemberID: user.ID,
+			ThreadID: draftThreadID(threadID),
+		})
+	} else {
+		span.AddEvent("queries.SaveDraft")
+		err = s.queries.SaveDraft(r.Context(), SaveDraftParams{
+			MemberID: user.ID,
+			ThreadID: draftThreadID(threadID),
+			Subject:  subject,
+			Body:     body,
+		})
+	}
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error saving draft", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDraftTemplateData(t *testing.T) {
+	saved := pgtype.Timestamptz{Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Valid: true}
+	rows := []ListMemberDraftsRow{
+		{ID: 1, Subject: "Ship it?", Body: "thoughts", DateSaved: saved},
+		{ID: 2, ThreadID: pgtype.Int8{Int64: 7, Valid: true}, Body: "+1", ThreadSubject: pgtype.Text{String: "Release notes", Valid: true}},
+		{ID: 3, Body: "no subject yet"},
+	}
+
+	drafts := buildDraftTemplateData(rows)
+	require.Len(t, drafts, 3)
+
+	assert.Equal(t, "Ship it?", drafts[0].Title)
+	assert.Equal(t, "/thread/new", drafts[0].URL)
+	assert.Equal(t, int64(0), drafts[0].ThreadID)
+	assert.Equal(t, saved, drafts[0].DateSaved)
+
+	assert.Equal(t, "Re: Release notes", drafts[1].Title)
+	assert.Equal(t, "/thread/7#reply", drafts[1].URL)
+	assert.Equal(t, int64(7), drafts[1].ThreadID)
+
+	assert.Equal(t, "(no subject)", drafts[2].Title)
+
+	assert.NotNil(t, buildDraftTemplateData(nil))
+}
+
+func TestDraftsSavedAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	s := &DiscussService{draftTTL: time.Hour}
+	assert.Equal(t, now.Add(-time.Hour), s.draftsSavedAfter(now).Time)
+
+	s = &DiscussService{}
+	assert.Equal(t, now.Add(-defaultDraftTTL), s.draftsSavedAfter(now).Time)
+}
+
+func TestDraftThreadID(t *testing.T) {
+	assert.False(t, draftThreadID(0).Valid)
+	assert.Equal(t, pgtype.Int8{Int64: 7, Valid: true}, draftThreadID(7))
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// Atom feed structures (RFC 4287)
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published,omitempty"`
+	Author    atomPerson  `xml:"author"`
+	Link      atomLink    `xml:"link"`
+	Content   atomContent `xml:"content"`
+}
+
+// feedTagDate is the date in the tag: URIs (RFC 4151) that identify feeds
+// and entries. Changing it would make readers see every entry again.
+const feedTagDate = "2026"
+
+// feedSite is where a feed's links point and whose name its IDs are minted
+// under. Links follow the host the request was made to, but IDs use the
+// board's configured hostname, so an entry keeps its ID whether the board is
+// reached by its short name, full name or address. Images in entries are
+// served through images.
+type feedSite struct {
+	baseURL  string
+	hostname string
+	images   *ImageProxy
+}
+
+func (s *DiscussService) feedSite(r *http.Request) feedSite {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return feedSite{baseURL: fmt.Sprintf("%s://%s", scheme, r.Host), hostname: s.hostname, images: s.images}
+}
+
+// tagURI returns the ID of the feed or entry at path.
+func (f feedSite) tagURI(path string) string {
+	return fmt.Sprintf("tag:%s,%s:%s", f.hostname, feedTagDate, path)
+}
+
+func atomTime(t pgtype.Timestamptz) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.UTC().Format(time.RFC3339)
+}
+
+// newAtomFeed creates a feed with self and alternate links. The feed's updated
+// time is the newest entry's updated time, falling back to now for empty feeds.
+func newAtomFeed(site feedSite, selfPath, alternatePath, title string, entries []atomEntry) atomFeed {
+	updated := ""
+	for _, e := range entries {
+		if e.Updated > updated {
+			updated = e.Updated
+		}
+	}
+	if updated == "" {
+		updated = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	return atomFeed{
+		Xmlns:   atomNamespace,
+		ID:      site.tagURI(selfPath),
+		Title:   title,
+		Updated: updated,
+		Links: []atomLink{
+			{Href: site.baseURL + selfPath, Rel: "self", Type: "application/atom+xml"},
+			{Href: site.baseURL + alternatePath, Rel: "alternate", Type: "text/html"},
+		},
+		Entries: entries,
+	}
+}
+
+// buildBoardFeed creates a feed with one entry per thread, using the thread's
+// first post as the entry content. path is the page the threads are listed
+// on, with the feed alongside it. Authors are named as on each thread's board.
+func buildBoardFeed(site feedSite, path, title string, threads []ListThreadsRow) atomFeed {
+	entries := make([]atomEntry, 0, len(threads))
+	for _, thread := range threads {
+		threadPath := fmt.Sprintf("/thread/%d", thread.ThreadID)
+		threadURL := site.baseURL + threadPath
+		entries = append(entries, atomEntry{
+			ID:      site.tagURI(threadPath),
+			Title:   thread.Subject,
+			Updated: atomTime(thread.DateLastPosted),
+			Author: atomPerson{
+				Name: displayName(thread.Email.String, thread.PreferredName.String, thread.HideEmails),
+				URI:  fmt.Sprintf("%s/member/%d", site.baseURL, thread.ID.Int64),
+			},
+			Link:    atomLink{Href: threadURL, Rel: "alternate", Type: "text/html"},
+			Content: atomContent{Type: "html", Body: site.images.proxyStoredImages(thread.Body.String)},
+		})
+	}
+
+	return newAtomFeed(site, strings.TrimSuffix(path, "/")+"/feed.atom", path, title, entries)
+}
+
+// buildThreadFeed creates a feed with one entry per post in a thread. Posts
+// awaiting moderation or hidden by a moderator are left out.
+func buildThreadFeed(site feedSite, subject string, threadID int64, hideEmails bool, posts []ListThreadPostsRow) atomFeed {
+	entries := make([]atomEntry, 0, len(posts))
+	for _, post := range posts {
+		if post.Held || post.Hidden {
+			continue
+		}
+		postURL := fmt.Sprintf("%s/thread/%d#post-%d", site.baseURL, threadID, post.ID)
+		entries = append(entries, atomEntry{
+			ID:        site.tagURI(fmt.Sprintf("/thread/%d/post/%d", threadID, post.ID)),
+			Title:     fmt.Sprintf("%s #%d", subject, post.ID),
+			Updated:   atomTime(post.DatePosted),
+			Published: atomTime(post.DatePosted),
+			Author: atomPerson{
+				Name: displayName(post.Email.String, post.PreferredName.String, hideEmails),
+				URI:  fmt.Sprintf("%s/member/%d", site.baseURL, post.MemberID.Int64),
+			},
+			Link:    atomLink{Href: postURL, Rel: "alternate", Type: "text/html"},
+			Content: atomContent{Type: "html", Body: site.images.proxyStoredImages(post.Body.String)},
+		})
+	}
+
+	return newAtomFeed(site,
+		fmt.Sprintf("/thread/%d/feed.atom", threadID),
+		fmt.Sprintf("/thread/%d", threadID),
+		subject, entries)
+}
+
+// buildMemberFeed creates a feed with one entry per thread started by the
+// member known by name. Each entry names them as on the thread's board.
+func buildMemberFeed(site feedSite, name string, memberID int64, threads []ListMemberThreadsRow) atomFeed {
+	entries := make([]atomEntry, 0, len(threads))
+	for _, thread := range threads {
+		threadPath := fmt.Sprintf("/thread/%d", thread.ThreadID)
+		threadURL := site.baseURL + threadPath
+		entries = append(entries, atomEntry{
+			ID:      site.tagURI(threadPath),
+			Title:   thread.Subject,
+			Updated: atomTime(thread.DateLastPosted),
+			Author: atomPerson{
+				Name: displayName(thread.Email.String, thread.PreferredName.String, thread.HideEmails),
+				URI:  fmt.Sprintf("%s/member/%d", site.baseURL, memberID),
+			},
+			Link:    atomLink{Href: threadURL, Rel: "alternate", Type: "text/html"},
+			Content: atomContent{Type: "html", Body: site.images.proxyStoredImages(thread.Body.String)},
+		})
+	}
+
+	return newAtomFeed(site,
+		fmt.Sprintf("/member/%d/feed.atom", memberID),
+		fmt.Sprintf("/member/%d", memberID),
+		fmt.Sprintf("Threads by %s", name), entries)
+}
+
+func (s *DiscussService) renderFeed(w http.ResponseWriter, r *http.Request, feed atomFeed) {
+	out, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error encoding feed", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	w.Write(out)
+}
+
+// BoardFeed serves an Atom feed of the most recently active threads.
+func (s *DiscussService) BoardFeed(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "BoardFeed")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	user, err := GetUser(r)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error getting user", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	// /feed.atom covers every board; /b/{slug}/feed.atom only that board and
+	// /tag/{tag}/feed.atom only threads with that tag
+	path, title := "/", GetBoardTitle(r)
+	var boardID pgtype.Int4
+	var tag pgtype.Text
+	if name := r.PathValue("tag"); name != "" {
+		if !boardSlugPattern.MatchString(name) {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		path, title = tagPath(name), "#"+name
+		tag = pgtype.Text{String: name, Valid: true}
+	} else if slug := r.PathValue("slug"); slug != "" {
+		board, ok := GetBoard(r)
+		if !ok || board.Slug != slug {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		path, title = boardPath(board.Slug), board.Title
+		boardID = pgtype.Int4{Int32: board.ID, Valid: true}
+	}
+
+	span.AddEvent("queries.ListThreads")
+	threads, err := s.queries.ListThreads(r.Context(), ListThreadsParams{
+		Email:    user.Email,
+		MemberID: user.ID,
+		BoardID:  boardID,
+		Tag:      tag,
+	})
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error listing threads", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	s.renderFeed(w, r, buildBoardFeed(s.feedSite(r), path, title, threads))
+}
+
+// ThreadFeed serves an Atom feed of the posts in a thread.
+func (s *DiscussService) ThreadFeed(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "ThreadFeed")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	threadID, err := strconv.ParseInt(r.PathValue("tid"), 10, 64)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error parsing thread ID", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	user, err := GetUser(r)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error getting user", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	span.AddEvent("queries.GetThreadSubject")
+	subject, err := s.queries.GetThreadSubjectById(r.Context(), threadID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "error getting thread subject", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	span.AddEvent("queries.GetThreadBoard")
+	board, err := s.queries.GetThreadBoard(r.Context(), threadID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "error getting thread board", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	span.AddEvent("queries.ListThreadPosts")
+	posts, err := s.queries.ListThreadPosts(r.Context(), ListThreadPostsParams{
+		ThreadID:   threadID,
+		Email:      user.Email,
+		MemberID:   user.ID,
+		EditWindow: board.EditWindow.Int32,
+	})
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error listing thread posts", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	s.renderFeed(w, r, buildThreadFeed(s.feedSite(r), subject, threadID, board.HideEmails, posts))
+}
+
+// MemberFeed serves an Atom feed of the threads started by a member.
+func (s *DiscussService) MemberFeed(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "MemberFeed")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	memberID, err := strconv.ParseInt(r.PathValue("mid"), 10, 64)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error parsing member ID", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	span.AddEvent("queries.GetMember")
+	member, err := s.queries.GetMember(r.Context(), memberID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "error getting member", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	span.AddEvent("queries.ListMemberThreads")
+	threads, err := s.queries.ListMemberThreads(r.Context(), ListMemberThreadsParams{MemberID: memberID})
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error getting member threads", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	// The feed covers every board, so it's titled without the member's email
+	// if any board hides emails
+	span.AddEvent("queries.AnyBoardHidesEmails")
+	hideEmails, err := s.queries.AnyBoardHidesEmails(r.Context())
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error checking boards' email settings", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	name := displayName(member.Email, member.PreferredName.String, hideEmails)
+	s.renderFeed(w, r, buildMemberFeed(s.feedSite(r), name, memberID, threads))
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imeyer/tdiscuss/middleware"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+var testFeedSite = feedSite{baseURL: "https://discuss.example.ts.net", hostname: "discuss"}
+
+func TestBuildBoardFeed(t *testing.T) {
+	older := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC)
+
+	feed := buildBoardFeed(testFeedSite, "/", "My Board", []ListThreadsRow{
+		{
+			ThreadID:       7,
+			DateLastPosted: pgtype.Timestamptz{Time: newer, Valid: true},
+			ID:             pgtype.Int8{Int64: 3, Valid: true},
+			Email:          pgtype.Text{String: "alice@example.com", Valid: true},
+			Subject:        "Hello",
+			Body:           pgtype.Text{String: "<p>first <strong>post</strong></p>", Valid: true},
+		},
+		{
+			ThreadID:       5,
+			DateLastPosted: pgtype.Timestamptz{Time: older, Valid: true},
+			ID:             pgtype.Int8{Int64: 4, Valid: true},
+			Email:          pgtype.Text{String: "bob@example.com", Valid: true},
+			PreferredName:  pgtype.Text{String: "Bob", Valid: true},
+			Subject:        "Older",
+			Body:           pgtype.Text{String: "<p>older</p>", Valid: true},
+		},
+	})
+
+	if feed.ID != "tag:discuss,2026:/feed.atom" {
+		t.Errorf("feed ID = %q", feed.ID)
+	}
+	if feed.Updated != "2024-05-02T08:30:00Z" {
+		t.Errorf("feed Updated = %q, want newest entry time", feed.Updated)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(feed.Entries))
+	}
+
+	entry := feed.Entries[0]
+	if entry.ID != "tag:discuss,2026:/thread/7" {
+		t.Errorf("entry ID = %q", entry.ID)
+	}
+	if entry.Link.Href != "https://discuss.example.ts.net/thread/7" {
+		t.Errorf("entry link = %q", entry.Link.Href)
+	}
+	if entry.Author.URI != "https://discuss.example.ts.net/member/3" {
+		t.Errorf("author URI = %q", entry.Author.URI)
+	}
+	if entry.Author.Name != "alice@example.com" {
+		t.Errorf("author name = %q", entry.Author.Name)
+	}
+	if got := feed.Entries[1].Author.Name; got != "Bob" {
+		t.Errorf("author name = %q, want preferred name", got)
+	}
+	if entry.Content.Type != "html" {
+		t.Errorf("content type = %q, want html", entry.Content.Type)
+	}
+
+	out, err := xml.Marshal(feed)
+	if err != nil {
+		t.Fatalf("xml.Marshal() error = %v", err)
+	}
+	// The HTML body must be escaped inside the content element
+	if !strings.Contains(string(out), "&lt;strong&gt;post&lt;/strong&gt;") {
+		t.Errorf("expected escaped HTML content, got %s", out)
+	}
+	if !strings.Contains(string(out), `xmlns="http://www.w3.org/2005/Atom"`) {
+		t.Errorf("expected Atom namespace, got %s", out)
+	}
+}
+
+func TestBuildBoardFeed_Board(t *testing.T) {
+	feed := buildBoardFeed(testFeedSite, boardPath("eng"), "Engineering", nil)
+
+	if feed.ID != "tag:discuss,2026:/b/eng/feed.atom" {
+		t.Errorf("feed ID = %q", feed.ID)
+	}
+	if feed.Title != "Engineering" {
+		t.Errorf("feed Title = %q", feed.Title)
+	}
+}
+
+func TestBuildBoardFeed_Tag(t *testing.T) {
+	feed := buildBoardFeed(testFeedSite, tagPath("rfc"), "#rfc", nil)
+
+	if feed.ID != "tag:discuss,2026:/tag/rfc/feed.atom" {
+		t.Errorf("feed ID = %q", feed.ID)
+	}
+	if feed.Links[1].Href != "https://discuss.example.ts.net/tag/rfc" {
+		t.Errorf("alternate link = %q", feed.Links[1].Href)
+	}
+}
+
+func TestBuildThreadFeed(t *testing.T) {
+	posted := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	feed := buildThreadFeed(feedSite{baseURL: "http://discuss", hostname: "discuss"}, "Subject", 42, true, []ListThreadPostsRow{
+		{
+			ID:         100,
+			DatePosted: pgtype.Timestamptz{Time: posted, Valid: true},
+			MemberID:   pgtype.Int8{Int64: 1, Valid: true},
+			Email:      pgtype.Text{String: "alice@example.com", Valid: true},
+			Body:       pgtype.Text{String: "<p>reply</p>", Valid: true},
+		},
+		{
+			ID:         101,
+			DatePosted: pgtype.Timestamptz{Time: posted, Valid: true},
+			Body:       pgtype.Text{String: "<p>held</p>", Valid: true},
+			Held:       true,
+		},
+		{
+			ID:         102,
+			DatePosted: pgtype.Timestamptz{Time: posted, Valid: true},
+			Body:       pgtype.Text{String: "<p>hidden</p>", Valid: true},
+			Hidden:     true,
+		},
+	})
+
+	if feed.ID != "tag:discuss,2026:/thread/42/feed.atom" {
+		t.Errorf("feed ID = %q", feed.ID)
+	}
+	if len(feed.Entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(feed.Entries))
+	}
+	if got := feed.Entries[0].ID; got != "tag:discuss,2026:/thread/42/post/100" {
+		t.Errorf("entry ID = %q", got)
+	}
+	if got := feed.Entries[0].Published; got != "2024-05-01T12:00:00Z" {
+		t.Errorf("entry Published = %q", got)
+	}
+	// The board hides emails, so authors are named without them
+	if got := feed.Entries[0].Author.Name; got != "alice" {
+		t.Errorf("author name = %q, want email hidden", got)
+	}
+}
+
+func TestBuildMemberFeedEmpty(t *testing.T) {
+	feed := buildMemberFeed(feedSite{baseURL: "http://discuss", hostname: "discuss"}, "alice@example.com", 9, nil)
+
+	if feed.Title != "Threads by alice@example.com" {
+		t.Errorf("feed Title = %q", feed.Title)
+	}
+	if feed.Updated == "" {
+		t.Error("expected feed Updated to be set for an empty feed")
+	}
+	if len(feed.Entries) != 0 {
+		t.Errorf("got %d entries, want 0", len(feed.Entries))
+	}
+}
+
+func TestBuildBoardFeed_StableIDs(t *testing.T) {
+	threads := []ListThreadsRow{{ThreadID: 7, Subject: "Hello"}}
+	want := buildBoardFeed(testFeedSite, "/", "My Board", threads)
+
+	// The same board reached by its short name and its address
+	for _, baseURL := range []string{"http://discuss", "http://100.64.0.1"} {
+		feed := buildBoardFeed(feedSite{baseURL: baseURL, hostname: "discuss"}, "/", "My Board", threads)
+		if feed.ID != want.ID || feed.Entries[0].ID != want.Entries[0].ID {
+			t.Errorf("IDs via %s = %q, %q, want %q, %q", baseURL, feed.ID, feed.Entries[0].ID, want.ID, want.Entries[0].ID)
+		}
+		if got := feed.Entries[0].Link.Href; got != baseURL+"/thread/7" {
+			t.Errorf("entry link = %q, want it on %s", got, baseURL)
+		}
+	}
+}
+
+func TestThreadFeed_UsesThreadBoard(t *testing.T) {
+	posted := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var listed ListThreadPostsParams
+	s := &DiscussService{
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		telemetry: &TelemetryConfig{Tracer: noop.NewTracerProvider().Tracer("test")},
+		hostname:  "discuss",
+		queries: &MockQueries{
+			GetThreadSubjectByIdFunc: func(ctx context.Context, id int64) (string, error) {
+				return "Subject", nil
+			},
+			GetThreadBoardFunc: func(ctx context.Context, id int64) (GetThreadBoardRow, error) {
+				return GetThreadBoardRow{ID: 2, EditWindow: pgtype.Int4{Int32: 3600, Valid: true}, HideEmails: true}, nil
+			},
+			ListThreadPostsFunc: func(ctx context.Context, arg ListThreadPostsParams) ([]ListThreadPostsRow, error) {
+				listed = arg
+				return []ListThreadPostsRow{{
+					ID:         100,
+					DatePosted: pgtype.Timestamptz{Time: posted, Valid: true},
+					MemberID:   pgtype.Int8{Int64: 1, Valid: true},
+					Email:      pgtype.Text{String: "alice@example.com", Valid: true},
+					Body:       pgtype.Text{String: "<p>reply</p>", Valid: true},
+				}}, nil
+			},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /thread/{tid}/feed.atom", s.ThreadFeed)
+
+	// The request carries no board, so only the thread's board hides emails
+	r := httptest.NewRequest(http.MethodGet, "/thread/42/feed.atom", nil)
+	r = r.WithContext(middleware.WithUser(r.Context(), &middleware.ContextUser{ID: 3, Email: "bob@example.com"}))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if listed.EditWindow != 3600 {
+		t.Errorf("edit window = %d, want the thread board's", listed.EditWindow)
+	}
+	if strings.Contains(w.Body.String(), "alice@example.com") {
+		t.Errorf("feed shows an email the thread's board hides:\n%s", w.Body.String())
+	}
+}
+
+func TestThreadFeed_ThreadBoardNotFound(t *testing.T) {
+	s := &DiscussService{
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		telemetry: &TelemetryConfig{Tracer: noop.NewTracerProvider().Tracer("test")},
+		hostname:  "discuss",
+		queries: &MockQueries{
+			GetThreadSubjectByIdFunc: func(ctx context.Context, id int64) (string, error) {
+				return "Subject", nil
+			},
+			GetThreadBoardFunc: func(ctx context.Context, id int64) (GetThreadBoardRow, error) {
+				return GetThreadBoardRow{}, pgx.ErrNoRows
+			},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /thread/{tid}/feed.atom", s.ThreadFeed)
+
+	r := httptest.NewRequest(http.MethodGet, "/thread/42/feed.atom", nil)
+	r = r.WithContext(middleware.WithUser(r.Context(), &middleware.ContextUser{ID: 3, Email: "bob@example.com"}))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestBuildBoardFeed_NamesAuthorsAsOnTheirBoard(t *testing.T) {
+	feed := buildBoardFeed(testFeedSite, "/", "My Board", []ListThreadsRow{
+		{ThreadID: 7, Email: pgtype.Text{String: "alice@example.com", Valid: true}},
+		{ThreadID: 8, Email: pgtype.Text{String: "carol@example.com", Valid: true}, HideEmails: true},
+	})
+
+	if got := feed.Entries[0].Author.Name; got != "alice@example.com" {
+		t.Errorf("author on a board showing emails = %q", got)
+	}
+	if got := feed.Entries[1].Author.Name; got != "carol" {
+		t.Errorf("author on a board hiding emails = %q, want email hidden", got)
+	}
+}
+
+func TestMemberFeed_HidesEmails(t *testing.T) {
+	s := &DiscussService{
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		telemetry: &TelemetryConfig{Tracer: noop.NewTracerProvider().Tracer("test")},
+		hostname:  "discuss",
+		queries: &MockQueries{
+			GetMemberFunc: func(ctx context.Context, id int64) (GetMemberRow, error) {
+				return GetMemberRow{ID: id, Email: "alice@example.com"}, nil
+			},
+			ListMemberThreadsFunc: func(ctx context.Context, arg ListMemberThreadsParams) ([]ListMemberThreadsRow, error) {
+				return []ListMemberThreadsRow{{
+					ThreadID:   7,
+					Email:      pgtype.Text{String: "alice@example.com", Valid: true},
+					Body:       pgtype.Text{String: "<p>hi</p>", Valid: true},
+					HideEmails: true,
+				}}, nil
+			},
+			// Some board other than the request's hides emails
+			AnyBoardHidesEmailsFunc: func(ctx context.Context) (bool, error) {
+				return true, nil
+			},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /member/{mid}/feed.atom", s.MemberFeed)
+
+	r := httptest.NewRequest(http.MethodGet, "/member/9/feed.atom", nil)
+	r = r.WithContext(middleware.WithUser(r.Context(), &middleware.ContextUser{ID: 3, Email: "bob@example.com"}))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "alice@example.com") {
+		t.Errorf("feed shows an email a board hides:\n%s", w.Body.String())
+	}
+}
@@ -1,17 +1,20 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"html/template"
 	"log/slog"
-	"net"
 	"net/http"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/imeyer/tdiscuss/middleware"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -25,15 +28,33 @@ type MemberThreadPostTemplateData struct {
 	PostCount   int64
 }
 
+// RateLimitTemplateData is one role's row in the admin rate limit form
+type RateLimitTemplateData struct {
+	Role          string
+	Requests      int
+	WindowSeconds int
+}
+
 type ThreadPostTemplateData struct {
 	ID       int64
 	Body     template.HTML
 	ThreadID pgtype.Int8
 	MemberID pgtype.Int8
-	Email    pgtype.Text
+	Author   Identity
 	// nosemgrep
 	DatePosted pgtype.Timestamptz
 	CanEdit    pgtype.Bool
+	Edited     bool
+	DateEdited pgtype.Timestamptz
+	// CanViewRevisions is true for the post's author and admins
+	CanViewRevisions bool
+	Reactions        []PostReactionTemplateData
+	// Links are preview cards for links in the post
+	Links []LinkCardTemplateData
+	// Held posts are awaiting moderation and only shown to their author
+	Held bool
+	// Hidden posts were hidden by a moderator
+	Hidden bool
 }
 
 type ThreadTemplateData struct {
@@ -42,16 +63,25 @@ type ThreadTemplateData struct {
 	Email          pgtype.Text
 	Lastid         pgtype.Int8
 	Lastname       pgtype.Text
+	LastPoster     Identity
 	Posts          pgtype.Int4
 	Views          pgtype.Int4
 	DateLastPosted pgtype.Timestamptz
 	CanEdit        pgtype.Bool
 	Sticky         pgtype.Bool
 	Locked         pgtype.Bool
+	BoardSlug      string
+	BoardTitle     string
+	Tags           []string
 }
 
 // Helper methods
 func (s *DiscussService) renderTemplate(w http.ResponseWriter, r *http.Request, tmpl string, data map[string]interface{}) {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	data["Clock"] = s.viewerClock(r)
+
 	if err := s.tmpls.ExecuteTemplate(w, tmpl, data); err != nil {
 		s.logger.ErrorContext(r.Context(), err.Error())
 		http.Error(w, "Failed to render template", http.StatusInternalServerError)
@@ -89,14 +119,32 @@ func (s *DiscussService) AdminGET(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get board data with statistics
-	boardData, err := s.queries.GetBoardData(r.Context())
+	// Get board data with statistics, for the board chosen with ?board=
+	boardData, err := s.getBoard(r.Context(), r.URL.Query().Get("board"))
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
 		s.logger.ErrorContext(r.Context(), "error getting board data", slog.String("error", err.Error()))
 		s.renderError(w, http.StatusInternalServerError)
 		return
 	}
 
+	boards, err := s.queries.ListBoards(r.Context())
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error listing boards", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	tags, err := s.queries.ListTags(r.Context())
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error listing tags", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
 	// TODO: Implement ListAllThreadPostsGroupedByMember query
 	// For now, return empty list
 	memberThreadPosts := []MemberThreadPostTemplateData{
@@ -105,16 +153,32 @@ func (s *DiscussService) AdminGET(w http.ResponseWriter, r *http.Request) {
 		{MemberID: 2, MemberEmail: "user@example.com", PostCount: 5},
 	}
 
+	// Show every configurable role, including any without a row yet
+	limits := s.roleLimits.Get(r.Context())
+	rateLimits := make([]RateLimitTemplateData, 0, len(middleware.RateLimitRoles))
+	for _, role := range middleware.RateLimitRoles {
+		rateLimits = append(rateLimits, RateLimitTemplateData{
+			Role:          role,
+			Requests:      limits[role].Requests,
+			WindowSeconds: int(limits[role].Window.Seconds()),
+		})
+	}
+
 	s.logger.DebugContext(r.Context(), "rendering admin template")
 
 	s.renderTemplate(w, r, "admin.html", map[string]interface{}{
 		"Title":            GetBoardTitle(r),
 		"BoardData":        boardData,
+		"Boards":           newBoardTemplateData(boards),
+		"Tags":             tags,
 		"Posts":            memberThreadPosts,
 		"Version":          s.version,
 		"GitSha":           s.gitSha,
 		"CurrentUserEmail": user.Email,
 		"User":             user,
+		"ReactionEmoji":    strings.Join(boardReactionEmoji(boardData.ReactionEmoji), " "),
+		"BoardACL":         strings.Join(boardData.Acl, "\n"),
+		"RateLimits":       rateLimits,
 	})
 }
 
@@ -185,35 +249,174 @@ func (s *DiscussService) AdminPOST(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		s.logger.InfoContext(r.Context(), "member blocked successfully", slog.Int64("memberID", memberID))
+		s.recordAudit(r, user, auditEntry{
+			Action:     auditBlockMember,
+			TargetType: auditTargetMember,
+			TargetID:   memberID,
+			After:      auditFlag("is_blocked", true),
+		})
 	case "delete_thread":
 		// TODO: Implement DeleteThread query
 		s.logger.InfoContext(r.Context(), "DeleteThread not implemented", slog.Int64("threadID", threadID))
 		// For now, just log and redirect
+	case "create_board":
+		slug := strings.TrimSpace(r.Form.Get("board_slug"))
+		title := SanitizeInput(r.Form.Get("board_title"))
+		description := SanitizeInput(r.Form.Get("board_description"))
+
+		if errs := ValidateBoardForm(slug, title, description); len(errs) > 0 {
+			s.logger.DebugContext(r.Context(), "invalid board", slog.String("error", errs.Error()))
+			http.Error(w, errs.Error(), http.StatusBadRequest)
+			return
+		}
+
+		boardID, err := s.queries.CreateBoard(r.Context(), CreateBoardParams{
+			Slug:        slug,
+			Title:       title,
+			Description: description,
+		})
+		if err != nil {
+			// 23505 is unique_violation: the slug is taken
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				http.Error(w, fmt.Sprintf("a board named %q already exists", slug), http.StatusConflict)
+				return
+			}
+			s.logger.ErrorContext(r.Context(), "failed to create board",
+				slog.String("error", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+
+		s.logger.InfoContext(r.Context(), "board created successfully", slog.String("board_slug", slug))
+		s.recordAudit(r, user, auditEntry{
+			Action:     auditCreateBoard,
+			TargetType: auditTargetBoard,
+			TargetID:   int64(boardID),
+			After:      boardConfigSnapshot{Slug: slug, Title: title, Description: description},
+		})
+
+		// nosemgrep
+		http.Redirect(w, r, boardPath(slug), http.StatusSeeOther)
+		return
 	case "update_config":
+		boardSlug := r.Form.Get("board")
 		boardTitle := r.Form.Get("board_title")
 		editWindowStr := r.Form.Get("edit_window")
+		reactionEmojiStr := r.Form.Get("reaction_emoji")
 
-		// Update board title
-		if boardTitle != "" {
-			if err := s.queries.UpdateBoardTitle(r.Context(), boardTitle); err != nil {
-				s.logger.ErrorContext(r.Context(), "failed to update board title",
+		before, err := s.getBoard(r.Context(), boardSlug)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "unknown board", http.StatusBadRequest)
+				return
+			}
+			s.logger.ErrorContext(r.Context(), "failed to get board data",
+				slog.String("error", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+
+		// Only the settings in the form are changed. Everything is checked
+		// before any of it is written.
+		var acl []string
+		if r.Form.Has("board_acl") {
+			var errs ValidationErrors
+			acl, errs = ValidateBoardACL(r.Form.Get("board_acl"))
+			if len(errs) > 0 {
+				s.logger.DebugContext(r.Context(), "invalid board access list", slog.String("error", errs.Error()))
+				http.Error(w, errs.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		// The default board holds the settings every page reads, so it
+		// stays visible to everyone
+		if len(acl) > 0 {
+			defaultBoard, err := s.getBoard(r.Context(), "")
+			if err != nil {
+				s.logger.ErrorContext(r.Context(), "failed to get default board",
 					slog.String("error", err.Error()))
 				s.renderError(w, http.StatusInternalServerError)
 				return
 			}
+			if defaultBoard.ID == before.ID {
+				http.Error(w, "board_acl: the default board can't be private", http.StatusBadRequest)
+				return
+			}
 		}
 
-		// Update edit window
+		// The description may be cleared
+		boardDescription := SanitizeInput(r.Form.Get("board_description"))
+		if len(boardDescription) > MaxBoardDescriptionLength {
+			http.Error(w, fmt.Sprintf("board_description: must not exceed %d characters", MaxBoardDescriptionLength), http.StatusBadRequest)
+			return
+		}
+
+		var editWindow int64
 		if editWindowStr != "" {
-			editWindow, err := strconv.ParseInt(editWindowStr, 10, 32)
-			if err != nil {
+			if editWindow, err = strconv.ParseInt(editWindowStr, 10, 32); err != nil {
 				s.logger.ErrorContext(r.Context(), "invalid edit window value",
 					slog.String("error", err.Error()))
 				s.renderError(w, http.StatusBadRequest)
 				return
 			}
+		}
 
-			if err := s.queries.UpdateBoardEditWindow(r.Context(), pgtype.Int4{Int32: int32(editWindow), Valid: true}); err != nil {
+		var reactionEmoji []string
+		if reactionEmojiStr != "" {
+			var errs ValidationErrors
+			if reactionEmoji, errs = ValidateReactionEmoji(reactionEmojiStr); len(errs) > 0 {
+				s.logger.ErrorContext(r.Context(), "invalid reaction emoji",
+					slog.String("error", errs.Error()))
+				s.renderError(w, http.StatusBadRequest)
+				return
+			}
+		}
+
+		// The settings are saved together, so a failure leaves the board as it was
+		tx, err := s.dbconn.Begin(r.Context())
+		if err != nil {
+			s.logger.ErrorContext(r.Context(), "error starting transaction", slog.String("SQLError", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		qtx := s.queries.(ExtendedQuerier).WithTx(tx)
+
+		if boardTitle != "" {
+			if err := qtx.UpdateBoardTitle(r.Context(), UpdateBoardTitleParams{Title: boardTitle, ID: before.ID}); err != nil {
+				s.logger.ErrorContext(r.Context(), "failed to update board title",
+					slog.String("error", err.Error()))
+				s.renderError(w, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if r.Form.Has("board_description") {
+			if err := qtx.UpdateBoardDescription(r.Context(), UpdateBoardDescriptionParams{Description: boardDescription, ID: before.ID}); err != nil {
+				s.logger.ErrorContext(r.Context(), "failed to update board description",
+					slog.String("error", err.Error()))
+				s.renderError(w, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// An empty access list makes the board public
+		if r.Form.Has("board_acl") {
+			if err := qtx.UpdateBoardAcl(r.Context(), UpdateBoardAclParams{Acl: acl, ID: before.ID}); err != nil {
+				s.logger.ErrorContext(r.Context(), "failed to update board access list",
+					slog.String("error", err.Error()))
+				s.renderError(w, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if editWindowStr != "" {
+			if err := qtx.UpdateBoardEditWindow(r.Context(), UpdateBoardEditWindowParams{
+				EditWindow: pgtype.Int4{Int32: int32(editWindow), Valid: true},
+				ID:         before.ID,
+			}); err != nil {
 				s.logger.ErrorContext(r.Context(), "failed to update edit window",
 					slog.String("error", err.Error()))
 				s.renderError(w, http.StatusInternalServerError)
@@ -221,9 +424,188 @@ func (s *DiscussService) AdminPOST(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		// Hiding emails is a checkbox after a hidden "off", so the form
+		// has "on" as well when it's ticked
+		if r.Form.Has("hide_emails") {
+			if err := qtx.UpdateBoardHideEmails(r.Context(), UpdateBoardHideEmailsParams{
+				HideEmails: slices.Contains(r.Form["hide_emails"], "on"),
+				ID:         before.ID,
+			}); err != nil {
+				s.logger.ErrorContext(r.Context(), "failed to update hide emails",
+					slog.String("error", err.Error()))
+				s.renderError(w, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if reactionEmojiStr != "" {
+			if err := qtx.UpdateBoardReactionEmoji(r.Context(), UpdateBoardReactionEmojiParams{
+				ReactionEmoji: reactionEmoji,
+				ID:            before.ID,
+			}); err != nil {
+				s.logger.ErrorContext(r.Context(), "failed to update reaction emoji",
+					slog.String("error", err.Error()))
+				s.renderError(w, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			s.logger.ErrorContext(r.Context(), "error committing transaction", slog.String("SQLError", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+
 		s.logger.InfoContext(r.Context(), "board config updated successfully",
 			slog.String("board_title", boardTitle),
-			slog.String("edit_window", editWindowStr))
+			slog.String("edit_window", editWindowStr),
+			slog.String("reaction_emoji", reactionEmojiStr))
+
+		after, err := s.getBoard(r.Context(), before.Slug)
+		if err != nil {
+			s.logger.ErrorContext(r.Context(), "failed to get board data",
+				slog.String("error", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+		s.recordAudit(r, user, auditEntry{
+			Action:     auditUpdateConfig,
+			TargetType: auditTargetBoard,
+			TargetID:   int64(after.ID),
+			Before:     newBoardConfigSnapshot(before),
+			After:      newBoardConfigSnapshot(after),
+		})
+	case "rename_tag":
+		tag, ok := s.adminTag(w, r, "tag_id")
+		if !ok {
+			return
+		}
+
+		names, errs := ValidateThreadTags(r.Form.Get("tag_name"))
+		if len(errs) > 0 || len(names) != 1 {
+			http.Error(w, "tag_name: must be a single tag of lowercase letters, digits and dashes", http.StatusBadRequest)
+			return
+		}
+
+		if err := s.queries.RenameTag(r.Context(), RenameTagParams{Name: names[0], ID: tag.ID}); err != nil {
+			// 23505 is unique_violation: merge into the existing tag instead
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				http.Error(w, fmt.Sprintf("a tag named %q already exists, merge into it instead", names[0]), http.StatusConflict)
+				return
+			}
+			s.logger.ErrorContext(r.Context(), "failed to rename tag",
+				slog.String("error", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+
+		s.logger.InfoContext(r.Context(), "tag renamed successfully", slog.String("tag", names[0]))
+		s.recordAudit(r, user, auditEntry{
+			Action:     auditRenameTag,
+			TargetType: auditTargetTag,
+			TargetID:   int64(tag.ID),
+			Before:     tagSnapshot{Name: tag.Name},
+			After:      tagSnapshot{Name: names[0]},
+		})
+	case "merge_tag":
+		from, ok := s.adminTag(w, r, "tag_id")
+		if !ok {
+			return
+		}
+		into, ok := s.adminTag(w, r, "into_tag_id")
+		if !ok {
+			return
+		}
+		if from.ID == into.ID {
+			http.Error(w, "into_tag_id: can't merge a tag into itself", http.StatusBadRequest)
+			return
+		}
+
+		// Move the threads over and drop the old tag together
+		tx, err := s.dbconn.Begin(r.Context())
+		if err != nil {
+			s.logger.ErrorContext(r.Context(), "error starting transaction", slog.String("SQLError", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		qtx := s.queries.(ExtendedQuerier).WithTx(tx)
+
+		if err := qtx.MergeThreadTags(r.Context(), MergeThreadTagsParams{IntoID: into.ID, FromID: from.ID}); err != nil {
+			s.logger.ErrorContext(r.Context(), "failed to merge tags",
+				slog.String("error", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+		if err := qtx.DeleteTag(r.Context(), from.ID); err != nil {
+			s.logger.ErrorContext(r.Context(), "failed to delete merged tag",
+				slog.String("error", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+		if err := tx.Commit(r.Context()); err != nil {
+			s.logger.ErrorContext(r.Context(), "error committing transaction", slog.String("SQLError", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+
+		s.logger.InfoContext(r.Context(), "tag merged successfully",
+			slog.String("from", from.Name),
+			slog.String("into", into.Name))
+		s.recordAudit(r, user, auditEntry{
+			Action:     auditMergeTag,
+			TargetType: auditTargetTag,
+			TargetID:   int64(into.ID),
+			Before:     tagSnapshot{Name: from.Name},
+			After:      tagSnapshot{Name: into.Name},
+		})
+	case "update_rate_limits":
+		// Validate every role before saving any, so a bad value doesn't
+		// leave the limits half updated
+		updates := make([]UpsertRateLimitParams, 0, len(middleware.RateLimitRoles))
+		for _, role := range middleware.RateLimitRoles {
+			requests, window, errs := ValidateRateLimit(role, r.Form.Get(role+"_requests"), r.Form.Get(role+"_window"))
+			if len(errs) > 0 {
+				s.logger.ErrorContext(r.Context(), "invalid rate limit",
+					slog.String("error", errs.Error()))
+				s.renderError(w, http.StatusBadRequest)
+				return
+			}
+			updates = append(updates, UpsertRateLimitParams{Role: role, Requests: requests, WindowSeconds: window})
+		}
+
+		before, err := s.queries.ListRateLimits(r.Context())
+		if err != nil {
+			s.logger.ErrorContext(r.Context(), "failed to list rate limits",
+				slog.String("error", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+
+		after := make([]RateLimit, 0, len(updates))
+		for _, update := range updates {
+			after = append(after, RateLimit(update))
+			if err := s.queries.UpsertRateLimit(r.Context(), update); err != nil {
+				s.logger.ErrorContext(r.Context(), "failed to update rate limit",
+					slog.String("role", update.Role),
+					slog.String("error", err.Error()))
+				s.renderError(w, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// Apply on this replica right away; others reload within roleLimitsTTL
+		s.roleLimits.Invalidate()
+
+		s.logger.InfoContext(r.Context(), "rate limits updated successfully")
+		s.recordAudit(r, user, auditEntry{
+			Action:     auditUpdateRateLimits,
+			TargetType: auditTargetRateLimits,
+			Before:     newRateLimitSnapshots(before),
+			After:      newRateLimitSnapshots(after),
+		})
 	default:
 		s.logger.ErrorContext(r.Context(), "unknown action", slog.String("action", action))
 		s.renderError(w, http.StatusBadRequest)
@@ -279,12 +661,53 @@ func (s *DiscussService) CreateThread(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tags, tagErrors := ValidateThreadTags(r.Form.Get("tags"))
+	if len(tagErrors) > 0 {
+		s.logger.DebugContext(r.Context(), "validation failed", slog.String("errors", tagErrors.Error()))
+		http.Error(w, tagErrors.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// A poll is optional, and only started when it has a question
+	pollQuestion := SanitizeInput(r.Form.Get("poll_question"))
+	var pollOptions []string
+	var pollCloses time.Time
+	if pollQuestion != "" {
+		var pollErrors ValidationErrors
+		pollOptions, pollCloses, pollErrors = ValidatePollForm(pollQuestion, SanitizeInput(r.Form.Get("poll_options")), r.Form.Get("poll_closes"), s.viewerClock(r).Location, time.Now())
+		if len(pollErrors) > 0 {
+			s.logger.DebugContext(r.Context(), "validation failed", slog.String("errors", pollErrors.Error()))
+			http.Error(w, pollErrors.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	span.AddEvent("getBoard")
+	board, err := s.getBoard(r.Context(), r.Form.Get("board"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "unknown board", http.StatusBadRequest)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "error getting board", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	span.AddEvent("checkSpam")
+	verdict, hash := s.checkSpam(r.Context(), user, bodyInput, true)
+	if verdict.Action == spamReject {
+		s.renderError(w, http.StatusTooManyRequests)
+		return
+	}
+	held := verdict.Action == spamHold
+
 	span.AddEvent("r.ParseSubject")
 	// For subjects, just sanitize HTML without markdown parsing (single-line text)
 	subject := parseHTMLStrict(subjectInput)
 	span.AddEvent("r.ParseBody")
 	// For body content, parse markdown and allow more HTML tags
-	body := parseHTMLLessStrict(parseMarkdownToHTML(bodyInput))
+	body := renderPostBody(s.diagrams, bodyInput)
 
 	span.AddEvent("BeginTxn")
 	tx, err := s.dbconn.Begin(r.Context())
@@ -302,6 +725,8 @@ func (s *DiscussService) CreateThread(w http.ResponseWriter, r *http.Request) {
 		Subject:      subject,
 		MemberID:     user.ID,
 		LastMemberID: user.ID,
+		Held:         held,
+		BoardID:      board.ID,
 	}); err != nil {
 		s.logger.ErrorContext(r.Context(), "error creating thread", slog.String("SQLError", err.Error()))
 		s.renderError(w, http.StatusInternalServerError)
@@ -318,15 +743,52 @@ func (s *DiscussService) CreateThread(w http.ResponseWriter, r *http.Request) {
 
 	span.AddEvent("qtx.CreateThreadPost")
 	if err := qtx.CreateThreadPost(r.Context(), CreateThreadPostParams{
-		ThreadID: threadID,
-		Body:     pgtype.Text{Valid: true, String: body},
-		MemberID: user.ID,
+		ThreadID:    threadID,
+		Body:        pgtype.Text{Valid: true, String: body},
+		MemberID:    user.ID,
+		BodyHash:    pgtype.Text{Valid: true, String: hash},
+		Held:        held,
+		HeldReason:  pgtype.Text{Valid: held, String: verdict.Reason},
+		Attachments: attachmentHashes(body),
 	}); err != nil {
 		s.logger.ErrorContext(r.Context(), "error creating thread post", slog.String("SQLError", err.Error()))
 		s.renderError(w, http.StatusInternalServerError)
 		return
 	}
 
+	span.AddEvent("saveThreadTags")
+	if err := saveThreadTags(r.Context(), qtx, threadID, tags); err != nil {
+		s.logger.ErrorContext(r.Context(), "error setting thread tags", slog.String("SQLError", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if pollQuestion != "" {
+		span.AddEvent("qtx.CreatePoll")
+		pollID, err := qtx.CreatePoll(r.Context(), CreatePollParams{
+			ThreadID:   threadID,
+			Question:   parseHTMLStrict(pollQuestion),
+			Multiple:   r.Form.Get("poll_multiple") == "on",
+			Anonymous:  r.Form.Get("poll_anonymous") == "on",
+			DateCloses: pgtype.Timestamptz{Time: pollCloses, Valid: !pollCloses.IsZero()},
+		})
+		if err != nil {
+			s.logger.ErrorContext(r.Context(), "error creating poll", slog.String("SQLError", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+
+		span.AddEvent("qtx.CreatePollOptions")
+		for i, option := range pollOptions {
+			pollOptions[i] = parseHTMLStrict(option)
+		}
+		if err := qtx.CreatePollOptions(r.Context(), CreatePollOptionsParams{PollID: pollID, Labels: pollOptions}); err != nil {
+			s.logger.ErrorContext(r.Context(), "error creating poll options", slog.String("SQLError", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+	}
+
 	span.AddEvent("tx.Commit")
 	if err := tx.Commit(r.Context()); err != nil {
 		s.logger.ErrorContext(r.Context(), "error committing transaction", slog.String("SQLError", err.Error()))
@@ -334,6 +796,8 @@ func (s *DiscussService) CreateThread(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.discardDraft(r, user, 0)
+
 	// nosemgrep
 	http.Redirect(w, r, fmt.Sprintf("/thread/%d", threadID), http.StatusSeeOther)
 }
@@ -385,7 +849,31 @@ func (s *DiscussService) CreateThreadPost(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	body := parseHTMLLessStrict(parseMarkdownToHTML(bodyInput))
+	locked, err := s.queries.IsThreadLocked(r.Context(), threadID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "IsThreadLocked", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	// Admins can still reply to a locked thread, e.g. to explain the lock
+	if locked && !user.IsAdmin {
+		http.Error(w, "thread is locked", http.StatusForbidden)
+		return
+	}
+
+	verdict, hash := s.checkSpam(r.Context(), user, bodyInput, false)
+	if verdict.Action == spamReject {
+		s.renderError(w, http.StatusTooManyRequests)
+		return
+	}
+	held := verdict.Action == spamHold
+
+	body := renderPostBody(s.diagrams, bodyInput)
 
 	if err := s.queries.CreateThreadPost(r.Context(), CreateThreadPostParams{
 		ThreadID: threadID,
@@ -393,13 +881,19 @@ func (s *DiscussService) CreateThreadPost(w http.ResponseWriter, r *http.Request
 			Valid:  true,
 			String: body,
 		},
-		MemberID: user.ID,
+		MemberID:    user.ID,
+		BodyHash:    pgtype.Text{Valid: true, String: hash},
+		Held:        held,
+		HeldReason:  pgtype.Text{Valid: held, String: verdict.Reason},
+		Attachments: attachmentHashes(body),
 	}); err != nil {
 		s.logger.ErrorContext(r.Context(), "error creating thread post", slog.String("SQLError", err.Error()))
 		s.renderError(w, http.StatusInternalServerError)
 		return
 	}
 
+	s.discardDraft(r, user, threadID)
+
 	// nosemgrep
 	http.Redirect(w, r, fmt.Sprintf("/thread/%d", threadID), http.StatusSeeOther)
 }
@@ -459,9 +953,11 @@ func (s *DiscussService) EditMemberProfile(w http.ResponseWriter, r *http.Reques
 	newPreferredName := SanitizeInput(r.Form.Get("preferred_name"))
 	newBio := SanitizeInput(r.Form.Get("bio"))
 	newPronouns := SanitizeInput(r.Form.Get("pronouns"))
+	newTimezone := SanitizeInput(r.Form.Get("timezone"))
+	relativeTimes := r.Form.Get("relative_times") == "on"
 
 	// Validate inputs
-	if errors := ValidateProfileForm(newPhotoURL, newLocation, newPreferredName, newBio, newPronouns); len(errors) > 0 {
+	if errors := ValidateProfileForm(newPhotoURL, newLocation, newPreferredName, newBio, newPronouns, newTimezone); len(errors) > 0 {
 		s.logger.DebugContext(r.Context(), "validation failed", slog.String("errors", errors.Error()))
 		http.Error(w, errors.Error(), http.StatusBadRequest)
 		return
@@ -490,6 +986,11 @@ func (s *DiscussService) EditMemberProfile(w http.ResponseWriter, r *http.Reques
 			String: parseHTMLStrict(newPronouns),
 			Valid:  true,
 		},
+		Timezone: pgtype.Text{
+			String: newTimezone,
+			Valid:  newTimezone != "",
+		},
+		RelativeTimes: relativeTimes,
 	})
 	if err != nil {
 		s.logger.ErrorContext(r.Context(), "UpdateMemberProfileByID", slog.String("error", err.Error()))
@@ -549,8 +1050,15 @@ func (s *DiscussService) editThreadPOST(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	tags, tagErrors := ValidateThreadTags(r.Form.Get("tags"))
+	if len(tagErrors) > 0 {
+		s.logger.DebugContext(r.Context(), "validation failed", slog.String("errors", tagErrors.Error()))
+		http.Error(w, tagErrors.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// For body content, parse markdown and allow more HTML tags
-	body := parseHTMLLessStrict(parseMarkdownToHTML(bodyInput))
+	body := renderPostBody(s.diagrams, bodyInput)
 	// For subjects, just sanitize HTML without markdown parsing (single-line text)
 	subject := parseHTMLStrict(subjectInput)
 
@@ -586,45 +1094,103 @@ func (s *DiscussService) editThreadPOST(w http.ResponseWriter, r *http.Request)
 
 	subjectChanged := t.Subject != subject
 	bodyChanged := t.Body.String != body
+	tagsChanged := !slices.Equal(t.Tags, tags)
 
-	if subjectChanged {
-		err = s.queries.UpdateThread(r.Context(), UpdateThreadParams{
-			Subject:  subject,
-			ID:       threadID,
-			MemberID: user.ID,
+	if !subjectChanged && !bodyChanged && !tagsChanged {
+		// No changes made, just redirect
+		// nosemgrep
+		http.Redirect(w, r, fmt.Sprintf("/thread/%d", threadID), http.StatusSeeOther)
+		return
+	}
+
+	tx, err := s.dbconn.Begin(r.Context())
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error starting transaction", slog.String("SQLError", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	qtx := s.queries.(ExtendedQuerier).WithTx(tx)
+
+	// Retagging alone doesn't count as an edit of the post
+	if subjectChanged || bodyChanged {
+		// Keep the previous subject and body before they are overwritten
+		err = qtx.CreateThreadPostRevision(r.Context(), CreateThreadPostRevisionParams{
+			ID:         threadPostID,
+			MemberID:   user.ID,
+			EditWindow: t.EditWindow.Int32,
 		})
 		if err != nil {
-			s.logger.ErrorContext(r.Context(), "UpdateThread", slog.String("error", err.Error()))
+			s.logger.ErrorContext(r.Context(), "CreateThreadPostRevision", slog.String("error", err.Error()))
 			s.renderError(w, http.StatusInternalServerError)
 			return
 		}
-	}
 
-	if bodyChanged {
-		err = s.queries.UpdateThreadPost(r.Context(), UpdateThreadPostParams{
+		if subjectChanged {
+			err = qtx.UpdateThread(r.Context(), UpdateThreadParams{
+				Subject:    subject,
+				ID:         threadID,
+				MemberID:   user.ID,
+				EditWindow: t.EditWindow.Int32,
+			})
+			if err != nil {
+				s.logger.ErrorContext(r.Context(), "UpdateThread", slog.String("error", err.Error()))
+				s.renderError(w, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// The first post is always updated so it carries the edited marker,
+		// even when only the subject changed
+		err = qtx.UpdateThreadPost(r.Context(), UpdateThreadPostParams{
 			Body: pgtype.Text{
 				Valid:  true,
 				String: body,
 			},
-			ID:       threadPostID,
-			MemberID: user.ID,
+			ID:         threadPostID,
+			MemberID:   user.ID,
+			EditWindow: t.EditWindow.Int32,
 		})
 		if err != nil {
 			s.logger.ErrorContext(r.Context(), "UpdateThreadPost", slog.String("error", err.Error()))
 			s.renderError(w, http.StatusInternalServerError)
 			return
 		}
+
+		err = qtx.LinkThreadPostAttachments(r.Context(), LinkThreadPostAttachmentsParams{
+			ThreadPostID: threadPostID,
+			Hashes:       attachmentHashes(body),
+		})
+		if err != nil {
+			s.logger.ErrorContext(r.Context(), "LinkThreadPostAttachments", slog.String("error", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
 	}
 
-	if subjectChanged || bodyChanged {
-		s.logger.InfoContext(r.Context(), "thread edited",
-			slog.Int64("thread_id", threadID),
-			slog.Int64("user_id", user.ID),
-			slog.Bool("subject_changed", subjectChanged),
-			slog.Bool("body_changed", bodyChanged),
-		)
+	if tagsChanged {
+		if err := saveThreadTags(r.Context(), qtx, threadID, tags); err != nil {
+			s.logger.ErrorContext(r.Context(), "saveThreadTags", slog.String("error", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
 	}
 
+	if err := tx.Commit(r.Context()); err != nil {
+		s.logger.ErrorContext(r.Context(), "error committing transaction", slog.String("SQLError", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "thread edited",
+		slog.Int64("thread_id", threadID),
+		slog.Int64("user_id", user.ID),
+		slog.Bool("subject_changed", subjectChanged),
+		slog.Bool("body_changed", bodyChanged),
+		slog.Bool("tags_changed", tagsChanged),
+	)
+
 	// nosemgrep
 	http.Redirect(w, r, fmt.Sprintf("/thread/%d", threadID), http.StatusSeeOther)
 }
@@ -671,6 +1237,7 @@ func (s *DiscussService) editThreadGET(w http.ResponseWriter, r *http.Request) {
 		"Version":          s.version,
 		"GitSha":           s.gitSha,
 				"Thread":           t,
+		"Tags":             formatTags(t.Tags),
 	})
 }
 
@@ -704,7 +1271,7 @@ func (s *DiscussService) editThreadPostPOST(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Get and sanitize input
-	bodyInput := SanitizeInput(r.Form.Get("thread_post_body"))
+	bodyInput := SanitizeInput(r.Form.Get("thread_body"))
 
 	// Validate input
 	if errors := ValidateThreadPostForm(bodyInput); len(errors) > 0 {
@@ -713,7 +1280,7 @@ func (s *DiscussService) editThreadPostPOST(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	body := parseHTMLLessStrict(parseMarkdownToHTML(bodyInput))
+	body := renderPostBody(s.diagrams, bodyInput)
 
 	// Parse thread post ID from path
 	postIDStr := r.PathValue("pid")
@@ -747,13 +1314,36 @@ func (s *DiscussService) editThreadPostPOST(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	err = s.queries.UpdateThreadPost(r.Context(), UpdateThreadPostParams{
+	tx, err := s.dbconn.Begin(r.Context())
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error starting transaction", slog.String("SQLError", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	qtx := s.queries.(ExtendedQuerier).WithTx(tx)
+
+	// Keep the previous body before it is overwritten
+	err = qtx.CreateThreadPostRevision(r.Context(), CreateThreadPostRevisionParams{
+		ID:         tp.ID,
+		MemberID:   user.ID,
+		EditWindow: tp.EditWindow.Int32,
+	})
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "CreateThreadPostRevision", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	err = qtx.UpdateThreadPost(r.Context(), UpdateThreadPostParams{
 		Body: pgtype.Text{
 			Valid:  true,
 			String: body,
 		},
-		ID:       tp.ID,
-		MemberID: user.ID,
+		ID:         tp.ID,
+		MemberID:   user.ID,
+		EditWindow: tp.EditWindow.Int32,
 	})
 	if err != nil {
 		s.logger.ErrorContext(r.Context(), "UpdateThreadPost", slog.String("error", err.Error()))
@@ -761,6 +1351,22 @@ func (s *DiscussService) editThreadPostPOST(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	err = qtx.LinkThreadPostAttachments(r.Context(), LinkThreadPostAttachmentsParams{
+		ThreadPostID: tp.ID,
+		Hashes:       attachmentHashes(body),
+	})
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "LinkThreadPostAttachments", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		s.logger.ErrorContext(r.Context(), "error committing transaction", slog.String("SQLError", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
 	threadIDStr := r.PathValue("tid")
 	threadID, _ := strconv.ParseInt(threadIDStr, 10, 64)
 
@@ -828,33 +1434,6 @@ func (s *DiscussService) editThreadPostGET(w http.ResponseWriter, r *http.Reques
 	})
 }
 
-func (s *DiscussService) GetTailscaleUserEmail(r *http.Request) (string, error) {
-	// Handle development mode
-	if s.devMode {
-		return "dev@example.com", nil
-	}
-
-	remoteAddr, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return "", fmt.Errorf("failed to split remote address: %w", err)
-	}
-
-	// Check if the IP is local and handle accordingly
-	if remoteAddr == "127.0.0.1" || remoteAddr == "::1" {
-		// For local requests, try to get the real client IP from headers
-		if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
-			remoteAddr = forwardedFor
-		}
-	}
-
-	whois, err := s.tailClient.WhoIs(r.Context(), remoteAddr)
-	if err != nil {
-		return "", fmt.Errorf("failed to get tailscale user info: %w", err)
-	}
-
-	return whois.UserProfile.LoginName, nil
-}
-
 // ListThreads handles listing all threads.
 func (s *DiscussService) ListThreads(w http.ResponseWriter, r *http.Request) {
 	ctx, span := s.telemetry.Tracer.Start(r.Context(), "ListThreads")
@@ -862,7 +1441,13 @@ func (s *DiscussService) ListThreads(w http.ResponseWriter, r *http.Request) {
 
 	r = r.WithContext(ctx)
 
-	if r.URL.Path != "/" {
+	slug := r.PathValue("slug")
+	tag := r.PathValue("tag")
+	if r.URL.Path != "/" && slug == "" && tag == "" {
+		s.renderError(w, http.StatusNotFound)
+		return
+	}
+	if tag != "" && !boardSlugPattern.MatchString(tag) {
 		s.renderError(w, http.StatusNotFound)
 		return
 	}
@@ -879,11 +1464,33 @@ func (s *DiscussService) ListThreads(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A board's page lists its own threads; the index lists every board's
+	var board *GetBoardDataRow
+	var boardID pgtype.Int4
+	if slug != "" {
+		b, ok := GetBoard(r)
+		if !ok || b.Slug != slug {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		board = &b
+		boardID = pgtype.Int4{Int32: b.ID, Valid: true}
+	}
+
+	span.AddEvent("queries.ListBoards")
+	boards, err := s.queries.ListBoards(r.Context())
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error listing boards", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
 
 	span.AddEvent("queries.ListThreads")
 	threads, err := s.queries.ListThreads(r.Context(), ListThreadsParams{
 		Email:    user.Email,
 		MemberID: user.ID,
+		BoardID:  boardID,
+		Tag:      pgtype.Text{String: tag, Valid: tag != ""},
 	})
 	if err != nil {
 		s.logger.ErrorContext(r.Context(), "error listing threads", slog.String("error", err.Error()))
@@ -894,6 +1501,8 @@ func (s *DiscussService) ListThreads(w http.ResponseWriter, r *http.Request) {
 	span.AddEvent("map threads to template data")
 	var threadData []ThreadTemplateData
 	for _, thread := range threads {
+		// Threads from every board can be listed, each named as on its board
+		ids := s.boardIdentities(r, thread.HideEmails)
 		// Subject is plain text (sanitized on input), template engine escapes on output
 		threadData = append(threadData, ThreadTemplateData{
 			ThreadID:       thread.ThreadID,
@@ -901,24 +1510,42 @@ func (s *DiscussService) ListThreads(w http.ResponseWriter, r *http.Request) {
 			Email:          thread.Email,
 			Lastid:         thread.Lastid,
 			Lastname:       thread.Lastname,
+			LastPoster:     ids.identify(thread.Lastid.Int64, thread.Lastname, thread.LastPreferredName, thread.LastPhotoUrl),
 			Posts:          thread.Posts,
 			Views:          thread.Views,
 			DateLastPosted: thread.DateLastPosted,
 			CanEdit:        pgtype.Bool{Bool: thread.CanEdit, Valid: true},
 			Sticky:         thread.Sticky,
 			Locked:         thread.Locked,
+			BoardSlug:      thread.BoardSlug,
+			BoardTitle:     thread.BoardTitle,
+			Tags:           thread.Tags,
 		})
 	}
 
-	span.AddEvent("render template")
-	s.renderTemplate(w, r, "index.html", map[string]interface{}{
+	data := map[string]interface{}{
 		"Title":            GetBoardTitle(r),
 		"Threads":          threadData,
 		"Version":          s.version,
 		"GitSha":           s.gitSha,
 		"CurrentUserEmail": user.Email,
-				"User":             user,
-	})
+		"User":             user,
+	}
+	if tag != "" {
+		data["Tag"] = tag
+		data["FeedURL"] = tagPath(tag) + "/feed.atom"
+		data["FeedTitle"] = "#" + tag
+	} else if board != nil {
+		data["Board"] = board
+		data["FeedURL"] = boardPath(board.Slug) + "feed.atom"
+		data["FeedTitle"] = board.Title
+	} else if len(boards) > 1 {
+		// With a single board there's nothing to choose between
+		data["Boards"] = newBoardTemplateData(boards)
+	}
+
+	span.AddEvent("render template")
+	s.renderTemplate(w, r, "index.html", data)
 }
 
 // ListThreadPosts handles displaying a specific thread with its posts.
@@ -963,9 +1590,23 @@ func (s *DiscussService) ListThreadPosts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	span.AddEvent("queries.GetThreadBoard")
+	board, err := s.queries.GetThreadBoard(r.Context(), threadID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "error getting thread board", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
 	posts, err := s.queries.ListThreadPosts(r.Context(), ListThreadPostsParams{
-		Email:    user.Email,
-		ThreadID: threadID,
+		Email:      user.Email,
+		ThreadID:   threadID,
+		MemberID:   user.ID,
+		EditWindow: board.EditWindow.Int32,
 	})
 	if err != nil {
 		s.logger.ErrorContext(r.Context(), "error listing thread posts", slog.String("error", err.Error()))
@@ -973,32 +1614,170 @@ func (s *DiscussService) ListThreadPosts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// The thread's own board decides its reactions and how members are shown
+	reactionEmoji := boardReactionEmoji(board.ReactionEmoji)
+	ids := s.boardIdentities(r, board.HideEmails)
+	linkCards := s.threadLinkCards(r, threadID)
+
 	var threadPosts []ThreadPostTemplateData
 	for _, post := range posts {
+		// Posts hidden by a moderator are only shown to their author and admins
+		body := template.HTML(s.images.proxyStoredImages(post.Body.String))
+		links := linkCards[post.ID]
+		if post.Hidden && !user.IsAdmin && post.MemberID.Int64 != user.ID {
+			body = ""
+			links = nil
+		}
+
 		threadPosts = append(threadPosts, ThreadPostTemplateData{
 			ID:       post.ID,
-			Body:     template.HTML(post.Body.String),
+			Body:     body,
 			ThreadID: post.ThreadID,
 			MemberID: post.MemberID,
-			Email:    post.Email,
+			Author:   ids.identify(post.MemberID.Int64, post.Email, post.PreferredName, post.PhotoUrl),
 			// nosemgrep
-			DatePosted: post.DatePosted,
-			CanEdit:    pgtype.Bool{Bool: post.CanEdit, Valid: true},
+			DatePosted:       post.DatePosted,
+			CanEdit:          pgtype.Bool{Bool: post.CanEdit, Valid: true},
+			Edited:           post.Edited,
+			DateEdited:       post.DateEdited,
+			CanViewRevisions: user.IsAdmin || post.MemberID.Int64 == user.ID,
+			Reactions:        buildPostReactions(reactionEmoji, post.Reactions),
+			Links:            links,
+			Held:             post.Held,
+			Hidden:           post.Hidden,
 		})
 	}
 
+	// A thread whose first post is held has nothing to show other members
+	if len(threadPosts) == 0 {
+		s.renderError(w, http.StatusNotFound)
+		return
+	}
+
+	span.AddEvent("threadPoll")
+	poll, err := s.threadPoll(r, threadID, user, ids)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error getting thread poll", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
 	s.renderTemplate(w, r, "thread.html", map[string]interface{}{
 		"Title":            GetBoardTitle(r),
 		"CurrentUserEmail": user.Email,
 		"ThreadPosts":      threadPosts,
 		"Subject":          subject,
+		"Board":            board,
 		"ID":               threadID,
+		"FeedURL":          fmt.Sprintf("/thread/%d/feed.atom", threadID),
+		"FeedTitle":        subject,
+		"Locked":           posts[0].Locked.Bool,
+		"Poll":             poll,
+		"Draft":            s.memberDraft(r, user, threadID),
 		"GitSha":    s.gitSha,
 		"Version":   s.version,
 				"User":      user,
 	})
 }
 
+// canViewPost reports whether the requesting member may see post. Deleted
+// posts are seen by no one, and posts awaiting moderation or hidden by a
+// moderator only by their author and admins.
+func (s *DiscussService) canViewPost(r *http.Request, post GetThreadPostRow) bool {
+	if post.Deleted {
+		return false
+	}
+	if !post.Held && !post.Hidden {
+		return true
+	}
+	user, err := GetUser(r)
+	if err != nil {
+		return false
+	}
+	return user.IsAdmin || user.ID == post.MemberID
+}
+
+// PostRedirect sends ">>1234" post references to the post within its thread.
+func (s *DiscussService) PostRedirect(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.ParseInt(r.PathValue("pid"), 10, 64)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error parsing post ID", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	post, err := s.queries.GetThreadPost(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "GetThreadPost", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !s.canViewPost(r, post) {
+		s.renderError(w, http.StatusNotFound)
+		return
+	}
+
+	// nosemgrep
+	http.Redirect(w, r, fmt.Sprintf("/thread/%d#post-%d", post.ThreadID, post.ID), http.StatusSeeOther)
+}
+
+// PostPreview renders a single post as an HTML fragment for hover previews.
+func (s *DiscussService) PostPreview(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.ParseInt(r.PathValue("pid"), 10, 64)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error parsing post ID", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	post, err := s.queries.GetThreadPost(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "GetThreadPost", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !s.canViewPost(r, post) {
+		s.renderError(w, http.StatusNotFound)
+		return
+	}
+
+	author, err := s.queries.GetMember(r.Context(), post.MemberID)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "GetMember", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	// The author is named as on the post's board
+	board, err := s.queries.GetThreadBoard(r.Context(), post.ThreadID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "GetThreadBoard", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	s.renderTemplate(w, r, "post-preview.html", map[string]interface{}{
+		"Post":    post,
+		"Author":  s.boardIdentities(r, board.HideEmails).identify(author.ID, pgtype.Text{String: author.Email, Valid: true}, author.PreferredName, author.PhotoUrl),
+		"Subject": post.Subject.String,
+		"Body":    template.HTML(s.images.proxyStoredImages(post.Body.String)),
+	})
+}
+
 // ListMember displays a member's profile.
 func (s *DiscussService) ListMember(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -1034,7 +1813,7 @@ func (s *DiscussService) ListMember(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get member's threads
-	threads, err := s.queries.ListMemberThreads(r.Context(), memberID)
+	threads, err := s.queries.ListMemberThreads(r.Context(), ListMemberThreadsParams{MemberID: memberID})
 	if err != nil {
 		s.logger.ErrorContext(r.Context(), "error getting member threads", slog.String("error", err.Error()))
 		// Don't fail the page, just show empty threads
@@ -1044,12 +1823,57 @@ func (s *DiscussService) ListMember(w http.ResponseWriter, r *http.Request) {
 	// Check if the current user can edit this profile
 	canEdit := user.ID == memberID || user.IsAdmin
 
+	// The member is listed with posts on every board, so their email is
+	// hidden if any board hides emails; each thread names its last poster
+	// as on its own board
+	hideEmails, err := s.queries.AnyBoardHidesEmails(r.Context())
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error checking boards' email settings", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+	identity := s.boardIdentities(r, hideEmails).identify(member.ID, pgtype.Text{String: member.Email, Valid: true}, member.PreferredName, member.PhotoUrl)
+
+	threadData := make([]ThreadTemplateData, 0, len(threads))
+	for _, thread := range threads {
+		ids := s.boardIdentities(r, thread.HideEmails)
+		threadData = append(threadData, ThreadTemplateData{
+			ThreadID:       thread.ThreadID,
+			Subject:        thread.Subject,
+			Email:          thread.Email,
+			Lastid:         thread.Lastid,
+			Lastname:       thread.Lastname,
+			LastPoster:     ids.identify(thread.Lastid.Int64, thread.Lastname, thread.LastPreferredName, thread.LastPhotoUrl),
+			Posts:          thread.Posts,
+			Views:          thread.Views,
+			DateLastPosted: thread.DateLastPosted,
+			Sticky:         thread.Sticky,
+			Locked:         thread.Locked,
+		})
+	}
+
+	// Drafts are only ever shown to the member writing them
+	var drafts []DraftTemplateData
+	if user.ID == memberID {
+		rows, err := s.queries.ListMemberDrafts(r.Context(), ListMemberDraftsParams{
+			MemberID:   memberID,
+			SavedAfter: s.draftsSavedAfter(time.Now()),
+		})
+		if err != nil {
+			s.logger.ErrorContext(r.Context(), "error getting member drafts", slog.String("error", err.Error()))
+		}
+		drafts = buildDraftTemplateData(rows)
+	}
 
 	s.renderTemplate(w, r, "member.html", map[string]interface{}{
 		"Title":            GetBoardTitle(r),
 		"Member":           member,
-		"Threads":          threads,
+		"Drafts":           drafts,
+		"Identity":         identity,
+		"Threads":          threadData,
 		"CanEdit":          canEdit,
+		"FeedURL":          fmt.Sprintf("/member/%d/feed.atom", memberID),
+		"FeedTitle":        fmt.Sprintf("Threads by %s", identity.Name),
 		"CurrentUserEmail": user.Email,
 		"Version":          s.version,
 		"GitSha":           s.gitSha,
@@ -1072,16 +1896,59 @@ func (s *DiscussService) NewThread(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	boards, err := s.queries.ListBoards(r.Context())
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error listing boards", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	// Linked to from a board's page, the new thread goes in that board
+	selected := r.URL.Query().Get("board")
+	if selected == "" && len(boards) > 0 {
+		selected = boards[0].Slug
+	}
 
 	s.renderTemplate(w, r, "newthread.html", map[string]interface{}{
 		"Title":            GetBoardTitle(r),
 		"CurrentUserEmail": user.Email,
 		"Version":          s.version,
 		"GitSha":           s.gitSha,
-				"User":             user,
+		"User":             user,
+		"Boards":           newBoardTemplateData(boards),
+		"SelectedBoard":    selected,
+		"Draft":            s.memberDraft(r, user, 0),
 	})
 }
 
+// PreviewPost renders a post's Markdown the same way posting it would and
+// returns the HTML fragment, for the preview tab of the post forms.
+func (s *DiscussService) PreviewPost(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "PreviewPost")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	if err := r.ParseForm(); err != nil {
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	bodyInput := SanitizeInput(r.Form.Get("thread_body"))
+
+	// An empty post has nothing to preview, which isn't an error here
+	if bodyInput != "" {
+		if errors := ValidateThreadPostForm(bodyInput); len(errors) > 0 {
+			http.Error(w, errors.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write([]byte(renderPreviewBody(s.diagrams, bodyInput)))
+}
+
 // FormattingGuide displays the markdown formatting help page.
 func (s *DiscussService) FormattingGuide(w http.ResponseWriter, r *http.Request) {
 	user, err := GetUser(r)
@@ -1099,6 +1966,14 @@ func (s *DiscussService) FormattingGuide(w http.ResponseWriter, r *http.Request)
 	thumbsUpEmoji := parseHTMLLessStrict(parseMarkdownToHTML(":+1:"))
 	smileEmoji := parseHTMLLessStrict(parseMarkdownToHTML(":smile:"))
 
+	// And the code block example, so it shows the real highlighting
+	codeExample := renderPostBody(s.diagrams, "```go\nfunc main() {\n    fmt.Println(\"Hello!\")\n}\n```")
+
+	// Math and diagrams too, as they're rendered when the post is saved.
+	// The diagram is rendered at startup, and shown as its source until then.
+	mathExample := renderPostBody(s.diagrams, "The roots are $x = \\frac{-b \\pm \\sqrt{b^2 - 4ac}}{2a}$.\n\n$$\n\\sum_{i=1}^{n} i = \\frac{n(n+1)}{2}\n$$")
+	diagramExample := renderPreviewBody(s.diagrams, "```mermaid\n"+formattingDiagram+"```")
+
 	s.renderTemplate(w, r, "formatting.html", map[string]interface{}{
 		"Title":          "Formatting Guide",
 		"Version":        s.version,
@@ -1108,6 +1983,9 @@ func (s *DiscussService) FormattingGuide(w http.ResponseWriter, r *http.Request)
 		"HeartEmoji":     template.HTML(heartEmoji),
 		"ThumbsUpEmoji":  template.HTML(thumbsUpEmoji),
 		"SmileEmoji":     template.HTML(smileEmoji),
+		"CodeExample":    template.HTML(codeExample),
+		"MathExample":    template.HTML(mathExample),
+		"DiagramExample": template.HTML(diagramExample),
 	})
 }
 
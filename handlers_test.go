@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imeyer/tdiscuss/middleware"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestEditThreadPostPOST_ReadsThreadBody(t *testing.T) {
+	// The stored post already has the submitted body, so a body read from the
+	// form redirects back to the thread without writing anything
+	stored := renderPostBody(nil, "unchanged reply")
+	s := &DiscussService{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		queries: &MockQueries{
+			GetThreadPostForEditFunc: func(ctx context.Context, arg GetThreadPostForEditParams) (GetThreadPostForEditRow, error) {
+				return GetThreadPostForEditRow{ID: arg.ID, Body: pgtype.Text{String: stored, Valid: true}}, nil
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		form       url.Values
+		wantStatus int
+	}{
+		{
+			name:       "body in the field the edit form posts",
+			form:       url.Values{"thread_body": {"unchanged reply"}},
+			wantStatus: http.StatusSeeOther,
+		},
+		{
+			name:       "body in the old field name is not read",
+			form:       url.Values{"thread_post_body": {"unchanged reply"}},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /thread/{tid}/{pid}/edit", s.editThreadPostPOST)
+
+			r := httptest.NewRequest(http.MethodPost, "/thread/1/2/edit", strings.NewReader(tt.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r = r.WithContext(middleware.WithUser(r.Context(), &middleware.ContextUser{ID: 3, Email: "alice@example.com"}))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusSeeOther {
+				assert.Equal(t, "/thread/1", w.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestCreateThreadPost_SpamRejected(t *testing.T) {
+	s := &DiscussService{
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		telemetry: &TelemetryConfig{Tracer: noop.NewTracerProvider().Tracer("test")},
+		spamGuard: SpamGuardConfig{ProbationPeriod: 24 * time.Hour, ProbationPostsPerHour: 3},
+		queries: &MockQueries{
+			GetMemberPostingActivityFunc: func(ctx context.Context, arg GetMemberPostingActivityParams) (GetMemberPostingActivityRow, error) {
+				return GetMemberPostingActivityRow{
+					DateJoined:  pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true},
+					RecentPosts: 3,
+				}, nil
+			},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /thread/{tid}", s.CreateThreadPost)
+
+	form := url.Values{"thread_body": {"one more reply"}}
+	r := httptest.NewRequest(http.MethodPost, "/thread/1", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(middleware.WithUser(r.Context(), &middleware.ContextUser{ID: 3, Email: "alice@example.com"}))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	// The same error page as the handler's other failures; the reason is logged
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, http.StatusText(http.StatusTooManyRequests)+"\n", w.Body.String())
+}
+
+func TestListThreadPosts_ThreadBoardNotFound(t *testing.T) {
+	s := &DiscussService{
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		telemetry: &TelemetryConfig{Tracer: noop.NewTracerProvider().Tracer("test")},
+		queries: &MockQueries{
+			GetThreadSubjectByIdFunc: func(ctx context.Context, id int64) (string, error) {
+				return "Subject", nil
+			},
+			GetThreadBoardFunc: func(ctx context.Context, id int64) (GetThreadBoardRow, error) {
+				return GetThreadBoardRow{}, pgx.ErrNoRows
+			},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /thread/{tid}", s.ListThreadPosts)
+
+	r := httptest.NewRequest(http.MethodGet, "/thread/42", nil)
+	r = r.WithContext(middleware.WithUser(r.Context(), &middleware.ContextUser{ID: 3, Email: "bob@example.com"}))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
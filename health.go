@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// expectedSchemaVersion is the schema_version this binary was written
+// against. Bump it together with every new migration in sqlc/.
+const expectedSchemaVersion = 15
+
+// healthCheckTimeout bounds each readiness check so a hung dependency makes
+// /readyz fail rather than hang.
+const healthCheckTimeout = 2 * time.Second
+
+const (
+	healthStatusOK      = "ok"
+	healthStatusError   = "error"
+	healthStatusSkipped = "skipped"
+)
+
+// HealthCheckResult is the outcome of one readiness check.
+type HealthCheckResult struct {
+	Status  string         `json:"status"`
+	Error   string         `json:"error,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// HealthReport is the JSON body served by /healthz and /readyz.
+type HealthReport struct {
+	Status  string                       `json:"status"`
+	Version string                       `json:"version"`
+	GitSha  string                       `json:"git_sha"`
+	Uptime  string                       `json:"uptime"`
+	Checks  map[string]HealthCheckResult `json:"checks,omitempty"`
+}
+
+// healthStatus returns the overall status for a set of checks: ok unless any
+// check failed. Skipped checks don't count against readiness.
+func healthStatus(checks map[string]HealthCheckResult) string {
+	for _, check := range checks {
+		if check.Status == healthStatusError {
+			return healthStatusError
+		}
+	}
+	return healthStatusOK
+}
+
+// WorkerRegistry tracks the liveness of background workers. Each worker
+// registers the interval it runs at and beats after every run; a worker that
+// hasn't beaten in two intervals is reported as stalled.
+type WorkerRegistry struct {
+	mu      sync.Mutex
+	workers map[string]*workerState
+	now     func() time.Time
+}
+
+type workerState struct {
+	interval time.Duration
+	lastBeat time.Time
+	lastErr  string
+}
+
+// NewWorkerRegistry creates an empty worker registry.
+func NewWorkerRegistry() *WorkerRegistry {
+	return &WorkerRegistry{
+		workers: make(map[string]*workerState),
+		now:     time.Now,
+	}
+}
+
+// Register adds a worker that is expected to beat every interval. The
+// registration time counts as the first beat.
+func (wr *WorkerRegistry) Register(name string, interval time.Duration) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	wr.workers[name] = &workerState{interval: interval, lastBeat: wr.now()}
+}
+
+// Beat records a completed run of the named worker. err is the run's error,
+// if any, and is shown in the readiness report until the next run.
+func (wr *WorkerRegistry) Beat(name string, err error) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	w, ok := wr.workers[name]
+	if !ok {
+		return
+	}
+	w.lastBeat = wr.now()
+	w.lastErr = ""
+	if err != nil {
+		w.lastErr = err.Error()
+	}
+}
+
+// Check reports every registered worker, failing if any has stalled.
+func (wr *WorkerRegistry) Check() HealthCheckResult {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	names := make([]string, 0, len(wr.workers))
+	for name := range wr.workers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := HealthCheckResult{Status: healthStatusOK, Details: make(map[string]any, len(names))}
+	var stalled []string
+	now := wr.now()
+	for _, name := range names {
+		w := wr.workers[name]
+		since := now.Sub(w.lastBeat)
+		detail := map[string]any{
+			"interval":  w.interval.String(),
+			"last_beat": since.Truncate(time.Second).String() + " ago",
+		}
+		if w.lastErr != "" {
+			detail["last_error"] = w.lastErr
+		}
+		if since > 2*w.interval {
+			stalled = append(stalled, name)
+		}
+		result.Details[name] = detail
+	}
+
+	if len(stalled) > 0 {
+		result.Status = healthStatusError
+		result.Error = fmt.Sprintf("stalled workers: %v", stalled)
+	}
+
+	return result
+}
+
+// startWorkers starts the background workers. They stop when ctx is done.
+func (s *DiscussService) startWorkers(ctx context.Context) {
+	if _, ok := s.rateLimitStore.(*PostgresRateLimitStore); ok {
+		go s.runWorker(ctx, "rate_limit_prune", rateLimitPruneInterval, s.pruneRateLimitWindows)
+	}
+	go s.runWorker(ctx, "draft_prune", draftPruneInterval, s.pruneDrafts)
+	go s.renderFormattingDiagram(ctx)
+	if s.unfurler != nil {
+		go s.runWorker(ctx, "link_unfurl", unfurlInterval, s.unfurlPosts)
+	}
+}
+
+// runWorker calls fn every interval until ctx is done, beating the registry
+// after each run. Errors are logged and the worker carries on.
+func (s *DiscussService) runWorker(ctx context.Context, name string, interval time.Duration, fn func(context.Context) error) {
+	s.workers.Register(name, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := fn(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.logger.ErrorContext(ctx, "background worker failed", slog.String("worker", name), slog.String("error", err.Error()))
+		}
+		s.workers.Beat(name, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkDatabase runs SELECT 1 and reports connection pool statistics.
+func (s *DiscussService) checkDatabase(ctx context.Context) HealthCheckResult {
+	if s.dbconn == nil {
+		return HealthCheckResult{Status: healthStatusSkipped}
+	}
+
+	stat := s.dbconn.Stat()
+	result := HealthCheckResult{
+		Status: healthStatusOK,
+		Details: map[string]any{
+			"total_conns":    stat.TotalConns(),
+			"idle_conns":     stat.IdleConns(),
+			"acquired_conns": stat.AcquiredConns(),
+			"max_conns":      stat.MaxConns(),
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := s.dbconn.Exec(ctx, "SELECT 1"); err != nil {
+		result.Status = healthStatusError
+		result.Error = err.Error()
+		return result
+	}
+	result.Details["latency"] = time.Since(start).String()
+
+	return result
+}
+
+// checkSchema compares the database's schema version with the one this
+// binary expects.
+func (s *DiscussService) checkSchema(ctx context.Context) HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	version, err := s.queries.GetSchemaVersion(ctx)
+	if err != nil {
+		return HealthCheckResult{Status: healthStatusError, Error: err.Error()}
+	}
+
+	result := HealthCheckResult{
+		Status: healthStatusOK,
+		Details: map[string]any{
+			"version":  version,
+			"expected": expectedSchemaVersion,
+		},
+	}
+	if version < expectedSchemaVersion {
+		result.Status = healthStatusError
+		result.Error = "database schema is older than this binary, apply the pending migrations in sqlc/"
+	}
+
+	return result
+}
+
+// checkTailscale requires the tailnet backend to be running. It is skipped in
+// local development mode, which doesn't join a tailnet.
+func (s *DiscussService) checkTailscale(ctx context.Context) HealthCheckResult {
+	if s.tailClient == nil {
+		return HealthCheckResult{Status: healthStatusSkipped}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	status, err := s.tailClient.StatusWithoutPeers(ctx)
+	if err != nil {
+		return HealthCheckResult{Status: healthStatusError, Error: err.Error()}
+	}
+
+	result := HealthCheckResult{
+		Status:  healthStatusOK,
+		Details: map[string]any{"backend_state": status.BackendState},
+	}
+	if status.BackendState != "Running" {
+		result.Status = healthStatusError
+		result.Error = "tailscale backend is not running"
+	}
+
+	return result
+}
+
+// Healthz is the liveness probe. It only shows the process is serving
+// requests and never touches dependencies, so a database outage doesn't get
+// the process restarted.
+func (s *DiscussService) Healthz(w http.ResponseWriter, r *http.Request) {
+	s.writeHealthReport(w, r, nil)
+}
+
+// Readyz is the readiness probe. It checks the database, schema version,
+// Tailscale and background workers, responding 503 if any of them fail.
+func (s *DiscussService) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "Readyz")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	checks := map[string]HealthCheckResult{
+		"database":  s.checkDatabase(r.Context()),
+		"schema":    s.checkSchema(r.Context()),
+		"tailscale": s.checkTailscale(r.Context()),
+		"workers":   s.workers.Check(),
+	}
+
+	s.writeHealthReport(w, r, checks)
+}
+
+func (s *DiscussService) writeHealthReport(w http.ResponseWriter, r *http.Request, checks map[string]HealthCheckResult) {
+	report := HealthReport{
+		Status:  healthStatus(checks),
+		Version: s.version,
+		GitSha:  s.gitSha,
+		Uptime:  time.Since(s.startTime).Truncate(time.Second).String(),
+		Checks:  checks,
+	}
+
+	for name, check := range checks {
+		if check.Status == healthStatusError {
+			s.logger.WarnContext(r.Context(), "health check failed", slog.String("check", name), slog.String("error", check.Error))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status != healthStatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		s.logger.ErrorContext(r.Context(), "error writing health report", slog.String("error", err.Error()))
+	}
+}
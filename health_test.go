@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks map[string]HealthCheckResult
+		want   string
+	}{
+		{
+			name:   "no checks",
+			checks: nil,
+			want:   healthStatusOK,
+		},
+		{
+			name: "all ok",
+			checks: map[string]HealthCheckResult{
+				"database": {Status: healthStatusOK},
+				"schema":   {Status: healthStatusOK},
+			},
+			want: healthStatusOK,
+		},
+		{
+			name: "skipped checks count as ok",
+			checks: map[string]HealthCheckResult{
+				"database":  {Status: healthStatusOK},
+				"tailscale": {Status: healthStatusSkipped},
+			},
+			want: healthStatusOK,
+		},
+		{
+			name: "one failure",
+			checks: map[string]HealthCheckResult{
+				"database": {Status: healthStatusOK},
+				"schema":   {Status: healthStatusError, Error: "behind"},
+			},
+			want: healthStatusError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, healthStatus(tt.checks))
+		})
+	}
+}
+
+func TestWorkerRegistry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	wr := NewWorkerRegistry()
+	wr.now = func() time.Time { return now }
+
+	// No workers is healthy
+	assert.Equal(t, healthStatusOK, wr.Check().Status)
+
+	wr.Register("sync", time.Minute)
+	wr.Register("expiry", time.Hour)
+	assert.Equal(t, healthStatusOK, wr.Check().Status)
+
+	// Within two intervals is still healthy
+	now = now.Add(90 * time.Second)
+	assert.Equal(t, healthStatusOK, wr.Check().Status)
+
+	// sync has now missed two runs
+	now = now.Add(time.Minute)
+	result := wr.Check()
+	assert.Equal(t, healthStatusError, result.Status)
+	assert.Contains(t, result.Error, "sync")
+	assert.NotContains(t, result.Error, "expiry")
+
+	// A beat brings it back, and the run's error is reported
+	wr.Beat("sync", errors.New("boom"))
+	result = wr.Check()
+	assert.Equal(t, healthStatusOK, result.Status)
+	assert.Equal(t, "boom", result.Details["sync"].(map[string]any)["last_error"])
+
+	// Beats for unknown workers are ignored
+	wr.Beat("unknown", nil)
+	assert.NotContains(t, wr.Check().Details, "unknown")
+}
@@ -7,6 +7,7 @@ import (
 	"html/template"
 	"io"
 	"log/slog"
+	"net"
 	"os"
 	"path/filepath"
 	"time"
@@ -47,6 +48,13 @@ func envOr(key, defaultVal string) string {
 	return defaultVal
 }
 
+// isLoopback reports whether a listener only accepts connections from this
+// machine
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
+
 func expandSNIName(ctx context.Context, lc TailscaleClient, logger *slog.Logger) string {
 	sni, ok := lc.ExpandSNIName(ctx, *hostname)
 	if !ok {
@@ -56,10 +64,6 @@ func expandSNIName(ctx context.Context, lc TailscaleClient, logger *slog.Logger)
 	return sni
 }
 
-func formatTimestamp(t time.Time) string {
-	return t.Format("2006-01-02 15:04:05")
-}
-
 func getTailscaleLocalClient(s *tsnet.Server, logger *slog.Logger) TailscaleClient {
 	lc, err := s.LocalClient()
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"io"
 	"log/slog"
+	"net"
 	"os"
 	"strings"
 	"testing"
@@ -252,6 +253,27 @@ func TestExpandSNIName(t *testing.T) {
 	}
 }
 
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		addr     net.Addr
+		expected bool
+	}{
+		{&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080}, true},
+		{&net.TCPAddr{IP: net.IPv6loopback, Port: 8080}, true},
+		{&net.TCPAddr{IP: net.IPv6unspecified, Port: 8080}, false},
+		{&net.TCPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 8080}, false},
+		{&net.UnixAddr{Name: "/tmp/tdiscuss.sock", Net: "unix"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr.String(), func(t *testing.T) {
+			if result := isLoopback(tt.addr); result != tt.expected {
+				t.Errorf("isLoopback(%v) = %v, want %v", tt.addr, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestEnvOr(t *testing.T) {
 	tests := []struct {
 		name       string
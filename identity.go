@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// tailnetAvatarsTTL is how long the tailnet's profile pictures are cached
+// before the Tailscale status is read again.
+const tailnetAvatarsTTL = 10 * time.Minute
+
+// Identity is how a member is shown to other members: by name, with a photo.
+type Identity struct {
+	ID   int64
+	Name string
+	// Email is empty when the board hides email addresses from the viewer
+	Email    string
+	PhotoURL string
+	// Initial stands in for the photo when the member has none
+	Initial string
+}
+
+// displayName is the name a member goes by: their preferred name, otherwise
+// their email address, or only the part before the @ when emails are hidden.
+func displayName(email, preferredName string, hideEmails bool) string {
+	if name := strings.TrimSpace(preferredName); name != "" {
+		return name
+	}
+	if hideEmails {
+		if local, _, ok := strings.Cut(email, "@"); ok && local != "" {
+			return local
+		}
+	}
+	return email
+}
+
+// initial is the uppercased first letter of name, shown in place of a photo.
+func initial(name string) string {
+	r, _ := utf8.DecodeRuneInString(name)
+	if r == utf8.RuneError {
+		return "?"
+	}
+	return string(unicode.ToUpper(r))
+}
+
+// identities resolves members to Identities for the member viewing a page.
+type identities struct {
+	viewer     User
+	hideEmails bool
+	// avatars maps Tailscale login names to their profile pictures
+	avatars map[string]string
+}
+
+// boardIdentities returns the resolver for the member making the request,
+// for what's shown from a board that does or doesn't hide emails. Pages can
+// show posts from boards other than the request's, so each post is named as
+// on its own board.
+func (s *DiscussService) boardIdentities(r *http.Request, hideEmails bool) identities {
+	viewer, _ := GetUser(r)
+	return identities{
+		viewer:     viewer,
+		hideEmails: hideEmails,
+		avatars:    s.avatars.Get(r.Context()),
+	}
+}
+
+// identify builds a member's Identity from their email and profile. Members
+// without a profile photo get their Tailscale profile picture. Emails are
+// left out when the board hides them, except from admins and the member
+// themselves.
+func (ids identities) identify(id int64, email, preferredName, photoURL pgtype.Text) Identity {
+	name := displayName(email.String, preferredName.String, ids.hideEmails)
+
+	identity := Identity{
+		ID:       id,
+		Name:     name,
+		PhotoURL: photoURL.String,
+		Initial:  initial(name),
+	}
+	if identity.PhotoURL == "" {
+		identity.PhotoURL = ids.avatars[email.String]
+	}
+	identity.PhotoURL = proxiedImageSrc(identity.PhotoURL)
+	if !ids.hideEmails || ids.viewer.IsAdmin || ids.viewer.ID == id {
+		identity.Email = email.String
+	}
+	return identity
+}
+
+// tailnetAvatarCache serves the profile pictures of the tailnet's users, by
+// login name, from the Tailscale status. Members who haven't set a profile
+// photo are shown with theirs.
+type tailnetAvatarCache struct {
+	client TailscaleClient
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu       sync.Mutex
+	photos   map[string]string
+	loadedAt time.Time
+}
+
+func newTailnetAvatarCache(client TailscaleClient, logger *slog.Logger) *tailnetAvatarCache {
+	return &tailnetAvatarCache{
+		client: client,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Get returns the profile pictures by login name, reloading them once
+// tailnetAvatarsTTL has passed. If the reload fails the previous pictures
+// are kept. Without a Tailscale client, as in local development, there are
+// none.
+func (c *tailnetAvatarCache) Get(ctx context.Context) map[string]string {
+	if c == nil || c.client == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.photos != nil && c.now().Sub(c.loadedAt) < tailnetAvatarsTTL {
+		return c.photos
+	}
+
+	st, err := c.client.Status(ctx)
+	if err != nil {
+		c.logger.WarnContext(ctx, "error reading tailscale status for avatars", slog.String("error", err.Error()))
+		return c.photos
+	}
+
+	photos := make(map[string]string, len(st.User))
+	for _, profile := range st.User {
+		if profile.LoginName != "" && profile.ProfilePicURL != "" {
+			photos[profile.LoginName] = profile.ProfilePicURL
+		}
+	}
+
+	c.photos = photos
+	c.loadedAt = c.now()
+	return c.photos
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/tailcfg"
+)
+
+func TestDisplayName(t *testing.T) {
+	tests := []struct {
+		name          string
+		email         string
+		preferredName string
+		hideEmails    bool
+		want          string
+	}{
+		{"preferred name", "alice@example.com", "Alice", false, "Alice"},
+		{"preferred name with emails hidden", "alice@example.com", "Alice", true, "Alice"},
+		{"blank preferred name", "alice@example.com", "  ", false, "alice@example.com"},
+		{"email", "alice@example.com", "", false, "alice@example.com"},
+		{"email hidden", "alice@example.com", "", true, "alice"},
+		{"not an email", "alice", "", true, "alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, displayName(tt.email, tt.preferredName, tt.hideEmails))
+		})
+	}
+}
+
+func TestInitial(t *testing.T) {
+	assert.Equal(t, "A", initial("alice"))
+	assert.Equal(t, "É", initial("élodie"))
+	assert.Equal(t, "?", initial(""))
+}
+
+func TestIdentify(t *testing.T) {
+	email := pgtype.Text{String: "alice@example.com", Valid: true}
+	avatars := map[string]string{"alice@example.com": "https://example.com/alice.png"}
+
+	tests := []struct {
+		name          string
+		ids           identities
+		preferredName pgtype.Text
+		photoURL      pgtype.Text
+		want          Identity
+	}{
+		{
+			name:          "profile photo and name",
+			ids:           identities{viewer: User{ID: 2}, avatars: avatars},
+			preferredName: pgtype.Text{String: "Alice", Valid: true},
+			photoURL:      pgtype.Text{String: "/file/abc", Valid: true},
+			want:          Identity{ID: 1, Name: "Alice", Email: "alice@example.com", PhotoURL: "/file/abc", Initial: "A"},
+		},
+		{
+			name: "falls back to tailscale picture",
+			ids:  identities{viewer: User{ID: 2}, avatars: avatars},
+			want: Identity{ID: 1, Name: "alice@example.com", Email: "alice@example.com", PhotoURL: "/image?url=https%3A%2F%2Fexample.com%2Falice.png", Initial: "A"},
+		},
+		{
+			name:     "external profile photo is proxied",
+			ids:      identities{viewer: User{ID: 2}},
+			photoURL: pgtype.Text{String: "https://photos.example.org/me.jpg", Valid: true},
+			want:     Identity{ID: 1, Name: "alice@example.com", Email: "alice@example.com", PhotoURL: "/image?url=https%3A%2F%2Fphotos.example.org%2Fme.jpg", Initial: "A"},
+		},
+		{
+			name: "no photo at all",
+			ids:  identities{viewer: User{ID: 2}},
+			want: Identity{ID: 1, Name: "alice@example.com", Email: "alice@example.com", Initial: "A"},
+		},
+		{
+			name: "emails hidden from members",
+			ids:  identities{viewer: User{ID: 2}, hideEmails: true},
+			want: Identity{ID: 1, Name: "alice", Initial: "A"},
+		},
+		{
+			name: "emails hidden but not from admins",
+			ids:  identities{viewer: User{ID: 2, IsAdmin: true}, hideEmails: true},
+			want: Identity{ID: 1, Name: "alice", Email: "alice@example.com", Initial: "A"},
+		},
+		{
+			name: "emails hidden but not from the member",
+			ids:  identities{viewer: User{ID: 1}, hideEmails: true},
+			want: Identity{ID: 1, Name: "alice", Email: "alice@example.com", Initial: "A"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.ids.identify(1, email, tt.preferredName, tt.photoURL))
+		})
+	}
+}
+
+type statusTailscaleClient struct {
+	MockTailscaleClient
+	status *ipnstate.Status
+	err    error
+	calls  int
+}
+
+func (c *statusTailscaleClient) Status(ctx context.Context) (*ipnstate.Status, error) {
+	c.calls++
+	return c.status, c.err
+}
+
+func TestTailnetAvatarCache(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	client := &statusTailscaleClient{status: &ipnstate.Status{
+		User: map[tailcfg.UserID]tailcfg.UserProfile{
+			1: {LoginName: "alice@example.com", ProfilePicURL: "https://example.com/alice.png"},
+			2: {LoginName: "bob@example.com"},
+		},
+	}}
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newTailnetAvatarCache(client, logger)
+	cache.now = func() time.Time { return now }
+
+	want := map[string]string{"alice@example.com": "https://example.com/alice.png"}
+	assert.Equal(t, want, cache.Get(context.Background()))
+
+	// Cached until the TTL passes
+	assert.Equal(t, want, cache.Get(context.Background()))
+	assert.Equal(t, 1, client.calls)
+
+	// A failed reload keeps the previous pictures
+	now = now.Add(tailnetAvatarsTTL)
+	client.err = errors.New("tailscale down")
+	assert.Equal(t, want, cache.Get(context.Background()))
+	assert.Equal(t, 2, client.calls)
+
+	// Without a Tailscale client there are no pictures
+	assert.Nil(t, newTailnetAvatarCache(nil, logger).Get(context.Background()))
+}
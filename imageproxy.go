@@ -0,0 +1,343 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// imageProxyPath is where images from other hosts are served from
+	imageProxyPath = "/image"
+
+	// imageProxyTimeout bounds fetching one image, redirects included
+	imageProxyTimeout = 10 * time.Second
+
+	// maxProxyImageBytes is the largest image the proxy will serve
+	maxProxyImageBytes = 5 * 1024 * 1024
+
+	// maxImageRedirects is how many redirects are followed
+	maxImageRedirects = 3
+
+	// imageCacheBytes bounds the memory used by cached images
+	imageCacheBytes = 64 * 1024 * 1024
+
+	// storedBodyCacheSize is how many post bodies stored before the image
+	// proxy are kept rewritten to use it
+	storedBodyCacheSize = 500
+
+	// imageEntryCost is what each cached image counts towards
+	// imageCacheBytes besides its data and link, so failures, which have no
+	// data, can't fill the cache without limit
+	imageEntryCost = 1024
+
+	// imageCacheTTL is how long a fetched image is served from the cache,
+	// and imageFailureTTL how long a failure to fetch one is
+	imageCacheTTL   = 24 * time.Hour
+	imageFailureTTL = 5 * time.Minute
+)
+
+var (
+	errImageTooLarge = errors.New("image proxy: image too large")
+	errNotAnImage    = errors.New("image proxy: not an image")
+)
+
+// proxyImageURL points an image's src at the image proxy when it's on
+// another host, so readers' browsers only ever talk to the board. Relative
+// URLs, such as attachments', are left alone.
+func proxyImageURL(u *url.URL) {
+	if u.Host == "" {
+		return
+	}
+	if u.Scheme == "" {
+		u.Scheme = "https"
+	}
+	link := u.String()
+	*u = url.URL{Path: imageProxyPath, RawQuery: url.Values{"url": {link}}.Encode()}
+}
+
+// proxiedImageSrc is proxyImageURL for a src that isn't yet parsed. An src
+// that doesn't parse is dropped.
+func proxiedImageSrc(src string) string {
+	if src == "" {
+		return ""
+	}
+	u, err := url.Parse(src)
+	if err != nil {
+		return ""
+	}
+	proxyImageURL(u)
+	return u.String()
+}
+
+// proxyableImage reports whether the proxy may fetch an image: over http or
+// https, on the standard ports and without credentials.
+func proxyableImage(link *url.URL) bool {
+	if link.Scheme != "http" && link.Scheme != "https" || link.User != nil || link.Hostname() == "" {
+		return false
+	}
+	return link.Port() == "" || link.Port() == "80" || link.Port() == "443"
+}
+
+// proxiedImage is a fetched image, or the failure to fetch it.
+type proxiedImage struct {
+	link        string
+	contentType string
+	data        []byte
+	etag        string
+	err         error
+	fetched     time.Time
+}
+
+// cost is what an image counts towards imageCacheBytes.
+func (image *proxiedImage) cost() int {
+	return imageEntryCost + len(image.link) + len(image.data)
+}
+
+// ImageProxy fetches images from other hosts on behalf of readers, so those
+// hosts see the board's server rather than who is reading which post and
+// when. Images are only fetched from public addresses, and recently served
+// ones are kept in memory.
+type ImageProxy struct {
+	client *http.Client
+	now    func() time.Time
+	// fetches makes the readers asking for the same image at once share
+	// one fetch
+	fetches singleflight.Group
+
+	mu     sync.Mutex
+	images map[string]*list.Element
+	// recent orders the cached images from most to least recently served
+	recent *list.List
+	size   int
+
+	// storedBodies keeps the post bodies proxyStoredImages rewrote, by the
+	// hash of the stored body
+	storedBodies *lruCache
+}
+
+// NewImageProxy creates an ImageProxy with an empty cache.
+func NewImageProxy() *ImageProxy {
+	return &ImageProxy{
+		client: &http.Client{
+			Timeout: imageProxyTimeout,
+			Transport: &http.Transport{
+				Proxy:                 nil,
+				DialContext:           guardedDialer(false).DialContext,
+				TLSHandshakeTimeout:   imageProxyTimeout,
+				ResponseHeaderTimeout: imageProxyTimeout,
+				MaxIdleConns:          10,
+				IdleConnTimeout:       time.Minute,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > maxImageRedirects {
+					return errors.New("image proxy: too many redirects")
+				}
+				if !proxyableImage(req.URL) {
+					return fmt.Errorf("image proxy: redirect to %s not allowed", req.URL.Redacted())
+				}
+				return nil
+			},
+		},
+		now:          time.Now,
+		images:       make(map[string]*list.Element),
+		recent:       list.New(),
+		storedBodies: newLRUCache(storedBodyCacheSize),
+	}
+}
+
+// Get returns an image from the cache, fetching it if it isn't there or
+// has expired.
+func (p *ImageProxy) Get(ctx context.Context, link string) (*proxiedImage, error) {
+	if image, ok := p.cached(link); ok {
+		return image, image.err
+	}
+
+	v, _, _ := p.fetches.Do(link, func() (any, error) {
+		// The fetch is shared, so one reader going away doesn't cut it
+		// short for the others; the client's timeout still bounds it
+		image := p.fetch(context.WithoutCancel(ctx), link)
+		p.store(image)
+		return image, nil
+	})
+	image := v.(*proxiedImage)
+	return image, image.err
+}
+
+func (p *ImageProxy) cached(link string) (*proxiedImage, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	el, ok := p.images[link]
+	if !ok {
+		return nil, false
+	}
+	image := el.Value.(*proxiedImage)
+
+	ttl := imageCacheTTL
+	if image.err != nil {
+		ttl = imageFailureTTL
+	}
+	if p.now().Sub(image.fetched) >= ttl {
+		p.remove(el)
+		return nil, false
+	}
+
+	p.recent.MoveToFront(el)
+	return image, true
+}
+
+// store caches an image, evicting the least recently served ones to stay
+// within imageCacheBytes.
+func (p *ImageProxy) store(image *proxiedImage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if el, ok := p.images[image.link]; ok {
+		p.remove(el)
+	}
+	p.images[image.link] = p.recent.PushFront(image)
+	p.size += image.cost()
+
+	for p.size > imageCacheBytes {
+		p.remove(p.recent.Back())
+	}
+}
+
+func (p *ImageProxy) remove(el *list.Element) {
+	image := p.recent.Remove(el).(*proxiedImage)
+	delete(p.images, image.link)
+	p.size -= image.cost()
+}
+
+// fetch gets an image. Its type is sniffed from the content rather than
+// trusted, and only the raster formats attachments may have are served:
+// an SVG could carry script.
+func (p *ImageProxy) fetch(ctx context.Context, link string) *proxiedImage {
+	image := &proxiedImage{link: link, fetched: p.now()}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		image.err = err
+		return image
+	}
+	req.Header.Set("Accept", "image/*")
+	req.Header.Set("User-Agent", "tdiscuss-image-proxy/"+version)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		image.err = err
+		return image
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		image.err = fmt.Errorf("image proxy: %s", resp.Status)
+		return image
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); !isImageType(mediaType) {
+		image.err = fmt.Errorf("%w: %q", errNotAnImage, mediaType)
+		return image
+	}
+	if resp.ContentLength > maxProxyImageBytes {
+		image.err = errImageTooLarge
+		return image
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxProxyImageBytes+1))
+	if err != nil {
+		image.err = err
+		return image
+	}
+	if len(data) > maxProxyImageBytes {
+		image.err = errImageTooLarge
+		return image
+	}
+
+	contentType, ok := sniffAttachmentType(data)
+	if !ok || !isImageType(contentType) {
+		image.err = fmt.Errorf("%w: %q", errNotAnImage, contentType)
+		return image
+	}
+
+	sum := sha256.Sum256(data)
+	image.contentType = contentType
+	image.data = data
+	image.etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	return image
+}
+
+// ProxyImage serves an image from another host, which posts and profiles
+// link to as /image?url=...
+func (s *DiscussService) ProxyImage(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "ProxyImage")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	link, err := url.Parse(r.URL.Query().Get("url"))
+	if err != nil || !proxyableImage(link) {
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	span.AddEvent("images.Get")
+	image, err := s.images.Get(r.Context(), link.String())
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error proxying image", slog.String("url", link.Redacted()), slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadGateway)
+		return
+	}
+
+	if r.Header.Get("If-None-Match") == image.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", image.contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(image.data)))
+	w.Header().Set("Content-Disposition", "inline")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Header().Set("ETag", image.etag)
+	w.Write(image.data)
+}
+
+// externalImage matches an <img> whose src is on another host.
+var externalImage = regexp.MustCompile(`<img[^>]*\ssrc="(https?:)?//`)
+
+// proxyStoredImages points the images in a post body stored before the
+// image proxy at it, as the body is shown. Bodies stored since are already
+// proxied and are returned as they are. Rewriting a body means sanitizing it
+// again, so recently shown ones are kept rewritten, unless p is nil.
+func (p *ImageProxy) proxyStoredImages(body string) string {
+	if !externalImage.MatchString(body) {
+		return body
+	}
+	if p == nil {
+		return parseHTMLLessStrict(body)
+	}
+
+	sum := sha256.Sum256([]byte(body))
+	key := hex.EncodeToString(sum[:])
+	if rewritten, ok := p.storedBodies.get(key); ok {
+		return rewritten
+	}
+	rewritten := parseHTMLLessStrict(body)
+	p.storedBodies.put(key, rewritten)
+	return rewritten
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruCache keeps up to size strings by key, evicting the least recently used
+// to make room for new ones.
+type lruCache struct {
+	mu    sync.Mutex
+	size  int
+	items map[string]*list.Element
+	// recent orders the entries from most to least recently used
+	recent *list.List
+}
+
+type lruEntry struct {
+	key   string
+	value string
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:   size,
+		items:  make(map[string]*list.Element),
+		recent: list.New(),
+	}
+}
+
+func (c *lruCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	c.recent.MoveToFront(el)
+	return el.Value.(*lruEntry).value, true
+}
+
+func (c *lruCache) put(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value.(*lruEntry).value = value
+		c.recent.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.recent.PushFront(&lruEntry{key: key, value: value})
+	if c.recent.Len() > c.size {
+		delete(c.items, c.recent.Remove(c.recent.Back()).(*lruEntry).key)
+	}
+}
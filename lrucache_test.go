@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCache(t *testing.T) {
+	c := newLRUCache(3)
+	for i := 0; i < 3; i++ {
+		c.put(strconv.Itoa(i), "value")
+	}
+	_, ok := c.get("0")
+	require.True(t, ok)
+
+	// "1" is now the least recently used
+	c.put("new", "value")
+	_, ok = c.get("1")
+	assert.False(t, ok)
+	_, ok = c.get("0")
+	assert.True(t, ok)
+	assert.Equal(t, 3, c.recent.Len())
+
+	c.put("0", "changed")
+	value, _ := c.get("0")
+	assert.Equal(t, "changed", value)
+	assert.Equal(t, 3, c.recent.Len())
+}
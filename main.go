@@ -6,11 +6,17 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	// Member timezones must resolve wherever the binary runs
+	_ "time/tzdata"
+
+	"github.com/imeyer/tdiscuss/middleware"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"tailscale.com/hostinfo"
@@ -31,6 +37,15 @@ var (
 	tsnetLog            = flag.Bool("tsnet-log", false, "Enable tsnet logging (warning: VERY verbose)")
 	otlpMode            = flag.Bool("otlp", false, "Enable OTLP metrics output, IYKYK")
 	showVersion         = flag.Bool("version", false, "Print version and exit")
+	blobStoreKind       = flag.String("blob-store", envOr("BLOB_STORE", "local"), "Attachment storage backend: local (under -data-location) or s3")
+	maxUploadSize       = flag.Int64("max-upload-size", 10*1024*1024, "Maximum attachment upload size in bytes")
+	unfurlHosts         = flag.String("unfurl-hosts", envOr("UNFURL_HOSTS", ""), "Comma separated hosts whose links in posts get preview cards, *.example.com for subdomains or * for any public host; empty turns unfurling off")
+	mermaidCLI          = flag.String("mermaid-cli", envOr("MERMAID_CLI", ""), "Path to the Mermaid CLI (mmdc) used to render diagrams in posts to SVG; empty shows diagrams as source")
+	draftTTL            = flag.Duration("draft-ttl", defaultDraftTTL, "How long an autosaved draft is kept after it was last saved")
+	rateLimitStoreKind  = flag.String("rate-limit-store", envOr("RATE_LIMIT_STORE", "memory"), "Rate limit counter storage: memory, or postgres to share counters between replicas")
+	listen              = flag.String("listen", "", "Run in local development mode on this plain TCP address (e.g. localhost:8080) without Tailscale")
+	devUsersFile        = flag.String("dev-users", "", "File of fake user emails for -listen mode, one per line")
+	listenAnyAddress    = flag.Bool("listen-any-address", false, "Allow -listen on a non-loopback address, letting anyone who can reach it sign in as any fake user")
 	version  string     = "dev"
 	gitSha   string     = "no-commit"
 	logLevel slog.Level = slog.LevelInfo
@@ -103,15 +118,15 @@ func main() {
 	}
 	defer dbconn.Close()
 
-	s := setupTsNetServer(logger)
-	defer s.Close()
+	if err := registerPoolMetrics(telemetry.Meter, dbconn); err != nil {
+		logger.Error("failed to register database pool metrics", slog.String("error", err.Error()))
+	}
 
 	tmpls := setupTemplates()
 
-	lc := getTailscaleLocalClient(s, logger)
-
-	if err := checkTailscaleReady(ctx, lc, logger); err != nil {
-		logger.Error("tailscale not ready", slog.String("error", err.Error()))
+	blobs, err := setupBlobStore(*blobStoreKind, *dataDir)
+	if err != nil {
+		logger.Error("failed to set up blob store", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 
@@ -119,18 +134,81 @@ func main() {
 	wrappedQueries := &QueriesWrapper{Queries: queries}
 
 	tracedQueries := NewTracedQueriesWrapper(wrappedQueries, telemetry)
+	// Handlers only ever see the boards the requesting member may see
+	boardQueries := NewBoardAccessQuerier(tracedQueries)
+	querierAdapter := NewQuerierAdapter(boardQueries)
+
+	rateLimitStore, err := setupRateLimitStore(*rateLimitStoreKind, tracedQueries)
+	if err != nil {
+		logger.Error("failed to set up rate limit store", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	// Local development mode: plain TCP, no tsnet, fake users
+	if *listen != "" {
+		users, err := middleware.LoadLocalUsers(*devUsersFile)
+		if err != nil {
+			logger.Error("failed to load local users", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+
+		authProvider := middleware.NewLocalAuthProvider(users, querierAdapter, logger)
+		dsvc := NewDiscussService(nil, logger, dbconn, boardQueries, tmpls, *hostname, version, gitSha, telemetry, blobs, rateLimitStore, authProvider)
+		dsvc.startWorkers(ctx)
+
+		ln, err := net.Listen("tcp", *listen)
+		if err != nil {
+			logger.Error("error creating listener", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		defer ln.Close()
+
+		// Anyone who can reach the listener can sign in as any fake user
+		if !isLoopback(ln.Addr()) && !*listenAnyAddress {
+			logger.Error("refusing to run local development mode on a non-loopback address; use -listen-any-address to override",
+				slog.String("listen", ln.Addr().String()))
+			os.Exit(1)
+		}
+
+		logger.Warn("running in local development mode without Tailscale; do not expose this listener",
+			slog.String("listen", ln.Addr().String()),
+			slog.Any("users", users))
+		logger.Info(fmt.Sprintf("switch users at http://%s/_/dev/users", ln.Addr().String()))
+
+		server := createHTTPServer(setupMux(dsvc))
+		go startServer(server, ln, logger, "http", ln.Addr().String())
+
+		waitForShutdown(sigChan, ctx, logger, map[string]*http.Server{"HTTP": server})
+		return
+	}
+
+	s := setupTsNetServer(logger)
+	defer s.Close()
+
+	lc := getTailscaleLocalClient(s, logger)
+
+	if err := checkTailscaleReady(ctx, lc, logger); err != nil {
+		logger.Error("tailscale not ready", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	authProvider := middleware.NewTailscaleAuthProvider(NewTailscaleClientAdapter(lc), querierAdapter, logger)
 
 	dsvc := NewDiscussService(
 		lc,
 		logger,
 		dbconn,
-		tracedQueries,
+		boardQueries,
 		tmpls,
 		*hostname,
 		version,
 		gitSha,
 		telemetry,
+		blobs,
+		rateLimitStore,
+		authProvider,
 	)
+	dsvc.startWorkers(ctx)
 
 	mux := setupMux(dsvc)
 
@@ -144,5 +222,8 @@ func main() {
 	go startServer(serverPlain, ln, logger, "http", *hostname)
 	go startServer(serverTls, tln, logger, "https", expandSNIName(ctx, lc, logger))
 
-	waitForShutdown(sigChan, ctx, logger, serverPlain, serverTls)
+	waitForShutdown(sigChan, ctx, logger, map[string]*http.Server{
+		"HTTP":  serverPlain,
+		"HTTPS": serverTls,
+	})
 }
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/wyatt915/treeblood"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Math is written in TeX, "$x^2$" inline and "$$...$$" for display, and is
+// rendered to MathML when the post is saved, so it needs no script to show.
+// As in pandoc, a "$" only opens math if it's followed by a non-space and
+// only closes it if it follows a non-space and isn't followed by a digit, so
+// "$5 and $10" stays as written.
+
+var mathDisplayDelimiter = []byte("$$")
+
+var (
+	kindMath      = ast.NewNodeKind("Math")
+	kindMathBlock = ast.NewNodeKind("MathBlock")
+)
+
+// mathInline is math within a line of text. $$...$$ on a line with other
+// text is still display math.
+type mathInline struct {
+	ast.BaseInline
+	tex     string
+	display bool
+}
+
+func (n *mathInline) Kind() ast.NodeKind { return kindMath }
+
+func (n *mathInline) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"TeX": n.tex}, nil)
+}
+
+// mathBlock is display math in a block of its own, which may span lines.
+type mathBlock struct {
+	ast.BaseBlock
+	tex bytes.Buffer
+	// closed is set when the closing $$ has been read
+	closed bool
+}
+
+func (n *mathBlock) Kind() ast.NodeKind { return kindMathBlock }
+
+func (n *mathBlock) IsRaw() bool { return true }
+
+func (n *mathBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"TeX": n.tex.String()}, nil)
+}
+
+// mathInlineParser reads $...$ and $$...$$ within a line.
+type mathInlineParser struct{}
+
+func (p *mathInlineParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (p *mathInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+
+	if bytes.HasPrefix(line, mathDisplayDelimiter) {
+		end := bytes.Index(line[2:], mathDisplayDelimiter)
+		if end <= 0 {
+			return nil
+		}
+		block.Advance(2 + end + 2)
+		return &mathInline{tex: string(line[2 : 2+end]), display: true}
+	}
+
+	if len(line) < 2 || util.IsSpace(line[1]) {
+		return nil
+	}
+	for i := 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '$':
+			if util.IsSpace(line[i-1]) || i+1 < len(line) && util.IsNumeric(line[i+1]) {
+				continue
+			}
+			block.Advance(i + 1)
+			return &mathInline{tex: string(line[1:i])}
+		}
+	}
+	return nil
+}
+
+// mathBlockParser reads display math in lines starting with $$, up to the
+// line ending with $$.
+type mathBlockParser struct{}
+
+func (b *mathBlockParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (b *mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], mathDisplayDelimiter) {
+		return nil, parser.NoChildren
+	}
+	rest := util.TrimRightSpace(line[pos+2:])
+
+	node := &mathBlock{}
+	if end := bytes.Index(rest, mathDisplayDelimiter); end >= 0 {
+		// Text after the closing $$ makes it inline math in a paragraph
+		if end+2 != len(rest) {
+			return nil, parser.NoChildren
+		}
+		node.tex.Write(rest[:end])
+		node.closed = true
+		advanceLine(reader, line, segment)
+		return node, parser.NoChildren
+	}
+
+	node.tex.Write(rest)
+	advanceLine(reader, line, segment)
+	return node, parser.NoChildren
+}
+
+func (b *mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	n := node.(*mathBlock)
+	line, segment := reader.PeekLine()
+	if n.closed || line == nil {
+		return parser.Close
+	}
+
+	trimmed := util.TrimRightSpace(line)
+	if end := bytes.LastIndex(trimmed, mathDisplayDelimiter); end >= 0 && end+2 == len(trimmed) {
+		n.tex.WriteByte('\n')
+		n.tex.Write(trimmed[:end])
+		advanceLine(reader, line, segment)
+		return parser.Close
+	}
+
+	n.tex.WriteByte('\n')
+	n.tex.Write(trimmed)
+	advanceLine(reader, line, segment)
+	return parser.Continue | parser.NoChildren
+}
+
+// advanceLine moves past the rest of a line but its newline, which the last
+// line of a post may not have.
+func advanceLine(reader text.Reader, line []byte, segment text.Segment) {
+	n := segment.Len()
+	if len(line) > 0 && line[len(line)-1] == '\n' {
+		n--
+	}
+	reader.Advance(n)
+}
+
+func (b *mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (b *mathBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (b *mathBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// mathRenderer writes math as MathML. TeX that doesn't parse is shown as
+// typed.
+type mathRenderer struct{}
+
+func (r *mathRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMath, r.renderMath)
+	reg.Register(kindMathBlock, r.renderMathBlock)
+}
+
+func (r *mathRenderer) renderMath(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		n := node.(*mathInline)
+		writeMathML(w, n.tex, n.display)
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *mathRenderer) renderMathBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		writeMathML(w, node.(*mathBlock).tex.String(), true)
+		_ = w.WriteByte('\n')
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func writeMathML(w util.BufWriter, tex string, display bool) {
+	mathML, err := treeblood.TexToMML(tex, nil, display, false)
+	if err != nil {
+		_, _ = w.WriteString("<code>")
+		_, _ = w.Write(util.EscapeHTML([]byte(tex)))
+		_, _ = w.WriteString("</code>")
+		return
+	}
+	// treeblood indents its output, which would show as a space inline
+	_, _ = w.WriteString(strings.TrimSpace(mathML))
+}
+
+type mathExtension struct{}
+
+// Math is a goldmark extension for TeX math rendered to MathML.
+var Math = &mathExtension{}
+
+func (e *mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		// Ahead of paragraphs, so $$ on a line of its own starts a block
+		parser.WithBlockParsers(
+			util.Prioritized(&mathBlockParser{}, 750),
+		),
+		parser.WithInlineParsers(
+			util.Prioritized(&mathInlineParser{}, 500),
+		),
+	)
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(
+			util.Prioritized(&mathRenderer{}, 500),
+		),
+	)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderPostBody_Math(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:     "Inline math",
+			input:    "so $x^2$ it is",
+			contains: []string{`<p>so <math display="inline">`, "<msup>", "<mi>x</mi>", "<mn>2</mn>", "</math> it is</p>"},
+		},
+		{
+			name:     "Display math in a block",
+			input:    "$$\na+b\n$$",
+			contains: []string{`<math display="block">`, "<mo>+</mo>"},
+		},
+		{
+			name:        "Display math on one line",
+			input:       "$$x$$",
+			contains:    []string{`<math display="block">`},
+			notContains: []string{"<p>"},
+		},
+		{
+			name:        "Unclosed display math at the end of a post",
+			input:       "$$\na+b",
+			contains:    []string{`<math display="block">`, "<mo>+</mo>"},
+			notContains: []string{"$"},
+		},
+		{
+			name:        "Display math followed by text stays in the paragraph",
+			input:       "$$x$$ inline",
+			contains:    []string{`<p><math display="block">`, "</math> inline</p>"},
+			notContains: []string{"$"},
+		},
+		{
+			name:        "Prices aren't math",
+			input:       "It costs $5 and $10.",
+			contains:    []string{"<p>It costs $5 and $10.</p>"},
+			notContains: []string{"<math"},
+		},
+		{
+			name:        "Escaped dollar signs aren't math",
+			input:       `a \$x$ b`,
+			contains:    []string{"<p>a $x$ b</p>"},
+			notContains: []string{"<math"},
+		},
+		{
+			name:        "TeX that doesn't parse is shown as typed",
+			input:       `$\frac{1}{$`,
+			contains:    []string{`<code>\frac{1}{</code>`},
+			notContains: []string{"<math"},
+		},
+		{
+			name:        "TeX is escaped",
+			input:       `$\text{<script>alert(1)</script>}$`,
+			notContains: []string{"<script>"},
+		},
+		{
+			name:        "MathML written by hand is dropped",
+			input:       `<math display="block"><mi>x</mi></math> and $y$`,
+			contains:    []string{"<p>x and <math display=\"inline\">"},
+			notContains: []string{`<math display="block">`, "<mi>x</mi>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderPostBody(nil, tt.input)
+			for _, want := range tt.contains {
+				assert.Contains(t, result, want)
+			}
+			for _, unwanted := range tt.notContains {
+				assert.NotContains(t, result, unwanted)
+			}
+		})
+	}
+}
+
+func TestBodyPolicy_MathML(t *testing.T) {
+	result := parseHTMLLessStrict(`<math display="evil" onclick="alert(1)"><mi href="javascript:alert(1)" mathcolor="red;background:url(x)">x</mi><annotation encoding="text/html"><b>y</b></annotation></math>`)
+	assert.Contains(t, result, "<math>")
+	assert.Contains(t, result, "<mi>x</mi>")
+	for _, unwanted := range []string{"evil", "onclick", "javascript", "url(", "text/html"} {
+		assert.NotContains(t, result, unwanted)
+	}
+}
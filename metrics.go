@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/otel/metric"
 )
 
@@ -151,3 +154,141 @@ func initializeHistograms(meter metric.Meter, config *TelemetryConfig) error {
 
 	return nil
 }
+
+// registerPoolMetrics exports pgxpool statistics as observable instruments,
+// read from pool.Stat() at each collection. Gauges show the pool's current
+// shape; counters accumulate since the pool was created, so starvation shows
+// up as a rising empty acquire count and wait time.
+func registerPoolMetrics(meter metric.Meter, pool *pgxpool.Pool) error {
+	gaugeMetrics := []struct {
+		Name        string
+		Description string
+		Value       func(*pgxpool.Stat) int64
+	}{
+		{
+			Name:        "db_pool_acquired_connections",
+			Description: "Connections currently checked out of the pool",
+			Value:       func(s *pgxpool.Stat) int64 { return int64(s.AcquiredConns()) },
+		},
+		{
+			Name:        "db_pool_idle_connections",
+			Description: "Connections currently idle in the pool",
+			Value:       func(s *pgxpool.Stat) int64 { return int64(s.IdleConns()) },
+		},
+		{
+			Name:        "db_pool_constructing_connections",
+			Description: "Connections currently being established",
+			Value:       func(s *pgxpool.Stat) int64 { return int64(s.ConstructingConns()) },
+		},
+		{
+			Name:        "db_pool_total_connections",
+			Description: "Total connections in the pool, acquired, idle and constructing",
+			Value:       func(s *pgxpool.Stat) int64 { return int64(s.TotalConns()) },
+		},
+		{
+			Name:        "db_pool_max_connections",
+			Description: "Maximum size of the pool",
+			Value:       func(s *pgxpool.Stat) int64 { return int64(s.MaxConns()) },
+		},
+	}
+
+	counterMetrics := []struct {
+		Name        string
+		Description string
+		Value       func(*pgxpool.Stat) int64
+	}{
+		{
+			Name:        "db_pool_acquires_total",
+			Description: "Successful connection acquires from the pool",
+			Value:       (*pgxpool.Stat).AcquireCount,
+		},
+		{
+			Name:        "db_pool_empty_acquires_total",
+			Description: "Successful acquires that had to wait for a connection because the pool was empty",
+			Value:       (*pgxpool.Stat).EmptyAcquireCount,
+		},
+		{
+			Name:        "db_pool_canceled_acquires_total",
+			Description: "Acquires canceled by their context before a connection became available",
+			Value:       (*pgxpool.Stat).CanceledAcquireCount,
+		},
+	}
+
+	durationMetrics := []struct {
+		Name        string
+		Description string
+		Value       func(*pgxpool.Stat) time.Duration
+	}{
+		{
+			Name:        "db_pool_acquire_duration_seconds_total",
+			Description: "Total time spent in successful acquires",
+			Value:       (*pgxpool.Stat).AcquireDuration,
+		},
+		{
+			Name:        "db_pool_empty_acquire_wait_seconds_total",
+			Description: "Total time acquires spent waiting for a connection because the pool was empty",
+			Value:       (*pgxpool.Stat).EmptyAcquireWaitTime,
+		},
+	}
+
+	var observables []metric.Observable
+	var observers []func(metric.Observer, *pgxpool.Stat)
+
+	for _, m := range gaugeMetrics {
+		gauge, err := meter.Int64ObservableGauge(m.Name,
+			metric.WithDescription(m.Description),
+			metric.WithUnit("{connections}"),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create gauge %s: %w", m.Name, err)
+		}
+		value := m.Value
+		observables = append(observables, gauge)
+		observers = append(observers, func(o metric.Observer, s *pgxpool.Stat) {
+			o.ObserveInt64(gauge, value(s))
+		})
+	}
+
+	for _, m := range counterMetrics {
+		counter, err := meter.Int64ObservableCounter(m.Name,
+			metric.WithDescription(m.Description),
+			metric.WithUnit("{acquires}"),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create counter %s: %w", m.Name, err)
+		}
+		value := m.Value
+		observables = append(observables, counter)
+		observers = append(observers, func(o metric.Observer, s *pgxpool.Stat) {
+			o.ObserveInt64(counter, value(s))
+		})
+	}
+
+	for _, m := range durationMetrics {
+		counter, err := meter.Float64ObservableCounter(m.Name,
+			metric.WithDescription(m.Description),
+			metric.WithUnit("s"),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create counter %s: %w", m.Name, err)
+		}
+		value := m.Value
+		observables = append(observables, counter)
+		observers = append(observers, func(o metric.Observer, s *pgxpool.Stat) {
+			o.ObserveFloat64(counter, value(s).Seconds())
+		})
+	}
+
+	_, err := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		stat := pool.Stat()
+		for _, observe := range observers {
+			observe(o, stat)
+		}
+		return nil
+	}, observables...)
+	if err != nil {
+		return fmt.Errorf("failed to register pool metrics callback: %w", err)
+	}
+
+	return nil
+}
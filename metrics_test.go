@@ -1 +1,64 @@
 package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestRegisterPoolMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	// The pool connects lazily, so no database is needed to read its stats
+	poolConfig, err := pgxpool.ParseConfig("postgres://tdiscuss@localhost:5432/tdiscuss")
+	require.NoError(t, err)
+	poolConfig.MaxConns = 4
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	require.NoError(t, registerPoolMetrics(provider.Meter("test"), pool))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	values := make(map[string]any)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				values[m.Name] = data.DataPoints[0].Value
+			case metricdata.Sum[int64]:
+				values[m.Name] = data.DataPoints[0].Value
+			case metricdata.Sum[float64]:
+				values[m.Name] = data.DataPoints[0].Value
+			}
+		}
+	}
+
+	assert.Equal(t, int64(4), values["db_pool_max_connections"])
+	for _, name := range []string{
+		"db_pool_acquired_connections",
+		"db_pool_idle_connections",
+		"db_pool_constructing_connections",
+		"db_pool_total_connections",
+		"db_pool_acquires_total",
+		"db_pool_empty_acquires_total",
+		"db_pool_canceled_acquires_total",
+	} {
+		assert.Equal(t, int64(0), values[name], name)
+	}
+	for _, name := range []string{
+		"db_pool_acquire_duration_seconds_total",
+		"db_pool_empty_acquire_wait_seconds_total",
+	} {
+		assert.Equal(t, float64(0), values[name], name)
+	}
+}
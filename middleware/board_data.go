@@ -8,19 +8,22 @@ import (
 // contextKey is an unexported type for context keys to prevent collisions
 type boardDataContextKey struct{}
 
-// BoardDataQuerier defines the interface for getting board data
+// BoardDataQuerier defines the interface for getting board data. An empty
+// slug selects the default board.
 type BoardDataQuerier interface {
-	GetBoardData(ctx context.Context) (interface{}, error)
+	GetBoardData(ctx context.Context, slug string) (interface{}, error)
 }
 
-// BoardDataMiddleware fetches board data and adds it to the request context
+// BoardDataMiddleware fetches board data and adds it to the request context.
+// Routes under /b/{slug}/ get that board; every other route gets the default
+// board. An unknown slug leaves the board data nil for the handler to 404.
 func BoardDataMiddleware(querier BoardDataQuerier) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
 			
 			// Fetch board data
-			boardData, err := querier.GetBoardData(ctx)
+			boardData, err := querier.GetBoardData(ctx, r.PathValue("slug"))
 			if err != nil {
 				// Log error but continue - we'll use default title
 				// Note: This error is expected if board_data table is empty
@@ -26,6 +26,9 @@ var (
 	// GetUser retrieves the authenticated user from context
 	GetUser = getUser
 
+	// WithUser returns a context carrying the given authenticated user
+	WithUser = withUser
+
 	// GetRequestID retrieves the request ID from context
 	GetRequestID = getRequestID
 
@@ -125,11 +128,26 @@ func NewTailscaleAuthProvider(client TailscaleClient, queries Querier, logger *s
 	return newTailscaleAuthProvider(client, queries, logger)
 }
 
+// NewLocalAuthProvider creates an auth provider with fake users for local development
+func NewLocalAuthProvider(users []string, queries Querier, logger *slog.Logger) *LocalAuthProvider {
+	return newLocalAuthProvider(users, queries, logger)
+}
+
+// LoadLocalUsers reads the fake users for local development from a file
+func LoadLocalUsers(path string) ([]string, error) {
+	return loadLocalUsers(path)
+}
+
 // NewRateLimiter creates a new rate limiter
 func NewRateLimiter(config *RateLimitConfig, logger *slog.Logger) *RateLimiter {
 	return newRateLimiter(config, logger)
 }
 
+// NewMemoryRateLimitStore creates a rate limit store that keeps its counters in memory
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return newMemoryRateLimitStore()
+}
+
 // NewObservabilityMiddleware creates comprehensive observability middleware
 func NewObservabilityMiddleware(config *ObservabilityConfig) Middleware {
 	return newObservabilityMiddleware(config)
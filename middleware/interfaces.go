@@ -13,16 +13,30 @@ type TailscaleClient interface {
 // WhoIsResponse represents the Tailscale WhoIs response
 type WhoIsResponse struct {
 	UserProfile *UserProfile
+	Node        *Node
+	// CapMap holds the capabilities the tailnet policy grants the caller,
+	// each with its raw JSON values
+	CapMap map[string][]string
 }
 
 // UserProfile represents a Tailscale user profile
 type UserProfile struct {
-	LoginName string
+	LoginName     string
+	DisplayName   string
+	ProfilePicURL string
+}
+
+// Node represents the Tailscale machine a request came from
+type Node struct {
+	Name string
+	OS   string
+	Tags []string
 }
 
 // Querier interface for database operations
 type Querier interface {
 	CreateOrReturnID(ctx context.Context, email string) (CreateOrReturnIDRow, error)
+	SyncMemberProfile(ctx context.Context, arg SyncMemberProfileParams) error
 }
 
 // CreateOrReturnIDRow represents a user row from the database
@@ -32,6 +46,15 @@ type CreateOrReturnIDRow struct {
 	IsBlocked bool
 }
 
+// SyncMemberProfileParams is a member's identity as Tailscale reports it
+type SyncMemberProfileParams struct {
+	MemberID      int64
+	DisplayName   string
+	ProfilePicURL string
+	NodeName      string
+	NodeOS        string
+}
+
 // Logger interface for structured logging
 type Logger interface {
 	DebugContext(ctx context.Context, msg string, args ...any)
@@ -2,9 +2,13 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -17,11 +21,44 @@ type AuthProvider interface {
 	CreateOrGetUser(ctx context.Context, email string) (*ContextUser, error)
 }
 
+// ProfileSyncer is implemented by auth providers that keep a member's
+// profile up to date with their identity
+type ProfileSyncer interface {
+	SyncProfile(r *http.Request, user *ContextUser)
+}
+
+// AccessResolver is implemented by auth providers that know which tags,
+// capabilities and groups a request carries, for private boards. They
+// resolve them along with the email, from the same lookup.
+type AccessResolver interface {
+	GetUserAccess(r *http.Request) (email string, principals []string, err error)
+}
+
+// profileSyncInterval is how often a member's profile is refreshed from
+// their Tailscale identity
+const profileSyncInterval = time.Hour
+
+// AccessCapability is the Tailscale app capability that tells tdiscuss which
+// groups a member is in. WhoIs doesn't report group membership, so the
+// tailnet policy file grants it to each group that private boards name:
+//
+//	"app": {"github.com/imeyer/tdiscuss": [{"groups": ["group:hr"]}]}
+const AccessCapability = "github.com/imeyer/tdiscuss"
+
+// accessCapabilityValue is one grant of AccessCapability
+type accessCapabilityValue struct {
+	Groups []string `json:"groups"`
+}
+
 // TailscaleAuthProvider implements AuthProvider for Tailscale
 type TailscaleAuthProvider struct {
 	client  TailscaleClient
 	queries Querier
 	logger  *slog.Logger
+	now     func() time.Time
+
+	mu     sync.Mutex
+	synced map[int64]time.Time
 }
 
 // newTailscaleAuthProvider creates a new Tailscale auth provider
@@ -30,26 +67,140 @@ func newTailscaleAuthProvider(client TailscaleClient, queries Querier, logger *s
 		client:  client,
 		queries: queries,
 		logger:  logger,
+		now:     time.Now,
+		synced:  make(map[int64]time.Time),
 	}
 }
 
 // GetUserEmail gets the user's email from Tailscale
 func (p *TailscaleAuthProvider) GetUserEmail(r *http.Request) (string, error) {
+	who, err := p.whoIs(r)
+	if err != nil {
+		return "", err
+	}
+
+	return who.UserProfile.LoginName, nil
+}
+
+// GetUserAccess gets the user's email and principals from one Tailscale
+// WhoIs
+func (p *TailscaleAuthProvider) GetUserAccess(r *http.Request) (string, []string, error) {
+	who, err := p.whoIs(r)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return who.UserProfile.LoginName, p.principals(r.Context(), who), nil
+}
+
+// whoIs looks up the caller, who must have a login name
+func (p *TailscaleAuthProvider) whoIs(r *http.Request) (*WhoIsResponse, error) {
 	who, err := p.client.WhoIs(r.Context(), r.RemoteAddr)
 	if err != nil {
-		return "", fmt.Errorf("failed to get WhoIs: %w", err)
+		return nil, fmt.Errorf("failed to get WhoIs: %w", err)
 	}
 
 	if who.UserProfile == nil || who.UserProfile.LoginName == "" {
-		return "", fmt.Errorf("no user profile in WhoIs response")
+		return nil, fmt.Errorf("no user profile in WhoIs response")
 	}
 
-	return who.UserProfile.LoginName, nil
+	return who, nil
 }
 
 // CreateOrGetUser creates or retrieves a user from the database
 func (p *TailscaleAuthProvider) CreateOrGetUser(ctx context.Context, email string) (*ContextUser, error) {
-	user, err := p.queries.CreateOrReturnID(ctx, email)
+	return createOrGetUser(ctx, p.queries, email)
+}
+
+// SyncProfile copies the member's Tailscale display name and profile picture
+// into their profile, and records the machine they're on. It runs on the
+// member's first request and then once every profileSyncInterval. Fields the
+// member has set themselves are left alone by the query. Failures are logged
+// and retried at the next interval; they never fail the request.
+func (p *TailscaleAuthProvider) SyncProfile(r *http.Request, user *ContextUser) {
+	if !p.claimSync(user.ID) {
+		return
+	}
+
+	ctx := r.Context()
+	who, err := p.client.WhoIs(ctx, r.RemoteAddr)
+	if err != nil {
+		p.logger.WarnContext(ctx, "failed to get WhoIs for profile sync",
+			slog.Int64("user_id", user.ID),
+			slog.String("error", err.Error()))
+		return
+	}
+	if who.UserProfile == nil {
+		return
+	}
+
+	arg := SyncMemberProfileParams{
+		MemberID:      user.ID,
+		DisplayName:   strings.TrimSpace(who.UserProfile.DisplayName),
+		ProfilePicURL: who.UserProfile.ProfilePicURL,
+	}
+	// Tailscale falls back to the login name for users without a display
+	// name, which would show the email on boards that hide them
+	if strings.EqualFold(arg.DisplayName, who.UserProfile.LoginName) {
+		arg.DisplayName = ""
+	}
+	if who.Node != nil {
+		arg.NodeName = who.Node.Name
+		arg.NodeOS = who.Node.OS
+	}
+
+	if err := p.queries.SyncMemberProfile(ctx, arg); err != nil {
+		p.logger.WarnContext(ctx, "failed to sync member profile",
+			slog.Int64("user_id", user.ID),
+			slog.String("error", err.Error()))
+	}
+}
+
+// principals returns what the caller carries for board access lists: the
+// tags of their machine, "cap:" followed by each capability the tailnet
+// policy grants them, and the groups named in their AccessCapability
+// grants. Grants that don't parse are logged and skipped.
+func (p *TailscaleAuthProvider) principals(ctx context.Context, who *WhoIsResponse) []string {
+	var principals []string
+	if who.Node != nil {
+		principals = append(principals, who.Node.Tags...)
+	}
+	for capability, values := range who.CapMap {
+		principals = append(principals, "cap:"+capability)
+		if capability != AccessCapability {
+			continue
+		}
+		for _, raw := range values {
+			var v accessCapabilityValue
+			if err := json.Unmarshal([]byte(raw), &v); err != nil {
+				p.logger.WarnContext(ctx, "invalid access capability value",
+					slog.String("value", raw),
+					slog.String("error", err.Error()))
+				continue
+			}
+			principals = append(principals, v.Groups...)
+		}
+	}
+	return principals
+}
+
+// claimSync reports whether the member's profile is due a sync, and if so
+// marks it synced so concurrent requests don't repeat it.
+func (p *TailscaleAuthProvider) claimSync(memberID int64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if last, ok := p.synced[memberID]; ok && now.Sub(last) < profileSyncInterval {
+		return false
+	}
+	p.synced[memberID] = now
+	return true
+}
+
+// createOrGetUser looks up or creates the member for an authenticated email
+func createOrGetUser(ctx context.Context, queries Querier, email string) (*ContextUser, error) {
+	user, err := queries.CreateOrReturnID(ctx, email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create or get user: %w", err)
 	}
@@ -79,8 +230,16 @@ func authMiddleware(provider AuthProvider, tracer trace.Tracer) Middleware {
 				defer span.End()
 			}
 
-			// Get user email
-			email, err := provider.GetUserEmail(r)
+			// Get user email, and with it their principals when the
+			// provider has them
+			var email string
+			var principals []string
+			var err error
+			if resolver, ok := provider.(AccessResolver); ok {
+				email, principals, err = resolver.GetUserAccess(r)
+			} else {
+				email, err = provider.GetUserEmail(r)
+			}
 			if err != nil {
 				logger := getLogger(ctx)
 				logger.WarnContext(ctx, "authentication failed",
@@ -135,6 +294,12 @@ func authMiddleware(provider AuthProvider, tracer trace.Tracer) Middleware {
 				return
 			}
 
+			if syncer, ok := provider.(ProfileSyncer); ok {
+				syncer.SyncProfile(r.WithContext(ctx), user)
+			}
+
+			user.Principals = principals
+
 			// Add user to context
 			rc := getOrCreateRequestContext(ctx)
 			rc.User = user
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	// LocalUserHeader selects the local development user for a request,
+	// e.g. curl -H 'X-Dev-User: bob@example.com'
+	LocalUserHeader = "X-Dev-User"
+
+	// LocalUserCookie remembers the user picked on the dev user switcher
+	LocalUserCookie = "tdiscuss_dev_user"
+)
+
+// defaultLocalUsers are used when no users file is given. The first member
+// to sign in becomes the board admin, so the first user here is the admin on
+// a fresh database.
+var defaultLocalUsers = []string{
+	"admin@example.com",
+	"alice@example.com",
+	"bob@example.com",
+}
+
+// LocalAuthProvider implements AuthProvider for local development without
+// Tailscale. The user is taken from the X-Dev-User header, then the dev user
+// cookie, and otherwise defaults to the first configured user.
+type LocalAuthProvider struct {
+	users   []string
+	queries Querier
+	logger  *slog.Logger
+}
+
+// newLocalAuthProvider creates a new local auth provider for the given fake users
+func newLocalAuthProvider(users []string, queries Querier, logger *slog.Logger) *LocalAuthProvider {
+	if len(users) == 0 {
+		users = defaultLocalUsers
+	}
+	return &LocalAuthProvider{
+		users:   users,
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+// loadLocalUsers reads fake user emails from path, one per line. Blank lines
+// and lines starting with # are ignored. An empty path returns the defaults.
+func loadLocalUsers(path string) ([]string, error) {
+	if path == "" {
+		return defaultLocalUsers, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open users file: %w", err)
+	}
+	defer f.Close()
+
+	var users []string
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		email := strings.TrimSpace(scanner.Text())
+		if email == "" || strings.HasPrefix(email, "#") {
+			continue
+		}
+		if _, err := mail.ParseAddress(email); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid email %q", path, line, email)
+		}
+		if !slices.Contains(users, email) {
+			users = append(users, email)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read users file: %w", err)
+	}
+
+	if len(users) == 0 {
+		return nil, fmt.Errorf("%s: no users defined", path)
+	}
+
+	return users, nil
+}
+
+// Users returns the configured fake users, in file order
+func (p *LocalAuthProvider) Users() []string {
+	return p.users
+}
+
+// GetUserEmail returns the local development user for the request. Like the
+// cookie, the header may only pick one of the configured users.
+func (p *LocalAuthProvider) GetUserEmail(r *http.Request) (string, error) {
+	if email := strings.TrimSpace(r.Header.Get(LocalUserHeader)); email != "" {
+		if !slices.Contains(p.users, email) {
+			return "", fmt.Errorf("%s header names unknown user %q", LocalUserHeader, email)
+		}
+		return email, nil
+	}
+
+	if cookie, err := r.Cookie(LocalUserCookie); err == nil && slices.Contains(p.users, cookie.Value) {
+		return cookie.Value, nil
+	}
+
+	return p.users[0], nil
+}
+
+// CreateOrGetUser creates or retrieves a user from the database
+func (p *LocalAuthProvider) CreateOrGetUser(ctx context.Context, email string) (*ContextUser, error) {
+	return createOrGetUser(ctx, p.queries, email)
+}
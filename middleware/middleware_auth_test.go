@@ -5,7 +5,10 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -13,24 +16,33 @@ import (
 
 // Mock implementations for testing
 type mockTailscaleClient struct {
-	email string
-	err   error
+	email       string
+	displayName string
+	node        *Node
+	capMap      map[string][]string
+	err         error
+	calls       int
 }
 
 func (m *mockTailscaleClient) WhoIs(ctx context.Context, remoteAddr string) (*WhoIsResponse, error) {
+	m.calls++
 	if m.err != nil {
 		return nil, m.err
 	}
 	return &WhoIsResponse{
 		UserProfile: &UserProfile{
-			LoginName: m.email,
+			LoginName:   m.email,
+			DisplayName: m.displayName,
 		},
+		Node:   m.node,
+		CapMap: m.capMap,
 	}, nil
 }
 
 type mockQuerier struct {
-	user CreateOrReturnIDRow
-	err  error
+	user   CreateOrReturnIDRow
+	err    error
+	synced []SyncMemberProfileParams
 }
 
 func (m *mockQuerier) CreateOrReturnID(ctx context.Context, email string) (CreateOrReturnIDRow, error) {
@@ -40,6 +52,11 @@ func (m *mockQuerier) CreateOrReturnID(ctx context.Context, email string) (Creat
 	return m.user, nil
 }
 
+func (m *mockQuerier) SyncMemberProfile(ctx context.Context, arg SyncMemberProfileParams) error {
+	m.synced = append(m.synced, arg)
+	return m.err
+}
+
 func TestAuthMiddleware_BlockedUser(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -299,6 +316,174 @@ func TestTailscaleAuthProvider_CreateOrGetUser(t *testing.T) {
 	}
 }
 
+func TestTailscaleAuthProvider_SyncProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		client      *mockTailscaleClient
+		expectedArg *SyncMemberProfileParams
+	}{
+		{
+			name: "syncs display name and node",
+			client: &mockTailscaleClient{
+				email:       "test@example.com",
+				displayName: "Test User",
+				node:        &Node{Name: "laptop", OS: "macOS"},
+			},
+			expectedArg: &SyncMemberProfileParams{
+				MemberID:    1,
+				DisplayName: "Test User",
+				NodeName:    "laptop",
+				NodeOS:      "macOS",
+			},
+		},
+		{
+			name: "display name that is the login name is dropped",
+			client: &mockTailscaleClient{
+				email:       "test@example.com",
+				displayName: "test@example.com",
+			},
+			expectedArg: &SyncMemberProfileParams{
+				MemberID: 1,
+			},
+		},
+		{
+			name: "WhoIs error skips the sync",
+			client: &mockTailscaleClient{
+				err: errors.New("tailscale error"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := &mockQuerier{}
+			provider := newTailscaleAuthProvider(tt.client, mockQueries, NewTestLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			provider.SyncProfile(req, &ContextUser{ID: 1, Email: "test@example.com"})
+
+			if tt.expectedArg == nil {
+				assert.Empty(t, mockQueries.synced)
+				return
+			}
+			require.Len(t, mockQueries.synced, 1)
+			assert.Equal(t, *tt.expectedArg, mockQueries.synced[0])
+		})
+	}
+}
+
+func TestTailscaleAuthProvider_SyncProfileInterval(t *testing.T) {
+	mockQueries := &mockQuerier{}
+	provider := newTailscaleAuthProvider(&mockTailscaleClient{email: "test@example.com"}, mockQueries, NewTestLogger())
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	provider.now = func() time.Time { return now }
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	user := &ContextUser{ID: 1, Email: "test@example.com"}
+
+	provider.SyncProfile(req, user)
+	provider.SyncProfile(req, user)
+	assert.Len(t, mockQueries.synced, 1, "second request within the interval should not sync")
+
+	provider.SyncProfile(req, &ContextUser{ID: 2, Email: "other@example.com"})
+	assert.Len(t, mockQueries.synced, 2, "other members sync independently")
+
+	now = now.Add(profileSyncInterval)
+	provider.SyncProfile(req, user)
+	assert.Len(t, mockQueries.synced, 3, "should sync again once the interval has passed")
+}
+
+func TestTailscaleAuthProvider_GetUserAccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		client   *mockTailscaleClient
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "no tags or capabilities",
+			client:   &mockTailscaleClient{email: "test@example.com"},
+			expected: nil,
+		},
+		{
+			name: "node tags",
+			client: &mockTailscaleClient{
+				email: "test@example.com",
+				node:  &Node{Name: "build", Tags: []string{"tag:ci", "tag:prod"}},
+			},
+			expected: []string{"tag:ci", "tag:prod"},
+		},
+		{
+			name: "capabilities and groups",
+			client: &mockTailscaleClient{
+				email: "test@example.com",
+				capMap: map[string][]string{
+					"example.com/cap/oncall": {`{}`},
+					AccessCapability:         {`{"groups":["group:hr"]}`, `{"groups":["group:security"]}`},
+				},
+			},
+			expected: []string{"cap:example.com/cap/oncall", "cap:" + AccessCapability, "group:hr", "group:security"},
+		},
+		{
+			name: "invalid access capability value is skipped",
+			client: &mockTailscaleClient{
+				email:  "test@example.com",
+				capMap: map[string][]string{AccessCapability: {`not json`, `{"groups":["group:hr"]}`}},
+			},
+			expected: []string{"cap:" + AccessCapability, "group:hr"},
+		},
+		{
+			name:    "whois error",
+			client:  &mockTailscaleClient{err: errors.New("whois failed")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newTailscaleAuthProvider(tt.client, &mockQuerier{}, NewTestLogger())
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+			email, principals, err := provider.GetUserAccess(req)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "test@example.com", email)
+			assert.ElementsMatch(t, tt.expected, principals)
+		})
+	}
+}
+
+func TestAuthMiddleware_Principals(t *testing.T) {
+	mockClient := &mockTailscaleClient{
+		email: "test@example.com",
+		node:  &Node{Name: "build", Tags: []string{"tag:ci"}},
+	}
+	provider := newTailscaleAuthProvider(mockClient, &mockQuerier{user: CreateOrReturnIDRow{ID: 1}}, NewTestLogger())
+
+	var principals []string
+	handler := requestContextMiddleware()(authMiddleware(provider, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := getUser(r.Context())
+		require.True(t, ok)
+		principals = user.Principals
+	})))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"tag:ci"}, principals)
+	// The email and principals come from one WhoIs, plus one for the
+	// member's first profile sync
+	assert.Equal(t, 2, mockClient.calls)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, 3, mockClient.calls)
+}
+
 func TestRequireAuthMiddleware(t *testing.T) {
 	middleware := requireAuthMiddleware()
 
@@ -439,3 +624,104 @@ func TestUserEnrichmentMiddleware(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, rec.Code)
 }
+
+func TestLocalAuthProvider_GetUserEmail(t *testing.T) {
+	users := []string{"admin@example.com", "alice@example.com"}
+
+	tests := []struct {
+		name          string
+		header        string
+		cookie        string
+		expectedEmail string
+		expectedErr   bool
+	}{
+		{
+			name:          "defaults to first user",
+			expectedEmail: "admin@example.com",
+		},
+		{
+			name:          "cookie selects a configured user",
+			cookie:        "alice@example.com",
+			expectedEmail: "alice@example.com",
+		},
+		{
+			name:          "unknown cookie user is ignored",
+			cookie:        "mallory@example.com",
+			expectedEmail: "admin@example.com",
+		},
+		{
+			name:          "header takes precedence over cookie",
+			header:        "alice@example.com",
+			cookie:        "admin@example.com",
+			expectedEmail: "alice@example.com",
+		},
+		{
+			name:        "unknown header user is rejected",
+			header:      "carol@example.com",
+			cookie:      "alice@example.com",
+			expectedErr: true,
+		},
+		{
+			name:        "invalid header email",
+			header:      "not an email",
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newLocalAuthProvider(users, nil, NewTestLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(LocalUserHeader, tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: LocalUserCookie, Value: tt.cookie})
+			}
+
+			email, err := provider.GetUserEmail(req)
+
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedEmail, email)
+			}
+		})
+	}
+}
+
+func TestLoadLocalUsers(t *testing.T) {
+	t.Run("defaults without a file", func(t *testing.T) {
+		users, err := loadLocalUsers("")
+		require.NoError(t, err)
+		assert.Equal(t, defaultLocalUsers, users)
+	})
+
+	t.Run("reads users file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "users")
+		content := "# local users\nadmin@example.com\n\n  bob@example.com  \nadmin@example.com\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		users, err := loadLocalUsers(path)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin@example.com", "bob@example.com"}, users)
+	})
+
+	t.Run("rejects invalid emails", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "users")
+		require.NoError(t, os.WriteFile(path, []byte("not an email\n"), 0o600))
+
+		_, err := loadLocalUsers(path)
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects empty file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "users")
+		require.NoError(t, os.WriteFile(path, []byte("# nobody\n"), 0o600))
+
+		_, err := loadLocalUsers(path)
+		assert.Error(t, err)
+	})
+}
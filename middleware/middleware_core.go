@@ -83,6 +83,9 @@ type ContextUser struct {
 	Email     string
 	IsAdmin   bool
 	IsBlocked bool
+	// Principals are the tags, capabilities and groups the request carries,
+	// matched against board access lists
+	Principals []string
 }
 
 // newRequestContext creates a new request context
@@ -126,6 +129,14 @@ func getOrCreateRequestContext(ctx context.Context) *RequestContext {
 	return newRequestContext()
 }
 
+// withUser returns a context carrying user as the authenticated member, for
+// work done on a member's behalf outside of the auth middleware
+func withUser(ctx context.Context, user *ContextUser) context.Context {
+	rc := newRequestContext()
+	rc.User = user
+	return withRequestContext(ctx, rc)
+}
+
 // Helper functions for common context operations
 func getUser(ctx context.Context) (*ContextUser, bool) {
 	rc, ok := getRequestContext(ctx)
@@ -12,25 +12,46 @@ import (
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
-	"golang.org/x/time/rate"
 )
 
+// Rate limit roles. Each signed-in request is limited by the role of the
+// member making it; requests without a member fall back to RoleAnonymous,
+// keyed by client IP.
+const (
+	RoleAdmin     = "admin"
+	RoleMember    = "member"
+	RoleAnonymous = "anonymous"
+)
+
+// RateLimitRoles are the roles whose limits can be configured, in display order
+var RateLimitRoles = []string{RoleAdmin, RoleMember}
+
+// RateLimit allows Requests requests per sliding Window
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// String formats the limit as e.g. "300/1m0s"
+func (l RateLimit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Window)
+}
+
 // RateLimitConfig holds rate limiting configuration
 type RateLimitConfig struct {
-	// Default rate limit
-	RequestsPerSecond float64
-	Burst             int
+	// Store holds the window counters. Defaults to an in-memory store, which
+	// resets on restart and isn't shared between replicas.
+	Store RateLimitStore
 
-	// Per-endpoint limits
-	EndpointLimits map[string]EndpointLimit
+	// RoleLimits returns the current limit for each role. Defaults to
+	// DefaultRoleLimits. Roles missing from the result use the default.
+	RoleLimits func(ctx context.Context) map[string]RateLimit
 
-	// User-based rate limiting
-	EnableUserRateLimit bool
-	UserRateMultiplier  float64 // Multiplier for authenticated users
+	// AnonymousLimit applies to requests without a signed-in member
+	AnonymousLimit RateLimit
 
-	// IP-based rate limiting
-	EnableIPRateLimit bool
-	CleanupInterval   time.Duration
+	// Per-endpoint limits, applied on top of the role limit
+	EndpointLimits map[string]EndpointLimit
 
 	// Response headers
 	IncludeHeaders bool
@@ -40,55 +61,158 @@ type RateLimitConfig struct {
 	MetricPrefix string
 }
 
-// EndpointLimit defines rate limits for specific endpoints
+// EndpointLimit defines rate limits for specific endpoints. Pattern uses
+// http.ServeMux syntax: an optional method, then a path whose {name}
+// segments match any single segment, e.g. "POST /thread/{tid}".
 type EndpointLimit struct {
-	Pattern string
-	Rate    float64
-	Burst   int
+	Pattern  string
+	Requests int
+	Window   time.Duration
+}
+
+// DefaultRoleLimits are used for roles with no configured limit
+func DefaultRoleLimits() map[string]RateLimit {
+	return map[string]RateLimit{
+		RoleAdmin:  {Requests: 1200, Window: time.Minute},
+		RoleMember: {Requests: 300, Window: time.Minute},
+	}
 }
 
 // defaultRateLimitConfig returns sensible defaults
 func defaultRateLimitConfig() *RateLimitConfig {
 	return &RateLimitConfig{
-		RequestsPerSecond:   10,
-		Burst:               20,
-		EnableUserRateLimit: true,
-		UserRateMultiplier:  5.0,
-		EnableIPRateLimit:   true,
-		CleanupInterval:     5 * time.Minute,
-		IncludeHeaders:      true,
+		AnonymousLimit: RateLimit{Requests: 60, Window: time.Minute},
+		IncludeHeaders: true,
 		EndpointLimits: map[string]EndpointLimit{
-			"/thread/new":     {Pattern: "/thread/new", Rate: 0.5, Burst: 2},   // 1 thread per 2 seconds
-			"/thread/*/reply": {Pattern: "/thread/*/reply", Rate: 2, Burst: 5}, // 2 replies per second
-			"/member/edit":    {Pattern: "/member/edit", Rate: 0.5, Burst: 2},  // 1 profile update per 2 seconds
-			"/admin":          {Pattern: "/admin", Rate: 0.2, Burst: 1},        // 1 admin action per 5 seconds
+			"POST /thread/new":   {Pattern: "POST /thread/new", Requests: 2, Window: 4 * time.Second},   // 1 thread per 2 seconds
+			"POST /thread/{tid}": {Pattern: "POST /thread/{tid}", Requests: 5, Window: 3 * time.Second}, // 2 replies per second
+			"POST /member/edit":  {Pattern: "POST /member/edit", Requests: 2, Window: 4 * time.Second},  // 1 profile update per 2 seconds
+			"POST /admin":        {Pattern: "POST /admin", Requests: 1, Window: 5 * time.Second},        // 1 admin action per 5 seconds
 		},
 	}
 }
 
-// RateLimiter provides flexible rate limiting
-type RateLimiter struct {
-	config   *RateLimitConfig
-	logger   *slog.Logger
-	visitors map[string]*visitor
-	mu       sync.RWMutex
+// RateLimitStore holds sliding window counters. Implementations must be safe
+// for concurrent use and, to be shared between replicas, keep their counters
+// outside the process.
+type RateLimitStore interface {
+	// Hit counts a request against key in the window of the given length
+	// containing now, and returns the count for that window (including this
+	// request) and the count for the window before it.
+	Hit(ctx context.Context, key string, window time.Duration, now time.Time) (current, previous int, err error)
+}
 
-	// Metrics
-	rateLimitHits  metric.Int64Counter
-	activeVisitors metric.Int64Gauge
+// rateLimitCheck is one window a request is counted against
+type rateLimitCheck struct {
+	key   string
+	limit RateLimit
+}
+
+// rateLimitDecision is the outcome of checking one sliding window
+type rateLimitDecision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Time
+	RetryAfter time.Duration
+}
+
+// slidingWindow approximates a sliding window from two fixed windows: the
+// previous window's count is weighted by how much of it still overlaps the
+// sliding window ending at now. Every request is counted, including rejected
+// ones, so a client that keeps retrying stays limited.
+func slidingWindow(limit RateLimit, current, previous int, now time.Time) rateLimitDecision {
+	windowStart := now.Truncate(limit.Window)
+	elapsed := now.Sub(windowStart)
+	weight := 1 - float64(elapsed)/float64(limit.Window)
+	estimate := float64(previous)*weight + float64(current)
+
+	d := rateLimitDecision{
+		Allowed: estimate <= float64(limit.Requests),
+		Limit:   limit.Requests,
+		Reset:   windowStart.Add(limit.Window),
+	}
+	if remaining := float64(limit.Requests) - estimate; remaining > 0 {
+		d.Remaining = int(remaining)
+	}
+	if !d.Allowed {
+		d.RetryAfter = limit.Window - elapsed
+	}
+
+	return d
+}
+
+// MemoryRateLimitStore keeps window counters in process memory
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryWindow
+	lastSweep time.Time
+}
+
+type memoryWindow struct {
+	length   time.Duration
+	start    time.Time
+	current  int
+	previous int
+}
+
+// newMemoryRateLimitStore creates an empty in-memory store
+func newMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{windows: make(map[string]*memoryWindow)}
+}
+
+// Hit implements RateLimitStore
+func (s *MemoryRateLimitStore) Hit(ctx context.Context, key string, window time.Duration, now time.Time) (int, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := now.Truncate(window)
+	w, ok := s.windows[key]
+	switch {
+	case !ok || w.length != window:
+		w = &memoryWindow{length: window, start: start}
+		s.windows[key] = w
+	case w.start.Equal(start.Add(-window)):
+		w.previous, w.current, w.start = w.current, 0, start
+	case !w.start.Equal(start):
+		w.previous, w.current, w.start = 0, 0, start
+	}
+	w.current++
+
+	// Drop counters that can no longer affect a decision, at most once a minute
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, old := range s.windows {
+			if now.Sub(old.start) > 2*old.length {
+				delete(s.windows, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	return w.current, w.previous, nil
 }
 
-type visitor struct {
-	limiter  *rate.Limiter
-	lastSeen time.Time
+// RateLimiter limits requests per member role and per endpoint
+type RateLimiter struct {
+	config *RateLimitConfig
+	logger *slog.Logger
+	store  RateLimitStore
+	now    func() time.Time
+
+	// Metrics
+	rateLimitHits metric.Int64Counter
 }
 
 // newRateLimiter creates a new rate limiter
 func newRateLimiter(config *RateLimitConfig, logger *slog.Logger) *RateLimiter {
 	rl := &RateLimiter{
-		config:   config,
-		logger:   logger,
-		visitors: make(map[string]*visitor),
+		config: config,
+		logger: logger,
+		store:  config.Store,
+		now:    time.Now,
+	}
+	if rl.store == nil {
+		rl.store = newMemoryRateLimitStore()
 	}
 
 	// Initialize metrics if meter is provided
@@ -103,42 +227,57 @@ func newRateLimiter(config *RateLimitConfig, logger *slog.Logger) *RateLimiter {
 			metric.WithDescription("Number of rate limit hits"),
 			metric.WithUnit("{hit}"),
 		)
-
-		rl.activeVisitors, _ = config.Meter.Int64Gauge(
-			prefix+".visitors",
-			metric.WithDescription("Number of active rate limit visitors"),
-			metric.WithUnit("{visitor}"),
-		)
 	}
 
-	// Start cleanup goroutine
-	go rl.cleanupVisitors()
-
 	return rl
 }
 
-// Middleware returns the rate limiting middleware
+// Middleware returns the rate limiting middleware. It must run after
+// authentication for member roles to apply.
 func (rl *RateLimiter) Middleware() Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Get visitor key
-			visitorKey := rl.getVisitorKey(r)
-
-			// Get endpoint-specific limits if available
-			limit, burst := rl.getLimitsForPath(r.URL.Path)
+			visitor, visitorRole := rl.getVisitor(r)
+			now := rl.now()
 
-			// Get or create visitor
-			v := rl.getVisitor(visitorKey, limit, burst)
+			// The role limit covers every request; matching endpoint limits
+			// are checked on top of it
+			checks := []rateLimitCheck{
+				{key: visitorRole + ":" + visitor, limit: rl.roleLimit(r.Context(), visitorRole)},
+			}
+			if endpoint, ok := rl.endpointLimit(r); ok {
+				checks = append(checks, rateLimitCheck{
+					key:   visitor + ":" + endpoint.Pattern,
+					limit: RateLimit{Requests: endpoint.Requests, Window: endpoint.Window},
+				})
+			}
 
-			// Check rate limit
-			if !v.limiter.Allow() {
-				rl.handleRateLimitExceeded(w, r, v.limiter)
-				return
+			var tightest *rateLimitDecision
+			for _, check := range checks {
+				if check.limit.Requests <= 0 || check.limit.Window <= 0 {
+					continue
+				}
+
+				current, previous, err := rl.store.Hit(r.Context(), check.key, check.limit.Window, now)
+				if err != nil {
+					// Fail open: a store outage shouldn't take the board down
+					rl.logger.ErrorContext(r.Context(), "rate limit store error", slog.String("error", err.Error()))
+					continue
+				}
+
+				d := slidingWindow(check.limit, current, previous, now)
+				if !d.Allowed {
+					rl.handleRateLimitExceeded(w, r, visitor, visitorRole, d)
+					return
+				}
+				if tightest == nil || d.Remaining < tightest.Remaining {
+					tightest = &d
+				}
 			}
 
 			// Add rate limit headers if configured
-			if rl.config.IncludeHeaders {
-				rl.addRateLimitHeaders(w, v.limiter)
+			if rl.config.IncludeHeaders && tightest != nil {
+				addRateLimitHeaders(w, *tightest)
 			}
 
 			next.ServeHTTP(w, r)
@@ -146,73 +285,84 @@ func (rl *RateLimiter) Middleware() Middleware {
 	}
 }
 
-// getVisitorKey determines the key for rate limiting
-func (rl *RateLimiter) getVisitorKey(r *http.Request) string {
-	// Prefer user-based rate limiting for authenticated users
-	if rl.config.EnableUserRateLimit {
-		if user, ok := getUser(r.Context()); ok && user != nil {
-			return fmt.Sprintf("user:%d", user.ID)
-		}
+// getVisitor returns the key and role a request is limited under. Members are
+// keyed by ID so limits follow them across devices; anyone else by IP.
+func (rl *RateLimiter) getVisitor(r *http.Request) (string, string) {
+	user, ok := getUser(r.Context())
+	if !ok || user == nil {
+		return "ip:" + getClientIP(r), RoleAnonymous
 	}
 
-	// Fall back to IP-based rate limiting
-	if rl.config.EnableIPRateLimit {
-		return "ip:" + getClientIP(r)
+	role := RoleMember
+	if user.IsAdmin {
+		role = RoleAdmin
 	}
 
-	// If neither is enabled, use a global key (not recommended)
-	return "global"
+	return fmt.Sprintf("user:%d", user.ID), role
 }
 
-// getLimitsForPath returns rate limits for a specific path
-func (rl *RateLimiter) getLimitsForPath(path string) (float64, int) {
-	// Check endpoint-specific limits
-	for pattern, limit := range rl.config.EndpointLimits {
-		if matchesPattern(path, pattern) {
-			return limit.Rate, limit.Burst
+// roleLimit returns the configured limit for role
+func (rl *RateLimiter) roleLimit(ctx context.Context, role string) RateLimit {
+	if role == RoleAnonymous {
+		return rl.config.AnonymousLimit
+	}
+
+	if rl.config.RoleLimits != nil {
+		if limit, ok := rl.config.RoleLimits(ctx)[role]; ok {
+			return limit
 		}
 	}
 
-	// Return default limits
-	return rl.config.RequestsPerSecond, rl.config.Burst
+	return DefaultRoleLimits()[role]
 }
 
-// getVisitor gets or creates a visitor
-func (rl *RateLimiter) getVisitor(key string, limit float64, burst int) *visitor {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	v, exists := rl.visitors[key]
-	if !exists {
-		// Apply user rate multiplier if applicable
-		if strings.HasPrefix(key, "user:") && rl.config.UserRateMultiplier > 0 {
-			limit *= rl.config.UserRateMultiplier
-			burst = int(float64(burst) * rl.config.UserRateMultiplier)
+// endpointLimit returns the most specific endpoint limit matching the
+// request, so "POST /thread/new" wins over "POST /thread/{tid}". Ties go to
+// the pattern that sorts first, keeping the choice independent of map order.
+func (rl *RateLimiter) endpointLimit(r *http.Request) (EndpointLimit, bool) {
+	var best EndpointLimit
+	found := false
+	for _, limit := range rl.config.EndpointLimits {
+		if !matchesPattern(r.Method, r.URL.Path, limit.Pattern) {
+			continue
 		}
-
-		v = &visitor{
-			limiter:  rate.NewLimiter(rate.Limit(limit), burst),
-			lastSeen: time.Now(),
+		if !found || moreSpecific(limit.Pattern, best.Pattern) {
+			best, found = limit, true
 		}
-		rl.visitors[key] = v
+	}
+	return best, found
+}
 
-		// Update metrics
-		if rl.activeVisitors != nil {
-			rl.activeVisitors.Record(context.Background(), int64(len(rl.visitors)))
-		}
-	} else {
-		v.lastSeen = time.Now()
+// moreSpecific reports whether pattern a should win over pattern b when both
+// match a request: fewer wildcard segments first, then a method over none.
+func moreSpecific(a, b string) bool {
+	if wa, wb := wildcardSegments(a), wildcardSegments(b); wa != wb {
+		return wa < wb
 	}
+	if ma, mb := strings.Contains(a, " "), strings.Contains(b, " "); ma != mb {
+		return ma
+	}
+	return a < b
+}
 
-	return v
+// wildcardSegments counts the segments of a pattern that match more than one
+// literal value
+func wildcardSegments(pattern string) int {
+	n := 0
+	for _, seg := range strings.Split(pattern, "/") {
+		if strings.HasPrefix(seg, "{") || strings.Contains(seg, "*") {
+			n++
+		}
+	}
+	return n
 }
 
 // handleRateLimitExceeded handles rate limit exceeded responses
-func (rl *RateLimiter) handleRateLimitExceeded(w http.ResponseWriter, r *http.Request, limiter *rate.Limiter) {
+func (rl *RateLimiter) handleRateLimitExceeded(w http.ResponseWriter, r *http.Request, visitor, role string, d rateLimitDecision) {
 	// Log rate limit hit
-	visitorKey := rl.getVisitorKey(r)
 	rl.logger.WarnContext(r.Context(), "rate limit exceeded",
-		slog.String("visitor", visitorKey),
+		slog.String("visitor", visitor),
+		slog.String("role", role),
 		slog.String("path", r.URL.Path),
 		slog.String("method", r.Method),
 		slog.String("remote_addr", r.RemoteAddr),
@@ -221,7 +371,7 @@ func (rl *RateLimiter) handleRateLimitExceeded(w http.ResponseWriter, r *http.Re
 	// Record metric
 	if rl.rateLimitHits != nil {
 		attrs := []attribute.KeyValue{
-			attribute.String("visitor_type", getVisitorType(visitorKey)),
+			attribute.String("role", role),
 			attribute.String("path", getRoutePattern(r.URL.Path)),
 		}
 		rl.rateLimitHits.Add(r.Context(), 1, metric.WithAttributes(attrs...))
@@ -229,14 +379,8 @@ func (rl *RateLimiter) handleRateLimitExceeded(w http.ResponseWriter, r *http.Re
 
 	// Add rate limit headers
 	if rl.config.IncludeHeaders {
-		rl.addRateLimitHeaders(w, limiter)
-
-		// Add Retry-After header
-		if reservation := limiter.Reserve(); reservation.OK() {
-			delay := reservation.Delay()
-			reservation.Cancel() // Cancel since we're not using it
-			w.Header().Set("Retry-After", strconv.Itoa(int(delay.Seconds())+1))
-		}
+		addRateLimitHeaders(w, d)
+		w.Header().Set("Retry-After", strconv.Itoa(int(d.RetryAfter.Seconds())+1))
 	}
 
 	// Send error response
@@ -244,40 +388,10 @@ func (rl *RateLimiter) handleRateLimitExceeded(w http.ResponseWriter, r *http.Re
 }
 
 // addRateLimitHeaders adds rate limit information headers
-func (rl *RateLimiter) addRateLimitHeaders(w http.ResponseWriter, limiter *rate.Limiter) {
-	limit := limiter.Limit()
-	burst := limiter.Burst()
-
-	// Standard rate limit headers
-	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(burst))
-	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(limiter.Tokens())))
-	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Second).Unix(), 10))
-
-	// Additional info
-	w.Header().Set("X-RateLimit-Policy", fmt.Sprintf("%.2f;w=1;burst=%d", limit, burst))
-}
-
-// cleanupVisitors removes old visitors periodically
-func (rl *RateLimiter) cleanupVisitors() {
-	ticker := time.NewTicker(rl.config.CleanupInterval)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		rl.mu.Lock()
-		now := time.Now()
-		for key, v := range rl.visitors {
-			if now.Sub(v.lastSeen) > rl.config.CleanupInterval {
-				delete(rl.visitors, key)
-			}
-		}
-
-		// Update metrics
-		if rl.activeVisitors != nil {
-			rl.activeVisitors.Record(context.Background(), int64(len(rl.visitors)))
-		}
-
-		rl.mu.Unlock()
-	}
+func addRateLimitHeaders(w http.ResponseWriter, d rateLimitDecision) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(d.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(d.Reset.Unix(), 10))
 }
 
 // Helper functions
@@ -305,29 +419,42 @@ func getClientIP(r *http.Request) string {
 	return r.RemoteAddr
 }
 
-func matchesPattern(path, pattern string) bool {
-	// Simple pattern matching with * wildcard
-	if !strings.Contains(pattern, "*") {
-		return path == pattern
+// matchesPattern reports whether a request matches an http.ServeMux style
+// pattern: "[METHOD ]/path/{name}/...". A {name} segment matches any single
+// path segment, and * matches any run of characters within a segment.
+func matchesPattern(method, path, pattern string) bool {
+	if m, p, ok := strings.Cut(pattern, " "); ok {
+		if m != method {
+			return false
+		}
+		pattern = p
 	}
 
-	// Convert pattern to prefix/suffix match
-	parts := strings.Split(pattern, "*")
-	if len(parts) != 2 {
+	patternSegments := strings.Split(pattern, "/")
+	pathSegments := strings.Split(path, "/")
+	if len(patternSegments) != len(pathSegments) {
 		return false
 	}
 
-	prefix, suffix := parts[0], parts[1]
-	return strings.HasPrefix(path, prefix) && strings.HasSuffix(path, suffix)
-}
-
-func getVisitorType(key string) string {
-	if strings.HasPrefix(key, "user:") {
-		return "user"
-	} else if strings.HasPrefix(key, "ip:") {
-		return "ip"
+	for i, seg := range patternSegments {
+		switch {
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			if pathSegments[i] == "" {
+				return false
+			}
+		case strings.Contains(seg, "*"):
+			prefix, suffix, _ := strings.Cut(seg, "*")
+			if !strings.HasPrefix(pathSegments[i], prefix) || !strings.HasSuffix(pathSegments[i], suffix) {
+				return false
+			}
+		default:
+			if seg != pathSegments[i] {
+				return false
+			}
+		}
 	}
-	return "global"
+
+	return true
 }
 
 // ipWhitelistMiddleware allows certain IPs to bypass rate limiting
@@ -0,0 +1,239 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	limit := RateLimit{Requests: 10, Window: time.Minute}
+	windowStart := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		current       int
+		previous      int
+		elapsed       time.Duration
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{
+			name:          "first request",
+			current:       1,
+			wantAllowed:   true,
+			wantRemaining: 9,
+		},
+		{
+			name:          "at the limit",
+			current:       10,
+			wantAllowed:   true,
+			wantRemaining: 0,
+		},
+		{
+			name:        "over the limit",
+			current:     11,
+			wantAllowed: false,
+		},
+		{
+			name:        "previous window still fully weighted",
+			current:     1,
+			previous:    10,
+			wantAllowed: false,
+		},
+		{
+			name:          "previous window half weighted",
+			current:       1,
+			previous:      10,
+			elapsed:       30 * time.Second,
+			wantAllowed:   true,
+			wantRemaining: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := slidingWindow(limit, tt.current, tt.previous, windowStart.Add(tt.elapsed))
+			assert.Equal(t, tt.wantAllowed, d.Allowed)
+			assert.Equal(t, tt.wantRemaining, d.Remaining)
+			assert.Equal(t, 10, d.Limit)
+			assert.Equal(t, windowStart.Add(time.Minute), d.Reset)
+			if !tt.wantAllowed {
+				assert.Equal(t, time.Minute-tt.elapsed, d.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryRateLimitStore()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	hit := func(key string, now time.Time) (int, int) {
+		current, previous, err := store.Hit(ctx, key, time.Minute, now)
+		require.NoError(t, err)
+		return current, previous
+	}
+
+	current, previous := hit("a", start)
+	assert.Equal(t, 1, current)
+	assert.Equal(t, 0, previous)
+
+	current, _ = hit("a", start.Add(10*time.Second))
+	assert.Equal(t, 2, current)
+
+	// Keys are counted separately
+	current, _ = hit("b", start)
+	assert.Equal(t, 1, current)
+
+	// The next window carries the count over as the previous window
+	current, previous = hit("a", start.Add(time.Minute))
+	assert.Equal(t, 1, current)
+	assert.Equal(t, 2, previous)
+
+	// After a gap of more than a window both counts reset
+	current, previous = hit("a", start.Add(5*time.Minute))
+	assert.Equal(t, 1, current)
+	assert.Equal(t, 0, previous)
+}
+
+func TestMatchesPattern(t *testing.T) {
+	tests := []struct {
+		method  string
+		path    string
+		pattern string
+		want    bool
+	}{
+		{"POST", "/thread/new", "POST /thread/new", true},
+		{"GET", "/thread/new", "POST /thread/new", false},
+		{"GET", "/thread/new", "/thread/new", true},
+		{"POST", "/thread/42", "POST /thread/{tid}", true},
+		{"POST", "/thread/42/edit", "POST /thread/{tid}", false},
+		{"POST", "/thread/", "POST /thread/{tid}", false},
+		{"POST", "/thread/42/reply", "/thread/*/reply", true},
+		{"POST", "/admin", "POST /admin", true},
+		{"POST", "/admin/audit", "POST /admin", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path+" "+tt.pattern, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchesPattern(tt.method, tt.path, tt.pattern))
+		})
+	}
+}
+
+func TestEndpointLimitPrefersSpecificPatterns(t *testing.T) {
+	rl := newRateLimiter(defaultRateLimitConfig(), NewTestLogger())
+
+	tests := []struct {
+		method, path string
+		want         string
+	}{
+		{"POST", "/thread/new", "POST /thread/new"},
+		{"POST", "/thread/42", "POST /thread/{tid}"},
+		{"POST", "/member/edit", "POST /member/edit"},
+		{"GET", "/thread/new", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			// Map iteration order varies between runs, so check repeatedly
+			for range 50 {
+				limit, ok := rl.endpointLimit(httptest.NewRequest(tt.method, tt.path, nil))
+				assert.Equal(t, tt.want != "", ok)
+				assert.Equal(t, tt.want, limit.Pattern)
+			}
+		})
+	}
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	config := defaultRateLimitConfig()
+	config.AnonymousLimit = RateLimit{Requests: 1, Window: time.Minute}
+	config.RoleLimits = func(ctx context.Context) map[string]RateLimit {
+		return map[string]RateLimit{
+			RoleAdmin:  {Requests: 3, Window: time.Minute},
+			RoleMember: {Requests: 2, Window: time.Minute},
+		}
+	}
+	config.EndpointLimits = map[string]EndpointLimit{
+		"POST /thread/new": {Pattern: "POST /thread/new", Requests: 1, Window: time.Minute},
+	}
+
+	rl := newRateLimiter(config, NewTestLogger())
+	rl.now = func() time.Time { return now }
+
+	handler := rl.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(h http.Handler, method, path string, user *ContextUser) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = "100.64.0.1:1234"
+		rc := newRequestContext()
+		rc.User = user
+		req = req.WithContext(withRequestContext(req.Context(), rc))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	member := &ContextUser{ID: 1}
+	admin := &ContextUser{ID: 2, IsAdmin: true}
+
+	// Members get their role's limit, with headers describing it
+	rec := request(handler, "GET", "/", member)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, http.StatusOK, request(handler, "GET", "/", member).Code)
+	rec = request(handler, "GET", "/", member)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	// Admins have their own, higher, limit
+	for range 3 {
+		assert.Equal(t, http.StatusOK, request(handler, "GET", "/", admin).Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, request(handler, "GET", "/", admin).Code)
+
+	// Endpoint limits apply on top of the role limit
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, http.StatusOK, request(handler, "POST", "/thread/new", admin).Code)
+	assert.Equal(t, http.StatusTooManyRequests, request(handler, "POST", "/thread/new", admin).Code)
+	assert.Equal(t, http.StatusOK, request(handler, "GET", "/thread/new", admin).Code)
+
+	// Requests without a member are limited by IP
+	assert.Equal(t, http.StatusOK, request(handler, "GET", "/healthz", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, request(handler, "GET", "/healthz", nil).Code)
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Hit(ctx context.Context, key string, window time.Duration, now time.Time) (int, int, error) {
+	return 0, 0, assert.AnError
+}
+
+func TestRateLimiterFailsOpen(t *testing.T) {
+	config := defaultRateLimitConfig()
+	config.Store = failingRateLimitStore{}
+	rl := newRateLimiter(config, NewTestLogger())
+
+	handler := rl.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for range 100 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+}
@@ -45,7 +45,7 @@ func defaultSecurityConfig() *SecurityConfig {
 			"default-src":               "'self'",
 			"script-src":                "'self'",
 			"style-src":                 "'self' 'unsafe-inline'",
-			"img-src":                   "'self' data: https:",
+			"img-src":                   "'self'",
 			"font-src":                  "'self'",
 			"connect-src":               "'self'",
 			"frame-ancestors":           "'none'",
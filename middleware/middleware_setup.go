@@ -26,12 +26,18 @@ type MiddlewareSetup struct {
 	RateLimitConfig     *RateLimitConfig
 	ObservabilityConfig *ObservabilityConfig
 
+	// Request body size limits, in bytes
+	MaxRequestSize int64
+	MaxUploadSize  int64
+
 	// Feature flags
 	EnableAuth      bool
 	EnableRateLimit bool
 	EnableMetrics   bool
 	EnableTracing   bool
 	EnableCSRF      bool
+
+	rateLimiter *RateLimiter
 }
 
 // NewMiddlewareSetup creates a new middleware setup with defaults
@@ -51,6 +57,9 @@ func NewMiddlewareSetup(logger *slog.Logger, telemetry *TelemetryConfig, authPro
 		SecurityConfig:  defaultSecurityConfig(),
 		RateLimitConfig: defaultRateLimitConfig(),
 
+		MaxRequestSize: 1024 * 1024,      // 1MB
+		MaxUploadSize:  10 * 1024 * 1024, // 10MB
+
 		// Enable features by default
 		EnableAuth:      true,
 		EnableRateLimit: true,
@@ -62,6 +71,20 @@ func NewMiddlewareSetup(logger *slog.Logger, telemetry *TelemetryConfig, authPro
 
 // CreatePublicChain creates middleware chain for public endpoints
 func (ms *MiddlewareSetup) CreatePublicChain() *Chain {
+	chain := ms.createBaseChain(ms.MaxRequestSize)
+
+	// Add rate limiting, by IP since there is no member to key on
+	if ms.EnableRateLimit {
+		chain = chain.Append(ms.limiter().Middleware())
+	}
+
+	// CSRF protection is now handled by CrossOriginProtection middleware in authenticated chains
+
+	return chain
+}
+
+// createBaseChain creates the middleware every browser-facing chain starts with
+func (ms *MiddlewareSetup) createBaseChain(maxRequestSize int64) *Chain {
 	middlewares := []Middleware{
 		// Always start with request context
 		requestContextMiddleware(),
@@ -79,23 +102,35 @@ func (ms *MiddlewareSetup) CreatePublicChain() *Chain {
 	middlewares = append(middlewares, securityHeadersMiddleware(ms.SecurityConfig))
 
 	// Add request size limiting
-	middlewares = append(middlewares, requestSizeLimitMiddleware(1024*1024)) // 1MB
-
-	// Add rate limiting
-	if ms.EnableRateLimit {
-		rl := newRateLimiter(ms.RateLimitConfig, ms.Logger)
-		middlewares = append(middlewares, rl.Middleware())
-	}
-
-	// CSRF protection is now handled by CrossOriginProtection middleware in authenticated chains
+	middlewares = append(middlewares, requestSizeLimitMiddleware(maxRequestSize))
 
 	return newChain(middlewares...)
 }
 
+// limiter returns the rate limiter shared by every chain, so a visitor's
+// requests count against the same windows whichever route they hit. It is
+// created on first use so RateLimitConfig can be adjusted after
+// NewMiddlewareSetup.
+func (ms *MiddlewareSetup) limiter() *RateLimiter {
+	if ms.rateLimiter == nil {
+		ms.rateLimiter = newRateLimiter(ms.RateLimitConfig, ms.Logger)
+	}
+	return ms.rateLimiter
+}
+
 // CreateAuthenticatedChain creates middleware chain for authenticated endpoints
 func (ms *MiddlewareSetup) CreateAuthenticatedChain() *Chain {
-	// Start with public chain
-	chain := ms.CreatePublicChain()
+	return ms.createAuthenticatedChain(ms.MaxRequestSize)
+}
+
+// CreateUploadChain creates an authenticated middleware chain that accepts
+// request bodies up to MaxUploadSize, for file uploads
+func (ms *MiddlewareSetup) CreateUploadChain() *Chain {
+	return ms.createAuthenticatedChain(ms.MaxUploadSize)
+}
+
+func (ms *MiddlewareSetup) createAuthenticatedChain(maxRequestSize int64) *Chain {
+	chain := ms.createBaseChain(maxRequestSize)
 
 	// Add authentication
 	if ms.EnableAuth {
@@ -105,6 +140,11 @@ func (ms *MiddlewareSetup) CreateAuthenticatedChain() *Chain {
 		)
 	}
 
+	// Add rate limiting after authentication so limits follow the member's role
+	if ms.EnableRateLimit {
+		chain = chain.Append(ms.limiter().Middleware())
+	}
+
 	// Add CSRF protection for state-changing operations
 	if ms.EnableCSRF {
 		chain = chain.Append(
@@ -156,16 +196,6 @@ func (ms *MiddlewareSetup) CreateAPIChain() *Chain {
 	// Add request size limiting (larger for API)
 	middlewares = append(middlewares, requestSizeLimitMiddleware(10*1024*1024)) // 10MB
 
-	// Add rate limiting with API-specific limits
-	if ms.EnableRateLimit {
-		apiRateLimitConfig := *ms.RateLimitConfig
-		apiRateLimitConfig.RequestsPerSecond = 100 // Higher rate for API
-		apiRateLimitConfig.Burst = 200
-
-		rl := newRateLimiter(&apiRateLimitConfig, ms.Logger)
-		middlewares = append(middlewares, rl.Middleware())
-	}
-
 	// Add API authentication (could be different from web auth)
 	if ms.EnableAuth {
 		middlewares = append(middlewares,
@@ -174,6 +204,11 @@ func (ms *MiddlewareSetup) CreateAPIChain() *Chain {
 		)
 	}
 
+	// Add rate limiting
+	if ms.EnableRateLimit {
+		middlewares = append(middlewares, ms.limiter().Middleware())
+	}
+
 	// Add JSON error handling
 	middlewares = append(middlewares, jsonErrorMiddleware())
 
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/imeyer/tdiscuss/middleware"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // TailscaleClientAdapter adapts the actual Tailscale client to the middleware interface
@@ -30,11 +31,36 @@ func (a *TailscaleClientAdapter) WhoIs(ctx context.Context, remoteAddr string) (
 		return &middleware.WhoIsResponse{}, nil
 	}
 
-	return &middleware.WhoIsResponse{
+	who := &middleware.WhoIsResponse{
 		UserProfile: &middleware.UserProfile{
-			LoginName: resp.UserProfile.LoginName,
+			LoginName:     resp.UserProfile.LoginName,
+			DisplayName:   resp.UserProfile.DisplayName,
+			ProfilePicURL: resp.UserProfile.ProfilePicURL,
 		},
-	}, nil
+	}
+
+	if node := resp.Node; node != nil {
+		who.Node = &middleware.Node{Name: node.ComputedName, Tags: node.Tags}
+		if node.Hostinfo.Valid() {
+			who.Node.OS = node.Hostinfo.OS()
+			if who.Node.Name == "" {
+				who.Node.Name = node.Hostinfo.Hostname()
+			}
+		}
+	}
+
+	if len(resp.CapMap) > 0 {
+		who.CapMap = make(map[string][]string, len(resp.CapMap))
+		for capability, values := range resp.CapMap {
+			raw := make([]string, 0, len(values))
+			for _, v := range values {
+				raw = append(raw, string(v))
+			}
+			who.CapMap[string(capability)] = raw
+		}
+	}
+
+	return who, nil
 }
 
 // QuerierAdapter adapts the actual database querier to the middleware interface
@@ -61,9 +87,20 @@ func (a *QuerierAdapter) CreateOrReturnID(ctx context.Context, email string) (mi
 	}, nil
 }
 
+// SyncMemberProfile implements the middleware.Querier interface
+func (a *QuerierAdapter) SyncMemberProfile(ctx context.Context, arg middleware.SyncMemberProfileParams) error {
+	return a.queries.SyncMemberTailscaleProfile(ctx, SyncMemberTailscaleProfileParams{
+		NodeName:      arg.NodeName,
+		NodeOs:        arg.NodeOS,
+		MemberID:      arg.MemberID,
+		DisplayName:   arg.DisplayName,
+		ProfilePicUrl: arg.ProfilePicURL,
+	})
+}
+
 // GetBoardData implements the middleware.BoardDataQuerier interface
-func (a *QuerierAdapter) GetBoardData(ctx context.Context) (interface{}, error) {
-	boardData, err := a.queries.GetBoardData(ctx)
+func (a *QuerierAdapter) GetBoardData(ctx context.Context, slug string) (interface{}, error) {
+	boardData, err := a.queries.GetBoardData(ctx, pgtype.Text{String: slug, Valid: slug != ""})
 	if err != nil {
 		return nil, err
 	}
@@ -33,22 +33,88 @@ func (m *MockTx) Rollback(ctx context.Context) error {
 }
 
 type MockQueries struct {
-	inTransaction             bool
-	CreateOrReturnIDFunc      func(ctx context.Context, email string) (CreateOrReturnIDRow, error)
-	CreateThreadFunc          func(ctx context.Context, arg CreateThreadParams) error
-	GetBoardDataFunc          func(ctx context.Context) (GetBoardDataRow, error)
-	GetMemberFunc             func(ctx context.Context, id int64) (GetMemberRow, error)
-	GetThreadForEditFunc      func(ctx context.Context, arg GetThreadForEditParams) (GetThreadForEditRow, error)
-	GetThreadPostForEditFunc  func(ctx context.Context, arg GetThreadPostForEditParams) (GetThreadPostForEditRow, error)
-	GetThreadSequenceIdFunc   func(ctx context.Context) (int64, error)
-	GetThreadSubjectByIdFunc  func(ctx context.Context, id int64) (string, error)
-	ListMemberThreadsFunc     func(ctx context.Context, memberID int64) ([]ListMemberThreadsRow, error)
-	ListThreadPostsFunc       func(ctx context.Context, arg ListThreadPostsParams) ([]ListThreadPostsRow, error)
-	UpdateBoardEditWindowFunc func(ctx context.Context, arg pgtype.Int4) error
-	UpdateBoardTitleFunc      func(ctx context.Context, arg string) error
-	UpdateThreadFunc          func(ctx context.Context, arg UpdateThreadParams) error
-	UpdateThreadPostFunc      func(ctx context.Context, arg UpdateThreadPostParams) error
-	BlockMemberFunc           func(ctx context.Context, id int64) error
+	inTransaction                     bool
+	CreateOrReturnIDFunc              func(ctx context.Context, email string) (CreateOrReturnIDRow, error)
+	CreateThreadFunc                  func(ctx context.Context, arg CreateThreadParams) error
+	GetBoardDataFunc                  func(ctx context.Context, slug pgtype.Text) (GetBoardDataRow, error)
+	GetMemberFunc                     func(ctx context.Context, id int64) (GetMemberRow, error)
+	GetThreadForEditFunc              func(ctx context.Context, arg GetThreadForEditParams) (GetThreadForEditRow, error)
+	GetThreadPostForEditFunc          func(ctx context.Context, arg GetThreadPostForEditParams) (GetThreadPostForEditRow, error)
+	GetThreadSequenceIdFunc           func(ctx context.Context) (int64, error)
+	GetThreadSubjectByIdFunc          func(ctx context.Context, id int64) (string, error)
+	ListMemberThreadsFunc             func(ctx context.Context, arg ListMemberThreadsParams) ([]ListMemberThreadsRow, error)
+	ListThreadsFunc                   func(ctx context.Context, arg ListThreadsParams) ([]ListThreadsRow, error)
+	ListThreadPostsFunc               func(ctx context.Context, arg ListThreadPostsParams) ([]ListThreadPostsRow, error)
+	UpdateBoardEditWindowFunc         func(ctx context.Context, arg UpdateBoardEditWindowParams) error
+	UpdateBoardTitleFunc              func(ctx context.Context, arg UpdateBoardTitleParams) error
+	UpdateThreadFunc                  func(ctx context.Context, arg UpdateThreadParams) error
+	UpdateThreadPostFunc              func(ctx context.Context, arg UpdateThreadPostParams) error
+	BlockMemberFunc                   func(ctx context.Context, id int64) error
+	CreateThreadPostRevisionFunc      func(ctx context.Context, arg CreateThreadPostRevisionParams) error
+	GetThreadPostFunc                 func(ctx context.Context, id int64) (GetThreadPostRow, error)
+	ListThreadPostRevisionsFunc       func(ctx context.Context, threadPostID int64) ([]ListThreadPostRevisionsRow, error)
+	CreatePostReactionFunc            func(ctx context.Context, arg CreatePostReactionParams) error
+	DeletePostReactionFunc            func(ctx context.Context, arg DeletePostReactionParams) (int64, error)
+	UpdateBoardReactionEmojiFunc      func(ctx context.Context, arg UpdateBoardReactionEmojiParams) error
+	CreateAttachmentFunc              func(ctx context.Context, arg CreateAttachmentParams) error
+	GetAttachmentFunc                 func(ctx context.Context, hash string) (Attachment, error)
+	CanSeeAttachmentFunc              func(ctx context.Context, arg CanSeeAttachmentParams) (bool, error)
+	LinkThreadPostAttachmentsFunc     func(ctx context.Context, arg LinkThreadPostAttachmentsParams) error
+	GetSchemaVersionFunc              func(ctx context.Context) (int32, error)
+	HitRateLimitWindowFunc            func(ctx context.Context, arg HitRateLimitWindowParams) (HitRateLimitWindowRow, error)
+	DeleteExpiredRateLimitWindowsFunc func(ctx context.Context, windowStart pgtype.Timestamptz) (int64, error)
+	ListRateLimitsFunc                func(ctx context.Context) ([]RateLimit, error)
+	UpsertRateLimitFunc               func(ctx context.Context, arg UpsertRateLimitParams) error
+	GetMemberPostingActivityFunc      func(ctx context.Context, arg GetMemberPostingActivityParams) (GetMemberPostingActivityRow, error)
+	ListHeldPostsFunc                 func(ctx context.Context) ([]ListHeldPostsRow, error)
+	ApproveHeldPostFunc               func(ctx context.Context, id int64) (int64, error)
+	RejectHeldPostFunc                func(ctx context.Context, id int64) (int64, error)
+	CreateReportFunc                  func(ctx context.Context, arg CreateReportParams) error
+	ListOpenReportsFunc               func(ctx context.Context) ([]ListOpenReportsRow, error)
+	ResolveReportsFunc                func(ctx context.Context, arg ResolveReportsParams) (int64, error)
+	HideThreadPostFunc                func(ctx context.Context, id int64) error
+	DeleteThreadPostFunc              func(ctx context.Context, id int64) error
+	LockThreadFunc                    func(ctx context.Context, id int64) error
+	IsThreadLockedFunc                func(ctx context.Context, id int64) (bool, error)
+	CreateAuditLogFunc                func(ctx context.Context, arg CreateAuditLogParams) error
+	ListAuditLogFunc                  func(ctx context.Context, arg ListAuditLogParams) ([]ListAuditLogRow, error)
+	GetMemberTimePreferencesFunc      func(ctx context.Context, memberID int64) (GetMemberTimePreferencesRow, error)
+	UpdateBoardHideEmailsFunc         func(ctx context.Context, arg UpdateBoardHideEmailsParams) error
+	SyncMemberTailscaleProfileFunc    func(ctx context.Context, arg SyncMemberTailscaleProfileParams) error
+	CreateBoardFunc                   func(ctx context.Context, arg CreateBoardParams) (int32, error)
+	GetBoardAclFunc                   func(ctx context.Context, id int32) ([]string, error)
+	AnyBoardHidesEmailsFunc           func(ctx context.Context) (bool, error)
+	GetThreadBoardFunc                func(ctx context.Context, id int64) (GetThreadBoardRow, error)
+	ListBoardsFunc                    func(ctx context.Context) ([]ListBoardsRow, error)
+	UpdateBoardDescriptionFunc        func(ctx context.Context, arg UpdateBoardDescriptionParams) error
+	GetThreadPostBoardFunc            func(ctx context.Context, id int64) (GetThreadPostBoardRow, error)
+	UpdateBoardAclFunc                func(ctx context.Context, arg UpdateBoardAclParams) error
+	CreateTagsFunc                    func(ctx context.Context, names []string) error
+	SetThreadTagsFunc                 func(ctx context.Context, arg SetThreadTagsParams) error
+	ListTagsFunc                      func(ctx context.Context) ([]ListTagsRow, error)
+	GetTagFunc                        func(ctx context.Context, id int32) (Tag, error)
+	RenameTagFunc                     func(ctx context.Context, arg RenameTagParams) error
+	MergeThreadTagsFunc               func(ctx context.Context, arg MergeThreadTagsParams) error
+	DeleteTagFunc                     func(ctx context.Context, id int32) error
+	CreatePollFunc                    func(ctx context.Context, arg CreatePollParams) (int64, error)
+	CreatePollOptionsFunc             func(ctx context.Context, arg CreatePollOptionsParams) error
+	GetThreadPollFunc                 func(ctx context.Context, threadID int64) (Poll, error)
+	ListPollOptionsFunc               func(ctx context.Context, arg ListPollOptionsParams) ([]ListPollOptionsRow, error)
+	ListPollVotersFunc                func(ctx context.Context, pollID int64) ([]ListPollVotersRow, error)
+	HasVotedInPollFunc                func(ctx context.Context, arg HasVotedInPollParams) (bool, error)
+	CreatePollBallotFunc              func(ctx context.Context, arg CreatePollBallotParams) (int64, error)
+	CreatePollVotesFunc               func(ctx context.Context, arg CreatePollVotesParams) error
+	SaveDraftFunc                     func(ctx context.Context, arg SaveDraftParams) error
+	GetDraftFunc                      func(ctx context.Context, arg GetDraftParams) (Draft, error)
+	DeleteDraftFunc                   func(ctx context.Context, arg DeleteDraftParams) error
+	ListMemberDraftsFunc              func(ctx context.Context, arg ListMemberDraftsParams) ([]ListMemberDraftsRow, error)
+	DeleteExpiredDraftsFunc           func(ctx context.Context, dateSaved pgtype.Timestamptz) (int64, error)
+	ListPostsToUnfurlFunc             func(ctx context.Context, limit int32) ([]ListPostsToUnfurlRow, error)
+	MarkThreadPostUnfurledFunc        func(ctx context.Context, arg MarkThreadPostUnfurledParams) error
+	GetLinkPreviewFunc                func(ctx context.Context, url string) (LinkPreview, error)
+	UpsertLinkPreviewFunc             func(ctx context.Context, arg UpsertLinkPreviewParams) error
+	SetThreadPostLinksFunc            func(ctx context.Context, arg SetThreadPostLinksParams) error
+	ListThreadLinkPreviewsFunc        func(ctx context.Context, threadID int64) ([]ListThreadLinkPreviewsRow, error)
 }
 
 func (m *MockQueries) CreateOrReturnID(ctx context.Context, pEmail string) (CreateOrReturnIDRow, error) {
@@ -71,13 +137,14 @@ func (m *MockQueries) CreateThreadPost(ctx context.Context, arg CreateThreadPost
 	return nil
 }
 
-func (m *MockQueries) GetBoardData(ctx context.Context) (GetBoardDataRow, error) {
+func (m *MockQueries) GetBoardData(ctx context.Context, slug pgtype.Text) (GetBoardDataRow, error) {
 	if m.GetBoardDataFunc != nil {
-		return m.GetBoardDataFunc(ctx)
+		return m.GetBoardDataFunc(ctx, slug)
 	}
 
 	return GetBoardDataRow{
 		EditWindow: pgtype.Int4{Int32: 900, Valid: true},
+		Slug:       "general",
 		Title:      "Mock Board Title",
 		ID:         1,
 	}, nil
@@ -98,15 +165,15 @@ func (m *MockQueries) GetMember(ctx context.Context, id int64) (GetMemberRow, er
 }
 
 func (m *MockQueries) GetMemberId(ctx context.Context, email string) (int64, error) {
-	return 1, nil
+	return 0, nil
 }
 
 func (m *MockQueries) GetThreadPostSequenceId(ctx context.Context) (int64, error) {
-	return 1, nil
+	return 0, nil
 }
 
 func (m *MockQueries) GetThreadSequenceId(ctx context.Context) (int64, error) {
-	return 1, nil
+	return 0, nil
 }
 
 func (m *MockQueries) GetThreadSubjectById(ctx context.Context, id int64) (string, error) {
@@ -142,9 +209,9 @@ func (m *MockQueries) GetThreadPostForEdit(ctx context.Context, arg GetThreadPos
 	}, nil
 }
 
-func (m *MockQueries) ListMemberThreads(ctx context.Context, memberID int64) ([]ListMemberThreadsRow, error) {
+func (m *MockQueries) ListMemberThreads(ctx context.Context, arg ListMemberThreadsParams) ([]ListMemberThreadsRow, error) {
 	if m.ListMemberThreadsFunc != nil {
-		return m.ListMemberThreadsFunc(ctx, memberID)
+		return m.ListMemberThreadsFunc(ctx, arg)
 	}
 
 	return []ListMemberThreadsRow{}, nil
@@ -159,6 +226,10 @@ func (m *MockQueries) ListThreadPosts(ctx context.Context, arg ListThreadPostsPa
 }
 
 func (m *MockQueries) ListThreads(ctx context.Context, arg ListThreadsParams) ([]ListThreadsRow, error) {
+	if m.ListThreadsFunc != nil {
+		return m.ListThreadsFunc(ctx, arg)
+	}
+
 	return []ListThreadsRow{
 		{
 			ThreadID:       1,
@@ -179,7 +250,7 @@ func (m *MockQueries) ListThreads(ctx context.Context, arg ListThreadsParams) ([
 	}, nil
 }
 
-func (m *MockQueries) UpdateBoardEditWindow(ctx context.Context, arg pgtype.Int4) error {
+func (m *MockQueries) UpdateBoardEditWindow(ctx context.Context, arg UpdateBoardEditWindowParams) error {
 	if m.UpdateBoardEditWindowFunc != nil {
 		return m.UpdateBoardEditWindowFunc(ctx, arg)
 	}
@@ -187,7 +258,7 @@ func (m *MockQueries) UpdateBoardEditWindow(ctx context.Context, arg pgtype.Int4
 	return nil
 }
 
-func (m *MockQueries) UpdateBoardTitle(ctx context.Context, arg string) error {
+func (m *MockQueries) UpdateBoardTitle(ctx context.Context, arg UpdateBoardTitleParams) error {
 	if m.UpdateBoardTitleFunc != nil {
 		return m.UpdateBoardTitleFunc(ctx, arg)
 	}
@@ -223,6 +294,533 @@ func (m *MockQueries) BlockMember(ctx context.Context, id int64) error {
 	return nil
 }
 
+func (m *MockQueries) CreateThreadPostRevision(ctx context.Context, arg CreateThreadPostRevisionParams) error {
+	if m.CreateThreadPostRevisionFunc != nil {
+		return m.CreateThreadPostRevisionFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) GetThreadPost(ctx context.Context, id int64) (GetThreadPostRow, error) {
+	if m.GetThreadPostFunc != nil {
+		return m.GetThreadPostFunc(ctx, id)
+	}
+
+	return GetThreadPostRow{
+		ID:         id,
+		ThreadID:   1,
+		MemberID:   1,
+		DatePosted: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		Body:       pgtype.Text{String: "Mock Body", Valid: true},
+		Subject:    pgtype.Text{String: "Mock Subject", Valid: true},
+	}, nil
+}
+
+func (m *MockQueries) ListThreadPostRevisions(ctx context.Context, threadPostID int64) ([]ListThreadPostRevisionsRow, error) {
+	if m.ListThreadPostRevisionsFunc != nil {
+		return m.ListThreadPostRevisionsFunc(ctx, threadPostID)
+	}
+
+	return []ListThreadPostRevisionsRow{}, nil
+}
+
+func (m *MockQueries) CreatePostReaction(ctx context.Context, arg CreatePostReactionParams) error {
+	if m.CreatePostReactionFunc != nil {
+		return m.CreatePostReactionFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) DeletePostReaction(ctx context.Context, arg DeletePostReactionParams) (int64, error) {
+	if m.DeletePostReactionFunc != nil {
+		return m.DeletePostReactionFunc(ctx, arg)
+	}
+
+	return 0, nil
+}
+
+func (m *MockQueries) UpdateBoardReactionEmoji(ctx context.Context, arg UpdateBoardReactionEmojiParams) error {
+	if m.UpdateBoardReactionEmojiFunc != nil {
+		return m.UpdateBoardReactionEmojiFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) CreateAttachment(ctx context.Context, arg CreateAttachmentParams) error {
+	if m.CreateAttachmentFunc != nil {
+		return m.CreateAttachmentFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) CanSeeAttachment(ctx context.Context, arg CanSeeAttachmentParams) (bool, error) {
+	if m.CanSeeAttachmentFunc != nil {
+		return m.CanSeeAttachmentFunc(ctx, arg)
+	}
+
+	return false, nil
+}
+
+func (m *MockQueries) LinkThreadPostAttachments(ctx context.Context, arg LinkThreadPostAttachmentsParams) error {
+	if m.LinkThreadPostAttachmentsFunc != nil {
+		return m.LinkThreadPostAttachmentsFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) GetAttachment(ctx context.Context, hash string) (Attachment, error) {
+	if m.GetAttachmentFunc != nil {
+		return m.GetAttachmentFunc(ctx, hash)
+	}
+
+	return Attachment{}, nil
+}
+
+func (m *MockQueries) GetSchemaVersion(ctx context.Context) (int32, error) {
+	if m.GetSchemaVersionFunc != nil {
+		return m.GetSchemaVersionFunc(ctx)
+	}
+
+	return 0, nil
+}
+
+func (m *MockQueries) HitRateLimitWindow(ctx context.Context, arg HitRateLimitWindowParams) (HitRateLimitWindowRow, error) {
+	if m.HitRateLimitWindowFunc != nil {
+		return m.HitRateLimitWindowFunc(ctx, arg)
+	}
+
+	return HitRateLimitWindowRow{}, nil
+}
+
+func (m *MockQueries) DeleteExpiredRateLimitWindows(ctx context.Context, windowStart pgtype.Timestamptz) (int64, error) {
+	if m.DeleteExpiredRateLimitWindowsFunc != nil {
+		return m.DeleteExpiredRateLimitWindowsFunc(ctx, windowStart)
+	}
+
+	return 0, nil
+}
+
+func (m *MockQueries) ListRateLimits(ctx context.Context) ([]RateLimit, error) {
+	if m.ListRateLimitsFunc != nil {
+		return m.ListRateLimitsFunc(ctx)
+	}
+
+	return nil, nil
+}
+
+func (m *MockQueries) UpsertRateLimit(ctx context.Context, arg UpsertRateLimitParams) error {
+	if m.UpsertRateLimitFunc != nil {
+		return m.UpsertRateLimitFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) GetMemberPostingActivity(ctx context.Context, arg GetMemberPostingActivityParams) (GetMemberPostingActivityRow, error) {
+	if m.GetMemberPostingActivityFunc != nil {
+		return m.GetMemberPostingActivityFunc(ctx, arg)
+	}
+
+	return GetMemberPostingActivityRow{}, nil
+}
+
+func (m *MockQueries) ListHeldPosts(ctx context.Context) ([]ListHeldPostsRow, error) {
+	if m.ListHeldPostsFunc != nil {
+		return m.ListHeldPostsFunc(ctx)
+	}
+
+	return nil, nil
+}
+
+func (m *MockQueries) ApproveHeldPost(ctx context.Context, id int64) (int64, error) {
+	if m.ApproveHeldPostFunc != nil {
+		return m.ApproveHeldPostFunc(ctx, id)
+	}
+
+	return 0, nil
+}
+
+func (m *MockQueries) RejectHeldPost(ctx context.Context, id int64) (int64, error) {
+	if m.RejectHeldPostFunc != nil {
+		return m.RejectHeldPostFunc(ctx, id)
+	}
+
+	return 0, nil
+}
+
+func (m *MockQueries) CreateReport(ctx context.Context, arg CreateReportParams) error {
+	if m.CreateReportFunc != nil {
+		return m.CreateReportFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) ListOpenReports(ctx context.Context) ([]ListOpenReportsRow, error) {
+	if m.ListOpenReportsFunc != nil {
+		return m.ListOpenReportsFunc(ctx)
+	}
+
+	return nil, nil
+}
+
+func (m *MockQueries) ResolveReports(ctx context.Context, arg ResolveReportsParams) (int64, error) {
+	if m.ResolveReportsFunc != nil {
+		return m.ResolveReportsFunc(ctx, arg)
+	}
+
+	return 0, nil
+}
+
+func (m *MockQueries) HideThreadPost(ctx context.Context, id int64) error {
+	if m.HideThreadPostFunc != nil {
+		return m.HideThreadPostFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) DeleteThreadPost(ctx context.Context, id int64) error {
+	if m.DeleteThreadPostFunc != nil {
+		return m.DeleteThreadPostFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) LockThread(ctx context.Context, id int64) error {
+	if m.LockThreadFunc != nil {
+		return m.LockThreadFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) IsThreadLocked(ctx context.Context, id int64) (bool, error) {
+	if m.IsThreadLockedFunc != nil {
+		return m.IsThreadLockedFunc(ctx, id)
+	}
+
+	return false, nil
+}
+
+func (m *MockQueries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error {
+	if m.CreateAuditLogFunc != nil {
+		return m.CreateAuditLogFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) ListAuditLog(ctx context.Context, arg ListAuditLogParams) ([]ListAuditLogRow, error) {
+	if m.ListAuditLogFunc != nil {
+		return m.ListAuditLogFunc(ctx, arg)
+	}
+
+	return nil, nil
+}
+
+func (m *MockQueries) GetMemberTimePreferences(ctx context.Context, memberID int64) (GetMemberTimePreferencesRow, error) {
+	if m.GetMemberTimePreferencesFunc != nil {
+		return m.GetMemberTimePreferencesFunc(ctx, memberID)
+	}
+
+	return GetMemberTimePreferencesRow{}, nil
+}
+
+func (m *MockQueries) UpdateBoardHideEmails(ctx context.Context, arg UpdateBoardHideEmailsParams) error {
+	if m.UpdateBoardHideEmailsFunc != nil {
+		return m.UpdateBoardHideEmailsFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) SyncMemberTailscaleProfile(ctx context.Context, arg SyncMemberTailscaleProfileParams) error {
+	if m.SyncMemberTailscaleProfileFunc != nil {
+		return m.SyncMemberTailscaleProfileFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) CreateBoard(ctx context.Context, arg CreateBoardParams) (int32, error) {
+	if m.CreateBoardFunc != nil {
+		return m.CreateBoardFunc(ctx, arg)
+	}
+
+	return 0, nil
+}
+
+func (m *MockQueries) AnyBoardHidesEmails(ctx context.Context) (bool, error) {
+	if m.AnyBoardHidesEmailsFunc != nil {
+		return m.AnyBoardHidesEmailsFunc(ctx)
+	}
+
+	return false, nil
+}
+
+func (m *MockQueries) GetBoardAcl(ctx context.Context, id int32) ([]string, error) {
+	if m.GetBoardAclFunc != nil {
+		return m.GetBoardAclFunc(ctx, id)
+	}
+
+	return []string{}, nil
+}
+
+func (m *MockQueries) GetThreadBoard(ctx context.Context, id int64) (GetThreadBoardRow, error) {
+	if m.GetThreadBoardFunc != nil {
+		return m.GetThreadBoardFunc(ctx, id)
+	}
+
+	return GetThreadBoardRow{}, nil
+}
+
+func (m *MockQueries) ListBoards(ctx context.Context) ([]ListBoardsRow, error) {
+	if m.ListBoardsFunc != nil {
+		return m.ListBoardsFunc(ctx)
+	}
+
+	return nil, nil
+}
+
+func (m *MockQueries) UpdateBoardDescription(ctx context.Context, arg UpdateBoardDescriptionParams) error {
+	if m.UpdateBoardDescriptionFunc != nil {
+		return m.UpdateBoardDescriptionFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) GetThreadPostBoard(ctx context.Context, id int64) (GetThreadPostBoardRow, error) {
+	if m.GetThreadPostBoardFunc != nil {
+		return m.GetThreadPostBoardFunc(ctx, id)
+	}
+
+	return GetThreadPostBoardRow{}, nil
+}
+
+func (m *MockQueries) UpdateBoardAcl(ctx context.Context, arg UpdateBoardAclParams) error {
+	if m.UpdateBoardAclFunc != nil {
+		return m.UpdateBoardAclFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) CreateTags(ctx context.Context, names []string) error {
+	if m.CreateTagsFunc != nil {
+		return m.CreateTagsFunc(ctx, names)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) SetThreadTags(ctx context.Context, arg SetThreadTagsParams) error {
+	if m.SetThreadTagsFunc != nil {
+		return m.SetThreadTagsFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) ListTags(ctx context.Context) ([]ListTagsRow, error) {
+	if m.ListTagsFunc != nil {
+		return m.ListTagsFunc(ctx)
+	}
+
+	return nil, nil
+}
+
+func (m *MockQueries) GetTag(ctx context.Context, id int32) (Tag, error) {
+	if m.GetTagFunc != nil {
+		return m.GetTagFunc(ctx, id)
+	}
+
+	return Tag{}, nil
+}
+
+func (m *MockQueries) RenameTag(ctx context.Context, arg RenameTagParams) error {
+	if m.RenameTagFunc != nil {
+		return m.RenameTagFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) MergeThreadTags(ctx context.Context, arg MergeThreadTagsParams) error {
+	if m.MergeThreadTagsFunc != nil {
+		return m.MergeThreadTagsFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) DeleteTag(ctx context.Context, id int32) error {
+	if m.DeleteTagFunc != nil {
+		return m.DeleteTagFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) CreatePoll(ctx context.Context, arg CreatePollParams) (int64, error) {
+	if m.CreatePollFunc != nil {
+		return m.CreatePollFunc(ctx, arg)
+	}
+
+	return 0, nil
+}
+
+func (m *MockQueries) CreatePollOptions(ctx context.Context, arg CreatePollOptionsParams) error {
+	if m.CreatePollOptionsFunc != nil {
+		return m.CreatePollOptionsFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) GetThreadPoll(ctx context.Context, threadID int64) (Poll, error) {
+	if m.GetThreadPollFunc != nil {
+		return m.GetThreadPollFunc(ctx, threadID)
+	}
+
+	return Poll{}, nil
+}
+
+func (m *MockQueries) ListPollOptions(ctx context.Context, arg ListPollOptionsParams) ([]ListPollOptionsRow, error) {
+	if m.ListPollOptionsFunc != nil {
+		return m.ListPollOptionsFunc(ctx, arg)
+	}
+
+	return nil, nil
+}
+
+func (m *MockQueries) ListPollVoters(ctx context.Context, pollID int64) ([]ListPollVotersRow, error) {
+	if m.ListPollVotersFunc != nil {
+		return m.ListPollVotersFunc(ctx, pollID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockQueries) HasVotedInPoll(ctx context.Context, arg HasVotedInPollParams) (bool, error) {
+	if m.HasVotedInPollFunc != nil {
+		return m.HasVotedInPollFunc(ctx, arg)
+	}
+
+	return false, nil
+}
+
+func (m *MockQueries) CreatePollBallot(ctx context.Context, arg CreatePollBallotParams) (int64, error) {
+	if m.CreatePollBallotFunc != nil {
+		return m.CreatePollBallotFunc(ctx, arg)
+	}
+
+	return 0, nil
+}
+
+func (m *MockQueries) CreatePollVotes(ctx context.Context, arg CreatePollVotesParams) error {
+	if m.CreatePollVotesFunc != nil {
+		return m.CreatePollVotesFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) SaveDraft(ctx context.Context, arg SaveDraftParams) error {
+	if m.SaveDraftFunc != nil {
+		return m.SaveDraftFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) GetDraft(ctx context.Context, arg GetDraftParams) (Draft, error) {
+	if m.GetDraftFunc != nil {
+		return m.GetDraftFunc(ctx, arg)
+	}
+
+	return Draft{}, nil
+}
+
+func (m *MockQueries) DeleteDraft(ctx context.Context, arg DeleteDraftParams) error {
+	if m.DeleteDraftFunc != nil {
+		return m.DeleteDraftFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) ListMemberDrafts(ctx context.Context, arg ListMemberDraftsParams) ([]ListMemberDraftsRow, error) {
+	if m.ListMemberDraftsFunc != nil {
+		return m.ListMemberDraftsFunc(ctx, arg)
+	}
+
+	return nil, nil
+}
+
+func (m *MockQueries) DeleteExpiredDrafts(ctx context.Context, dateSaved pgtype.Timestamptz) (int64, error) {
+	if m.DeleteExpiredDraftsFunc != nil {
+		return m.DeleteExpiredDraftsFunc(ctx, dateSaved)
+	}
+
+	return 0, nil
+}
+
+func (m *MockQueries) ListPostsToUnfurl(ctx context.Context, limit int32) ([]ListPostsToUnfurlRow, error) {
+	if m.ListPostsToUnfurlFunc != nil {
+		return m.ListPostsToUnfurlFunc(ctx, limit)
+	}
+
+	return nil, nil
+}
+
+func (m *MockQueries) MarkThreadPostUnfurled(ctx context.Context, arg MarkThreadPostUnfurledParams) error {
+	if m.MarkThreadPostUnfurledFunc != nil {
+		return m.MarkThreadPostUnfurledFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) GetLinkPreview(ctx context.Context, url string) (LinkPreview, error) {
+	if m.GetLinkPreviewFunc != nil {
+		return m.GetLinkPreviewFunc(ctx, url)
+	}
+
+	return LinkPreview{}, nil
+}
+
+func (m *MockQueries) UpsertLinkPreview(ctx context.Context, arg UpsertLinkPreviewParams) error {
+	if m.UpsertLinkPreviewFunc != nil {
+		return m.UpsertLinkPreviewFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) SetThreadPostLinks(ctx context.Context, arg SetThreadPostLinksParams) error {
+	if m.SetThreadPostLinksFunc != nil {
+		return m.SetThreadPostLinksFunc(ctx, arg)
+	}
+
+	return nil
+}
+
+func (m *MockQueries) ListThreadLinkPreviews(ctx context.Context, threadID int64) ([]ListThreadLinkPreviewsRow, error) {
+	if m.ListThreadLinkPreviewsFunc != nil {
+		return m.ListThreadLinkPreviewsFunc(ctx, threadID)
+	}
+
+	return nil, nil
+}
+
 func (m *MockQueries) WithTx(pgx.Tx) ExtendedQuerier {
 	return &MockQueries{
 		inTransaction: true,
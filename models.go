@@ -8,15 +8,66 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Attachment struct {
+	Hash         string
+	ContentType  string
+	Size         int64
+	Filename     string
+	MemberID     int64
+	DateUploaded pgtype.Timestamptz
+}
+
+type AttachmentUpload struct {
+	Hash     string
+	MemberID int64
+}
+
+type AuditLog struct {
+	ID          int64
+	DateCreated pgtype.Timestamptz
+	ActorID     int64
+	Action      string
+	TargetType  string
+	TargetID    string
+	Before      []byte
+	After       []byte
+	RequestID   string
+	TraceID     string
+}
+
 type BoardDatum struct {
 	ID               int32
+	Slug             string
 	Title            string
+	Description      string
 	AllowEditing     pgtype.Bool
 	AllowDeleting    pgtype.Bool
 	EditWindow       pgtype.Int4
 	TotalMembers     pgtype.Int4
 	TotalThreads     pgtype.Int4
 	TotalThreadPosts pgtype.Int4
+	ReactionEmoji    []string
+	HideEmails       bool
+	Acl              []string
+}
+
+type Draft struct {
+	ID        int64
+	MemberID  int64
+	ThreadID  pgtype.Int8
+	Subject   string
+	Body      string
+	DateSaved pgtype.Timestamptz
+}
+
+type LinkPreview struct {
+	Url         string
+	Ok          bool
+	Title       string
+	Description string
+	SiteName    string
+	ImageUrl    string
+	DateFetched pgtype.Timestamptz
 }
 
 type Member struct {
@@ -30,22 +81,97 @@ type Member struct {
 	LastView         pgtype.Timestamp
 	TotalThreadPosts pgtype.Int4
 	TotalThreads     pgtype.Int4
+	LastSeenNode     pgtype.Text
+	LastSeenOs       pgtype.Text
+	LastSeenAt       pgtype.Timestamptz
 }
 
 type MemberProfile struct {
-	ID            int64
-	MemberID      int64
-	Location      pgtype.Text
-	Pronouns      pgtype.Text
-	PreferredName pgtype.Text
-	ProperName    pgtype.Text
-	PhotoUrl      pgtype.Text
-	Timezone      pgtype.Text
-	Bio           pgtype.Text
+	ID                int64
+	MemberID          int64
+	Location          pgtype.Text
+	Pronouns          pgtype.Text
+	PreferredName     pgtype.Text
+	ProperName        pgtype.Text
+	PhotoUrl          pgtype.Text
+	Timezone          pgtype.Text
+	RelativeTimes     bool
+	Bio               pgtype.Text
+	TailscaleName     pgtype.Text
+	TailscalePhotoUrl pgtype.Text
+}
+
+type Poll struct {
+	ID          int64
+	ThreadID    int64
+	Question    string
+	Multiple    bool
+	Anonymous   bool
+	DateCloses  pgtype.Timestamptz
+	DateCreated pgtype.Timestamptz
+}
+
+type PollBallot struct {
+	PollID    int64
+	MemberID  int64
+	DateVoted pgtype.Timestamptz
+}
+
+type PollOption struct {
+	ID       int64
+	PollID   int64
+	Position int32
+	Label    string
+}
+
+type PollVote struct {
+	PollOptionID int64
+	MemberID     pgtype.Int8
+}
+
+type PostReaction struct {
+	ThreadPostID int64
+	MemberID     int64
+	Emoji        string
+	DateReacted  pgtype.Timestamptz
+}
+
+type RateLimit struct {
+	Role          string
+	Requests      int32
+	WindowSeconds int32
+}
+
+type RateLimitWindow struct {
+	Key         string
+	WindowStart pgtype.Timestamptz
+	Count       int32
+}
+
+type Report struct {
+	ID           int64
+	ThreadPostID int64
+	MemberID     int64
+	Reason       string
+	DateReported pgtype.Timestamptz
+	Resolution   pgtype.Text
+	ResolvedBy   pgtype.Int8
+	DateResolved pgtype.Timestamptz
+}
+
+type SchemaVersion struct {
+	Version     int32
+	DateApplied pgtype.Timestamptz
+}
+
+type Tag struct {
+	ID   int32
+	Name string
 }
 
 type Thread struct {
 	ID             int64
+	BoardID        int32
 	MemberID       int64
 	Subject        string
 	DatePosted     pgtype.Timestamptz
@@ -59,6 +185,7 @@ type Thread struct {
 	Indexed        bool
 	Edited         bool
 	Deleted        bool
+	Held           bool
 }
 
 type ThreadMember struct {
@@ -77,5 +204,36 @@ type ThreadPost struct {
 	Indexed    bool
 	Edited     bool
 	Deleted    bool
+	DateEdited pgtype.Timestamptz
 	Body       pgtype.Text
+	BodyHash   pgtype.Text
+	Held       bool
+	HeldReason pgtype.Text
+	Hidden     bool
+	Unfurled   bool
+}
+
+type ThreadPostAttachment struct {
+	ThreadPostID int64
+	Hash         string
+}
+
+type ThreadPostLink struct {
+	ThreadPostID int64
+	Position     int32
+	Url          string
+}
+
+type ThreadPostRevision struct {
+	ID           int64
+	ThreadPostID int64
+	MemberID     int64
+	DateRevised  pgtype.Timestamptz
+	Subject      pgtype.Text
+	Body         pgtype.Text
+}
+
+type ThreadTag struct {
+	ThreadID int64
+	TagID    int32
 }
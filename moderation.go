@@ -0,0 +1,247 @@
+package main
+
+import (
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// HeldPostTemplateData is a post in the moderation queue.
+type HeldPostTemplateData struct {
+	ID          int64
+	ThreadID    int64
+	Subject     string
+	IsFirstPost bool
+	MemberID    int64
+	Email       string
+	DateJoined  pgtype.Timestamptz
+	DatePosted  pgtype.Timestamptz
+	Reason      string
+	Body        template.HTML
+}
+
+// Moderation shows admins the posts held by the spam guard and the posts
+// members have reported, and lets them act on each one.
+func (s *DiscussService) Moderation(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "Moderation")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	user, err := GetUser(r)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "GetUser", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !user.IsAdmin {
+		s.renderError(w, http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		s.moderatePost(w, r, user)
+		return
+	}
+
+	span.AddEvent("queries.ListHeldPosts")
+	rows, err := s.queries.ListHeldPosts(r.Context())
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "ListHeldPosts", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	posts := make([]HeldPostTemplateData, 0, len(rows))
+	for _, row := range rows {
+		posts = append(posts, HeldPostTemplateData{
+			ID:          row.ID,
+			ThreadID:    row.ThreadID,
+			Subject:     row.Subject.String,
+			IsFirstPost: row.IsFirstPost,
+			MemberID:    row.MemberID.Int64,
+			Email:       row.Email.String,
+			DateJoined:  row.DateJoined,
+			DatePosted:  row.DatePosted,
+			Reason:      row.HeldReason.String,
+			Body:        template.HTML(s.images.proxyStoredImages(row.Body.String)),
+		})
+	}
+
+	span.AddEvent("queries.ListOpenReports")
+	reports, err := s.queries.ListOpenReports(r.Context())
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "ListOpenReports", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	s.renderTemplate(w, r, "moderation.html", map[string]interface{}{
+		"Title":            GetBoardTitle(r),
+		"User":             user,
+		"CurrentUserEmail": user.Email,
+		"Version":          s.version,
+		"GitSha":           s.gitSha,
+		"HeldPosts":        posts,
+		"ReportedPosts":    groupReports(reports, s.images),
+	})
+}
+
+// moderatePost acts on a post from the moderation queue.
+//
+// Held posts are approved or rejected. Approved posts are shown to everyone
+// and bump their thread; rejected posts stay hidden, from their author too,
+// and are flagged deleted.
+//
+// Reported posts are dismissed, hidden, deleted, have their thread locked or
+// their author blocked. The action resolves every open report on the post
+// and is recorded on them along with the admin who took it.
+func (s *DiscussService) moderatePost(w http.ResponseWriter, r *http.Request, user User) {
+	if err := r.ParseForm(); err != nil {
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	postID, err := strconv.ParseInt(r.Form.Get("post_id"), 10, 64)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error parsing post ID", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	action := r.Form.Get("action")
+	switch action {
+	case "approve":
+		var rows int64
+		if rows, err = s.queries.ApproveHeldPost(r.Context(), postID); err == nil && rows == 0 {
+			// Not held, already moderated, or no such post
+			err = pgx.ErrNoRows
+		} else if err == nil {
+			s.recordAudit(r, user, auditEntry{
+				Action:     auditApprovePost,
+				TargetType: auditTargetPost,
+				TargetID:   postID,
+				Before:     auditFlag("held", true),
+				After:      auditFlag("held", false),
+			})
+		}
+	case "reject":
+		var rows int64
+		if rows, err = s.queries.RejectHeldPost(r.Context(), postID); err == nil && rows == 0 {
+			err = pgx.ErrNoRows
+		} else if err == nil {
+			s.recordAudit(r, user, auditEntry{
+				Action:     auditRejectPost,
+				TargetType: auditTargetPost,
+				TargetID:   postID,
+				Before:     auditFlag("deleted", false),
+				After:      auditFlag("deleted", true),
+			})
+		}
+	case reportDismiss, reportHide, reportDelete, reportLock, reportBlock:
+		err = s.resolveReports(r, user, postID, action)
+	default:
+		s.logger.ErrorContext(r.Context(), "unknown action", slog.String("action", action))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		s.renderError(w, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error moderating post",
+			slog.String("action", action),
+			slog.Int64("post_id", postID),
+			slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "post moderated", slog.String("action", action), slog.Int64("post_id", postID))
+
+	// nosemgrep
+	http.Redirect(w, r, "/admin/moderation", http.StatusSeeOther)
+}
+
+// resolveReports applies an admin's resolution to a reported post and closes
+// its open reports. Both happen in one transaction, so a report is never
+// closed without its action taken or the other way around.
+func (s *DiscussService) resolveReports(r *http.Request, user User, postID int64, resolution string) error {
+	post, err := s.queries.GetThreadPost(r.Context(), postID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.dbconn.Begin(r.Context())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(r.Context())
+
+	qtx := s.queries.(ExtendedQuerier).WithTx(tx)
+
+	entry := auditEntry{
+		Action:     auditDismissReports,
+		TargetType: auditTargetPost,
+		TargetID:   postID,
+	}
+
+	switch resolution {
+	case reportHide:
+		err = qtx.HideThreadPost(r.Context(), postID)
+		entry.Action = auditHidePost
+		entry.Before = auditFlag("hidden", post.Hidden)
+		entry.After = auditFlag("hidden", true)
+	case reportDelete:
+		err = qtx.DeleteThreadPost(r.Context(), postID)
+		entry.Action = auditDeletePost
+		entry.Before = auditFlag("deleted", post.Deleted)
+		entry.After = auditFlag("deleted", true)
+	case reportLock:
+		var locked bool
+		if locked, err = qtx.IsThreadLocked(r.Context(), post.ThreadID); err == nil {
+			err = qtx.LockThread(r.Context(), post.ThreadID)
+		}
+		entry.Action = auditLockThread
+		entry.TargetType = auditTargetThread
+		entry.TargetID = post.ThreadID
+		entry.Before = auditFlag("locked", locked)
+		entry.After = auditFlag("locked", true)
+	case reportBlock:
+		err = qtx.BlockMember(r.Context(), post.MemberID)
+		entry.Action = auditBlockMember
+		entry.TargetType = auditTargetMember
+		entry.TargetID = post.MemberID
+		entry.After = auditFlag("is_blocked", true)
+	}
+	if err != nil {
+		return err
+	}
+
+	resolved, err := qtx.ResolveReports(r.Context(), ResolveReportsParams{
+		ThreadPostID: postID,
+		Resolution:   pgtype.Text{String: resolution, Valid: true},
+		ResolvedBy:   pgtype.Int8{Int64: user.ID, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		return err
+	}
+	s.recordAudit(r, user, entry)
+
+	s.logger.InfoContext(r.Context(), "reports resolved",
+		slog.Int64("post_id", postID),
+		slog.String("resolution", resolution),
+		slog.Int64("reports", resolved),
+		slog.Int64("admin_id", user.ID))
+	return nil
+}
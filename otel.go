@@ -7,6 +7,7 @@ import (
 	"sync/atomic"
 
 	"go.opentelemetry.io/contrib/bridges/otelslog"
+	otelruntime "go.opentelemetry.io/contrib/instrumentation/runtime"
 	"go.opentelemetry.io/contrib/processors/minsev"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -109,6 +110,11 @@ func setupTelemetry(ctx context.Context, config *Config) (*TelemetryConfig, func
 	otel.SetMeterProvider(meterProvider)
 	telemetryConfig.Meter = meterProvider.Meter(config.ServiceName)
 
+	// Go runtime metrics: goroutines, heap, GC pauses and scheduler latency
+	if err := otelruntime.Start(otelruntime.WithMeterProvider(meterProvider)); err != nil {
+		return nil, nil, fmt.Errorf("failed to start runtime metrics: %w", err)
+	}
+
 	// Configure OTLP log handler
 	logExporter, err := otlploghttp.New(ctx,
 		otlploghttp.WithCompression(otlploghttp.GzipCompression),
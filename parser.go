@@ -7,29 +7,54 @@ import (
 	"regexp"
 	"strconv"
 
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/yuin/goldmark"
 	emoji "github.com/yuin/goldmark-emoji"
+	"github.com/yuin/goldmark-emoji/definition"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
 	"github.com/yuin/goldmark/extension"
 	gmhtml "github.com/yuin/goldmark/renderer/html"
 )
 
+// emojiDefinitions is the set of :shortcodes: rendered in posts. Post
+// reactions are drawn from the same set.
+var emojiDefinitions = definition.Github()
+
+// parseMarkdownToHTML renders Markdown, showing diagrams as their source.
 func parseMarkdownToHTML(text string) string {
+	return markdownToHTML(text, &diagramExtension{})
+}
+
+func markdownToHTML(text string, diagramExt *diagramExtension) string {
 	var buf bytes.Buffer
 
 	md := goldmark.New(
 		goldmark.WithExtensions(
-			emoji.Emoji,
+			emoji.New(emoji.WithEmojis(emojiDefinitions)),
 			// GFM extensions individually
 			extension.Strikethrough,
 			extension.Table,
 			extension.TaskList,
+			// ">>1234" links to post 1234
+			PostRefs,
+			// $TeX$ math as MathML and ```mermaid diagrams as SVG
+			Math,
+			diagramExt,
+			// Fenced code blocks with a language are highlighted with
+			// classes, not inline styles, so the CSP can stay strict. The
+			// colours come from the theme in style.css.
+			highlighting.NewHighlighting(
+				highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+			),
 			// Linkify URLs but not email addresses.
 			// Note: passing nil uses goldmark's default email finder, so we use
 			// a regex that only matches empty strings to effectively disable it.
 			extension.NewLinkify(
 				extension.WithLinkifyEmailRegexp(regexp.MustCompile(`^$`)),
 			),
+			// HTML in the post, without any SVG or MathML
+			RawHTML,
 		),
 		goldmark.WithRendererOptions(
 			gmhtml.WithUnsafe(),
@@ -43,6 +68,20 @@ func parseMarkdownToHTML(text string) string {
 	return buf.String()
 }
 
+// renderPostBody turns a post's sanitized Markdown into the HTML that's
+// stored and shown, with its diagrams rendered by diagrams. Posting and
+// editing go through it.
+func renderPostBody(diagrams *Diagrammer, text string) string {
+	return parseHTMLLessStrict(markdownToHTML(text, &diagramExtension{diagrams: diagrams}))
+}
+
+// renderPreviewBody is renderPostBody for a preview, which shows the source
+// of diagrams that aren't rendered yet rather than starting a browser for
+// each as the post is typed.
+func renderPreviewBody(diagrams *Diagrammer, text string) string {
+	return parseHTMLLessStrict(markdownToHTML(text, &diagramExtension{diagrams: diagrams, preview: true}))
+}
+
 func parseHTMLStrict(text string) string {
 	strict := bluemonday.StrictPolicy()
 
@@ -55,6 +94,45 @@ func parseHTMLLessStrict(text string) string {
 	return bodyPolicy().Sanitize(text)
 }
 
+// chromaTokenClass matches the classes chroma puts on the lines and tokens
+// of highlighted code, e.g. "kd" for a declaration keyword.
+var chromaTokenClass = regexp.MustCompile(`^(line|cl|[a-z][a-z0-9]{0,2})$`)
+
+// mathMLElements are the MathML elements math is rendered with. Elements that
+// can hold HTML or links, like <annotation-xml> and <maction>, are left out.
+var mathMLElements = []string{
+	"math", "semantics", "annotation", "mrow", "mi", "mn", "mo", "ms", "mtext", "mspace",
+	"mfrac", "msqrt", "mroot", "msub", "msup", "msubsup", "munder", "mover", "munderover",
+	"mmultiscripts", "mprescripts", "none", "mtable", "mtr", "mtd", "mstyle", "mpadded",
+	"mphantom", "menclose", "merror",
+}
+
+var mathMLAttributes = []string{
+	"mathvariant", "displaystyle", "scriptlevel", "stretchy", "fence", "separator",
+	"largeop", "movablelimits", "accent", "accentunder", "form", "lspace", "rspace",
+	"minsize", "maxsize", "symmetric", "linethickness", "notation", "width", "height",
+	"depth", "voffset", "columnalign", "rowalign", "columnspacing", "rowspacing",
+	"columnlines", "rowlines", "frame",
+}
+
+// svgElements are the SVG elements diagrams are drawn with.
+var svgElements = []string{
+	"svg", "g", "defs", "marker", "path", "rect", "circle", "ellipse", "line",
+	"polyline", "polygon", "text", "tspan",
+}
+
+var svgAttributes = []string{
+	"viewbox", "preserveaspectratio", "width", "height", "x", "y", "x1", "y1", "x2", "y2",
+	"cx", "cy", "r", "rx", "ry", "d", "points", "transform", "dx", "dy", "text-anchor",
+	"dominant-baseline", "alignment-baseline", "markerwidth", "markerheight",
+	"markerunits", "refx", "refy", "orient",
+}
+
+// presentationValue matches the values of the MathML and SVG attributes
+// above: keywords, lengths, coordinates and transforms, but not URLs or
+// anything quoted.
+var presentationValue = regexp.MustCompile(`^[\w\s.,()%+-]*$`)
+
 // bodyPolicy returns a bluemonday policy for thread/post bodies.
 // Based on UGCPolicy but excludes headings (h1-h6) to prevent
 // users from dominating the page with large headers.
@@ -75,16 +153,41 @@ func bodyPolicy() *bluemonday.Policy {
 	p.AllowElements("code")
 	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w-]+$`)).OnElements("code")
 
+	// Highlighted code: chroma's wrapper and token classes
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^chroma$`)).OnElements("pre")
+	p.AllowAttrs("class").Matching(chromaTokenClass).OnElements("span")
+
+	// Math, as the MathML it's rendered to
+	p.AllowElements(mathMLElements...)
+	p.AllowNoAttrs().OnElements(mathMLElements...)
+	p.AllowAttrs("display").Matching(regexp.MustCompile(`^(block|inline)$`)).OnElements("math")
+	p.AllowAttrs("encoding").Matching(regexp.MustCompile(`^application/x-tex$`)).OnElements("annotation")
+	p.AllowAttrs(mathMLAttributes...).Matching(presentationValue).OnElements(mathMLElements...)
+
+	// Diagrams, as the SVG they're rendered to: shapes and text only, with
+	// no styles, links or embedded content. IDs are scoped to the diagram
+	// so they can't clash with the page's.
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^diagram$`)).OnElements("div")
+	p.AllowElements(svgElements...)
+	p.AllowNoAttrs().OnElements(svgElements...)
+	p.SkipElementsContent("annotation-xml", "foreignobject")
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^diagram-[0-9a-f]{12}[\w-]*$`)).OnElements(svgElements...)
+	p.AllowAttrs("marker-start", "marker-end").Matching(regexp.MustCompile(`^url\(#diagram-[0-9a-f]{12}[\w-]*\)$`)).OnElements(svgElements...)
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^[\w -]*$`)).OnElements(svgElements...)
+	p.AllowAttrs(svgAttributes...).Matching(presentationValue).OnElements(svgElements...)
+
 	// Links
 	p.AllowAttrs("href").OnElements("a")
 	p.AllowAttrs("title").OnElements("a")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^post-ref$`)).OnElements("a")
 	p.AllowRelativeURLs(true)
 	p.RequireNoFollowOnLinks(true)
 	p.RequireNoReferrerOnLinks(true)
 	p.AddTargetBlankToFullyQualifiedLinks(true)
 
-	// Images
+	// Images, with those on other hosts served through the image proxy
 	p.AllowImages()
+	p.RewriteSrc(proxyImageURL)
 
 	// Task lists (GFM)
 	p.AllowAttrs("type", "disabled", "checked").OnElements("input")
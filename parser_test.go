@@ -217,7 +217,7 @@ func TestParseMarkdownToHTML(t *testing.T) {
 		{
 			name:     "Code Blocks",
 			input:    "```go\nfunc main() {\n\tfmt.Println(\"Hello, World!\")\n}\n```",
-			expected: "<pre><code class=\"language-go\">func main() {\n\tfmt.Println(&quot;Hello, World!&quot;)\n}\n</code></pre>\n",
+			expected: "<pre class=\"chroma\"><code><span class=\"line\"><span class=\"cl\"><span class=\"kd\">func</span><span class=\"w\"> </span><span class=\"nf\">main</span><span class=\"p\">()</span><span class=\"w\"> </span><span class=\"p\">{</span><span class=\"w\">\n</span></span></span><span class=\"line\"><span class=\"cl\"><span class=\"w\">\t</span><span class=\"nx\">fmt</span><span class=\"p\">.</span><span class=\"nf\">Println</span><span class=\"p\">(</span><span class=\"s\">&#34;Hello, World!&#34;</span><span class=\"p\">)</span><span class=\"w\">\n</span></span></span><span class=\"line\"><span class=\"cl\"><span class=\"p\">}</span><span class=\"w\">\n</span></span></span></code></pre>",
 		},
 		{
 			name:     "Lists",
@@ -277,3 +277,134 @@ func TestParseMarkdownToHTMLError(t *testing.T) {
 		t.Errorf("parseMarkdownToHTML failed to handle large input")
 	}
 }
+
+func TestPostReferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Reference at line start",
+			input:    ">>12 good point",
+			expected: "<p><a href=\"/post/12\" class=\"post-ref\" rel=\"nofollow noreferrer\">&gt;&gt;12</a> good point</p>\n",
+		},
+		{
+			name:     "Reference mid sentence",
+			input:    "see >>34.",
+			expected: "<p>see <a href=\"/post/34\" class=\"post-ref\" rel=\"nofollow noreferrer\">&gt;&gt;34</a>.</p>\n",
+		},
+		{
+			name:     "Reference inside a quote",
+			input:    "> >>56 said",
+			expected: "<blockquote>\n<p><a href=\"/post/56\" class=\"post-ref\" rel=\"nofollow noreferrer\">&gt;&gt;56</a> said</p>\n</blockquote>\n",
+		},
+		{
+			name:     "Reference after a paragraph line",
+			input:    "hello\n>>78",
+			expected: "<p>hello</p>\n<p><a href=\"/post/78\" class=\"post-ref\" rel=\"nofollow noreferrer\">&gt;&gt;78</a></p>\n",
+		},
+		{
+			name:     "Not a reference inside a word",
+			input:    "a>>9",
+			expected: "<p>a&gt;&gt;9</p>\n",
+		},
+		{
+			name:     "Nested blockquotes still work",
+			input:    ">> nested",
+			expected: "<blockquote>\n<blockquote>\n<p>nested</p>\n</blockquote>\n</blockquote>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseHTMLLessStrict(parseMarkdownToHTML(tt.input))
+			if result != tt.expected {
+				t.Errorf("post reference %q = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderPostBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Markdown is rendered",
+			input:    "**bold** and ~~gone~~",
+			expected: "<p><strong>bold</strong> and <del>gone</del></p>\n",
+		},
+		{
+			name:     "Scripts are removed",
+			input:    "hi <script>alert('xss')</script>",
+			expected: "<p>hi </p>\n",
+		},
+		{
+			name:     "Empty post",
+			input:    "",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderPostBody(nil, tt.input)
+			if result != tt.expected {
+				t.Errorf("renderPostBody(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderPostBody_Highlighting(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:        "Fenced code with a language is highlighted with classes",
+			input:       "```go\nfunc main() {}\n```",
+			contains:    []string{`<pre class="chroma">`, `<span class="kd">func</span>`},
+			notContains: []string{"style="},
+		},
+		{
+			name:        "Highlighted code is still escaped",
+			input:       "```html\n<script>alert('xss')</script>\n```",
+			contains:    []string{"&lt;"},
+			notContains: []string{"<script>"},
+		},
+		{
+			name:        "Fenced code without a language is left alone",
+			input:       "```\nplain\n```",
+			contains:    []string{"<pre><code>plain\n</code></pre>"},
+			notContains: []string{"chroma"},
+		},
+		{
+			name:        "Only chroma's classes survive on spans",
+			input:       `<span class="kd">a</span><span class="evil-class">b</span>`,
+			contains:    []string{`<span class="kd">a</span>`, `<span>b</span>`},
+			notContains: []string{"evil-class"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderPostBody(nil, tt.input)
+			for _, want := range tt.contains {
+				if !strings.Contains(result, want) {
+					t.Errorf("renderPostBody(%q) = %q, want it to contain %q", tt.input, result, want)
+				}
+			}
+			for _, unwanted := range tt.notContains {
+				if strings.Contains(result, unwanted) {
+					t.Errorf("renderPostBody(%q) = %q, want it not to contain %q", tt.input, result, unwanted)
+				}
+			}
+		})
+	}
+}
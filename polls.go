@@ -0,0 +1,288 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PollTemplateData is a thread's poll as shown above its first post.
+type PollTemplateData struct {
+	ID         int64
+	ThreadID   int64
+	Question   string
+	Multiple   bool
+	Anonymous  bool
+	DateCloses pgtype.Timestamptz
+	Closed     bool
+	// Voted is set once the viewer has cast their ballot
+	Voted   bool
+	Total   int64
+	Options []PollOptionTemplateData
+}
+
+// PollOptionTemplateData is one option of a poll with its tally.
+type PollOptionTemplateData struct {
+	ID      int64
+	Label   string
+	Votes   int64
+	Percent int64
+	// Chosen is set when the viewer voted for the option, which can't be
+	// known in anonymous polls
+	Chosen bool
+	// Voters are who chose the option, left empty in anonymous polls
+	Voters []Identity
+}
+
+// buildPollTemplateData merges a poll with its options' tallies and, unless
+// the poll is anonymous, who voted for each.
+func buildPollTemplateData(poll Poll, options []ListPollOptionsRow, voters []ListPollVotersRow, voted bool, now time.Time, ids identities) *PollTemplateData {
+	data := &PollTemplateData{
+		ID:         poll.ID,
+		ThreadID:   poll.ThreadID,
+		Question:   poll.Question,
+		Multiple:   poll.Multiple,
+		Anonymous:  poll.Anonymous,
+		DateCloses: poll.DateCloses,
+		Closed:     poll.DateCloses.Valid && !now.Before(poll.DateCloses.Time),
+		Voted:      voted,
+	}
+
+	for _, option := range options {
+		data.Total += option.Votes
+	}
+
+	data.Options = make([]PollOptionTemplateData, 0, len(options))
+	for _, option := range options {
+		o := PollOptionTemplateData{
+			ID:     option.ID,
+			Label:  option.Label,
+			Votes:  option.Votes,
+			Chosen: option.Chosen,
+		}
+		if data.Total > 0 {
+			o.Percent = option.Votes * 100 / data.Total
+		}
+		if !poll.Anonymous {
+			for _, voter := range voters {
+				if voter.PollOptionID == option.ID {
+					o.Voters = append(o.Voters, ids.identify(voter.ID, pgtype.Text{String: voter.Email, Valid: true}, voter.PreferredName, voter.PhotoUrl))
+				}
+			}
+		}
+		data.Options = append(data.Options, o)
+	}
+
+	return data
+}
+
+// threadPoll loads the poll started with a thread, as seen by the member
+// making the request, with voters named by ids. It returns nil if the
+// thread has no poll.
+func (s *DiscussService) threadPoll(r *http.Request, threadID int64, user User, ids identities) (*PollTemplateData, error) {
+	poll, err := s.queries.GetThreadPoll(r.Context(), threadID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	options, err := s.queries.ListPollOptions(r.Context(), ListPollOptionsParams{
+		MemberID: pgtype.Int8{Int64: user.ID, Valid: true},
+		PollID:   poll.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	voted, err := s.queries.HasVotedInPoll(r.Context(), HasVotedInPollParams{PollID: poll.ID, MemberID: user.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	var voters []ListPollVotersRow
+	if !poll.Anonymous {
+		if voters, err = s.queries.ListPollVoters(r.Context(), poll.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return buildPollTemplateData(poll, options, voters, voted, time.Now(), ids), nil
+}
+
+// ThreadPoll serves a thread's poll on its own, so the page can refresh the
+// tallies while it's open.
+func (s *DiscussService) ThreadPoll(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "ThreadPoll")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	threadID, err := strconv.ParseInt(r.PathValue("tid"), 10, 64)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error parsing thread ID", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	user, err := GetUser(r)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "GetUser", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	span.AddEvent("queries.GetThreadBoard")
+	board, err := s.queries.GetThreadBoard(r.Context(), threadID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "error getting thread board", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	span.AddEvent("threadPoll")
+	poll, err := s.threadPoll(r, threadID, user, s.boardIdentities(r, board.HideEmails))
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error getting thread poll", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+	if poll == nil {
+		s.renderError(w, http.StatusNotFound)
+		return
+	}
+
+	s.renderTemplate(w, r, "poll", map[string]interface{}{
+		"Poll": poll,
+	})
+}
+
+// VoteInPoll casts the current member's ballot in a thread's poll. Each
+// member votes once; in anonymous polls the ballot is recorded apart from
+// the options chosen.
+func (s *DiscussService) VoteInPoll(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "VoteInPoll")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	threadID, err := strconv.ParseInt(r.PathValue("tid"), 10, 64)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error parsing thread ID", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	user, err := GetUser(r)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "GetUser", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	span.AddEvent("queries.GetThreadPoll")
+	poll, err := s.queries.GetThreadPoll(r.Context(), threadID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "GetThreadPoll", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if poll.DateCloses.Valid && !time.Now().Before(poll.DateCloses.Time) {
+		http.Error(w, "this poll has closed", http.StatusConflict)
+		return
+	}
+
+	span.AddEvent("queries.ListPollOptions")
+	options, err := s.queries.ListPollOptions(r.Context(), ListPollOptionsParams{
+		MemberID: pgtype.Int8{Int64: user.ID, Valid: true},
+		PollID:   poll.ID,
+	})
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "ListPollOptions", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	var chosen []int64
+	for _, value := range r.Form["option"] {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || !slices.ContainsFunc(options, func(o ListPollOptionsRow) bool { return o.ID == id }) {
+			http.Error(w, "option: unknown poll option", http.StatusBadRequest)
+			return
+		}
+		if !slices.Contains(chosen, id) {
+			chosen = append(chosen, id)
+		}
+	}
+	if len(chosen) == 0 || (!poll.Multiple && len(chosen) > 1) {
+		http.Error(w, "option: choose one option", http.StatusBadRequest)
+		return
+	}
+
+	span.AddEvent("BeginTxn")
+	tx, err := s.dbconn.Begin(r.Context())
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "error starting transaction", slog.String("SQLError", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	qtx := s.queries.(ExtendedQuerier).WithTx(tx)
+
+	// The ballot's primary key is what stops a member voting twice, even
+	// from two requests at once
+	span.AddEvent("qtx.CreatePollBallot")
+	cast, err := qtx.CreatePollBallot(r.Context(), CreatePollBallotParams{PollID: poll.ID, MemberID: user.ID})
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "CreatePollBallot", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+	if cast == 0 {
+		http.Error(w, "you've already voted in this poll", http.StatusConflict)
+		return
+	}
+
+	span.AddEvent("qtx.CreatePollVotes")
+	if err := qtx.CreatePollVotes(r.Context(), CreatePollVotesParams{
+		MemberID:  pgtype.Int8{Int64: user.ID, Valid: !poll.Anonymous},
+		PollID:    poll.ID,
+		OptionIds: chosen,
+	}); err != nil {
+		s.logger.ErrorContext(r.Context(), "CreatePollVotes", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		s.logger.ErrorContext(r.Context(), "error committing transaction", slog.String("SQLError", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	// nosemgrep
+	http.Redirect(w, r, fmt.Sprintf("/thread/%d#poll", threadID), http.StatusSeeOther)
+}
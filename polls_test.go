@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPollTemplateData(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	options := []ListPollOptionsRow{
+		{ID: 1, Label: "Yes", Votes: 3, Chosen: true},
+		{ID: 2, Label: "No", Votes: 1},
+		{ID: 3, Label: "Maybe"},
+	}
+	voters := []ListPollVotersRow{
+		{PollOptionID: 1, ID: 10, Email: "alice@example.com"},
+		{PollOptionID: 1, ID: 11, Email: "bob@example.com", PreferredName: pgtype.Text{String: "Bob", Valid: true}},
+		{PollOptionID: 2, ID: 12, Email: "carol@example.com"},
+	}
+
+	t.Run("tallies and voters", func(t *testing.T) {
+		poll := buildPollTemplateData(Poll{ID: 5, ThreadID: 7, Question: "Ship it?"}, options, voters, true, now, identities{})
+
+		assert.Equal(t, int64(4), poll.Total)
+		assert.True(t, poll.Voted)
+		assert.False(t, poll.Closed)
+		require.Len(t, poll.Options, 3)
+		assert.Equal(t, int64(75), poll.Options[0].Percent)
+		assert.Equal(t, int64(25), poll.Options[1].Percent)
+		assert.Equal(t, int64(0), poll.Options[2].Percent)
+		assert.True(t, poll.Options[0].Chosen)
+		require.Len(t, poll.Options[0].Voters, 2)
+		assert.Equal(t, "Bob", poll.Options[0].Voters[1].Name)
+		assert.Len(t, poll.Options[1].Voters, 1)
+		assert.Empty(t, poll.Options[2].Voters)
+	})
+
+	t.Run("anonymous polls name no one", func(t *testing.T) {
+		poll := buildPollTemplateData(Poll{ID: 5, Anonymous: true}, options, voters, false, now, identities{})
+
+		for _, option := range poll.Options {
+			assert.Empty(t, option.Voters)
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		closes := pgtype.Timestamptz{Time: now.Add(-time.Minute), Valid: true}
+		poll := buildPollTemplateData(Poll{ID: 5, DateCloses: closes}, nil, nil, false, now, identities{})
+		assert.True(t, poll.Closed)
+
+		closes.Time = now.Add(time.Minute)
+		poll = buildPollTemplateData(Poll{ID: 5, DateCloses: closes}, nil, nil, false, now, identities{})
+		assert.False(t, poll.Closed)
+	})
+}
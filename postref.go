@@ -0,0 +1,87 @@
+package main
+
+import (
+	"regexp"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Post references use the ">>1234" shorthand and link to /post/1234, which
+// redirects to the post wherever it lives on the board.
+var (
+	postRefPattern     = regexp.MustCompile(`^>>([0-9]+)\b`)
+	postRefLinePattern = regexp.MustCompile(`^ {0,3}>>[0-9]+\b`)
+)
+
+// postRefInlineParser turns ">>1234" into a link to the referenced post.
+type postRefInlineParser struct{}
+
+func (p *postRefInlineParser) Trigger() []byte {
+	return []byte{'>'}
+}
+
+func (p *postRefInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	// Only match at the start of a word so "a>>1" and ">>>1" are left alone
+	if prev := block.PrecendingCharacter(); util.IsAlphaNumeric(byte(prev)) || prev == '>' {
+		return nil
+	}
+
+	line, segment := block.PeekLine()
+	m := postRefPattern.FindSubmatchIndex(line)
+	if m == nil {
+		return nil
+	}
+
+	link := ast.NewLink()
+	link.Destination = append([]byte("/post/"), line[m[2]:m[3]]...)
+	link.SetAttributeString("class", []byte("post-ref"))
+	link.AppendChild(link, ast.NewTextSegment(text.NewSegment(segment.Start, segment.Start+m[1])))
+	block.Advance(m[1])
+
+	return link
+}
+
+// postRefParagraphParser claims lines starting with ">>1234" as paragraphs
+// before the blockquote parser can read them as a nested quote.
+type postRefParagraphParser struct {
+	parser.BlockParser
+}
+
+func (b *postRefParagraphParser) Trigger() []byte {
+	return []byte{'>'}
+}
+
+func (b *postRefParagraphParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, _ := reader.PeekLine()
+	if !postRefLinePattern.Match(line) {
+		return nil, parser.NoChildren
+	}
+	return b.BlockParser.Open(parent, reader, pc)
+}
+
+// CanInterruptParagraph is true so a reference on the line after a paragraph
+// starts a new paragraph instead of a blockquote.
+func (b *postRefParagraphParser) CanInterruptParagraph() bool {
+	return true
+}
+
+type postRefs struct{}
+
+// PostRefs is a goldmark extension for ">>1234" post references.
+var PostRefs = &postRefs{}
+
+func (e *postRefs) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		// Blockquotes are registered at priority 800; run just ahead of them
+		parser.WithBlockParsers(
+			util.Prioritized(&postRefParagraphParser{BlockParser: parser.NewParagraphParser()}, 799),
+		),
+		parser.WithInlineParsers(
+			util.Prioritized(&postRefInlineParser{}, 500),
+		),
+	)
+}
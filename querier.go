@@ -11,26 +11,91 @@ import (
 )
 
 type Querier interface {
+	AnyBoardHidesEmails(ctx context.Context) (bool, error)
+	ApproveHeldPost(ctx context.Context, id int64) (int64, error)
 	BlockMember(ctx context.Context, id int64) error
+	CanSeeAttachment(ctx context.Context, arg CanSeeAttachmentParams) (bool, error)
+	CreateAttachment(ctx context.Context, arg CreateAttachmentParams) error
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error
+	CreateBoard(ctx context.Context, arg CreateBoardParams) (int32, error)
 	CreateOrReturnID(ctx context.Context, pEmail string) (CreateOrReturnIDRow, error)
+	CreatePoll(ctx context.Context, arg CreatePollParams) (int64, error)
+	CreatePollBallot(ctx context.Context, arg CreatePollBallotParams) (int64, error)
+	CreatePollOptions(ctx context.Context, arg CreatePollOptionsParams) error
+	CreatePollVotes(ctx context.Context, arg CreatePollVotesParams) error
+	CreatePostReaction(ctx context.Context, arg CreatePostReactionParams) error
+	CreateReport(ctx context.Context, arg CreateReportParams) error
+	CreateTags(ctx context.Context, names []string) error
 	CreateThread(ctx context.Context, arg CreateThreadParams) error
 	CreateThreadPost(ctx context.Context, arg CreateThreadPostParams) error
-	GetBoardData(ctx context.Context) (GetBoardDataRow, error)
+	CreateThreadPostRevision(ctx context.Context, arg CreateThreadPostRevisionParams) error
+	DeleteDraft(ctx context.Context, arg DeleteDraftParams) error
+	DeleteExpiredDrafts(ctx context.Context, dateSaved pgtype.Timestamptz) (int64, error)
+	DeleteExpiredRateLimitWindows(ctx context.Context, windowStart pgtype.Timestamptz) (int64, error)
+	DeletePostReaction(ctx context.Context, arg DeletePostReactionParams) (int64, error)
+	DeleteTag(ctx context.Context, id int32) error
+	DeleteThreadPost(ctx context.Context, id int64) error
+	GetAttachment(ctx context.Context, hash string) (Attachment, error)
+	GetBoardAcl(ctx context.Context, id int32) ([]string, error)
+	GetBoardData(ctx context.Context, slug pgtype.Text) (GetBoardDataRow, error)
+	GetDraft(ctx context.Context, arg GetDraftParams) (Draft, error)
+	GetLinkPreview(ctx context.Context, url string) (LinkPreview, error)
 	GetMember(ctx context.Context, id int64) (GetMemberRow, error)
 	GetMemberId(ctx context.Context, email string) (int64, error)
+	GetMemberPostingActivity(ctx context.Context, arg GetMemberPostingActivityParams) (GetMemberPostingActivityRow, error)
+	GetMemberTimePreferences(ctx context.Context, memberID int64) (GetMemberTimePreferencesRow, error)
+	GetSchemaVersion(ctx context.Context) (int32, error)
+	GetTag(ctx context.Context, id int32) (Tag, error)
+	GetThreadBoard(ctx context.Context, id int64) (GetThreadBoardRow, error)
 	GetThreadForEdit(ctx context.Context, arg GetThreadForEditParams) (GetThreadForEditRow, error)
+	GetThreadPoll(ctx context.Context, threadID int64) (Poll, error)
+	GetThreadPost(ctx context.Context, id int64) (GetThreadPostRow, error)
+	GetThreadPostBoard(ctx context.Context, id int64) (GetThreadPostBoardRow, error)
 	GetThreadPostForEdit(ctx context.Context, arg GetThreadPostForEditParams) (GetThreadPostForEditRow, error)
 	GetThreadPostSequenceId(ctx context.Context) (int64, error)
 	GetThreadSequenceId(ctx context.Context) (int64, error)
 	GetThreadSubjectById(ctx context.Context, id int64) (string, error)
-	ListMemberThreads(ctx context.Context, memberID int64) ([]ListMemberThreadsRow, error)
+	HasVotedInPoll(ctx context.Context, arg HasVotedInPollParams) (bool, error)
+	HideThreadPost(ctx context.Context, id int64) error
+	HitRateLimitWindow(ctx context.Context, arg HitRateLimitWindowParams) (HitRateLimitWindowRow, error)
+	IsThreadLocked(ctx context.Context, id int64) (bool, error)
+	LinkThreadPostAttachments(ctx context.Context, arg LinkThreadPostAttachmentsParams) error
+	ListAuditLog(ctx context.Context, arg ListAuditLogParams) ([]ListAuditLogRow, error)
+	ListBoards(ctx context.Context) ([]ListBoardsRow, error)
+	ListHeldPosts(ctx context.Context) ([]ListHeldPostsRow, error)
+	ListMemberDrafts(ctx context.Context, arg ListMemberDraftsParams) ([]ListMemberDraftsRow, error)
+	ListMemberThreads(ctx context.Context, arg ListMemberThreadsParams) ([]ListMemberThreadsRow, error)
+	ListOpenReports(ctx context.Context) ([]ListOpenReportsRow, error)
+	ListPollOptions(ctx context.Context, arg ListPollOptionsParams) ([]ListPollOptionsRow, error)
+	ListPollVoters(ctx context.Context, pollID int64) ([]ListPollVotersRow, error)
+	ListPostsToUnfurl(ctx context.Context, limit int32) ([]ListPostsToUnfurlRow, error)
+	ListRateLimits(ctx context.Context) ([]RateLimit, error)
+	ListTags(ctx context.Context) ([]ListTagsRow, error)
+	ListThreadLinkPreviews(ctx context.Context, threadID int64) ([]ListThreadLinkPreviewsRow, error)
+	ListThreadPostRevisions(ctx context.Context, threadPostID int64) ([]ListThreadPostRevisionsRow, error)
 	ListThreadPosts(ctx context.Context, arg ListThreadPostsParams) ([]ListThreadPostsRow, error)
 	ListThreads(ctx context.Context, arg ListThreadsParams) ([]ListThreadsRow, error)
-	UpdateBoardEditWindow(ctx context.Context, editWindow pgtype.Int4) error
-	UpdateBoardTitle(ctx context.Context, title string) error
+	LockThread(ctx context.Context, id int64) error
+	MarkThreadPostUnfurled(ctx context.Context, arg MarkThreadPostUnfurledParams) error
+	MergeThreadTags(ctx context.Context, arg MergeThreadTagsParams) error
+	RejectHeldPost(ctx context.Context, id int64) (int64, error)
+	RenameTag(ctx context.Context, arg RenameTagParams) error
+	ResolveReports(ctx context.Context, arg ResolveReportsParams) (int64, error)
+	SaveDraft(ctx context.Context, arg SaveDraftParams) error
+	SetThreadPostLinks(ctx context.Context, arg SetThreadPostLinksParams) error
+	SetThreadTags(ctx context.Context, arg SetThreadTagsParams) error
+	SyncMemberTailscaleProfile(ctx context.Context, arg SyncMemberTailscaleProfileParams) error
+	UpdateBoardAcl(ctx context.Context, arg UpdateBoardAclParams) error
+	UpdateBoardDescription(ctx context.Context, arg UpdateBoardDescriptionParams) error
+	UpdateBoardEditWindow(ctx context.Context, arg UpdateBoardEditWindowParams) error
+	UpdateBoardHideEmails(ctx context.Context, arg UpdateBoardHideEmailsParams) error
+	UpdateBoardReactionEmoji(ctx context.Context, arg UpdateBoardReactionEmojiParams) error
+	UpdateBoardTitle(ctx context.Context, arg UpdateBoardTitleParams) error
 	UpdateMemberProfileByID(ctx context.Context, arg UpdateMemberProfileByIDParams) error
 	UpdateThread(ctx context.Context, arg UpdateThreadParams) error
 	UpdateThreadPost(ctx context.Context, arg UpdateThreadPostParams) error
+	UpsertLinkPreview(ctx context.Context, arg UpsertLinkPreviewParams) error
+	UpsertRateLimit(ctx context.Context, arg UpsertRateLimitParams) error
 }
 
 var _ Querier = (*Queries)(nil)
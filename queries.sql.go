@@ -11,6 +11,42 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const anyBoardHidesEmails = `-- name: AnyBoardHidesEmails :one
+SELECT EXISTS (SELECT 1 FROM board_data WHERE hide_emails)::boolean AS hides
+`
+
+func (q *Queries) AnyBoardHidesEmails(ctx context.Context) (bool, error) {
+	row := q.db.QueryRow(ctx, anyBoardHidesEmails)
+	var hides bool
+	err := row.Scan(&hides)
+	return hides, err
+}
+
+const approveHeldPost = `-- name: ApproveHeldPost :execrows
+WITH approved AS (
+  UPDATE thread_post
+  SET held = false, held_reason = NULL
+  WHERE thread_post.id = $1 AND thread_post.held IS true AND thread_post.deleted IS false
+  RETURNING id, thread_id, member_id
+)
+UPDATE thread
+SET
+  held = (thread.held AND thread.first_post_id <> approved.id),
+  posts = thread.posts + 1,
+  last_member_id = approved.member_id,
+  date_last_posted = now()
+FROM approved
+WHERE thread.id = approved.thread_id
+`
+
+func (q *Queries) ApproveHeldPost(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, approveHeldPost, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const blockMember = `-- name: BlockMember :exec
 UPDATE member SET
   is_blocked = true
@@ -22,6 +58,128 @@ func (q *Queries) BlockMember(ctx context.Context, id int64) error {
 	return err
 }
 
+const canSeeAttachment = `-- name: CanSeeAttachment :one
+SELECT (
+  $1::bool
+  OR EXISTS (SELECT 1 FROM attachment_upload au WHERE au.hash = $2 AND au.member_id = $3)
+  OR EXISTS (
+    SELECT 1
+    FROM thread_post_attachment pa
+    JOIN thread_post tp ON tp.id=pa.thread_post_id
+    JOIN thread t ON t.id=tp.thread_id
+    JOIN board_data b ON b.id=t.board_id
+    WHERE pa.hash = $2
+    AND tp.deleted IS false
+    AND ((tp.held IS false AND tp.hidden IS false) OR tp.member_id = $3)
+    AND (cardinality(b.acl) = 0
+      OR EXISTS (SELECT 1 FROM unnest(b.acl) entry WHERE entry = ANY($4::text[]) OR lower(entry) = lower($5::text)))
+  )
+)::bool AS visible
+`
+
+type CanSeeAttachmentParams struct {
+	SeeAllBoards bool
+	Hash         string
+	MemberID     int64
+	Principals   []string
+	ViewerEmail  string
+}
+
+func (q *Queries) CanSeeAttachment(ctx context.Context, arg CanSeeAttachmentParams) (bool, error) {
+	row := q.db.QueryRow(ctx, canSeeAttachment,
+		arg.SeeAllBoards,
+		arg.Hash,
+		arg.MemberID,
+		arg.Principals,
+		arg.ViewerEmail,
+	)
+	var visible bool
+	err := row.Scan(&visible)
+	return visible, err
+}
+
+const createAttachment = `-- name: CreateAttachment :exec
+WITH stored AS (
+  INSERT INTO attachment (hash, content_type, size, filename, member_id)
+  VALUES ($1, $2, $3, $4, $5)
+  ON CONFLICT (hash) DO NOTHING
+)
+INSERT INTO attachment_upload (hash, member_id)
+VALUES ($1, $5)
+ON CONFLICT DO NOTHING
+`
+
+type CreateAttachmentParams struct {
+	Hash        string
+	ContentType string
+	Size        int64
+	Filename    string
+	MemberID    int64
+}
+
+func (q *Queries) CreateAttachment(ctx context.Context, arg CreateAttachmentParams) error {
+	_, err := q.db.Exec(ctx, createAttachment,
+		arg.Hash,
+		arg.ContentType,
+		arg.Size,
+		arg.Filename,
+		arg.MemberID,
+	)
+	return err
+}
+
+const createAuditLog = `-- name: CreateAuditLog :exec
+INSERT INTO audit_log (actor_id, action, target_type, target_id, before, after, request_id, trace_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type CreateAuditLogParams struct {
+	ActorID    int64
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     []byte
+	After      []byte
+	RequestID  string
+	TraceID    string
+}
+
+func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error {
+	_, err := q.db.Exec(ctx, createAuditLog,
+		arg.ActorID,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Before,
+		arg.After,
+		arg.RequestID,
+		arg.TraceID,
+	)
+	return err
+}
+
+const createBoard = `-- name: CreateBoard :one
+INSERT INTO board_data (slug, title, description, edit_window, total_members, reaction_emoji, hide_emails)
+SELECT $1, $2, $3, edit_window, total_members, reaction_emoji, hide_emails
+FROM board_data
+ORDER BY id
+LIMIT 1
+RETURNING id
+`
+
+type CreateBoardParams struct {
+	Slug        string
+	Title       string
+	Description string
+}
+
+func (q *Queries) CreateBoard(ctx context.Context, arg CreateBoardParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createBoard, arg.Slug, arg.Title, arg.Description)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
 const createOrReturnID = `-- name: CreateOrReturnID :one
 SELECT id::bigint, is_admin::boolean, is_blocked::boolean FROM createOrReturnID($1)
 `
@@ -39,70 +197,431 @@ func (q *Queries) CreateOrReturnID(ctx context.Context, pEmail string) (CreateOr
 	return i, err
 }
 
+const createPoll = `-- name: CreatePoll :one
+INSERT INTO poll (thread_id, question, multiple, anonymous, date_closes)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id
+`
+
+type CreatePollParams struct {
+	ThreadID   int64
+	Question   string
+	Multiple   bool
+	Anonymous  bool
+	DateCloses pgtype.Timestamptz
+}
+
+func (q *Queries) CreatePoll(ctx context.Context, arg CreatePollParams) (int64, error) {
+	row := q.db.QueryRow(ctx, createPoll,
+		arg.ThreadID,
+		arg.Question,
+		arg.Multiple,
+		arg.Anonymous,
+		arg.DateCloses,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createPollBallot = `-- name: CreatePollBallot :execrows
+INSERT INTO poll_ballot (poll_id, member_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type CreatePollBallotParams struct {
+	PollID   int64
+	MemberID int64
+}
+
+func (q *Queries) CreatePollBallot(ctx context.Context, arg CreatePollBallotParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createPollBallot, arg.PollID, arg.MemberID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createPollOptions = `-- name: CreatePollOptions :exec
+INSERT INTO poll_option (poll_id, position, label)
+SELECT $1, o.position, o.label
+FROM unnest($2::text[]) WITH ORDINALITY AS o(label, position)
+`
+
+type CreatePollOptionsParams struct {
+	PollID int64
+	Labels []string
+}
+
+func (q *Queries) CreatePollOptions(ctx context.Context, arg CreatePollOptionsParams) error {
+	_, err := q.db.Exec(ctx, createPollOptions, arg.PollID, arg.Labels)
+	return err
+}
+
+const createPollVotes = `-- name: CreatePollVotes :exec
+INSERT INTO poll_vote (poll_option_id, member_id)
+SELECT id, $1::bigint
+FROM poll_option
+WHERE poll_id = $2 AND id = ANY($3::bigint[])
+`
+
+type CreatePollVotesParams struct {
+	MemberID  pgtype.Int8
+	PollID    int64
+	OptionIds []int64
+}
+
+func (q *Queries) CreatePollVotes(ctx context.Context, arg CreatePollVotesParams) error {
+	_, err := q.db.Exec(ctx, createPollVotes, arg.MemberID, arg.PollID, arg.OptionIds)
+	return err
+}
+
+const createPostReaction = `-- name: CreatePostReaction :exec
+INSERT INTO post_reaction (thread_post_id, member_id, emoji)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING
+`
+
+type CreatePostReactionParams struct {
+	ThreadPostID int64
+	MemberID     int64
+	Emoji        string
+}
+
+func (q *Queries) CreatePostReaction(ctx context.Context, arg CreatePostReactionParams) error {
+	_, err := q.db.Exec(ctx, createPostReaction, arg.ThreadPostID, arg.MemberID, arg.Emoji)
+	return err
+}
+
+const createReport = `-- name: CreateReport :exec
+INSERT INTO report (thread_post_id, member_id, reason)
+VALUES ($1, $2, $3)
+ON CONFLICT (thread_post_id, member_id) WHERE resolution IS NULL DO NOTHING
+`
+
+type CreateReportParams struct {
+	ThreadPostID int64
+	MemberID     int64
+	Reason       string
+}
+
+func (q *Queries) CreateReport(ctx context.Context, arg CreateReportParams) error {
+	_, err := q.db.Exec(ctx, createReport, arg.ThreadPostID, arg.MemberID, arg.Reason)
+	return err
+}
+
+const createTags = `-- name: CreateTags :exec
+INSERT INTO tag (name)
+SELECT unnest($1::varchar[])
+ON CONFLICT (name) DO NOTHING
+`
+
+func (q *Queries) CreateTags(ctx context.Context, names []string) error {
+	_, err := q.db.Exec(ctx, createTags, names)
+	return err
+}
+
 const createThread = `-- name: CreateThread :exec
-INSERT INTO thread (subject,member_id,last_member_id) VALUES ($1,$2,$3)
+INSERT INTO thread (subject,member_id,last_member_id,held,board_id) VALUES ($1,$2,$3,$4,$5)
 `
 
 type CreateThreadParams struct {
 	Subject      string
 	MemberID     int64
 	LastMemberID int64
+	Held         bool
+	BoardID      int32
 }
 
 func (q *Queries) CreateThread(ctx context.Context, arg CreateThreadParams) error {
-	_, err := q.db.Exec(ctx, createThread, arg.Subject, arg.MemberID, arg.LastMemberID)
+	_, err := q.db.Exec(ctx, createThread,
+		arg.Subject,
+		arg.MemberID,
+		arg.LastMemberID,
+		arg.Held,
+		arg.BoardID,
+	)
 	return err
 }
 
 const createThreadPost = `-- name: CreateThreadPost :exec
-INSERT INTO
-  thread_post
-    (thread_id,body,member_id)
-  VALUES
-    ($1,$2,$3)
+WITH post AS (
+  INSERT INTO
+    thread_post
+      (thread_id,body,member_id,body_hash,held,held_reason)
+    VALUES
+      ($1,$2,$3,$4,$5,$6)
+  RETURNING id
+)
+INSERT INTO thread_post_attachment (thread_post_id, hash)
+SELECT post.id, a.hash FROM post, attachment a WHERE a.hash = ANY($7::varchar[])
 `
 
 type CreateThreadPostParams struct {
-	ThreadID int64
-	Body     pgtype.Text
-	MemberID int64
+	ThreadID    int64
+	Body        pgtype.Text
+	MemberID    int64
+	BodyHash    pgtype.Text
+	Held        bool
+	HeldReason  pgtype.Text
+	Attachments []string
 }
 
 func (q *Queries) CreateThreadPost(ctx context.Context, arg CreateThreadPostParams) error {
-	_, err := q.db.Exec(ctx, createThreadPost, arg.ThreadID, arg.Body, arg.MemberID)
+	_, err := q.db.Exec(ctx, createThreadPost,
+		arg.ThreadID,
+		arg.Body,
+		arg.MemberID,
+		arg.BodyHash,
+		arg.Held,
+		arg.HeldReason,
+		arg.Attachments,
+	)
+	return err
+}
+
+const createThreadPostRevision = `-- name: CreateThreadPostRevision :exec
+INSERT INTO thread_post_revision (thread_post_id, member_id, subject, body)
+SELECT tp.id, tp.member_id, t.subject, tp.body
+FROM thread_post tp
+LEFT JOIN thread t
+  ON t.id=tp.thread_id AND t.first_post_id=tp.id
+WHERE tp.id = $1
+  AND tp.member_id = $2
+  AND tp.date_posted >= NOW() - $3::int * INTERVAL '1 second'
+`
+
+type CreateThreadPostRevisionParams struct {
+	ID         int64
+	MemberID   int64
+	EditWindow int32
+}
+
+func (q *Queries) CreateThreadPostRevision(ctx context.Context, arg CreateThreadPostRevisionParams) error {
+	_, err := q.db.Exec(ctx, createThreadPostRevision, arg.ID, arg.MemberID, arg.EditWindow)
+	return err
+}
+
+const deleteDraft = `-- name: DeleteDraft :exec
+DELETE FROM draft
+WHERE member_id = $1
+  AND thread_id IS NOT DISTINCT FROM $2::bigint
+`
+
+type DeleteDraftParams struct {
+	MemberID int64
+	ThreadID pgtype.Int8
+}
+
+func (q *Queries) DeleteDraft(ctx context.Context, arg DeleteDraftParams) error {
+	_, err := q.db.Exec(ctx, deleteDraft, arg.MemberID, arg.ThreadID)
+	return err
+}
+
+const deleteExpiredDrafts = `-- name: DeleteExpiredDrafts :execrows
+DELETE FROM draft
+WHERE date_saved < $1
+`
+
+func (q *Queries) DeleteExpiredDrafts(ctx context.Context, dateSaved pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredDrafts, dateSaved)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredRateLimitWindows = `-- name: DeleteExpiredRateLimitWindows :execrows
+DELETE FROM rate_limit_window
+WHERE window_start < $1
+`
+
+func (q *Queries) DeleteExpiredRateLimitWindows(ctx context.Context, windowStart pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredRateLimitWindows, windowStart)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deletePostReaction = `-- name: DeletePostReaction :execrows
+DELETE FROM post_reaction
+WHERE thread_post_id = $1
+  AND member_id = $2
+  AND emoji = $3
+`
+
+type DeletePostReactionParams struct {
+	ThreadPostID int64
+	MemberID     int64
+	Emoji        string
+}
+
+func (q *Queries) DeletePostReaction(ctx context.Context, arg DeletePostReactionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePostReaction, arg.ThreadPostID, arg.MemberID, arg.Emoji)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteTag = `-- name: DeleteTag :exec
+DELETE FROM tag WHERE id=$1
+`
+
+func (q *Queries) DeleteTag(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, deleteTag, id)
+	return err
+}
+
+const deleteThreadPost = `-- name: DeleteThreadPost :exec
+WITH deleted_post AS (
+  UPDATE thread_post
+  SET deleted = true
+  WHERE thread_post.id = $1
+  RETURNING thread_post.id, thread_post.thread_id
+)
+UPDATE thread
+SET deleted = true
+FROM deleted_post
+WHERE thread.id = deleted_post.thread_id
+  AND thread.first_post_id = deleted_post.id
+`
+
+func (q *Queries) DeleteThreadPost(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, deleteThreadPost, id)
 	return err
 }
 
+const getAttachment = `-- name: GetAttachment :one
+SELECT hash, content_type, size, filename, member_id, date_uploaded
+FROM attachment
+WHERE hash = $1
+`
+
+func (q *Queries) GetAttachment(ctx context.Context, hash string) (Attachment, error) {
+	row := q.db.QueryRow(ctx, getAttachment, hash)
+	var i Attachment
+	err := row.Scan(
+		&i.Hash,
+		&i.ContentType,
+		&i.Size,
+		&i.Filename,
+		&i.MemberID,
+		&i.DateUploaded,
+	)
+	return i, err
+}
+
+const getBoardAcl = `-- name: GetBoardAcl :one
+SELECT acl FROM board_data WHERE id=$1
+`
+
+func (q *Queries) GetBoardAcl(ctx context.Context, id int32) ([]string, error) {
+	row := q.db.QueryRow(ctx, getBoardAcl, id)
+	var acl []string
+	err := row.Scan(&acl)
+	return acl, err
+}
+
 const getBoardData = `-- name: GetBoardData :one
 SELECT
   id,
+  slug,
   title,
+  description,
   total_members,
   total_threads,
   total_thread_posts,
-  edit_window
+  edit_window,
+  reaction_emoji,
+  hide_emails,
+  acl
 FROM board_data
+WHERE $1::varchar IS NULL OR slug = $1
+ORDER BY id
+LIMIT 1
 `
 
 type GetBoardDataRow struct {
 	ID               int32
+	Slug             string
 	Title            string
+	Description      string
 	TotalMembers     pgtype.Int4
 	TotalThreads     pgtype.Int4
 	TotalThreadPosts pgtype.Int4
 	EditWindow       pgtype.Int4
+	ReactionEmoji    []string
+	HideEmails       bool
+	Acl              []string
 }
 
-func (q *Queries) GetBoardData(ctx context.Context) (GetBoardDataRow, error) {
-	row := q.db.QueryRow(ctx, getBoardData)
+func (q *Queries) GetBoardData(ctx context.Context, slug pgtype.Text) (GetBoardDataRow, error) {
+	row := q.db.QueryRow(ctx, getBoardData, slug)
 	var i GetBoardDataRow
 	err := row.Scan(
 		&i.ID,
+		&i.Slug,
 		&i.Title,
+		&i.Description,
 		&i.TotalMembers,
 		&i.TotalThreads,
 		&i.TotalThreadPosts,
 		&i.EditWindow,
+		&i.ReactionEmoji,
+		&i.HideEmails,
+		&i.Acl,
+	)
+	return i, err
+}
+
+const getDraft = `-- name: GetDraft :one
+SELECT id, member_id, thread_id, subject, body, date_saved
+FROM draft
+WHERE member_id = $1
+  AND thread_id IS NOT DISTINCT FROM $2::bigint
+  AND date_saved > $3
+`
+
+type GetDraftParams struct {
+	MemberID   int64
+	ThreadID   pgtype.Int8
+	SavedAfter pgtype.Timestamptz
+}
+
+func (q *Queries) GetDraft(ctx context.Context, arg GetDraftParams) (Draft, error) {
+	row := q.db.QueryRow(ctx, getDraft, arg.MemberID, arg.ThreadID, arg.SavedAfter)
+	var i Draft
+	err := row.Scan(
+		&i.ID,
+		&i.MemberID,
+		&i.ThreadID,
+		&i.Subject,
+		&i.Body,
+		&i.DateSaved,
+	)
+	return i, err
+}
+
+const getLinkPreview = `-- name: GetLinkPreview :one
+SELECT url, ok, title, description, site_name, image_url, date_fetched
+FROM link_preview
+WHERE url = $1
+`
+
+func (q *Queries) GetLinkPreview(ctx context.Context, url string) (LinkPreview, error) {
+	row := q.db.QueryRow(ctx, getLinkPreview, url)
+	var i LinkPreview
+	err := row.Scan(
+		&i.Url,
+		&i.Ok,
+		&i.Title,
+		&i.Description,
+		&i.SiteName,
+		&i.ImageUrl,
+		&i.DateFetched,
 	)
 	return i, err
 }
@@ -118,7 +637,11 @@ SELECT
   mp.proper_name,
   mp.pronouns,
   m.date_joined,
-  mp.photo_url
+  mp.photo_url,
+  mp.relative_times,
+  m.last_seen_node,
+  m.last_seen_os,
+  m.last_seen_at
 FROM
   member m
 LEFT JOIN
@@ -140,6 +663,10 @@ type GetMemberRow struct {
 	Pronouns      pgtype.Text
 	DateJoined    pgtype.Timestamptz
 	PhotoUrl      pgtype.Text
+	RelativeTimes pgtype.Bool
+	LastSeenNode  pgtype.Text
+	LastSeenOs    pgtype.Text
+	LastSeenAt    pgtype.Timestamptz
 }
 
 func (q *Queries) GetMember(ctx context.Context, id int64) (GetMemberRow, error) {
@@ -156,6 +683,10 @@ func (q *Queries) GetMember(ctx context.Context, id int64) (GetMemberRow, error)
 		&i.Pronouns,
 		&i.DateJoined,
 		&i.PhotoUrl,
+		&i.RelativeTimes,
+		&i.LastSeenNode,
+		&i.LastSeenOs,
+		&i.LastSeenAt,
 	)
 	return i, err
 }
@@ -171,13 +702,134 @@ func (q *Queries) GetMemberId(ctx context.Context, email string) (int64, error)
 	return id, err
 }
 
+const getMemberPostingActivity = `-- name: GetMemberPostingActivity :one
+SELECT
+  m.date_joined,
+  (SELECT count(*) FROM thread t
+    WHERE t.member_id = m.id AND t.date_posted >= $1) AS recent_threads,
+  (SELECT count(*) FROM thread_post tp
+    WHERE tp.member_id = m.id AND tp.date_posted >= $2) AS recent_posts,
+  (SELECT count(*) FROM thread_post tp
+    WHERE tp.member_id = m.id AND tp.body_hash = $3 AND tp.date_posted >= $4) AS duplicate_posts
+FROM member m
+WHERE m.id = $5
+`
+
+type GetMemberPostingActivityParams struct {
+	ThreadsSince    pgtype.Timestamptz
+	PostsSince      pgtype.Timestamptz
+	BodyHash        pgtype.Text
+	DuplicatesSince pgtype.Timestamptz
+	MemberID        int64
+}
+
+type GetMemberPostingActivityRow struct {
+	DateJoined     pgtype.Timestamptz
+	RecentThreads  int64
+	RecentPosts    int64
+	DuplicatePosts int64
+}
+
+func (q *Queries) GetMemberPostingActivity(ctx context.Context, arg GetMemberPostingActivityParams) (GetMemberPostingActivityRow, error) {
+	row := q.db.QueryRow(ctx, getMemberPostingActivity,
+		arg.ThreadsSince,
+		arg.PostsSince,
+		arg.BodyHash,
+		arg.DuplicatesSince,
+		arg.MemberID,
+	)
+	var i GetMemberPostingActivityRow
+	err := row.Scan(
+		&i.DateJoined,
+		&i.RecentThreads,
+		&i.RecentPosts,
+		&i.DuplicatePosts,
+	)
+	return i, err
+}
+
+const getMemberTimePreferences = `-- name: GetMemberTimePreferences :one
+SELECT timezone, relative_times FROM member_profile WHERE member_id = $1
+`
+
+type GetMemberTimePreferencesRow struct {
+	Timezone      pgtype.Text
+	RelativeTimes bool
+}
+
+func (q *Queries) GetMemberTimePreferences(ctx context.Context, memberID int64) (GetMemberTimePreferencesRow, error) {
+	row := q.db.QueryRow(ctx, getMemberTimePreferences, memberID)
+	var i GetMemberTimePreferencesRow
+	err := row.Scan(&i.Timezone, &i.RelativeTimes)
+	return i, err
+}
+
+const getSchemaVersion = `-- name: GetSchemaVersion :one
+SELECT COALESCE(max(version), 0)::int AS version
+FROM schema_version
+`
+
+func (q *Queries) GetSchemaVersion(ctx context.Context) (int32, error) {
+	row := q.db.QueryRow(ctx, getSchemaVersion)
+	var version int32
+	err := row.Scan(&version)
+	return version, err
+}
+
+const getTag = `-- name: GetTag :one
+SELECT id, name FROM tag WHERE id=$1
+`
+
+func (q *Queries) GetTag(ctx context.Context, id int32) (Tag, error) {
+	row := q.db.QueryRow(ctx, getTag, id)
+	var i Tag
+	err := row.Scan(&i.ID, &i.Name)
+	return i, err
+}
+
+const getThreadBoard = `-- name: GetThreadBoard :one
+SELECT b.id, b.slug, b.title, b.acl, b.edit_window, b.reaction_emoji, b.hide_emails
+FROM thread t
+JOIN board_data b ON b.id=t.board_id
+WHERE t.id=$1
+`
+
+type GetThreadBoardRow struct {
+	ID            int32
+	Slug          string
+	Title         string
+	Acl           []string
+	EditWindow    pgtype.Int4
+	ReactionEmoji []string
+	HideEmails    bool
+}
+
+func (q *Queries) GetThreadBoard(ctx context.Context, id int64) (GetThreadBoardRow, error) {
+	row := q.db.QueryRow(ctx, getThreadBoard, id)
+	var i GetThreadBoardRow
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Title,
+		&i.Acl,
+		&i.EditWindow,
+		&i.ReactionEmoji,
+		&i.HideEmails,
+	)
+	return i, err
+}
+
 const getThreadForEdit = `-- name: GetThreadForEdit :one
 SELECT m.email AS email,
   t.id AS thread_id,
   t.subject AS subject,
   tp.id AS thread_post_id,
-  tp.body AS body
+  tp.body AS body,
+  ARRAY(SELECT tg.name FROM thread_tag tt JOIN tag tg ON tg.id=tt.tag_id WHERE tt.thread_id=t.id ORDER BY tg.name)::varchar[] AS tags,
+  b.edit_window
 FROM thread t
+JOIN board_data b
+  ON b.id=t.board_id
 LEFT JOIN thread_post tp
   ON tp.thread_id=t.id
 LEFT JOIN member m
@@ -196,6 +848,8 @@ type GetThreadForEditRow struct {
 	Subject      string
 	ThreadPostID pgtype.Int8
 	Body         pgtype.Text
+	Tags         []string
+	EditWindow   pgtype.Int4
 }
 
 func (q *Queries) GetThreadForEdit(ctx context.Context, arg GetThreadForEditParams) (GetThreadForEditRow, error) {
@@ -207,31 +861,141 @@ func (q *Queries) GetThreadForEdit(ctx context.Context, arg GetThreadForEditPara
 		&i.Subject,
 		&i.ThreadPostID,
 		&i.Body,
+		&i.Tags,
+		&i.EditWindow,
 	)
 	return i, err
 }
 
-const getThreadPostForEdit = `-- name: GetThreadPostForEdit :one
-SELECT tp.id, tp.body
-FROM thread_post tp LEFT JOIN member m
-  ON tp.member_id=m.id
-WHERE tp.id=$1 AND m.id=$2
+const getThreadPoll = `-- name: GetThreadPoll :one
+SELECT id, thread_id, question, multiple, anonymous, date_closes, date_created
+FROM poll
+WHERE thread_id=$1
 `
 
-type GetThreadPostForEditParams struct {
-	ID   int64
-	ID_2 int64
-}
-
-type GetThreadPostForEditRow struct {
-	ID   int64
-	Body pgtype.Text
+func (q *Queries) GetThreadPoll(ctx context.Context, threadID int64) (Poll, error) {
+	row := q.db.QueryRow(ctx, getThreadPoll, threadID)
+	var i Poll
+	err := row.Scan(
+		&i.ID,
+		&i.ThreadID,
+		&i.Question,
+		&i.Multiple,
+		&i.Anonymous,
+		&i.DateCloses,
+		&i.DateCreated,
+	)
+	return i, err
+}
+
+const getThreadPost = `-- name: GetThreadPost :one
+SELECT
+  tp.id,
+  tp.thread_id,
+  tp.member_id,
+  tp.date_posted,
+  tp.edited,
+  tp.date_edited,
+  tp.body,
+  t.subject,
+  (CASE WHEN t.first_post_id=tp.id THEN 't' ELSE 'f' END)::boolean as is_first_post,
+  tp.held,
+  tp.hidden,
+  tp.deleted
+FROM thread_post tp
+LEFT JOIN thread t
+  ON t.id=tp.thread_id
+WHERE tp.id=$1
+`
+
+type GetThreadPostRow struct {
+	ID          int64
+	ThreadID    int64
+	MemberID    int64
+	DatePosted  pgtype.Timestamptz
+	Edited      bool
+	DateEdited  pgtype.Timestamptz
+	Body        pgtype.Text
+	Subject     pgtype.Text
+	IsFirstPost bool
+	Held        bool
+	Hidden      bool
+	Deleted     bool
+}
+
+func (q *Queries) GetThreadPost(ctx context.Context, id int64) (GetThreadPostRow, error) {
+	row := q.db.QueryRow(ctx, getThreadPost, id)
+	var i GetThreadPostRow
+	err := row.Scan(
+		&i.ID,
+		&i.ThreadID,
+		&i.MemberID,
+		&i.DatePosted,
+		&i.Edited,
+		&i.DateEdited,
+		&i.Body,
+		&i.Subject,
+		&i.IsFirstPost,
+		&i.Held,
+		&i.Hidden,
+		&i.Deleted,
+	)
+	return i, err
+}
+
+const getThreadPostBoard = `-- name: GetThreadPostBoard :one
+SELECT b.id, b.slug, b.title, b.acl
+FROM thread_post tp
+JOIN thread t ON t.id=tp.thread_id
+JOIN board_data b ON b.id=t.board_id
+WHERE tp.id=$1
+`
+
+type GetThreadPostBoardRow struct {
+	ID    int32
+	Slug  string
+	Title string
+	Acl   []string
+}
+
+func (q *Queries) GetThreadPostBoard(ctx context.Context, id int64) (GetThreadPostBoardRow, error) {
+	row := q.db.QueryRow(ctx, getThreadPostBoard, id)
+	var i GetThreadPostBoardRow
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Title,
+		&i.Acl,
+	)
+	return i, err
+}
+
+const getThreadPostForEdit = `-- name: GetThreadPostForEdit :one
+SELECT tp.id, tp.body, b.edit_window
+FROM thread_post tp LEFT JOIN member m
+  ON tp.member_id=m.id
+JOIN thread t
+  ON t.id=tp.thread_id
+JOIN board_data b
+  ON b.id=t.board_id
+WHERE tp.id=$1 AND m.id=$2
+`
+
+type GetThreadPostForEditParams struct {
+	ID   int64
+	ID_2 int64
+}
+
+type GetThreadPostForEditRow struct {
+	ID         int64
+	Body       pgtype.Text
+	EditWindow pgtype.Int4
 }
 
 func (q *Queries) GetThreadPostForEdit(ctx context.Context, arg GetThreadPostForEditParams) (GetThreadPostForEditRow, error) {
 	row := q.db.QueryRow(ctx, getThreadPostForEdit, arg.ID, arg.ID_2)
 	var i GetThreadPostForEditRow
-	err := row.Scan(&i.ID, &i.Body)
+	err := row.Scan(&i.ID, &i.Body, &i.EditWindow)
 	return i, err
 }
 
@@ -257,95 +1021,875 @@ func (q *Queries) GetThreadSequenceId(ctx context.Context) (int64, error) {
 	return currval, err
 }
 
-const getThreadSubjectById = `-- name: GetThreadSubjectById :one
-SELECT subject FROM thread WHERE id=$1
+const getThreadSubjectById = `-- name: GetThreadSubjectById :one
+SELECT subject FROM thread WHERE id=$1 AND deleted IS false
+`
+
+func (q *Queries) GetThreadSubjectById(ctx context.Context, id int64) (string, error) {
+	row := q.db.QueryRow(ctx, getThreadSubjectById, id)
+	var subject string
+	err := row.Scan(&subject)
+	return subject, err
+}
+
+const hasVotedInPoll = `-- name: HasVotedInPoll :one
+SELECT EXISTS(SELECT 1 FROM poll_ballot WHERE poll_id=$1 AND member_id=$2)
+`
+
+type HasVotedInPollParams struct {
+	PollID   int64
+	MemberID int64
+}
+
+func (q *Queries) HasVotedInPoll(ctx context.Context, arg HasVotedInPollParams) (bool, error) {
+	row := q.db.QueryRow(ctx, hasVotedInPoll, arg.PollID, arg.MemberID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const hideThreadPost = `-- name: HideThreadPost :exec
+UPDATE thread_post
+SET hidden = true
+WHERE id = $1
+`
+
+func (q *Queries) HideThreadPost(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, hideThreadPost, id)
+	return err
+}
+
+const hitRateLimitWindow = `-- name: HitRateLimitWindow :one
+WITH hit AS (
+  INSERT INTO rate_limit_window (key, window_start, count)
+  VALUES ($1, $3, 1)
+  ON CONFLICT (key, window_start) DO UPDATE SET count = rate_limit_window.count + 1
+  RETURNING count
+)
+SELECT hit.count AS current_count,
+  COALESCE((
+    SELECT rate_limit_window.count
+    FROM rate_limit_window
+    WHERE rate_limit_window.key = $1
+      AND rate_limit_window.window_start = $2
+  ), 0)::int AS previous_count
+FROM hit
+`
+
+type HitRateLimitWindowParams struct {
+	Key                 string
+	PreviousWindowStart pgtype.Timestamptz
+	WindowStart         pgtype.Timestamptz
+}
+
+type HitRateLimitWindowRow struct {
+	CurrentCount  int32
+	PreviousCount int32
+}
+
+func (q *Queries) HitRateLimitWindow(ctx context.Context, arg HitRateLimitWindowParams) (HitRateLimitWindowRow, error) {
+	row := q.db.QueryRow(ctx, hitRateLimitWindow, arg.Key, arg.PreviousWindowStart, arg.WindowStart)
+	var i HitRateLimitWindowRow
+	err := row.Scan(&i.CurrentCount, &i.PreviousCount)
+	return i, err
+}
+
+const isThreadLocked = `-- name: IsThreadLocked :one
+SELECT COALESCE(locked, false)::boolean AS locked
+FROM thread
+WHERE id = $1 AND deleted IS false
+`
+
+func (q *Queries) IsThreadLocked(ctx context.Context, id int64) (bool, error) {
+	row := q.db.QueryRow(ctx, isThreadLocked, id)
+	var locked bool
+	err := row.Scan(&locked)
+	return locked, err
+}
+
+const linkThreadPostAttachments = `-- name: LinkThreadPostAttachments :exec
+INSERT INTO thread_post_attachment (thread_post_id, hash)
+SELECT $1, a.hash FROM attachment a WHERE a.hash = ANY($2::varchar[])
+ON CONFLICT DO NOTHING
+`
+
+type LinkThreadPostAttachmentsParams struct {
+	ThreadPostID int64
+	Hashes       []string
+}
+
+func (q *Queries) LinkThreadPostAttachments(ctx context.Context, arg LinkThreadPostAttachmentsParams) error {
+	_, err := q.db.Exec(ctx, linkThreadPostAttachments, arg.ThreadPostID, arg.Hashes)
+	return err
+}
+
+const listAuditLog = `-- name: ListAuditLog :many
+SELECT
+  a.id,
+  a.date_created,
+  a.actor_id,
+  m.email as actor_email,
+  a.action,
+  a.target_type,
+  a.target_id,
+  a.before,
+  a.after,
+  a.request_id,
+  a.trace_id
+FROM audit_log a
+LEFT JOIN member m
+  ON m.id=a.actor_id
+WHERE ($1::bigint IS NULL OR a.actor_id = $1)
+  AND ($2::text IS NULL OR a.action = $2)
+  AND ($3::text IS NULL OR a.target_type = $3)
+  AND ($4::text IS NULL OR a.target_id = $4)
+  AND ($5::timestamptz IS NULL OR a.date_created >= $5)
+  AND ($6::timestamptz IS NULL OR a.date_created < $6)
+  AND ($7::bigint IS NULL OR a.id < $7)
+ORDER BY a.id DESC
+LIMIT $8
+`
+
+type ListAuditLogParams struct {
+	ActorID    pgtype.Int8
+	Action     pgtype.Text
+	TargetType pgtype.Text
+	TargetID   pgtype.Text
+	Since      pgtype.Timestamptz
+	Until      pgtype.Timestamptz
+	BeforeID   pgtype.Int8
+	RowLimit   int32
+}
+
+type ListAuditLogRow struct {
+	ID          int64
+	DateCreated pgtype.Timestamptz
+	ActorID     int64
+	ActorEmail  pgtype.Text
+	Action      string
+	TargetType  string
+	TargetID    string
+	Before      []byte
+	After       []byte
+	RequestID   string
+	TraceID     string
+}
+
+func (q *Queries) ListAuditLog(ctx context.Context, arg ListAuditLogParams) ([]ListAuditLogRow, error) {
+	rows, err := q.db.Query(ctx, listAuditLog,
+		arg.ActorID,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Since,
+		arg.Until,
+		arg.BeforeID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAuditLogRow
+	for rows.Next() {
+		var i ListAuditLogRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.DateCreated,
+			&i.ActorID,
+			&i.ActorEmail,
+			&i.Action,
+			&i.TargetType,
+			&i.TargetID,
+			&i.Before,
+			&i.After,
+			&i.RequestID,
+			&i.TraceID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBoards = `-- name: ListBoards :many
+SELECT
+  id,
+  slug,
+  title,
+  description,
+  total_threads,
+  total_thread_posts,
+  acl
+FROM board_data
+ORDER BY id
+`
+
+type ListBoardsRow struct {
+	ID               int32
+	Slug             string
+	Title            string
+	Description      string
+	TotalThreads     pgtype.Int4
+	TotalThreadPosts pgtype.Int4
+	Acl              []string
+}
+
+func (q *Queries) ListBoards(ctx context.Context) ([]ListBoardsRow, error) {
+	rows, err := q.db.Query(ctx, listBoards)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBoardsRow
+	for rows.Next() {
+		var i ListBoardsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.Title,
+			&i.Description,
+			&i.TotalThreads,
+			&i.TotalThreadPosts,
+			&i.Acl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHeldPosts = `-- name: ListHeldPosts :many
+SELECT
+  tp.id,
+  tp.thread_id,
+  tp.date_posted,
+  tp.body,
+  tp.held_reason,
+  m.id as member_id,
+  m.email,
+  m.date_joined,
+  t.subject,
+  (CASE WHEN t.first_post_id=tp.id THEN 't' ELSE 'f' END)::boolean as is_first_post
+FROM thread_post tp
+LEFT JOIN member m
+  ON m.id=tp.member_id
+LEFT JOIN thread t
+  ON t.id=tp.thread_id
+WHERE tp.held IS true
+  AND tp.deleted IS false
+ORDER BY tp.date_posted ASC
+LIMIT 100
+`
+
+type ListHeldPostsRow struct {
+	ID          int64
+	ThreadID    int64
+	DatePosted  pgtype.Timestamptz
+	Body        pgtype.Text
+	HeldReason  pgtype.Text
+	MemberID    pgtype.Int8
+	Email       pgtype.Text
+	DateJoined  pgtype.Timestamptz
+	Subject     pgtype.Text
+	IsFirstPost bool
+}
+
+func (q *Queries) ListHeldPosts(ctx context.Context) ([]ListHeldPostsRow, error) {
+	rows, err := q.db.Query(ctx, listHeldPosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListHeldPostsRow
+	for rows.Next() {
+		var i ListHeldPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ThreadID,
+			&i.DatePosted,
+			&i.Body,
+			&i.HeldReason,
+			&i.MemberID,
+			&i.Email,
+			&i.DateJoined,
+			&i.Subject,
+			&i.IsFirstPost,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMemberDrafts = `-- name: ListMemberDrafts :many
+SELECT
+  d.id,
+  d.thread_id,
+  d.subject,
+  d.body,
+  d.date_saved,
+  t.subject AS thread_subject
+FROM draft d
+LEFT JOIN thread t ON t.id=d.thread_id
+LEFT JOIN board_data b ON b.id=t.board_id
+WHERE d.member_id = $1
+  AND d.date_saved > $2
+  AND (COALESCE(cardinality(b.acl), 0) = 0
+    OR $3::bool
+    OR EXISTS (SELECT 1 FROM unnest(b.acl) entry WHERE entry = ANY($4::text[]) OR lower(entry) = lower($5::text)))
+ORDER BY d.date_saved DESC
+`
+
+type ListMemberDraftsParams struct {
+	MemberID     int64
+	SavedAfter   pgtype.Timestamptz
+	SeeAllBoards bool
+	Principals   []string
+	ViewerEmail  string
+}
+
+type ListMemberDraftsRow struct {
+	ID            int64
+	ThreadID      pgtype.Int8
+	Subject       string
+	Body          string
+	DateSaved     pgtype.Timestamptz
+	ThreadSubject pgtype.Text
+}
+
+func (q *Queries) ListMemberDrafts(ctx context.Context, arg ListMemberDraftsParams) ([]ListMemberDraftsRow, error) {
+	rows, err := q.db.Query(ctx, listMemberDrafts,
+		arg.MemberID,
+		arg.SavedAfter,
+		arg.SeeAllBoards,
+		arg.Principals,
+		arg.ViewerEmail,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMemberDraftsRow
+	for rows.Next() {
+		var i ListMemberDraftsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ThreadID,
+			&i.Subject,
+			&i.Body,
+			&i.DateSaved,
+			&i.ThreadSubject,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMemberThreads = `-- name: ListMemberThreads :many
+SELECT
+  t.id as thread_id,
+  t.date_last_posted,
+  m.id,
+  m.email,
+  l.id as lastid,
+  l.email as lastname,
+  t.subject,
+  t.posts,
+  t.views,
+  tp.body,
+  (CASE WHEN tm.last_view_posts IS null THEN 0 ELSE tm.last_view_posts END) as last_view_posts,
+  (CASE WHEN tm.date_posted IS NOT null AND tm.undot IS false AND tm.member_id IS NOT null THEN 't' ELSE 'f' END)::boolean as dot,
+  t.sticky,
+  t.locked,
+  mp.preferred_name,
+  mp.photo_url,
+  lp.preferred_name as last_preferred_name,
+  lp.photo_url as last_photo_url,
+  b.hide_emails
+FROM
+  thread t
+JOIN
+  board_data b
+ON
+  b.id=t.board_id
+LEFT JOIN
+  member m
+ON
+  m.id=t.member_id
+LEFT JOIN
+  member_profile mp
+ON
+  mp.member_id=m.id
+LEFT JOIN
+  member l
+ON
+  l.id=t.last_member_id
+LEFT JOIN
+  member_profile lp
+ON
+  lp.member_id=l.id
+LEFT JOIN
+  thread_post tp
+ON
+  tp.id=t.first_post_id
+LEFT OUTER JOIN
+  thread_member tm
+ON
+  (tm.member_id=$1 AND tm.thread_id=t.id)
+WHERE t.sticky IS false
+AND t.deleted IS false
+AND t.held IS false
+AND m.id=$1
+AND (cardinality(b.acl) = 0
+  OR $2::bool
+  OR EXISTS (SELECT 1 FROM unnest(b.acl) entry WHERE entry = ANY($3::text[]) OR lower(entry) = lower($4::text)))
+ORDER BY t.date_last_posted DESC
+LIMIT 10
+`
+
+type ListMemberThreadsParams struct {
+	MemberID     int64
+	SeeAllBoards bool
+	Principals   []string
+	ViewerEmail  string
+}
+
+type ListMemberThreadsRow struct {
+	ThreadID          int64
+	DateLastPosted    pgtype.Timestamptz
+	ID                pgtype.Int8
+	Email             pgtype.Text
+	Lastid            pgtype.Int8
+	Lastname          pgtype.Text
+	Subject           string
+	Posts             pgtype.Int4
+	Views             pgtype.Int4
+	Body              pgtype.Text
+	LastViewPosts     interface{}
+	Dot               bool
+	Sticky            pgtype.Bool
+	Locked            pgtype.Bool
+	PreferredName     pgtype.Text
+	PhotoUrl          pgtype.Text
+	LastPreferredName pgtype.Text
+	LastPhotoUrl      pgtype.Text
+	HideEmails        bool
+}
+
+func (q *Queries) ListMemberThreads(ctx context.Context, arg ListMemberThreadsParams) ([]ListMemberThreadsRow, error) {
+	rows, err := q.db.Query(ctx, listMemberThreads,
+		arg.MemberID,
+		arg.SeeAllBoards,
+		arg.Principals,
+		arg.ViewerEmail,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMemberThreadsRow
+	for rows.Next() {
+		var i ListMemberThreadsRow
+		if err := rows.Scan(
+			&i.ThreadID,
+			&i.DateLastPosted,
+			&i.ID,
+			&i.Email,
+			&i.Lastid,
+			&i.Lastname,
+			&i.Subject,
+			&i.Posts,
+			&i.Views,
+			&i.Body,
+			&i.LastViewPosts,
+			&i.Dot,
+			&i.Sticky,
+			&i.Locked,
+			&i.PreferredName,
+			&i.PhotoUrl,
+			&i.LastPreferredName,
+			&i.LastPhotoUrl,
+			&i.HideEmails,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpenReports = `-- name: ListOpenReports :many
+SELECT
+  r.id,
+  r.thread_post_id,
+  r.reason,
+  r.date_reported,
+  rm.email as reporter_email,
+  tp.thread_id,
+  tp.body,
+  tp.hidden,
+  tp.member_id as author_id,
+  am.email as author_email,
+  t.subject,
+  t.locked
+FROM report r
+JOIN thread_post tp
+  ON tp.id=r.thread_post_id
+JOIN thread t
+  ON t.id=tp.thread_id
+LEFT JOIN member rm
+  ON rm.id=r.member_id
+LEFT JOIN member am
+  ON am.id=tp.member_id
+WHERE r.resolution IS NULL
+ORDER BY r.date_reported ASC
+LIMIT 500
+`
+
+type ListOpenReportsRow struct {
+	ID            int64
+	ThreadPostID  int64
+	Reason        string
+	DateReported  pgtype.Timestamptz
+	ReporterEmail pgtype.Text
+	ThreadID      int64
+	Body          pgtype.Text
+	Hidden        bool
+	AuthorID      int64
+	AuthorEmail   pgtype.Text
+	Subject       string
+	Locked        pgtype.Bool
+}
+
+func (q *Queries) ListOpenReports(ctx context.Context) ([]ListOpenReportsRow, error) {
+	rows, err := q.db.Query(ctx, listOpenReports)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOpenReportsRow
+	for rows.Next() {
+		var i ListOpenReportsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ThreadPostID,
+			&i.Reason,
+			&i.DateReported,
+			&i.ReporterEmail,
+			&i.ThreadID,
+			&i.Body,
+			&i.Hidden,
+			&i.AuthorID,
+			&i.AuthorEmail,
+			&i.Subject,
+			&i.Locked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPollOptions = `-- name: ListPollOptions :many
+SELECT
+  o.id,
+  o.label,
+  count(v.poll_option_id) AS votes,
+  COALESCE(bool_or(v.member_id = $1), false)::boolean AS chosen
+FROM poll_option o
+LEFT JOIN poll_vote v ON v.poll_option_id=o.id
+WHERE o.poll_id = $2
+GROUP BY o.id
+ORDER BY o.position
+`
+
+type ListPollOptionsParams struct {
+	MemberID pgtype.Int8
+	PollID   int64
+}
+
+type ListPollOptionsRow struct {
+	ID     int64
+	Label  string
+	Votes  int64
+	Chosen bool
+}
+
+func (q *Queries) ListPollOptions(ctx context.Context, arg ListPollOptionsParams) ([]ListPollOptionsRow, error) {
+	rows, err := q.db.Query(ctx, listPollOptions, arg.MemberID, arg.PollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPollOptionsRow
+	for rows.Next() {
+		var i ListPollOptionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Label,
+			&i.Votes,
+			&i.Chosen,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPollVoters = `-- name: ListPollVoters :many
+SELECT v.poll_option_id, m.id, m.email, mp.preferred_name, mp.photo_url
+FROM poll_vote v
+JOIN poll_option o ON o.id=v.poll_option_id
+JOIN member m ON m.id=v.member_id
+LEFT JOIN member_profile mp ON mp.member_id=m.id
+WHERE o.poll_id=$1
+ORDER BY v.poll_option_id, m.id
+`
+
+type ListPollVotersRow struct {
+	PollOptionID  int64
+	ID            int64
+	Email         string
+	PreferredName pgtype.Text
+	PhotoUrl      pgtype.Text
+}
+
+func (q *Queries) ListPollVoters(ctx context.Context, pollID int64) ([]ListPollVotersRow, error) {
+	rows, err := q.db.Query(ctx, listPollVoters, pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPollVotersRow
+	for rows.Next() {
+		var i ListPollVotersRow
+		if err := rows.Scan(
+			&i.PollOptionID,
+			&i.ID,
+			&i.Email,
+			&i.PreferredName,
+			&i.PhotoUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsToUnfurl = `-- name: ListPostsToUnfurl :many
+SELECT id, body
+FROM thread_post
+WHERE NOT unfurled
+ORDER BY id
+LIMIT $1
+`
+
+type ListPostsToUnfurlRow struct {
+	ID   int64
+	Body pgtype.Text
+}
+
+func (q *Queries) ListPostsToUnfurl(ctx context.Context, limit int32) ([]ListPostsToUnfurlRow, error) {
+	rows, err := q.db.Query(ctx, listPostsToUnfurl, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPostsToUnfurlRow
+	for rows.Next() {
+		var i ListPostsToUnfurlRow
+		if err := rows.Scan(&i.ID, &i.Body); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRateLimits = `-- name: ListRateLimits :many
+SELECT role, requests, window_seconds
+FROM rate_limit
+ORDER BY role
+`
+
+func (q *Queries) ListRateLimits(ctx context.Context) ([]RateLimit, error) {
+	rows, err := q.db.Query(ctx, listRateLimits)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RateLimit
+	for rows.Next() {
+		var i RateLimit
+		if err := rows.Scan(&i.Role, &i.Requests, &i.WindowSeconds); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT tg.id, tg.name, count(tt.thread_id) AS threads
+FROM tag tg
+LEFT JOIN thread_tag tt ON tt.tag_id=tg.id
+GROUP BY tg.id
+ORDER BY tg.name
+`
+
+type ListTagsRow struct {
+	ID      int32
+	Name    string
+	Threads int64
+}
+
+func (q *Queries) ListTags(ctx context.Context) ([]ListTagsRow, error) {
+	rows, err := q.db.Query(ctx, listTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTagsRow
+	for rows.Next() {
+		var i ListTagsRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Threads); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadLinkPreviews = `-- name: ListThreadLinkPreviews :many
+SELECT l.thread_post_id, p.url, p.title, p.description, p.site_name, p.image_url
+FROM thread_post_link l
+JOIN thread_post tp ON tp.id=l.thread_post_id
+JOIN link_preview p ON p.url=l.url
+WHERE tp.thread_id=$1 AND p.ok
+ORDER BY l.thread_post_id, l.position
 `
 
-func (q *Queries) GetThreadSubjectById(ctx context.Context, id int64) (string, error) {
-	row := q.db.QueryRow(ctx, getThreadSubjectById, id)
-	var subject string
-	err := row.Scan(&subject)
-	return subject, err
+type ListThreadLinkPreviewsRow struct {
+	ThreadPostID int64
+	Url          string
+	Title        string
+	Description  string
+	SiteName     string
+	ImageUrl     string
 }
 
-const listMemberThreads = `-- name: ListMemberThreads :many
+func (q *Queries) ListThreadLinkPreviews(ctx context.Context, threadID int64) ([]ListThreadLinkPreviewsRow, error) {
+	rows, err := q.db.Query(ctx, listThreadLinkPreviews, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListThreadLinkPreviewsRow
+	for rows.Next() {
+		var i ListThreadLinkPreviewsRow
+		if err := rows.Scan(
+			&i.ThreadPostID,
+			&i.Url,
+			&i.Title,
+			&i.Description,
+			&i.SiteName,
+			&i.ImageUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadPostRevisions = `-- name: ListThreadPostRevisions :many
 SELECT
-  t.id as thread_id,
-  t.date_last_posted,
-  m.id,
+  r.id,
+  r.thread_post_id,
+  r.member_id,
   m.email,
-  l.id as lastid,
-  l.email as lastname,
-  t.subject,
-  t.posts,
-  t.views,
-  (CASE WHEN tm.last_view_posts IS null THEN 0 ELSE tm.last_view_posts END) as last_view_posts,
-  (CASE WHEN tm.date_posted IS NOT null AND tm.undot IS false AND tm.member_id IS NOT null THEN 't' ELSE 'f' END)::boolean as dot,
-  t.sticky,
-  t.locked
-FROM
-  thread t
-LEFT JOIN
-  member m
-ON
-  m.id=t.member_id
-LEFT JOIN
-  member l
-ON
-  l.id=t.last_member_id
-LEFT JOIN
-  thread_post tp
-ON
-  tp.id=t.first_post_id
-LEFT OUTER JOIN
-  thread_member tm
-ON
-  (tm.member_id=$1 AND tm.thread_id=t.id)
-WHERE t.sticky IS false
-AND m.id=$1
-ORDER BY t.date_last_posted DESC
-LIMIT 10
+  r.date_revised,
+  r.subject,
+  r.body
+FROM thread_post_revision r
+LEFT JOIN member m
+  ON m.id=r.member_id
+WHERE r.thread_post_id=$1
+ORDER BY r.date_revised ASC, r.id ASC
 `
 
-type ListMemberThreadsRow struct {
-	ThreadID       int64
-	DateLastPosted pgtype.Timestamptz
-	ID             pgtype.Int8
-	Email          pgtype.Text
-	Lastid         pgtype.Int8
-	Lastname       pgtype.Text
-	Subject        string
-	Posts          pgtype.Int4
-	Views          pgtype.Int4
-	LastViewPosts  interface{}
-	Dot            bool
-	Sticky         pgtype.Bool
-	Locked         pgtype.Bool
-}
-
-func (q *Queries) ListMemberThreads(ctx context.Context, memberID int64) ([]ListMemberThreadsRow, error) {
-	rows, err := q.db.Query(ctx, listMemberThreads, memberID)
+type ListThreadPostRevisionsRow struct {
+	ID           int64
+	ThreadPostID int64
+	MemberID     int64
+	Email        pgtype.Text
+	DateRevised  pgtype.Timestamptz
+	Subject      pgtype.Text
+	Body         pgtype.Text
+}
+
+func (q *Queries) ListThreadPostRevisions(ctx context.Context, threadPostID int64) ([]ListThreadPostRevisionsRow, error) {
+	rows, err := q.db.Query(ctx, listThreadPostRevisions, threadPostID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListMemberThreadsRow
+	var items []ListThreadPostRevisionsRow
 	for rows.Next() {
-		var i ListMemberThreadsRow
+		var i ListThreadPostRevisionsRow
 		if err := rows.Scan(
-			&i.ThreadID,
-			&i.DateLastPosted,
 			&i.ID,
+			&i.ThreadPostID,
+			&i.MemberID,
 			&i.Email,
-			&i.Lastid,
-			&i.Lastname,
+			&i.DateRevised,
 			&i.Subject,
-			&i.Posts,
-			&i.Views,
-			&i.LastViewPosts,
-			&i.Dot,
-			&i.Sticky,
-			&i.Locked,
+			&i.Body,
 		); err != nil {
 			return nil, err
 		}
@@ -367,40 +1911,77 @@ SELECT
   t.subject,
   t.id as thread_id,
   m.is_admin,
-  (CASE WHEN (m.email = $2 AND t.date_posted >= NOW() - INTERVAL '900 seconds') THEN 't' ELSE 'f' END)::boolean as can_edit
+  tp.edited,
+  tp.date_edited,
+  COALESCE((
+    SELECT jsonb_agg(jsonb_build_object('emoji', r.emoji, 'count', r.count, 'reacted', r.reacted) ORDER BY r.first_reacted)
+    FROM (
+      SELECT pr.emoji, count(*) AS count, bool_or(pr.member_id = $3) AS reacted, min(pr.date_reacted) AS first_reacted
+      FROM post_reaction pr
+      WHERE pr.thread_post_id = tp.id
+      GROUP BY pr.emoji
+    ) r
+  ), '[]')::jsonb as reactions,
+  (CASE WHEN (m.email = $2 AND t.date_posted >= NOW() - $4::int * INTERVAL '1 second') THEN 't' ELSE 'f' END)::boolean as can_edit,
+  tp.held,
+  tp.hidden,
+  t.locked,
+  mp.preferred_name,
+  mp.photo_url
 FROM
   thread_post tp
 LEFT JOIN
   member m
 ON
   m.id=tp.member_id
+LEFT JOIN
+  member_profile mp
+ON
+  mp.member_id=m.id
 LEFT JOIN
   thread t
 ON
   t.id = tp.thread_id
 WHERE tp.thread_id=$1
+AND tp.deleted IS false
+AND (tp.held IS false OR tp.member_id=$3)
 ORDER BY tp.date_posted ASC
 `
 
 type ListThreadPostsParams struct {
-	ThreadID int64
-	Email    string
+	ThreadID   int64
+	Email      string
+	MemberID   int64
+	EditWindow int32
 }
 
 type ListThreadPostsRow struct {
-	ID         int64
-	DatePosted pgtype.Timestamptz
-	MemberID   pgtype.Int8
-	Email      pgtype.Text
-	Body       pgtype.Text
-	Subject    pgtype.Text
-	ThreadID   pgtype.Int8
-	IsAdmin    pgtype.Bool
-	CanEdit    bool
+	ID            int64
+	DatePosted    pgtype.Timestamptz
+	MemberID      pgtype.Int8
+	Email         pgtype.Text
+	Body          pgtype.Text
+	Subject       pgtype.Text
+	ThreadID      pgtype.Int8
+	IsAdmin       pgtype.Bool
+	Edited        bool
+	DateEdited    pgtype.Timestamptz
+	Reactions     []byte
+	CanEdit       bool
+	Held          bool
+	Hidden        bool
+	Locked        pgtype.Bool
+	PreferredName pgtype.Text
+	PhotoUrl      pgtype.Text
 }
 
 func (q *Queries) ListThreadPosts(ctx context.Context, arg ListThreadPostsParams) ([]ListThreadPostsRow, error) {
-	rows, err := q.db.Query(ctx, listThreadPosts, arg.ThreadID, arg.Email)
+	rows, err := q.db.Query(ctx, listThreadPosts,
+		arg.ThreadID,
+		arg.Email,
+		arg.MemberID,
+		arg.EditWindow,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -417,7 +1998,15 @@ func (q *Queries) ListThreadPosts(ctx context.Context, arg ListThreadPostsParams
 			&i.Subject,
 			&i.ThreadID,
 			&i.IsAdmin,
+			&i.Edited,
+			&i.DateEdited,
+			&i.Reactions,
 			&i.CanEdit,
+			&i.Held,
+			&i.Hidden,
+			&i.Locked,
+			&i.PreferredName,
+			&i.PhotoUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -441,21 +2030,41 @@ SELECT
   t.posts,
   t.views,
   tp.body,
-  (CASE WHEN (m.email = $1 AND t.date_posted >= NOW() - INTERVAL '900 seconds') THEN 't' ELSE 'f' END)::boolean as can_edit,
+  (CASE WHEN (m.email = $1 AND t.date_posted >= NOW() - COALESCE(b.edit_window, 0) * INTERVAL '1 second') THEN 't' ELSE 'f' END)::boolean as can_edit,
   (CASE WHEN tm.last_view_posts IS null THEN 0 ELSE tm.last_view_posts END) as last_view_posts,
   (CASE WHEN tm.date_posted IS NOT null AND tm.undot IS false AND tm.member_id IS NOT null THEN 't' ELSE 'f' END)::boolean as dot,
   t.sticky,
-  t.locked
+  t.locked,
+  mp.preferred_name,
+  mp.photo_url,
+  lp.preferred_name as last_preferred_name,
+  lp.photo_url as last_photo_url,
+  b.slug as board_slug,
+  b.title as board_title,
+  b.hide_emails,
+  ARRAY(SELECT tg.name FROM thread_tag tt JOIN tag tg ON tg.id=tt.tag_id WHERE tt.thread_id=t.id ORDER BY tg.name)::varchar[] as tags
 FROM
   thread t
+JOIN
+  board_data b
+ON
+  b.id=t.board_id
 LEFT JOIN
   member m
 ON
   m.id=t.member_id
+LEFT JOIN
+  member_profile mp
+ON
+  mp.member_id=m.id
 LEFT JOIN
   member l
 ON
   l.id=t.last_member_id
+LEFT JOIN
+  member_profile lp
+ON
+  lp.member_id=l.id
 LEFT JOIN
   thread_post tp
 ON
@@ -465,35 +2074,63 @@ LEFT OUTER JOIN
 ON
   (tm.member_id=$2 AND tm.thread_id=t.id)
 WHERE t.sticky IS false
+AND t.deleted IS false
+AND (t.held IS false OR t.member_id=$2)
+AND ($3::int IS NULL OR t.board_id = $3)
+AND ($4::varchar IS NULL OR EXISTS (SELECT 1 FROM thread_tag tt JOIN tag tg ON tg.id=tt.tag_id WHERE tt.thread_id=t.id AND tg.name = $4))
+AND (cardinality(b.acl) = 0
+  OR $5::bool
+  OR EXISTS (SELECT 1 FROM unnest(b.acl) entry WHERE entry = ANY($6::text[]) OR lower(entry) = lower($7::text)))
 ORDER BY t.date_last_posted DESC
 LIMIT 100
 `
 
 type ListThreadsParams struct {
-	Email    string
-	MemberID int64
+	Email        string
+	MemberID     int64
+	BoardID      pgtype.Int4
+	Tag          pgtype.Text
+	SeeAllBoards bool
+	Principals   []string
+	ViewerEmail  string
 }
 
 type ListThreadsRow struct {
-	ThreadID       int64
-	DateLastPosted pgtype.Timestamptz
-	ID             pgtype.Int8
-	Email          pgtype.Text
-	Lastid         pgtype.Int8
-	Lastname       pgtype.Text
-	Subject        string
-	Posts          pgtype.Int4
-	Views          pgtype.Int4
-	Body           pgtype.Text
-	CanEdit        bool
-	LastViewPosts  interface{}
-	Dot            bool
-	Sticky         pgtype.Bool
-	Locked         pgtype.Bool
+	ThreadID          int64
+	DateLastPosted    pgtype.Timestamptz
+	ID                pgtype.Int8
+	Email             pgtype.Text
+	Lastid            pgtype.Int8
+	Lastname          pgtype.Text
+	Subject           string
+	Posts             pgtype.Int4
+	Views             pgtype.Int4
+	Body              pgtype.Text
+	CanEdit           bool
+	LastViewPosts     interface{}
+	Dot               bool
+	Sticky            pgtype.Bool
+	Locked            pgtype.Bool
+	PreferredName     pgtype.Text
+	PhotoUrl          pgtype.Text
+	LastPreferredName pgtype.Text
+	LastPhotoUrl      pgtype.Text
+	BoardSlug         string
+	BoardTitle        string
+	HideEmails        bool
+	Tags              []string
 }
 
 func (q *Queries) ListThreads(ctx context.Context, arg ListThreadsParams) ([]ListThreadsRow, error) {
-	rows, err := q.db.Query(ctx, listThreads, arg.Email, arg.MemberID)
+	rows, err := q.db.Query(ctx, listThreads,
+		arg.Email,
+		arg.MemberID,
+		arg.BoardID,
+		arg.Tag,
+		arg.SeeAllBoards,
+		arg.Principals,
+		arg.ViewerEmail,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -517,6 +2154,14 @@ func (q *Queries) ListThreads(ctx context.Context, arg ListThreadsParams) ([]Lis
 			&i.Dot,
 			&i.Sticky,
 			&i.Locked,
+			&i.PreferredName,
+			&i.PhotoUrl,
+			&i.LastPreferredName,
+			&i.LastPhotoUrl,
+			&i.BoardSlug,
+			&i.BoardTitle,
+			&i.HideEmails,
+			&i.Tags,
 		); err != nil {
 			return nil, err
 		}
@@ -528,23 +2173,300 @@ func (q *Queries) ListThreads(ctx context.Context, arg ListThreadsParams) ([]Lis
 	return items, nil
 }
 
+const lockThread = `-- name: LockThread :exec
+UPDATE thread
+SET locked = true
+WHERE id = $1
+`
+
+func (q *Queries) LockThread(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, lockThread, id)
+	return err
+}
+
+const markThreadPostUnfurled = `-- name: MarkThreadPostUnfurled :exec
+UPDATE thread_post SET unfurled = true
+WHERE id = $1 AND body IS NOT DISTINCT FROM $2
+`
+
+type MarkThreadPostUnfurledParams struct {
+	ID   int64
+	Body pgtype.Text
+}
+
+func (q *Queries) MarkThreadPostUnfurled(ctx context.Context, arg MarkThreadPostUnfurledParams) error {
+	_, err := q.db.Exec(ctx, markThreadPostUnfurled, arg.ID, arg.Body)
+	return err
+}
+
+const mergeThreadTags = `-- name: MergeThreadTags :exec
+INSERT INTO thread_tag (thread_id, tag_id)
+SELECT tt.thread_id, $1::int FROM thread_tag tt WHERE tt.tag_id = $2
+ON CONFLICT DO NOTHING
+`
+
+type MergeThreadTagsParams struct {
+	IntoID int32
+	FromID int32
+}
+
+func (q *Queries) MergeThreadTags(ctx context.Context, arg MergeThreadTagsParams) error {
+	_, err := q.db.Exec(ctx, mergeThreadTags, arg.IntoID, arg.FromID)
+	return err
+}
+
+const rejectHeldPost = `-- name: RejectHeldPost :execrows
+UPDATE thread_post
+SET deleted = true
+WHERE id = $1 AND held IS true AND deleted IS false
+`
+
+func (q *Queries) RejectHeldPost(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, rejectHeldPost, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const renameTag = `-- name: RenameTag :exec
+UPDATE tag SET name=$1 WHERE id=$2
+`
+
+type RenameTagParams struct {
+	Name string
+	ID   int32
+}
+
+func (q *Queries) RenameTag(ctx context.Context, arg RenameTagParams) error {
+	_, err := q.db.Exec(ctx, renameTag, arg.Name, arg.ID)
+	return err
+}
+
+const resolveReports = `-- name: ResolveReports :execrows
+UPDATE report
+SET resolution = $2, resolved_by = $3, date_resolved = now()
+WHERE thread_post_id = $1
+  AND resolution IS NULL
+`
+
+type ResolveReportsParams struct {
+	ThreadPostID int64
+	Resolution   pgtype.Text
+	ResolvedBy   pgtype.Int8
+}
+
+func (q *Queries) ResolveReports(ctx context.Context, arg ResolveReportsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, resolveReports, arg.ThreadPostID, arg.Resolution, arg.ResolvedBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const saveDraft = `-- name: SaveDraft :exec
+INSERT INTO draft (member_id, thread_id, subject, body)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (member_id, COALESCE(thread_id, 0)) DO UPDATE
+SET subject=EXCLUDED.subject, body=EXCLUDED.body, date_saved=now()
+`
+
+type SaveDraftParams struct {
+	MemberID int64
+	ThreadID pgtype.Int8
+	Subject  string
+	Body     string
+}
+
+func (q *Queries) SaveDraft(ctx context.Context, arg SaveDraftParams) error {
+	_, err := q.db.Exec(ctx, saveDraft,
+		arg.MemberID,
+		arg.ThreadID,
+		arg.Subject,
+		arg.Body,
+	)
+	return err
+}
+
+const setThreadPostLinks = `-- name: SetThreadPostLinks :exec
+WITH removed AS (
+  DELETE FROM thread_post_link
+  WHERE thread_post_link.thread_post_id = $1
+  AND thread_post_link.url <> ALL($2::text[])
+)
+INSERT INTO thread_post_link (thread_post_id, position, url)
+SELECT $1, l.position, l.url
+FROM unnest($2::text[]) WITH ORDINALITY AS l(url, position)
+ON CONFLICT (thread_post_id, url) DO UPDATE SET position=EXCLUDED.position
+`
+
+type SetThreadPostLinksParams struct {
+	ThreadPostID int64
+	Urls         []string
+}
+
+func (q *Queries) SetThreadPostLinks(ctx context.Context, arg SetThreadPostLinksParams) error {
+	_, err := q.db.Exec(ctx, setThreadPostLinks, arg.ThreadPostID, arg.Urls)
+	return err
+}
+
+const setThreadTags = `-- name: SetThreadTags :exec
+WITH removed AS (
+  DELETE FROM thread_tag
+  WHERE thread_tag.thread_id = $1
+  AND thread_tag.tag_id NOT IN (SELECT id FROM tag WHERE name = ANY($2::varchar[]))
+)
+INSERT INTO thread_tag (thread_id, tag_id)
+SELECT $1, id FROM tag WHERE name = ANY($2::varchar[])
+ON CONFLICT DO NOTHING
+`
+
+type SetThreadTagsParams struct {
+	ThreadID int64
+	Names    []string
+}
+
+func (q *Queries) SetThreadTags(ctx context.Context, arg SetThreadTagsParams) error {
+	_, err := q.db.Exec(ctx, setThreadTags, arg.ThreadID, arg.Names)
+	return err
+}
+
+const syncMemberTailscaleProfile = `-- name: SyncMemberTailscaleProfile :exec
+WITH seen AS (
+  UPDATE member SET
+    last_seen_node = NULLIF($4::varchar, ''),
+    last_seen_os = NULLIF($5::varchar, ''),
+    last_seen_at = now()
+  WHERE id = $3
+)
+UPDATE member_profile SET
+  preferred_name = CASE
+    WHEN COALESCE(preferred_name, '') = '' OR preferred_name = tailscale_name
+    THEN NULLIF($1::varchar, '')
+    ELSE preferred_name
+  END,
+  photo_url = CASE
+    WHEN COALESCE(photo_url, '') = '' OR photo_url = tailscale_photo_url
+    THEN NULLIF($2::varchar, '')
+    ELSE photo_url
+  END,
+  tailscale_name = NULLIF($1::varchar, ''),
+  tailscale_photo_url = NULLIF($2::varchar, '')
+WHERE member_id = $3
+`
+
+type SyncMemberTailscaleProfileParams struct {
+	DisplayName   string
+	ProfilePicUrl string
+	MemberID      int64
+	NodeName      string
+	NodeOs        string
+}
+
+func (q *Queries) SyncMemberTailscaleProfile(ctx context.Context, arg SyncMemberTailscaleProfileParams) error {
+	_, err := q.db.Exec(ctx, syncMemberTailscaleProfile,
+		arg.DisplayName,
+		arg.ProfilePicUrl,
+		arg.MemberID,
+		arg.NodeName,
+		arg.NodeOs,
+	)
+	return err
+}
+
+const updateBoardAcl = `-- name: UpdateBoardAcl :exec
+UPDATE board_data
+SET acl=$1
+WHERE id=$2
+`
+
+type UpdateBoardAclParams struct {
+	Acl []string
+	ID  int32
+}
+
+func (q *Queries) UpdateBoardAcl(ctx context.Context, arg UpdateBoardAclParams) error {
+	_, err := q.db.Exec(ctx, updateBoardAcl, arg.Acl, arg.ID)
+	return err
+}
+
+const updateBoardDescription = `-- name: UpdateBoardDescription :exec
+UPDATE board_data
+SET description=$1
+WHERE id=$2
+`
+
+type UpdateBoardDescriptionParams struct {
+	Description string
+	ID          int32
+}
+
+func (q *Queries) UpdateBoardDescription(ctx context.Context, arg UpdateBoardDescriptionParams) error {
+	_, err := q.db.Exec(ctx, updateBoardDescription, arg.Description, arg.ID)
+	return err
+}
+
 const updateBoardEditWindow = `-- name: UpdateBoardEditWindow :exec
 UPDATE board_data
 SET edit_window=$1
+WHERE id=$2
+`
+
+type UpdateBoardEditWindowParams struct {
+	EditWindow pgtype.Int4
+	ID         int32
+}
+
+func (q *Queries) UpdateBoardEditWindow(ctx context.Context, arg UpdateBoardEditWindowParams) error {
+	_, err := q.db.Exec(ctx, updateBoardEditWindow, arg.EditWindow, arg.ID)
+	return err
+}
+
+const updateBoardHideEmails = `-- name: UpdateBoardHideEmails :exec
+UPDATE board_data
+SET hide_emails=$1
+WHERE id=$2
+`
+
+type UpdateBoardHideEmailsParams struct {
+	HideEmails bool
+	ID         int32
+}
+
+func (q *Queries) UpdateBoardHideEmails(ctx context.Context, arg UpdateBoardHideEmailsParams) error {
+	_, err := q.db.Exec(ctx, updateBoardHideEmails, arg.HideEmails, arg.ID)
+	return err
+}
+
+const updateBoardReactionEmoji = `-- name: UpdateBoardReactionEmoji :exec
+UPDATE board_data
+SET reaction_emoji=$1
+WHERE id=$2
 `
 
-func (q *Queries) UpdateBoardEditWindow(ctx context.Context, editWindow pgtype.Int4) error {
-	_, err := q.db.Exec(ctx, updateBoardEditWindow, editWindow)
+type UpdateBoardReactionEmojiParams struct {
+	ReactionEmoji []string
+	ID            int32
+}
+
+func (q *Queries) UpdateBoardReactionEmoji(ctx context.Context, arg UpdateBoardReactionEmojiParams) error {
+	_, err := q.db.Exec(ctx, updateBoardReactionEmoji, arg.ReactionEmoji, arg.ID)
 	return err
 }
 
 const updateBoardTitle = `-- name: UpdateBoardTitle :exec
 UPDATE board_data
 SET title=$1
+WHERE id=$2
 `
 
-func (q *Queries) UpdateBoardTitle(ctx context.Context, title string) error {
-	_, err := q.db.Exec(ctx, updateBoardTitle, title)
+type UpdateBoardTitleParams struct {
+	Title string
+	ID    int32
+}
+
+func (q *Queries) UpdateBoardTitle(ctx context.Context, arg UpdateBoardTitleParams) error {
+	_, err := q.db.Exec(ctx, updateBoardTitle, arg.Title, arg.ID)
 	return err
 }
 
@@ -555,7 +2477,8 @@ UPDATE member_profile SET
   bio = $4,
   timezone = $5,
   preferred_name = $6,
-  pronouns = $7
+  pronouns = $7,
+  relative_times = $8
 WHERE member_id = $1
 `
 
@@ -567,6 +2490,7 @@ type UpdateMemberProfileByIDParams struct {
 	Timezone      pgtype.Text
 	PreferredName pgtype.Text
 	Pronouns      pgtype.Text
+	RelativeTimes bool
 }
 
 func (q *Queries) UpdateMemberProfileByID(ctx context.Context, arg UpdateMemberProfileByIDParams) error {
@@ -578,44 +2502,107 @@ func (q *Queries) UpdateMemberProfileByID(ctx context.Context, arg UpdateMemberP
 		arg.Timezone,
 		arg.PreferredName,
 		arg.Pronouns,
+		arg.RelativeTimes,
 	)
 	return err
 }
 
 const updateThread = `-- name: UpdateThread :exec
 UPDATE thread SET
-  subject = $1
+  subject = $1,
+  edited = true
 WHERE id = $2
   AND member_id = $3
-  AND date_posted >= NOW() - INTERVAL '900 seconds'
+  AND date_posted >= NOW() - $4::int * INTERVAL '1 second'
 `
 
 type UpdateThreadParams struct {
-	Subject  string
-	ID       int64
-	MemberID int64
+	Subject    string
+	ID         int64
+	MemberID   int64
+	EditWindow int32
 }
 
 func (q *Queries) UpdateThread(ctx context.Context, arg UpdateThreadParams) error {
-	_, err := q.db.Exec(ctx, updateThread, arg.Subject, arg.ID, arg.MemberID)
+	_, err := q.db.Exec(ctx, updateThread,
+		arg.Subject,
+		arg.ID,
+		arg.MemberID,
+		arg.EditWindow,
+	)
 	return err
 }
 
 const updateThreadPost = `-- name: UpdateThreadPost :exec
 UPDATE thread_post SET
-  body = $1
+  body = $1,
+  edited = true,
+  date_edited = now(),
+  unfurled = false
 WHERE id = $2
   AND member_id = $3
-  AND date_posted >= NOW() - INTERVAL '900 seconds'
+  AND date_posted >= NOW() - $4::int * INTERVAL '1 second'
 `
 
 type UpdateThreadPostParams struct {
-	Body     pgtype.Text
-	ID       int64
-	MemberID int64
+	Body       pgtype.Text
+	ID         int64
+	MemberID   int64
+	EditWindow int32
 }
 
 func (q *Queries) UpdateThreadPost(ctx context.Context, arg UpdateThreadPostParams) error {
-	_, err := q.db.Exec(ctx, updateThreadPost, arg.Body, arg.ID, arg.MemberID)
+	_, err := q.db.Exec(ctx, updateThreadPost,
+		arg.Body,
+		arg.ID,
+		arg.MemberID,
+		arg.EditWindow,
+	)
+	return err
+}
+
+const upsertLinkPreview = `-- name: UpsertLinkPreview :exec
+INSERT INTO link_preview (url, ok, title, description, site_name, image_url)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (url) DO UPDATE
+SET ok=EXCLUDED.ok, title=EXCLUDED.title, description=EXCLUDED.description,
+  site_name=EXCLUDED.site_name, image_url=EXCLUDED.image_url, date_fetched=now()
+`
+
+type UpsertLinkPreviewParams struct {
+	Url         string
+	Ok          bool
+	Title       string
+	Description string
+	SiteName    string
+	ImageUrl    string
+}
+
+func (q *Queries) UpsertLinkPreview(ctx context.Context, arg UpsertLinkPreviewParams) error {
+	_, err := q.db.Exec(ctx, upsertLinkPreview,
+		arg.Url,
+		arg.Ok,
+		arg.Title,
+		arg.Description,
+		arg.SiteName,
+		arg.ImageUrl,
+	)
+	return err
+}
+
+const upsertRateLimit = `-- name: UpsertRateLimit :exec
+INSERT INTO rate_limit (role, requests, window_seconds)
+VALUES ($1, $2, $3)
+ON CONFLICT (role) DO UPDATE SET requests = EXCLUDED.requests, window_seconds = EXCLUDED.window_seconds
+`
+
+type UpsertRateLimitParams struct {
+	Role          string
+	Requests      int32
+	WindowSeconds int32
+}
+
+func (q *Queries) UpsertRateLimit(ctx context.Context, arg UpsertRateLimitParams) error {
+	_, err := q.db.Exec(ctx, upsertRateLimit, arg.Role, arg.Requests, arg.WindowSeconds)
 	return err
 }
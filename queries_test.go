@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDatabase connects to the Postgres database at TEST_DATABASE_URL and
+// loads sqlc/schema.sql into a schema of the test's own, which is dropped
+// when the test ends. Tests using it are skipped without a database.
+func testDatabase(t *testing.T) *pgx.Conn {
+	t.Helper()
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, dbURL)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close(context.Background()) })
+
+	schema := fmt.Sprintf("tdiscuss_test_%d", time.Now().UnixNano())
+	_, err = conn.Exec(ctx, "CREATE SCHEMA "+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE") })
+
+	_, err = conn.Exec(ctx, "SET search_path TO "+schema)
+	require.NoError(t, err)
+
+	ddl, err := os.ReadFile("sqlc/schema.sql")
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, string(ddl))
+	require.NoError(t, err)
+
+	return conn
+}
+
+func TestListMemberDrafts_Database(t *testing.T) {
+	conn := testDatabase(t)
+	ctx := context.Background()
+	q := New(conn)
+
+	var memberID int64
+	require.NoError(t, conn.QueryRow(ctx, "INSERT INTO member (email) VALUES ('alice@example.com') RETURNING id").Scan(&memberID))
+	var boardID int32
+	require.NoError(t, conn.QueryRow(ctx, "UPDATE board_data SET acl = '{group:hr}' WHERE slug = 'general' RETURNING id").Scan(&boardID))
+	var threadID int64
+	require.NoError(t, conn.QueryRow(ctx,
+		"INSERT INTO thread (board_id, member_id, last_member_id, subject) VALUES ($1, $2, $2, 'Hiring') RETURNING id",
+		boardID, memberID).Scan(&threadID))
+
+	_, err := conn.Exec(ctx, "INSERT INTO draft (member_id, subject, body, date_saved) VALUES ($1, 'New thread', 'a', now() - interval '1 minute')", memberID)
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, "INSERT INTO draft (member_id, thread_id, body) VALUES ($1, $2, 'b')", memberID, threadID)
+	require.NoError(t, err)
+
+	savedAfter := pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true}
+	drafts, err := q.ListMemberDrafts(ctx, ListMemberDraftsParams{
+		MemberID:   memberID,
+		SavedAfter: savedAfter,
+		Principals: []string{"group:hr"},
+	})
+	require.NoError(t, err)
+	require.Len(t, drafts, 2)
+
+	// Most recently saved first, with the subject of the thread replied to
+	assert.Equal(t, pgtype.Int8{Int64: threadID, Valid: true}, drafts[0].ThreadID)
+	assert.Equal(t, "Hiring", drafts[0].ThreadSubject.String)
+
+	assert.False(t, drafts[1].ThreadID.Valid)
+	assert.Equal(t, "New thread", drafts[1].Subject)
+
+	// A reply to a thread on a board the member can no longer see is left out
+	drafts, err = q.ListMemberDrafts(ctx, ListMemberDraftsParams{
+		MemberID:   memberID,
+		SavedAfter: savedAfter,
+	})
+	require.NoError(t, err)
+	require.Len(t, drafts, 1)
+	assert.Equal(t, "New thread", drafts[0].Subject)
+}
+
+func TestListThreads_BoardAccess_Database(t *testing.T) {
+	conn := testDatabase(t)
+	ctx := context.Background()
+	q := New(conn)
+
+	var memberID int64
+	require.NoError(t, conn.QueryRow(ctx, "INSERT INTO member (email) VALUES ('alice@example.com') RETURNING id").Scan(&memberID))
+	var general, private int32
+	require.NoError(t, conn.QueryRow(ctx, "SELECT id FROM board_data WHERE slug = 'general'").Scan(&general))
+	require.NoError(t, conn.QueryRow(ctx,
+		"INSERT INTO board_data (slug, title, acl) VALUES ('hr', 'HR', '{group:hr,Carol@Example.com}') RETURNING id").Scan(&private))
+
+	// Enough private threads to fill the page, so the public one only shows
+	// up if the access list is applied before the LIMIT
+	_, err := conn.Exec(ctx, `
+		INSERT INTO thread (board_id, member_id, last_member_id, subject, date_last_posted)
+		SELECT $1, $2, $2, 'private ' || n, now() - n * interval '1 second' FROM generate_series(1, 100) n`,
+		private, memberID)
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx,
+		"INSERT INTO thread (board_id, member_id, last_member_id, subject, date_last_posted) VALUES ($1, $2, $2, 'public', now() - interval '1 day')",
+		general, memberID)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		arg    ListThreadsParams
+		public bool
+		count  int
+	}{
+		{name: "outsider", arg: ListThreadsParams{ViewerEmail: "bob@example.com", Principals: []string{"group:eng"}}, public: true, count: 1},
+		{name: "group", arg: ListThreadsParams{ViewerEmail: "bob@example.com", Principals: []string{"group:hr"}}, count: 100},
+		{name: "email ignores case", arg: ListThreadsParams{ViewerEmail: "carol@example.com"}, count: 100},
+		{name: "admin", arg: ListThreadsParams{ViewerEmail: "admin@example.com", SeeAllBoards: true}, count: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			threads, err := q.ListThreads(ctx, tt.arg)
+			require.NoError(t, err)
+			assert.Len(t, threads, tt.count)
+			if tt.public {
+				assert.Equal(t, "public", threads[0].Subject)
+			}
+		})
+	}
+}
+
+func TestUpdateBoardSettings_Database(t *testing.T) {
+	conn := testDatabase(t)
+	ctx := context.Background()
+	q := New(conn)
+
+	var general, other int32
+	require.NoError(t, conn.QueryRow(ctx, "SELECT id FROM board_data WHERE slug = 'general'").Scan(&general))
+	require.NoError(t, conn.QueryRow(ctx, "INSERT INTO board_data (slug, title) VALUES ('other', 'Other') RETURNING id").Scan(&other))
+
+	require.NoError(t, q.UpdateBoardHideEmails(ctx, UpdateBoardHideEmailsParams{HideEmails: true, ID: other}))
+	require.NoError(t, q.UpdateBoardReactionEmoji(ctx, UpdateBoardReactionEmojiParams{ReactionEmoji: []string{"rocket"}, ID: other}))
+
+	// Only the board being edited changes
+	var hideEmails bool
+	var reactionEmoji []string
+	require.NoError(t, conn.QueryRow(ctx, "SELECT hide_emails, reaction_emoji FROM board_data WHERE id = $1", other).Scan(&hideEmails, &reactionEmoji))
+	assert.True(t, hideEmails)
+	assert.Equal(t, []string{"rocket"}, reactionEmoji)
+
+	require.NoError(t, conn.QueryRow(ctx, "SELECT hide_emails, reaction_emoji FROM board_data WHERE id = $1", general).Scan(&hideEmails, &reactionEmoji))
+	assert.False(t, hideEmails)
+	assert.Equal(t, []string{"+1", "tada", "eyes", "heart", "laughing"}, reactionEmoji)
+}
+
+func TestUpdateThreadPost_EditWindow_Database(t *testing.T) {
+	conn := testDatabase(t)
+	ctx := context.Background()
+	q := New(conn)
+
+	var memberID int64
+	require.NoError(t, conn.QueryRow(ctx, "INSERT INTO member (email) VALUES ('alice@example.com') RETURNING id").Scan(&memberID))
+	var boardID int32
+	require.NoError(t, conn.QueryRow(ctx, "INSERT INTO board_data (slug, title, edit_window) VALUES ('slow', 'Slow', 3600) RETURNING id").Scan(&boardID))
+	var threadID int64
+	require.NoError(t, conn.QueryRow(ctx,
+		"INSERT INTO thread (board_id, member_id, last_member_id, subject) VALUES ($1, $2, $2, 'Slow thread') RETURNING id",
+		boardID, memberID).Scan(&threadID))
+
+	// Older than the default board's 15 minutes, inside this board's hour
+	var postID int64
+	require.NoError(t, conn.QueryRow(ctx,
+		"INSERT INTO thread_post (thread_id, member_id, body, date_posted) VALUES ($1, $2, 'first', now() - interval '30 minutes') RETURNING id",
+		threadID, memberID).Scan(&postID))
+
+	post, err := q.GetThreadPostForEdit(ctx, GetThreadPostForEditParams{ID: postID, ID_2: memberID})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3600), post.EditWindow.Int32)
+
+	edit := func(window int32, body string) string {
+		require.NoError(t, q.UpdateThreadPost(ctx, UpdateThreadPostParams{
+			Body:       pgtype.Text{String: body, Valid: true},
+			ID:         postID,
+			MemberID:   memberID,
+			EditWindow: window,
+		}))
+		var stored string
+		require.NoError(t, conn.QueryRow(ctx, "SELECT body FROM thread_post WHERE id = $1", postID).Scan(&stored))
+		return stored
+	}
+	assert.Equal(t, "first", edit(900, "too late"))
+	assert.Equal(t, "second", edit(post.EditWindow.Int32, "second"))
+}
+
+func TestCanSeeAttachment_Database(t *testing.T) {
+	conn := testDatabase(t)
+	ctx := context.Background()
+	q := New(conn)
+
+	var alice, bob int64
+	require.NoError(t, conn.QueryRow(ctx, "INSERT INTO member (email) VALUES ('alice@example.com') RETURNING id").Scan(&alice))
+	require.NoError(t, conn.QueryRow(ctx, "INSERT INTO member (email) VALUES ('bob@example.com') RETURNING id").Scan(&bob))
+	var private int32
+	require.NoError(t, conn.QueryRow(ctx, "INSERT INTO board_data (slug, title, acl) VALUES ('hr', 'HR', '{group:hr}') RETURNING id").Scan(&private))
+	var threadID int64
+	require.NoError(t, conn.QueryRow(ctx,
+		"INSERT INTO thread (board_id, member_id, last_member_id, subject) VALUES ($1, $2, $2, 'Hiring') RETURNING id",
+		private, alice).Scan(&threadID))
+
+	hash := strings.Repeat("a", 64)
+	upload := CreateAttachmentParams{Hash: hash, ContentType: "image/png", Size: 1, Filename: "cat.png", MemberID: alice}
+	require.NoError(t, q.CreateAttachment(ctx, upload))
+	require.NoError(t, q.CreateThreadPost(ctx, CreateThreadPostParams{
+		ThreadID:    threadID,
+		Body:        pgtype.Text{String: `<p><img src="/file/` + hash + `"></p>`, Valid: true},
+		MemberID:    alice,
+		Attachments: []string{hash, strings.Repeat("b", 64)},
+	}))
+
+	visible := func(arg CanSeeAttachmentParams) bool {
+		arg.Hash = hash
+		ok, err := q.CanSeeAttachment(ctx, arg)
+		require.NoError(t, err)
+		return ok
+	}
+	assert.True(t, visible(CanSeeAttachmentParams{MemberID: alice, ViewerEmail: "alice@example.com"}), "uploader")
+	assert.False(t, visible(CanSeeAttachmentParams{MemberID: bob, ViewerEmail: "bob@example.com"}), "outsider")
+	assert.True(t, visible(CanSeeAttachmentParams{MemberID: bob, ViewerEmail: "bob@example.com", Principals: []string{"group:hr"}}), "can see the post")
+	assert.True(t, visible(CanSeeAttachmentParams{MemberID: bob, ViewerEmail: "bob@example.com", SeeAllBoards: true}), "admin")
+
+	// Posts held for moderation or hidden by a moderator grant no access
+	for _, column := range []string{"held", "hidden"} {
+		_, err := conn.Exec(ctx, "UPDATE thread_post SET "+column+" = true WHERE thread_id = $1", threadID)
+		require.NoError(t, err)
+		assert.False(t, visible(CanSeeAttachmentParams{MemberID: bob, ViewerEmail: "bob@example.com", Principals: []string{"group:hr"}}), column)
+		_, err = conn.Exec(ctx, "UPDATE thread_post SET "+column+" = false WHERE thread_id = $1", threadID)
+		require.NoError(t, err)
+	}
+
+	// Uploading the same file again gives its second uploader access too
+	upload.MemberID = bob
+	require.NoError(t, q.CreateAttachment(ctx, upload))
+	assert.True(t, visible(CanSeeAttachmentParams{MemberID: bob, ViewerEmail: "bob@example.com"}), "second uploader")
+}
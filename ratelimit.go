@@ -4,181 +4,139 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"net/http"
-	"path"
 	"sync"
 	"time"
 
-	"golang.org/x/time/rate"
+	"github.com/imeyer/tdiscuss/middleware"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
-// RateLimiter manages rate limiting for the application
-type RateLimiter struct {
-	visitors map[string]*visitor
-	mu       sync.RWMutex
-	limit    rate.Limit
-	burst    int
-	logger   *slog.Logger
-}
+const (
+	// roleLimitsTTL is how long role limits are cached before being reloaded,
+	// which bounds how long other replicas take to pick up an admin's change
+	roleLimitsTTL = time.Minute
 
-// visitor represents a single user's rate limiter
-type visitor struct {
-	limiter  *rate.Limiter
-	lastSeen time.Time
-}
+	// rateLimitPruneInterval is how often expired window counters are deleted
+	rateLimitPruneInterval = 10 * time.Minute
 
-// NewRateLimiter creates a new rate limiter
-func NewRateLimiter(requestsPerSecond float64, burst int, logger *slog.Logger) *RateLimiter {
-	rl := &RateLimiter{
-		visitors: make(map[string]*visitor),
-		limit:    rate.Limit(requestsPerSecond),
-		burst:    burst,
-		logger:   logger,
-	}
+	// rateLimitRetention is how long window counters are kept. It must be at
+	// least twice the longest configurable window.
+	rateLimitRetention = 2 * time.Duration(MaxRateLimitWindowSeconds) * time.Second
+)
 
-	// Start cleanup goroutine to remove old visitors
-	go rl.cleanupVisitors()
+// PostgresRateLimitStore keeps sliding window counters in the
+// rate_limit_window table so limits survive restarts and are shared between
+// replicas.
+type PostgresRateLimitStore struct {
+	queries Querier
+}
 
-	return rl
+// NewPostgresRateLimitStore creates a rate limit store backed by queries.
+func NewPostgresRateLimitStore(queries Querier) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{queries: queries}
 }
 
-// getVisitor retrieves or creates a rate limiter for a specific user
-func (rl *RateLimiter) getVisitor(userID string) *rate.Limiter {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	v, exists := rl.visitors[userID]
-	if !exists {
-		limiter := rate.NewLimiter(rl.limit, rl.burst)
-		rl.visitors[userID] = &visitor{
-			limiter:  limiter,
-			lastSeen: time.Now(),
-		}
-		return limiter
+// Hit implements middleware.RateLimitStore with a single upsert that also
+// reads the previous window's count.
+func (s *PostgresRateLimitStore) Hit(ctx context.Context, key string, window time.Duration, now time.Time) (int, int, error) {
+	start := now.Truncate(window)
+	row, err := s.queries.HitRateLimitWindow(ctx, HitRateLimitWindowParams{
+		Key:                 key,
+		WindowStart:         pgtype.Timestamptz{Time: start, Valid: true},
+		PreviousWindowStart: pgtype.Timestamptz{Time: start.Add(-window), Valid: true},
+	})
+	if err != nil {
+		return 0, 0, err
 	}
-
-	v.lastSeen = time.Now()
-	return v.limiter
+	return int(row.CurrentCount), int(row.PreviousCount), nil
 }
 
-// cleanupVisitors removes visitors that haven't been seen for over an hour
-func (rl *RateLimiter) cleanupVisitors() {
-	ticker := time.NewTicker(15 * time.Minute)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		rl.mu.Lock()
-		for id, v := range rl.visitors {
-			if time.Since(v.lastSeen) > time.Hour {
-				delete(rl.visitors, id)
-			}
-		}
-		rl.mu.Unlock()
+// setupRateLimitStore creates the store selected by the -rate-limit-store flag.
+// Counters are kept in memory unless the board runs as several replicas, as
+// the postgres store writes to the database on every request.
+func setupRateLimitStore(kind string, queries Querier) (middleware.RateLimitStore, error) {
+	switch kind {
+	case "postgres":
+		return NewPostgresRateLimitStore(queries), nil
+	case "memory":
+		return middleware.NewMemoryRateLimitStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", kind)
 	}
 }
 
-// RateLimitMiddleware creates a middleware that enforces rate limits
-func (rl *RateLimiter) RateLimitMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Get user from context
-		user, err := GetUser(r)
-		if err != nil {
-			// If no user context, fall back to IP-based limiting
-			userID := r.RemoteAddr
-			limiter := rl.getVisitor(userID)
-
-			if !limiter.Allow() {
-				rl.logger.WarnContext(r.Context(), "rate limit exceeded (IP)",
-					slog.String("ip", r.RemoteAddr),
-					slog.String("path", r.URL.Path))
-				http.Error(w, "Rate limit exceeded. Please try again later.", http.StatusTooManyRequests)
-				return
-			}
-		} else {
-			// User-based rate limiting
-			userID := fmt.Sprintf("user:%d", user.ID)
-			limiter := rl.getVisitor(userID)
-
-			if !limiter.Allow() {
-				rl.logger.WarnContext(r.Context(), "rate limit exceeded (user)",
-					slog.Int64("user_id", user.ID),
-					slog.String("path", r.URL.Path))
-				http.Error(w, "Rate limit exceeded. Please try again later.", http.StatusTooManyRequests)
-				return
-			}
-		}
-
-		// Add rate limit headers
-		w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%.0f", float64(rl.burst)))
-		w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", time.Now().Add(time.Second).Unix()))
+// roleLimitCache serves the per-role limits from the rate_limit table to the
+// rate limiter, which consults them on every request.
+type roleLimitCache struct {
+	queries Querier
+	logger  *slog.Logger
+	now     func() time.Time
 
-		next.ServeHTTP(w, r)
-	})
+	mu       sync.Mutex
+	limits   map[string]middleware.RateLimit
+	loadedAt time.Time
 }
 
-// EndpointRateLimiter provides per-endpoint rate limiting
-type EndpointRateLimiter struct {
-	limiters map[string]*RateLimiter
-	logger   *slog.Logger
+func newRoleLimitCache(queries Querier, logger *slog.Logger) *roleLimitCache {
+	return &roleLimitCache{
+		queries: queries,
+		logger:  logger,
+		now:     time.Now,
+	}
 }
 
-// NewEndpointRateLimiter creates a new endpoint-specific rate limiter
-func NewEndpointRateLimiter(logger *slog.Logger) *EndpointRateLimiter {
-	return &EndpointRateLimiter{
-		limiters: make(map[string]*RateLimiter),
-		logger:   logger,
+// Get returns the current role limits, reloading them once roleLimitsTTL has
+// passed. If the reload fails, the previous limits (or the defaults) are kept.
+func (c *roleLimitCache) Get(ctx context.Context) map[string]middleware.RateLimit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.limits != nil && c.now().Sub(c.loadedAt) < roleLimitsTTL {
+		return c.limits
+	}
+
+	rows, err := c.queries.ListRateLimits(ctx)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "ListRateLimits", slog.String("error", err.Error()))
+		if c.limits == nil {
+			return middleware.DefaultRoleLimits()
+		}
+		return c.limits
 	}
+
+	c.limits = roleLimits(rows)
+	c.loadedAt = c.now()
+	return c.limits
 }
 
-// AddEndpoint adds a rate limiter for a specific endpoint pattern
-func (erl *EndpointRateLimiter) AddEndpoint(pattern string, requestsPerSecond float64, burst int) {
-	erl.limiters[pattern] = NewRateLimiter(requestsPerSecond, burst, erl.logger)
+// Invalidate forces the next Get to reload the limits.
+func (c *roleLimitCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limits = nil
 }
 
-// Middleware returns a middleware that applies endpoint-specific rate limits
-func (erl *EndpointRateLimiter) Middleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Check for endpoint-specific rate limiter
-		for pattern, limiter := range erl.limiters {
-			if matched, _ := path.Match(pattern, r.URL.Path); matched {
-				limiter.RateLimitMiddleware(next).ServeHTTP(w, r)
-				return
-			}
+// roleLimits converts rate_limit rows to limits, starting from the defaults
+// so a role without a row still has a limit.
+func roleLimits(rows []RateLimit) map[string]middleware.RateLimit {
+	limits := middleware.DefaultRoleLimits()
+	for _, row := range rows {
+		limits[row.Role] = middleware.RateLimit{
+			Requests: int(row.Requests),
+			Window:   time.Duration(row.WindowSeconds) * time.Second,
 		}
-
-		// No specific rate limit for this endpoint, pass through
-		next.ServeHTTP(w, r)
-	})
+	}
+	return limits
 }
 
-// WaitRateLimiter provides a rate limiter that waits instead of rejecting
-func (rl *RateLimiter) WaitRateLimitMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ctx, cancel := context.WithTimeout(r.Context(), timeout)
-			defer cancel()
-
-			user, err := GetUser(r)
-			var userID string
-			if err != nil {
-				userID = r.RemoteAddr
-			} else {
-				userID = fmt.Sprintf("user:%d", user.ID)
-			}
-
-			limiter := rl.getVisitor(userID)
-
-			// Wait for permission with timeout
-			err = limiter.Wait(ctx)
-			if err != nil {
-				rl.logger.WarnContext(r.Context(), "rate limit wait timeout",
-					slog.String("user", userID),
-					slog.String("path", r.URL.Path))
-				http.Error(w, "Rate limit exceeded. Please try again later.", http.StatusTooManyRequests)
-				return
-			}
-
-			next.ServeHTTP(w, r)
-		})
+// pruneRateLimitWindows deletes window counters too old to affect any limit.
+func (s *DiscussService) pruneRateLimitWindows(ctx context.Context) error {
+	cutoff := time.Now().Add(-rateLimitRetention)
+	deleted, err := s.queries.DeleteExpiredRateLimitWindows(ctx, pgtype.Timestamptz{Time: cutoff, Valid: true})
+	if err != nil {
+		return err
 	}
+
+	s.logger.DebugContext(ctx, "pruned rate limit windows", slog.Int64("deleted", deleted))
+	return nil
 }
@@ -1,191 +1,101 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"io"
 	"log/slog"
-	"net/http"
-	"net/http/httptest"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/imeyer/tdiscuss/middleware"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestRateLimiter(t *testing.T) {
-	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+func TestPostgresRateLimitStoreHit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 42, 0, time.UTC)
 
-	t.Run("allows requests within limit", func(t *testing.T) {
-		rl := NewRateLimiter(10, 10, logger) // 10 req/s, burst 10
-		handler := rl.RateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-		}))
-
-		// Make 5 requests - all should succeed
-		for i := 0; i < 5; i++ {
-			req := httptest.NewRequest("GET", "/", nil)
-			req.RemoteAddr = "127.0.0.1:12345"
-			rr := httptest.NewRecorder()
-
-			handler.ServeHTTP(rr, req)
-			assert.Equal(t, http.StatusOK, rr.Code)
-		}
-	})
-
-	t.Run("blocks requests exceeding limit", func(t *testing.T) {
-		rl := NewRateLimiter(1, 2, logger) // 1 req/s, burst 2
-		handler := rl.RateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-		}))
-
-		// First 2 requests should succeed (burst)
-		for i := 0; i < 2; i++ {
-			req := httptest.NewRequest("GET", "/", nil)
-			req.RemoteAddr = "127.0.0.1:12345"
-			rr := httptest.NewRecorder()
-
-			handler.ServeHTTP(rr, req)
-			assert.Equal(t, http.StatusOK, rr.Code)
-		}
-
-		// Third request should be rate limited
-		req := httptest.NewRequest("GET", "/", nil)
-		req.RemoteAddr = "127.0.0.1:12345"
-		rr := httptest.NewRecorder()
-
-		handler.ServeHTTP(rr, req)
-		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
-		assert.Contains(t, rr.Body.String(), "Rate limit exceeded")
+	var got HitRateLimitWindowParams
+	store := NewPostgresRateLimitStore(&MockQueries{
+		HitRateLimitWindowFunc: func(ctx context.Context, arg HitRateLimitWindowParams) (HitRateLimitWindowRow, error) {
+			got = arg
+			return HitRateLimitWindowRow{CurrentCount: 3, PreviousCount: 7}, nil
+		},
 	})
 
-	t.Run("different IPs have separate limits", func(t *testing.T) {
-		rl := NewRateLimiter(1, 1, logger) // 1 req/s, burst 1
-		handler := rl.RateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-		}))
-
-		// First IP - one request
-		req1 := httptest.NewRequest("GET", "/", nil)
-		req1.RemoteAddr = "127.0.0.1:12345"
-		rr1 := httptest.NewRecorder()
-		handler.ServeHTTP(rr1, req1)
-		assert.Equal(t, http.StatusOK, rr1.Code)
-
-		// Second request from same IP should fail
-		req2 := httptest.NewRequest("GET", "/", nil)
-		req2.RemoteAddr = "127.0.0.1:12345"
-		rr2 := httptest.NewRecorder()
-		handler.ServeHTTP(rr2, req2)
-		assert.Equal(t, http.StatusTooManyRequests, rr2.Code)
-
-		// Different IP should succeed
-		req3 := httptest.NewRequest("GET", "/", nil)
-		req3.RemoteAddr = "192.168.1.1:12345"
-		rr3 := httptest.NewRecorder()
-		handler.ServeHTTP(rr3, req3)
-		assert.Equal(t, http.StatusOK, rr3.Code)
-	})
+	current, previous, err := store.Hit(context.Background(), "member:user:1", time.Minute, now)
+	require.NoError(t, err)
+	assert.Equal(t, 3, current)
+	assert.Equal(t, 7, previous)
 
-	t.Run("rate limit headers are set", func(t *testing.T) {
-		rl := NewRateLimiter(10, 20, logger)
-		handler := rl.RateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-		}))
+	assert.Equal(t, "member:user:1", got.Key)
+	assert.Equal(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), got.WindowStart.Time)
+	assert.Equal(t, time.Date(2024, 1, 1, 11, 59, 0, 0, time.UTC), got.PreviousWindowStart.Time)
+}
 
-		req := httptest.NewRequest("GET", "/", nil)
-		req.RemoteAddr = "127.0.0.1:12345"
-		rr := httptest.NewRecorder()
+func TestSetupRateLimitStore(t *testing.T) {
+	store, err := setupRateLimitStore("postgres", &MockQueries{})
+	require.NoError(t, err)
+	assert.IsType(t, &PostgresRateLimitStore{}, store)
 
-		handler.ServeHTTP(rr, req)
+	store, err = setupRateLimitStore("memory", &MockQueries{})
+	require.NoError(t, err)
+	assert.IsType(t, &middleware.MemoryRateLimitStore{}, store)
 
-		assert.Equal(t, "20", rr.Header().Get("X-RateLimit-Limit"))
-		assert.NotEmpty(t, rr.Header().Get("X-RateLimit-Reset"))
-	})
+	_, err = setupRateLimitStore("redis", &MockQueries{})
+	assert.Error(t, err)
 }
 
-func TestEndpointRateLimiter(t *testing.T) {
-	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-
-	t.Run("applies endpoint-specific limits", func(t *testing.T) {
-		erl := NewEndpointRateLimiter(logger)
-		erl.AddEndpoint("/api/*", 0.5, 1) // 1 request per 2 seconds
-
-		handler := erl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-		}))
-
-		// First request to /api/test should succeed
-		req1 := httptest.NewRequest("GET", "/api/test", nil)
-		req1.RemoteAddr = "127.0.0.1:12345"
-		rr1 := httptest.NewRecorder()
-		handler.ServeHTTP(rr1, req1)
-		assert.Equal(t, http.StatusOK, rr1.Code)
-
-		// Second immediate request should fail
-		req2 := httptest.NewRequest("GET", "/api/test", nil)
-		req2.RemoteAddr = "127.0.0.1:12345"
-		rr2 := httptest.NewRecorder()
-		handler.ServeHTTP(rr2, req2)
-		assert.Equal(t, http.StatusTooManyRequests, rr2.Code)
-
-		// Request to different endpoint should succeed
-		req3 := httptest.NewRequest("GET", "/other", nil)
-		req3.RemoteAddr = "127.0.0.1:12345"
-		rr3 := httptest.NewRecorder()
-		handler.ServeHTTP(rr3, req3)
-		assert.Equal(t, http.StatusOK, rr3.Code)
-	})
+func TestRateLimitStoreFlagDefault(t *testing.T) {
+	if os.Getenv("RATE_LIMIT_STORE") != "" {
+		t.Skip("RATE_LIMIT_STORE is set")
+	}
+	assert.Equal(t, "memory", flag.Lookup("rate-limit-store").DefValue)
 }
 
-func TestWaitRateLimiter(t *testing.T) {
-	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-
-	t.Run("waits for rate limit", func(t *testing.T) {
-		rl := NewRateLimiter(2, 1, logger) // 2 req/s, burst 1
-		handler := rl.WaitRateLimitMiddleware(1 * time.Second)(
-			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				w.WriteHeader(http.StatusOK)
-			}))
-
-		start := time.Now()
-
-		// First request should be immediate
-		req1 := httptest.NewRequest("GET", "/", nil)
-		req1.RemoteAddr = "127.0.0.1:12345"
-		rr1 := httptest.NewRecorder()
-		handler.ServeHTTP(rr1, req1)
-		assert.Equal(t, http.StatusOK, rr1.Code)
-
-		// Second request should wait but succeed
-		req2 := httptest.NewRequest("GET", "/", nil)
-		req2.RemoteAddr = "127.0.0.1:12345"
-		rr2 := httptest.NewRecorder()
-		handler.ServeHTTP(rr2, req2)
-		assert.Equal(t, http.StatusOK, rr2.Code)
-
-		// Should have waited approximately 500ms (1/2 second)
-		elapsed := time.Since(start)
-		assert.Greater(t, elapsed.Milliseconds(), int64(400))
-	})
-
-	t.Run("times out if wait too long", func(t *testing.T) {
-		rl := NewRateLimiter(0.1, 1, logger) // 0.1 req/s (1 per 10s), burst 1
-		handler := rl.WaitRateLimitMiddleware(50 * time.Millisecond)(
-			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				w.WriteHeader(http.StatusOK)
-			}))
-
-		// First request succeeds
-		req1 := httptest.NewRequest("GET", "/", nil)
-		req1.RemoteAddr = "127.0.0.1:12345"
-		rr1 := httptest.NewRecorder()
-		handler.ServeHTTP(rr1, req1)
-		assert.Equal(t, http.StatusOK, rr1.Code)
-
-		// Second request should timeout (would need to wait 10s, but timeout is 50ms)
-		req2 := httptest.NewRequest("GET", "/", nil)
-		req2.RemoteAddr = "127.0.0.1:12345"
-		rr2 := httptest.NewRecorder()
-		handler.ServeHTTP(rr2, req2)
-		assert.Equal(t, http.StatusTooManyRequests, rr2.Code)
-	})
+func TestRoleLimitCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	calls := 0
+	var listErr error
+	rows := []RateLimit{{Role: "member", Requests: 10, WindowSeconds: 30}}
+	queries := &MockQueries{
+		ListRateLimitsFunc: func(ctx context.Context) ([]RateLimit, error) {
+			calls++
+			return rows, listErr
+		},
+	}
+
+	cache := newRoleLimitCache(queries, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	cache.now = func() time.Time { return now }
+
+	limits := cache.Get(ctx)
+	assert.Equal(t, middleware.RateLimit{Requests: 10, Window: 30 * time.Second}, limits[middleware.RoleMember])
+	// Roles without a row keep their default
+	assert.Equal(t, middleware.DefaultRoleLimits()[middleware.RoleAdmin], limits[middleware.RoleAdmin])
+
+	// Cached until the TTL passes
+	cache.Get(ctx)
+	assert.Equal(t, 1, calls)
+	now = now.Add(roleLimitsTTL)
+	cache.Get(ctx)
+	assert.Equal(t, 2, calls)
+
+	// Invalidate forces a reload
+	rows = []RateLimit{{Role: "member", Requests: 20, WindowSeconds: 60}}
+	cache.Invalidate()
+	assert.Equal(t, 20, cache.Get(ctx)[middleware.RoleMember].Requests)
+
+	// A failed reload keeps the last known limits
+	listErr = errors.New("connection refused")
+	now = now.Add(roleLimitsTTL)
+	assert.Equal(t, 20, cache.Get(ctx)[middleware.RoleMember].Requests)
+
+	// With nothing loaded yet, a failure falls back to the defaults
+	cache.Invalidate()
+	assert.Equal(t, middleware.DefaultRoleLimits(), cache.Get(ctx))
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+	"golang.org/x/net/html"
+)
+
+// HTML written into a post is passed through to the body policy, less any SVG
+// or MathML elements. A body only has those as the diagrams and math rendered
+// from Markdown, and written by hand they could pass for them, down to the
+// diagram IDs the policy allows.
+
+// foreignElements are the SVG and MathML elements the body policy allows.
+var foreignElements = func() map[string]bool {
+	elements := make(map[string]bool)
+	for _, name := range append(svgElements, mathMLElements...) {
+		elements[name] = true
+	}
+	return elements
+}()
+
+// rawHTMLRenderer writes HTML blocks and inline HTML without their foreign
+// elements.
+type rawHTMLRenderer struct{}
+
+func (r *rawHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindHTMLBlock, r.renderHTMLBlock)
+	reg.Register(ast.KindRawHTML, r.renderRawHTML)
+}
+
+func (r *rawHTMLRenderer) renderHTMLBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.HTMLBlock)
+
+	// A tag can span lines, so the block is filtered as a whole
+	var raw bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		raw.Write(segment.Value(source))
+	}
+	if n.HasClosure() {
+		raw.Write(n.ClosureLine.Value(source))
+	}
+	_, _ = w.Write(stripForeignElements(raw.Bytes()))
+	return ast.WalkContinue, nil
+}
+
+func (r *rawHTMLRenderer) renderRawHTML(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkSkipChildren, nil
+	}
+	n := node.(*ast.RawHTML)
+
+	var raw bytes.Buffer
+	for i := 0; i < n.Segments.Len(); i++ {
+		segment := n.Segments.At(i)
+		raw.Write(segment.Value(source))
+	}
+	_, _ = w.Write(stripForeignElements(raw.Bytes()))
+	return ast.WalkSkipChildren, nil
+}
+
+// stripForeignElements drops the tags of foreign elements from HTML, leaving
+// everything else as written. What they held is left for the body policy.
+func stripForeignElements(raw []byte) []byte {
+	var out bytes.Buffer
+	z := html.NewTokenizer(bytes.NewReader(raw))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return out.Bytes()
+		}
+
+		token := bytes.Clone(z.Raw())
+		if tt == html.StartTagToken || tt == html.EndTagToken || tt == html.SelfClosingTagToken {
+			if name, _ := z.TagName(); foreignElements[string(name)] {
+				continue
+			}
+		}
+		out.Write(token)
+	}
+}
+
+type rawHTMLExtension struct{}
+
+// RawHTML is a goldmark extension that keeps SVG and MathML out of the HTML
+// written into a post.
+var RawHTML = &rawHTMLExtension{}
+
+func (e *rawHTMLExtension) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(
+			util.Prioritized(&rawHTMLRenderer{}, 100),
+		),
+	)
+}
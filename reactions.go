@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/imeyer/tdiscuss/middleware"
+	"github.com/jackc/pgx/v5"
+)
+
+// defaultReactionEmoji is used when the board has no reaction set configured.
+var defaultReactionEmoji = []string{"+1", "tada", "eyes", "heart", "laughing"}
+
+// PostReactionTemplateData is one reaction button shown under a post.
+type PostReactionTemplateData struct {
+	Emoji   string
+	Unicode string
+	Count   int64
+	Reacted bool
+}
+
+// postReactionCount is one element of the reactions column of ListThreadPosts.
+type postReactionCount struct {
+	Emoji   string `json:"emoji"`
+	Count   int64  `json:"count"`
+	Reacted bool   `json:"reacted"`
+}
+
+// emojiUnicode returns the characters for an emoji short name, or false if
+// the name is unknown or has no unicode form.
+func emojiUnicode(shortName string) (string, bool) {
+	e, ok := emojiDefinitions.Get(shortName)
+	if !ok || !e.IsUnicode() {
+		return "", false
+	}
+	return string(e.Unicode), true
+}
+
+// GetBoardReactionEmoji returns the board's configured reaction emoji.
+func GetBoardReactionEmoji(r *http.Request) []string {
+	if r != nil && r.Context() != nil {
+		if boardData, ok := middleware.GetBoardData(r.Context()); ok && boardData != nil {
+			if bd, ok := boardData.(GetBoardDataRow); ok {
+				return boardReactionEmoji(bd.ReactionEmoji)
+			}
+			if bd, ok := boardData.(*GetBoardDataRow); ok && bd != nil {
+				return boardReactionEmoji(bd.ReactionEmoji)
+			}
+		}
+	}
+	return defaultReactionEmoji
+}
+
+// boardReactionEmoji returns a board's reaction emoji, or the defaults if
+// it has none configured.
+func boardReactionEmoji(configured []string) []string {
+	if len(configured) > 0 {
+		return configured
+	}
+	return defaultReactionEmoji
+}
+
+// buildPostReactions merges the configured reaction set with a post's
+// aggregated counts. Configured emoji come first in their configured order,
+// followed by any emoji that were reacted with before being removed from the
+// set, so existing reactions can still be seen and taken back.
+func buildPostReactions(configured []string, raw []byte) []PostReactionTemplateData {
+	var counts []postReactionCount
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &counts); err != nil {
+			counts = nil
+		}
+	}
+
+	byEmoji := make(map[string]postReactionCount, len(counts))
+	for _, c := range counts {
+		byEmoji[c.Emoji] = c
+	}
+
+	reactions := make([]PostReactionTemplateData, 0, len(configured)+len(counts))
+	for _, name := range configured {
+		unicode, ok := emojiUnicode(name)
+		if !ok {
+			continue
+		}
+		c := byEmoji[name]
+		reactions = append(reactions, PostReactionTemplateData{
+			Emoji:   name,
+			Unicode: unicode,
+			Count:   c.Count,
+			Reacted: c.Reacted,
+		})
+	}
+
+	for _, c := range counts {
+		if slices.Contains(configured, c.Emoji) {
+			continue
+		}
+		unicode, ok := emojiUnicode(c.Emoji)
+		if !ok {
+			continue
+		}
+		reactions = append(reactions, PostReactionTemplateData{
+			Emoji:   c.Emoji,
+			Unicode: unicode,
+			Count:   c.Count,
+			Reacted: c.Reacted,
+		})
+	}
+
+	return reactions
+}
+
+// ReactToThreadPost toggles the current member's reaction on a post. Adding a
+// reaction is limited to the board's configured set; removing one is always
+// allowed.
+func (s *DiscussService) ReactToThreadPost(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "ReactToThreadPost")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	threadID, err := strconv.ParseInt(r.PathValue("tid"), 10, 64)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error parsing thread ID", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	postID, err := strconv.ParseInt(r.PathValue("pid"), 10, 64)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error parsing post ID", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	user, err := GetUser(r)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "GetUser", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+	emoji := r.Form.Get("emoji")
+	if emoji == "" {
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	span.AddEvent("queries.GetThreadPost")
+	post, err := s.queries.GetThreadPost(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "GetThreadPost", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if post.ThreadID != threadID {
+		s.renderError(w, http.StatusNotFound)
+		return
+	}
+
+	span.AddEvent("queries.DeletePostReaction")
+	removed, err := s.queries.DeletePostReaction(r.Context(), DeletePostReactionParams{
+		ThreadPostID: postID,
+		MemberID:     user.ID,
+		Emoji:        emoji,
+	})
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "DeletePostReaction", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if removed == 0 {
+		// The emoji a post can get are its own board's, not the request's
+		span.AddEvent("queries.GetThreadBoard")
+		board, err := s.queries.GetThreadBoard(r.Context(), threadID)
+		if err != nil {
+			s.logger.ErrorContext(r.Context(), "GetThreadBoard", slog.String("error", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+		if !slices.Contains(boardReactionEmoji(board.ReactionEmoji), emoji) {
+			s.logger.DebugContext(r.Context(), "emoji not in reaction set", slog.String("emoji", emoji))
+			s.renderError(w, http.StatusBadRequest)
+			return
+		}
+
+		span.AddEvent("queries.CreatePostReaction")
+		if err := s.queries.CreatePostReaction(r.Context(), CreatePostReactionParams{
+			ThreadPostID: postID,
+			MemberID:     user.ID,
+			Emoji:        emoji,
+		}); err != nil {
+			s.logger.ErrorContext(r.Context(), "CreatePostReaction", slog.String("error", err.Error()))
+			s.renderError(w, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// nosemgrep
+	http.Redirect(w, r, fmt.Sprintf("/thread/%d#post-%d", threadID, postID), http.StatusSeeOther)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildPostReactions(t *testing.T) {
+	configured := []string{"+1", "tada"}
+
+	tests := []struct {
+		name string
+		raw  []byte
+		want []PostReactionTemplateData
+	}{
+		{
+			name: "No reactions",
+			raw:  []byte(`[]`),
+			want: []PostReactionTemplateData{
+				{Emoji: "+1", Unicode: "👍"},
+				{Emoji: "tada", Unicode: "🎉"},
+			},
+		},
+		{
+			name: "Counts merged into configured set",
+			raw:  []byte(`[{"emoji":"tada","count":3,"reacted":true}]`),
+			want: []PostReactionTemplateData{
+				{Emoji: "+1", Unicode: "👍"},
+				{Emoji: "tada", Unicode: "🎉", Count: 3, Reacted: true},
+			},
+		},
+		{
+			name: "Reactions outside the configured set are kept",
+			raw:  []byte(`[{"emoji":"eyes","count":1,"reacted":false}]`),
+			want: []PostReactionTemplateData{
+				{Emoji: "+1", Unicode: "👍"},
+				{Emoji: "tada", Unicode: "🎉"},
+				{Emoji: "eyes", Unicode: "👀", Count: 1},
+			},
+		},
+		{
+			name: "Unknown emoji are dropped",
+			raw:  []byte(`[{"emoji":"not-an-emoji","count":1,"reacted":false}]`),
+			want: []PostReactionTemplateData{
+				{Emoji: "+1", Unicode: "👍"},
+				{Emoji: "tada", Unicode: "🎉"},
+			},
+		},
+		{
+			name: "Malformed JSON",
+			raw:  []byte(`{`),
+			want: []PostReactionTemplateData{
+				{Emoji: "+1", Unicode: "👍"},
+				{Emoji: "tada", Unicode: "🎉"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildPostReactions(configured, tt.raw)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildPostReactions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Resolutions an admin can choose for a reported post. Each is recorded on
+// the post's open reports.
+const (
+	reportDismiss = "dismiss"
+	reportHide    = "hide"
+	reportDelete  = "delete"
+	reportLock    = "lock"
+	reportBlock   = "block"
+)
+
+// ReportTemplateData is one member's report of a post.
+type ReportTemplateData struct {
+	ReporterEmail string
+	Reason        string
+	DateReported  pgtype.Timestamptz
+}
+
+// ReportedPostTemplateData is a post in the moderation queue with its open
+// reports.
+type ReportedPostTemplateData struct {
+	PostID      int64
+	ThreadID    int64
+	Subject     string
+	AuthorID    int64
+	AuthorEmail string
+	Body        template.HTML
+	Hidden      bool
+	Locked      bool
+	Reports     []ReportTemplateData
+}
+
+// groupReports collects open reports by post, keeping posts in the order
+// they were first reported. Their images are served through images.
+func groupReports(rows []ListOpenReportsRow, images *ImageProxy) []ReportedPostTemplateData {
+	var posts []ReportedPostTemplateData
+	index := make(map[int64]int)
+	for _, row := range rows {
+		i, ok := index[row.ThreadPostID]
+		if !ok {
+			i = len(posts)
+			index[row.ThreadPostID] = i
+			posts = append(posts, ReportedPostTemplateData{
+				PostID:      row.ThreadPostID,
+				ThreadID:    row.ThreadID,
+				Subject:     row.Subject,
+				AuthorID:    row.AuthorID,
+				AuthorEmail: row.AuthorEmail.String,
+				Body:        template.HTML(images.proxyStoredImages(row.Body.String)),
+				Hidden:      row.Hidden,
+				Locked:      row.Locked.Bool,
+			})
+		}
+		posts[i].Reports = append(posts[i].Reports, ReportTemplateData{
+			ReporterEmail: row.ReporterEmail.String,
+			Reason:        row.Reason,
+			DateReported:  row.DateReported,
+		})
+	}
+	return posts
+}
+
+// ReportThreadPost records a member's report of a post for the admins'
+// moderation queue. Reporting a post again while the first report is open
+// has no effect.
+func (s *DiscussService) ReportThreadPost(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "ReportThreadPost")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	threadID, err := strconv.ParseInt(r.PathValue("tid"), 10, 64)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error parsing thread ID", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	postID, err := strconv.ParseInt(r.PathValue("pid"), 10, 64)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error parsing post ID", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	user, err := GetUser(r)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "GetUser", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	reason := SanitizeInput(r.Form.Get("reason"))
+	if errors := ValidateReportForm(reason); len(errors) > 0 {
+		s.logger.DebugContext(r.Context(), "validation failed", slog.String("errors", errors.Error()))
+		http.Error(w, errors.Error(), http.StatusBadRequest)
+		return
+	}
+
+	span.AddEvent("queries.GetThreadPost")
+	post, err := s.queries.GetThreadPost(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "GetThreadPost", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if post.ThreadID != threadID || !s.canViewPost(r, post) {
+		s.renderError(w, http.StatusNotFound)
+		return
+	}
+
+	span.AddEvent("queries.CreateReport")
+	if err := s.queries.CreateReport(r.Context(), CreateReportParams{
+		ThreadPostID: postID,
+		MemberID:     user.ID,
+		Reason:       reason,
+	}); err != nil {
+		s.logger.ErrorContext(r.Context(), "CreateReport", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "post reported", slog.Int64("post_id", postID), slog.Int64("user_id", user.ID))
+
+	// nosemgrep
+	http.Redirect(w, r, fmt.Sprintf("/thread/%d#post-%d", threadID, postID), http.StatusSeeOther)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupReports(t *testing.T) {
+	assert.Empty(t, groupReports(nil, nil))
+
+	rows := []ListOpenReportsRow{
+		{
+			ThreadPostID:  7,
+			ThreadID:      1,
+			Subject:       "first",
+			AuthorID:      3,
+			AuthorEmail:   pgtype.Text{String: "spammer@example.com", Valid: true},
+			ReporterEmail: pgtype.Text{String: "alice@example.com", Valid: true},
+			Reason:        "spam",
+		},
+		{
+			ThreadPostID:  9,
+			ThreadID:      2,
+			Subject:       "second",
+			Hidden:        true,
+			Locked:        pgtype.Bool{Bool: true, Valid: true},
+			ReporterEmail: pgtype.Text{String: "alice@example.com", Valid: true},
+			Reason:        "rude",
+		},
+		{
+			ThreadPostID:  7,
+			ThreadID:      1,
+			Subject:       "first",
+			ReporterEmail: pgtype.Text{String: "bob@example.com", Valid: true},
+			Reason:        "also spam",
+		},
+	}
+
+	posts := groupReports(rows, nil)
+	assert.Len(t, posts, 2)
+
+	// Posts keep the order they were first reported in
+	assert.Equal(t, int64(7), posts[0].PostID)
+	assert.Equal(t, "spammer@example.com", posts[0].AuthorEmail)
+	assert.Len(t, posts[0].Reports, 2)
+	assert.Equal(t, "spam", posts[0].Reports[0].Reason)
+	assert.Equal(t, "bob@example.com", posts[0].Reports[1].ReporterEmail)
+
+	assert.Equal(t, int64(9), posts[1].PostID)
+	assert.True(t, posts[1].Hidden)
+	assert.True(t, posts[1].Locked)
+	assert.Len(t, posts[1].Reports, 1)
+}
+
+func TestModeratePost_HeldPost(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     string
+		rows       int64
+		wantStatus int
+		wantAudit  string
+	}{
+		{name: "approve held post", action: "approve", rows: 1, wantStatus: http.StatusSeeOther, wantAudit: auditApprovePost},
+		{name: "approve post that isn't held", action: "approve", rows: 0, wantStatus: http.StatusNotFound},
+		{name: "reject held post", action: "reject", rows: 1, wantStatus: http.StatusSeeOther, wantAudit: auditRejectPost},
+		{name: "reject post that isn't held", action: "reject", rows: 0, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var audited []string
+			heldPost := func(ctx context.Context, id int64) (int64, error) { return tt.rows, nil }
+			s := &DiscussService{
+				logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+				queries: &MockQueries{
+					ApproveHeldPostFunc: heldPost,
+					RejectHeldPostFunc:  heldPost,
+					CreateAuditLogFunc: func(ctx context.Context, arg CreateAuditLogParams) error {
+						audited = append(audited, arg.Action)
+						return nil
+					},
+				},
+			}
+
+			form := url.Values{"post_id": {"7"}, "action": {tt.action}}
+			r := httptest.NewRequest(http.MethodPost, "/admin/moderation", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			s.moderatePost(w, r, User{ID: 1, IsAdmin: true})
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantAudit == "" {
+				assert.Empty(t, audited, "nothing changed, so nothing is audited")
+			} else {
+				assert.Equal(t, []string{tt.wantAudit}, audited)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"html"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// PostRevisionTemplateData is one version of a post, diffed against the version before it.
+type PostRevisionTemplateData struct {
+	Number      int
+	Email       pgtype.Text
+	Date        pgtype.Timestamptz
+	Current     bool
+	SubjectDiff []DiffSegment
+	BodyDiff    []DiffSegment
+}
+
+// revisionText reduces a stored HTML body to plain text for diffing.
+func revisionText(body string) string {
+	p := bluemonday.StrictPolicy()
+	p.AddSpaceWhenStrippingTag(true)
+	return html.UnescapeString(p.Sanitize(body))
+}
+
+// buildPostRevisions turns the stored revisions of a post into displayable
+// versions, newest first. Each revision row holds the content from before an
+// edit, so version N's content is revision N's row (or the current post for
+// the latest version) and it is attributed to the edit recorded in row N-1.
+func buildPostRevisions(post GetThreadPostRow, author pgtype.Text, revisions []ListThreadPostRevisionsRow) []PostRevisionTemplateData {
+	type version struct {
+		email   pgtype.Text
+		date    pgtype.Timestamptz
+		subject pgtype.Text
+		body    string
+	}
+
+	versions := make([]version, 0, len(revisions)+1)
+	for i, rev := range revisions {
+		v := version{subject: rev.Subject, body: revisionText(rev.Body.String)}
+		if i == 0 {
+			v.email, v.date = author, post.DatePosted
+		} else {
+			v.email, v.date = revisions[i-1].Email, revisions[i-1].DateRevised
+		}
+		versions = append(versions, v)
+	}
+
+	current := version{body: revisionText(post.Body.String)}
+	if post.IsFirstPost {
+		current.subject = post.Subject
+	}
+	if n := len(revisions); n > 0 {
+		current.email, current.date = revisions[n-1].Email, revisions[n-1].DateRevised
+	} else {
+		current.email, current.date = author, post.DatePosted
+	}
+	versions = append(versions, current)
+
+	result := make([]PostRevisionTemplateData, 0, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i]
+		prev := v
+		if i > 0 {
+			prev = versions[i-1]
+		}
+
+		data := PostRevisionTemplateData{
+			Number:   i,
+			Email:    v.email,
+			Date:     v.date,
+			Current:  i == len(versions)-1,
+			BodyDiff: diffWords(prev.body, v.body),
+		}
+		if v.subject.Valid && prev.subject.Valid {
+			data.SubjectDiff = diffWords(prev.subject.String, v.subject.String)
+		}
+		result = append(result, data)
+	}
+
+	return result
+}
+
+// ThreadPostRevisions shows the edit history of a post to its author and to admins.
+func (s *DiscussService) ThreadPostRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := s.telemetry.Tracer.Start(r.Context(), "ThreadPostRevisions")
+	defer span.End()
+
+	r = r.WithContext(ctx)
+
+	threadID, err := strconv.ParseInt(r.PathValue("tid"), 10, 64)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error parsing thread ID", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	postID, err := strconv.ParseInt(r.PathValue("pid"), 10, 64)
+	if err != nil {
+		s.logger.DebugContext(r.Context(), "error parsing post ID", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusBadRequest)
+		return
+	}
+
+	user, err := GetUser(r)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "GetUser", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	span.AddEvent("queries.GetThreadPost")
+	post, err := s.queries.GetThreadPost(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.renderError(w, http.StatusNotFound)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "GetThreadPost", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	if post.ThreadID != threadID {
+		s.renderError(w, http.StatusNotFound)
+		return
+	}
+
+	if !user.IsAdmin && post.MemberID != user.ID {
+		s.renderError(w, http.StatusForbidden)
+		return
+	}
+
+	span.AddEvent("queries.GetMember")
+	author, err := s.queries.GetMember(r.Context(), post.MemberID)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "GetMember", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	span.AddEvent("queries.ListThreadPostRevisions")
+	revisions, err := s.queries.ListThreadPostRevisions(r.Context(), postID)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "ListThreadPostRevisions", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return
+	}
+
+	s.renderTemplate(w, r, "revisions.html", map[string]interface{}{
+		"Title":            GetBoardTitle(r),
+		"User":             user,
+		"CurrentUserEmail": user.Email,
+		"Version":          s.version,
+		"GitSha":           s.gitSha,
+		"Subject":          post.Subject.String,
+		"ThreadID":         threadID,
+		"PostID":           postID,
+		"Revisions":        buildPostRevisions(post, pgtype.Text{String: author.Email, Valid: true}, revisions),
+	})
+}
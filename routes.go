@@ -15,36 +15,48 @@ import (
 
 // SetupRoutes configures all HTTP routes with their appropriate middleware chains
 func SetupRoutes(dsvc *DiscussService, staticFS embed.FS) http.Handler {
-	// Create auth provider with adapters
-	tailscaleAdapter := NewTailscaleClientAdapter(dsvc.tailClient)
 	querierAdapter := NewQuerierAdapter(dsvc.queries)
-	authProvider := middleware.NewTailscaleAuthProvider(tailscaleAdapter, querierAdapter, dsvc.logger)
 
 	// Create middleware setup with converted telemetry config
 	telemetryConfig := ConvertTelemetryConfig(dsvc.telemetry)
-	ms := middleware.NewMiddlewareSetup(dsvc.logger, telemetryConfig, authProvider)
+	ms := middleware.NewMiddlewareSetup(dsvc.logger, telemetryConfig, dsvc.authProvider)
+
+	// Local development mode is served over plain HTTP, so requests must not
+	// be upgraded to HTTPS
+	if dsvc.devMode {
+		delete(ms.SecurityConfig.CSPDirectives, "upgrade-insecure-requests")
+	}
 
 	// Configure rate limiting
 	// We need to use the actual metric.Meter from the original config
 	ms.RateLimitConfig.Meter = dsvc.telemetry.Meter
+	ms.RateLimitConfig.Store = dsvc.rateLimitStore
+	ms.RateLimitConfig.RoleLimits = dsvc.roleLimits.Get
 
 	// Check if we're in dev mode based on debug flag
 	isDevMode := dsvc.logger.Enabled(context.Background(), slog.LevelDebug)
 
-	// Configure rate limits with more permissive admin rate in dev mode
-	adminRate := 0.2 // Default: 1 request per 5 seconds
+	// Configure admin action limits, more permissive in dev mode
+	adminLimit := middleware.EndpointLimit{Pattern: "POST /admin", Requests: 1, Window: 5 * time.Second} // Default: 1 request per 5 seconds
 	if isDevMode {
-		adminRate = 10.0 // Dev mode: 10 requests per second
+		adminLimit.Requests, adminLimit.Window = 10, time.Second // Dev mode: 10 requests per second
 	}
 
 	ms.RateLimitConfig.EndpointLimits = map[string]middleware.EndpointLimit{
-		"/thread/new":        {Pattern: "/thread/new", Rate: 0.5, Burst: 2},      // 1 thread per 2 seconds
-		"/thread/{tid}":      {Pattern: "/thread/{tid}", Rate: 2, Burst: 5},      // 2 posts per second
-		"/thread/{tid}/edit": {Pattern: "/thread/{tid}/edit", Rate: 1, Burst: 3}, // 1 edit per second
-		"/member/edit":       {Pattern: "/member/edit", Rate: 0.5, Burst: 2},     // 1 profile update per 2 seconds
-		"/admin":             {Pattern: "/admin", Rate: adminRate, Burst: 1},     // Varies based on dev mode
+		"POST /thread/new":                {Pattern: "POST /thread/new", Requests: 2, Window: 4 * time.Second},            // 1 thread per 2 seconds
+		"POST /thread/{tid}":              {Pattern: "POST /thread/{tid}", Requests: 5, Window: 3 * time.Second},          // 2 posts per second
+		"POST /thread/{tid}/edit":         {Pattern: "POST /thread/{tid}/edit", Requests: 3, Window: 3 * time.Second},     // 1 edit per second
+		"POST /preview":                   {Pattern: "POST /preview", Requests: 5, Window: 3 * time.Second},               // 2 previews per second, as for posts
+		"POST /member/edit":               {Pattern: "POST /member/edit", Requests: 2, Window: 4 * time.Second},           // 1 profile update per 2 seconds
+		"POST /upload":                    {Pattern: "POST /upload", Requests: 5, Window: 10 * time.Second},               // 1 upload per 2 seconds
+		"POST /draft":                     {Pattern: "POST /draft", Requests: 10, Window: 10 * time.Second},               // 1 autosave per second
+		"POST /thread/{tid}/{pid}/report": {Pattern: "POST /thread/{tid}/{pid}/report", Requests: 5, Window: time.Minute}, // 5 reports per minute
+		"POST /admin":                     adminLimit,                                                                     // Varies based on dev mode
 	}
 
+	// Uploads get their own, larger, request size limit
+	ms.MaxUploadSize = *maxUploadSize
+
 	// Configure observability
 	// Use the actual OpenTelemetry types from the original config
 	ms.ObservabilityConfig = &middleware.ObservabilityConfig{
@@ -68,9 +80,12 @@ func SetupRoutes(dsvc *DiscussService, staticFS embed.FS) http.Handler {
 	// All routes require Tailscale authentication
 	authChain := ms.CreateAuthenticatedChain().Append(boardDataMiddleware)
 	adminChain := ms.CreateAdminChain().Append(boardDataMiddleware)
+	uploadChain := ms.CreateUploadChain().Append(boardDataMiddleware)
 
 	// Routes accessible to all authenticated Tailscale users
 	mux.Handle("GET /{$}", authChain.ThenFunc(dsvc.ListThreads))
+	mux.Handle("GET /b/{slug}/{$}", authChain.ThenFunc(dsvc.ListThreads))
+	mux.Handle("GET /tag/{tag}", authChain.ThenFunc(dsvc.ListThreads))
 	mux.Handle("GET /thread/{tid}", authChain.ThenFunc(dsvc.ListThreadPosts))
 	mux.Handle("GET /member/{mid}", authChain.ThenFunc(dsvc.ListMember))
 	mux.Handle("GET /thread/new", authChain.ThenFunc(dsvc.NewThread))
@@ -79,14 +94,47 @@ func SetupRoutes(dsvc *DiscussService, staticFS embed.FS) http.Handler {
 	mux.Handle("POST /thread/{tid}/edit", authChain.ThenFunc(dsvc.EditThread))
 	mux.Handle("GET /thread/{tid}/{pid}/edit", authChain.ThenFunc(dsvc.EditThreadPost))
 	mux.Handle("POST /thread/{tid}/{pid}/edit", authChain.ThenFunc(dsvc.EditThreadPost))
+	mux.Handle("GET /thread/{tid}/{pid}/revisions", authChain.ThenFunc(dsvc.ThreadPostRevisions))
+	mux.Handle("POST /thread/{tid}/{pid}/react", authChain.ThenFunc(dsvc.ReactToThreadPost))
+	mux.Handle("POST /thread/{tid}/{pid}/report", authChain.ThenFunc(dsvc.ReportThreadPost))
+	mux.Handle("GET /post/{pid}", authChain.ThenFunc(dsvc.PostRedirect))
+	mux.Handle("GET /post/{pid}/preview", authChain.ThenFunc(dsvc.PostPreview))
 	mux.Handle("POST /thread/{tid}", authChain.ThenFunc(dsvc.CreateThreadPost))
+	mux.Handle("GET /thread/{tid}/poll", authChain.ThenFunc(dsvc.ThreadPoll))
+	mux.Handle("POST /thread/{tid}/poll", authChain.ThenFunc(dsvc.VoteInPoll))
 	mux.Handle("GET /member/edit", authChain.ThenFunc(dsvc.EditMemberProfile))
 	mux.Handle("POST /member/edit", authChain.ThenFunc(dsvc.EditMemberProfile))
 	mux.Handle("GET /formatting", authChain.ThenFunc(dsvc.FormattingGuide))
+	mux.Handle("POST /draft", authChain.ThenFunc(dsvc.SaveDraft))
+	mux.Handle("POST /preview", authChain.ThenFunc(dsvc.PreviewPost))
+
+	// Attachments
+	mux.Handle("POST /upload", uploadChain.ThenFunc(dsvc.UploadAttachment))
+	mux.Handle("GET /file/{hash}", authChain.ThenFunc(dsvc.ServeAttachment))
+
+	// Images from other hosts, so readers never fetch them directly
+	mux.Handle("GET "+imageProxyPath, authChain.ThenFunc(dsvc.ProxyImage))
+
+	// Atom feeds, authenticated the same way as the pages they mirror
+	mux.Handle("GET /feed.atom", authChain.ThenFunc(dsvc.BoardFeed))
+	mux.Handle("GET /b/{slug}/feed.atom", authChain.ThenFunc(dsvc.BoardFeed))
+	mux.Handle("GET /tag/{tag}/feed.atom", authChain.ThenFunc(dsvc.BoardFeed))
+	mux.Handle("GET /thread/{tid}/feed.atom", authChain.ThenFunc(dsvc.ThreadFeed))
+	mux.Handle("GET /member/{mid}/feed.atom", authChain.ThenFunc(dsvc.MemberFeed))
+
+	// Fake user switcher for local development mode
+	if dsvc.devMode {
+		mux.Handle("GET /_/dev/users", authChain.ThenFunc(dsvc.DevUsers))
+		mux.Handle("POST /_/dev/users", authChain.ThenFunc(dsvc.DevUsers))
+	}
 
 	// Admin routes
 	mux.Handle("GET /admin", adminChain.ThenFunc(dsvc.Admin))
 	mux.Handle("POST /admin", adminChain.ThenFunc(dsvc.Admin))
+	mux.Handle("GET /admin/audit", adminChain.ThenFunc(dsvc.AdminAudit))
+	mux.Handle("GET /admin/audit/export.jsonl", adminChain.ThenFunc(dsvc.AdminAuditExport))
+	mux.Handle("GET /admin/moderation", adminChain.ThenFunc(dsvc.Moderation))
+	mux.Handle("POST /admin/moderation", adminChain.ThenFunc(dsvc.Moderation))
 
 	// Static files - serve directly from embed.FS
 	staticHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -130,6 +178,12 @@ func SetupRoutes(dsvc *DiscussService, staticFS embed.FS) http.Handler {
 	)
 	mux.Handle("GET /health", healthChain.ThenFunc(dsvc.HealthCheck))
 
+	// Liveness and readiness probes are reachable from the tailnet without
+	// signing in, so they use the public chain
+	probeChain := ms.CreatePublicChain()
+	mux.Handle("GET /healthz", probeChain.ThenFunc(dsvc.Healthz))
+	mux.Handle("GET /readyz", probeChain.ThenFunc(dsvc.Readyz))
+
 	// Metrics endpoint
 	metricsChain := middleware.NewChain(
 		middleware.RequestContextMiddleware(),
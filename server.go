@@ -12,6 +12,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/imeyer/tdiscuss/middleware"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"tailscale.com/client/tailscale/apitype"
@@ -120,6 +121,28 @@ type DiscussService struct {
 	version    string
 	gitSha     string
 	telemetry  *TelemetryConfig
+	blobs      BlobStore
+	// rateLimitStore holds rate limit counters; roleLimits caches the
+	// per-role limits admins configure
+	rateLimitStore middleware.RateLimitStore
+	roleLimits     *roleLimitCache
+	// spamGuard holds the content-level limits on new threads and posts
+	spamGuard SpamGuardConfig
+	// avatars caches the tailnet's Tailscale profile pictures
+	avatars *tailnetAvatarCache
+	// authProvider identifies members: Tailscale WhoIs, or fake users in local development mode
+	authProvider middleware.AuthProvider
+	// unfurler fetches previews of links in posts, nil unless turned on
+	unfurler *Unfurler
+	// images serves images from other hosts to readers
+	images *ImageProxy
+	// diagrams renders the diagrams in posts, nil unless turned on
+	diagrams *Diagrammer
+	// draftTTL is how long autosaved drafts are kept after they were last saved
+	draftTTL time.Duration
+	// workers tracks background worker liveness for /readyz
+	workers   *WorkerRegistry
+	startTime time.Time
 }
 
 // NewDiscussService creates a new DiscussService instance
@@ -133,18 +156,37 @@ func NewDiscussService(
 	version string,
 	gitSha string,
 	telemetry *TelemetryConfig,
+	blobs BlobStore,
+	rateLimitStore middleware.RateLimitStore,
+	authProvider middleware.AuthProvider,
 ) *DiscussService {
+	_, devMode := authProvider.(*middleware.LocalAuthProvider)
+
 	return &DiscussService{
 		tailClient: tailClient,
 		logger:     logger,
 		dbconn:     dbconn,
 		queries:    queries,
 		tmpls:      tmpls,
-		devMode:    false,
+		devMode:    devMode,
 		hostname:   hostname,
 		version:    version,
 		gitSha:     gitSha,
 		telemetry:  telemetry,
+		blobs:      blobs,
+
+		rateLimitStore: rateLimitStore,
+		roleLimits:     newRoleLimitCache(queries, logger),
+		spamGuard:      DefaultSpamGuardConfig(),
+		avatars:        newTailnetAvatarCache(tailClient, logger),
+		draftTTL:       *draftTTL,
+		unfurler:       NewUnfurler(*unfurlHosts),
+		images:         NewImageProxy(),
+		diagrams:       NewDiagrammer(*mermaidCLI, logger),
+
+		authProvider: authProvider,
+		workers:      NewWorkerRegistry(),
+		startTime:    time.Now(),
 	}
 }
 
@@ -223,7 +265,7 @@ func startServer(server *http.Server, ln net.Listener, logger *slog.Logger, sche
 	}
 }
 
-func waitForShutdown(sigChan chan os.Signal, ctx context.Context, logger *slog.Logger, serverPlain, serverTls *http.Server) {
+func waitForShutdown(sigChan chan os.Signal, ctx context.Context, logger *slog.Logger, servers map[string]*http.Server) {
 	sig := <-sigChan
 	sigName := sig.String()
 	logger.Info("received shutdown signal, initiating graceful shutdown",
@@ -234,33 +276,22 @@ func waitForShutdown(sigChan chan os.Signal, ctx context.Context, logger *slog.L
 	defer shutdownCancel()
 
 	// Track shutdown completion
-	serversDone := make(chan struct{}, 2)
-
-	// Shutdown HTTP server
-	go func() {
-		defer func() { serversDone <- struct{}{} }()
-		logger.Info("shutting down HTTP server")
-		if err := serverPlain.Shutdown(shutdownCtx); err != nil {
-			logger.Error("failed to gracefully shutdown HTTP server",
-				slog.String("error", err.Error()))
-		} else {
-			logger.Info("HTTP server shutdown complete")
-		}
-	}()
-
-	// Shutdown HTTPS server
-	go func() {
-		defer func() { serversDone <- struct{}{} }()
-		logger.Info("shutting down HTTPS server")
-		if err := serverTls.Shutdown(shutdownCtx); err != nil {
-			logger.Error("failed to gracefully shutdown HTTPS server",
-				slog.String("error", err.Error()))
-		} else {
-			logger.Info("HTTPS server shutdown complete")
-		}
-	}()
+	serversDone := make(chan struct{}, len(servers))
+
+	for name, server := range servers {
+		go func() {
+			defer func() { serversDone <- struct{}{} }()
+			logger.Info(fmt.Sprintf("shutting down %s server", name))
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				logger.Error(fmt.Sprintf("failed to gracefully shutdown %s server", name),
+					slog.String("error", err.Error()))
+			} else {
+				logger.Info(fmt.Sprintf("%s server shutdown complete", name))
+			}
+		}()
+	}
 
-	// Wait for both servers to shutdown or timeout
+	// Wait for all servers to shutdown or timeout
 	serversShutdown := 0
 	shutdownComplete := false
 
@@ -268,7 +299,7 @@ func waitForShutdown(sigChan chan os.Signal, ctx context.Context, logger *slog.L
 		select {
 		case <-serversDone:
 			serversShutdown++
-			if serversShutdown >= 2 {
+			if serversShutdown >= len(servers) {
 				shutdownComplete = true
 				logger.Info("all servers shutdown successfully")
 			}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// SpamGuardConfig holds the content-level limits applied when a member
+// creates a thread or post. They complement the request rate limits, which
+// can't tell one message pasted into twenty threads from twenty replies.
+type SpamGuardConfig struct {
+	// DuplicateWindow is how far back a member's posts are compared against
+	// a new one. A repeated body is held for moderation.
+	DuplicateWindow time.Duration
+
+	// ThreadsPerDay caps the threads a member may start in 24 hours.
+	ThreadsPerDay int
+
+	// ProbationPeriod is how long after joining a member is considered new
+	// and gets the probation limits below instead.
+	ProbationPeriod time.Duration
+
+	// ProbationThreadsPerDay caps the threads a new member may start in 24 hours.
+	ProbationThreadsPerDay int
+
+	// ProbationPostsPerHour caps the posts, including first posts, a new
+	// member may make in an hour.
+	ProbationPostsPerHour int
+
+	// ProbationMaxLinks is the most links a new member's post may contain
+	// before it is held for moderation.
+	ProbationMaxLinks int
+}
+
+// DefaultSpamGuardConfig returns the limits used unless configured otherwise.
+func DefaultSpamGuardConfig() SpamGuardConfig {
+	return SpamGuardConfig{
+		DuplicateWindow:        24 * time.Hour,
+		ThreadsPerDay:          10,
+		ProbationPeriod:        72 * time.Hour,
+		ProbationThreadsPerDay: 2,
+		ProbationPostsPerHour:  10,
+		ProbationMaxLinks:      2,
+	}
+}
+
+type spamAction int
+
+const (
+	spamAllow spamAction = iota
+	spamHold
+	spamReject
+)
+
+// spamVerdict is the spam guard's decision on a new post. Held posts are
+// saved but only shown to their author until an admin approves them.
+type spamVerdict struct {
+	Action spamAction
+	Reason string
+}
+
+// postingActivity is what the spam guard knows about a member's new post
+// and their recent posting.
+type postingActivity struct {
+	NewThread      bool
+	OnProbation    bool
+	RecentThreads  int64 // threads started in the last 24 hours
+	RecentPosts    int64 // posts made in the last hour
+	DuplicatePosts int64 // posts with the same body within DuplicateWindow
+	Links          int
+}
+
+// evaluate applies the limits to a new post. Hard limits reject the post so
+// the member can try again later; anything merely suspicious is held.
+func (c SpamGuardConfig) evaluate(a postingActivity) spamVerdict {
+	threadsPerDay := c.ThreadsPerDay
+	if a.OnProbation {
+		threadsPerDay = c.ProbationThreadsPerDay
+	}
+
+	if a.NewThread && a.RecentThreads >= int64(threadsPerDay) {
+		return spamVerdict{Action: spamReject, Reason: fmt.Sprintf("you can start at most %d threads a day", threadsPerDay)}
+	}
+
+	if a.OnProbation && a.RecentPosts >= int64(c.ProbationPostsPerHour) {
+		return spamVerdict{Action: spamReject, Reason: fmt.Sprintf("new members can post at most %d times an hour", c.ProbationPostsPerHour)}
+	}
+
+	if a.DuplicatePosts > 0 {
+		return spamVerdict{Action: spamHold, Reason: "duplicate of a recent post"}
+	}
+
+	if a.OnProbation && a.Links > c.ProbationMaxLinks {
+		return spamVerdict{Action: spamHold, Reason: fmt.Sprintf("%d links from a new member", a.Links)}
+	}
+
+	return spamVerdict{Action: spamAllow}
+}
+
+// bodyHash fingerprints a post's markdown for duplicate detection. Case and
+// whitespace are ignored so trivial changes don't defeat it.
+func bodyHash(bodyInput string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(bodyInput)), " ")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://`)
+
+// countLinks counts the URLs in a post's markdown.
+func countLinks(bodyInput string) int {
+	return len(linkPattern.FindAllStringIndex(bodyInput, -1))
+}
+
+// checkSpam runs the spam guard for a member's new thread or post and
+// returns its verdict along with the body hash to store with the post.
+// Admins are trusted. If the member's activity can't be loaded the post is
+// allowed, like the rate limiter, rather than blocking everyone on a
+// database hiccup.
+func (s *DiscussService) checkSpam(ctx context.Context, user User, bodyInput string, newThread bool) (spamVerdict, string) {
+	hash := bodyHash(bodyInput)
+	if user.IsAdmin {
+		return spamVerdict{Action: spamAllow}, hash
+	}
+
+	now := time.Now()
+	activity, err := s.queries.GetMemberPostingActivity(ctx, GetMemberPostingActivityParams{
+		ThreadsSince:    pgtype.Timestamptz{Time: now.Add(-24 * time.Hour), Valid: true},
+		PostsSince:      pgtype.Timestamptz{Time: now.Add(-time.Hour), Valid: true},
+		BodyHash:        pgtype.Text{String: hash, Valid: true},
+		DuplicatesSince: pgtype.Timestamptz{Time: now.Add(-s.spamGuard.DuplicateWindow), Valid: true},
+		MemberID:        user.ID,
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "GetMemberPostingActivity", slog.String("error", err.Error()))
+		return spamVerdict{Action: spamAllow}, hash
+	}
+
+	verdict := s.spamGuard.evaluate(postingActivity{
+		NewThread:      newThread,
+		OnProbation:    activity.DateJoined.Valid && now.Sub(activity.DateJoined.Time) < s.spamGuard.ProbationPeriod,
+		RecentThreads:  activity.RecentThreads,
+		RecentPosts:    activity.RecentPosts,
+		DuplicatePosts: activity.DuplicatePosts,
+		Links:          countLinks(bodyInput),
+	})
+	if verdict.Action != spamAllow {
+		s.logger.InfoContext(ctx, "spam guard",
+			slog.Int64("user_id", user.ID),
+			slog.Bool("held", verdict.Action == spamHold),
+			slog.String("reason", verdict.Reason))
+	}
+
+	return verdict, hash
+}
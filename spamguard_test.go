@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpamGuardEvaluate(t *testing.T) {
+	config := DefaultSpamGuardConfig()
+
+	tests := []struct {
+		name       string
+		activity   postingActivity
+		wantAction spamAction
+	}{
+		{
+			name:       "ordinary reply",
+			activity:   postingActivity{RecentPosts: 50, RecentThreads: 9},
+			wantAction: spamAllow,
+		},
+		{
+			name:       "thread under the daily cap",
+			activity:   postingActivity{NewThread: true, RecentThreads: 9},
+			wantAction: spamAllow,
+		},
+		{
+			name:       "thread at the daily cap",
+			activity:   postingActivity{NewThread: true, RecentThreads: 10},
+			wantAction: spamReject,
+		},
+		{
+			name:       "reply after reaching the thread cap",
+			activity:   postingActivity{RecentThreads: 10},
+			wantAction: spamAllow,
+		},
+		{
+			name:       "new member thread at the probation cap",
+			activity:   postingActivity{NewThread: true, OnProbation: true, RecentThreads: 2},
+			wantAction: spamReject,
+		},
+		{
+			name:       "new member over the hourly post cap",
+			activity:   postingActivity{OnProbation: true, RecentPosts: 10},
+			wantAction: spamReject,
+		},
+		{
+			name:       "duplicate is held",
+			activity:   postingActivity{DuplicatePosts: 1},
+			wantAction: spamHold,
+		},
+		{
+			name:       "hard limits win over holding",
+			activity:   postingActivity{NewThread: true, RecentThreads: 10, DuplicatePosts: 3},
+			wantAction: spamReject,
+		},
+		{
+			name:       "new member with many links is held",
+			activity:   postingActivity{OnProbation: true, Links: 3},
+			wantAction: spamHold,
+		},
+		{
+			name:       "established member with many links",
+			activity:   postingActivity{Links: 20},
+			wantAction: spamAllow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := config.evaluate(tt.activity)
+			assert.Equal(t, tt.wantAction, verdict.Action)
+			if tt.wantAction == spamAllow {
+				assert.Empty(t, verdict.Reason)
+			} else {
+				assert.NotEmpty(t, verdict.Reason)
+			}
+		})
+	}
+}
+
+func TestBodyHash(t *testing.T) {
+	hash := bodyHash("Buy cheap widgets at example.com")
+	assert.Len(t, hash, 64)
+
+	// Case and whitespace changes are still duplicates
+	assert.Equal(t, hash, bodyHash("  buy CHEAP widgets\n\nat   example.com "))
+
+	// Different words are not
+	assert.NotEqual(t, hash, bodyHash("Buy cheap gadgets at example.com"))
+}
+
+func TestCountLinks(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"no links here", 0},
+		{"see https://example.com", 1},
+		{"[one](http://a.example) and [two](HTTPS://b.example)", 2},
+		{"<https://a.example> https://b.example https://c.example", 3},
+		{"ftp://example.com and shttps://example.com", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, countLinks(tt.input))
+		})
+	}
+}
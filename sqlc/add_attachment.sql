@@ -0,0 +1,13 @@
+-- Uploaded files, stored content-addressed in the blob store and keyed by hash
+CREATE TABLE attachment
+(
+  hash            varchar(64) PRIMARY KEY,
+  content_type    varchar NOT NULL,
+  size            bigint NOT NULL,
+  filename        varchar NOT NULL DEFAULT '',
+  member_id       bigint NOT NULL,
+  date_uploaded   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX attachment_member_id_index ON attachment(member_id);
+ALTER TABLE attachment ADD FOREIGN KEY (member_id) REFERENCES member(id);
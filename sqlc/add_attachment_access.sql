@@ -0,0 +1,34 @@
+-- Attachment access: a file is served to the members who uploaded it and to
+-- members who can see a post linking it, so files posted to private boards
+-- stay private.
+CREATE TABLE attachment_upload
+(
+  hash       varchar(64) NOT NULL,
+  member_id  bigint NOT NULL,
+  PRIMARY KEY (hash, member_id)
+);
+
+CREATE TABLE thread_post_attachment
+(
+  thread_post_id  bigint NOT NULL,
+  hash            varchar(64) NOT NULL,
+  PRIMARY KEY (thread_post_id, hash)
+);
+
+ALTER TABLE attachment_upload ADD FOREIGN KEY (hash) REFERENCES attachment(hash);
+ALTER TABLE attachment_upload ADD FOREIGN KEY (member_id) REFERENCES member(id);
+CREATE INDEX thread_post_attachment_hash_index ON thread_post_attachment(hash);
+ALTER TABLE thread_post_attachment ADD FOREIGN KEY (thread_post_id) REFERENCES thread_post(id) ON DELETE CASCADE;
+ALTER TABLE thread_post_attachment ADD FOREIGN KEY (hash) REFERENCES attachment(hash);
+
+-- Files uploaded and posted before this
+INSERT INTO attachment_upload (hash, member_id)
+SELECT hash, member_id FROM attachment;
+
+INSERT INTO thread_post_attachment (thread_post_id, hash)
+SELECT DISTINCT tp.id, a.hash
+FROM thread_post tp
+CROSS JOIN LATERAL regexp_matches(tp.body, '/file/([0-9a-f]{64})', 'g') AS m(match)
+JOIN attachment a ON a.hash = m.match[1];
+
+INSERT INTO schema_version (version) VALUES (15);
@@ -0,0 +1,22 @@
+-- Durable record of admin and moderation actions
+CREATE TABLE audit_log
+(
+  id            bigserial UNIQUE PRIMARY KEY,
+  date_created  timestamptz NOT NULL DEFAULT now(),
+  actor_id      bigint NOT NULL,
+  action        varchar NOT NULL CHECK(action <> ''),
+  target_type   varchar NOT NULL DEFAULT '',
+  target_id     varchar NOT NULL DEFAULT '',
+  before        jsonb,
+  after         jsonb,
+  request_id    varchar NOT NULL DEFAULT '',
+  trace_id      varchar NOT NULL DEFAULT ''
+);
+
+CREATE INDEX audit_log_date_created_index ON audit_log(date_created);
+CREATE INDEX audit_log_actor_id_index ON audit_log(actor_id);
+CREATE INDEX audit_log_action_index ON audit_log(action);
+CREATE INDEX audit_log_target_index ON audit_log(target_type, target_id);
+ALTER TABLE audit_log ADD FOREIGN KEY (actor_id) REFERENCES member(id);
+
+INSERT INTO schema_version (version) VALUES (5);
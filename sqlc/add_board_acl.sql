@@ -0,0 +1,5 @@
+-- Private boards: a board with an access list is only visible to members
+-- who match one of its entries. Existing boards stay visible to everyone.
+ALTER TABLE board_data ADD COLUMN acl text[] NOT NULL DEFAULT '{}';
+
+INSERT INTO schema_version (version) VALUES (10);
@@ -0,0 +1,100 @@
+-- Multiple boards: each thread belongs to a board, and the thread and post
+-- counters are kept per board. Existing threads move to the first board.
+ALTER TABLE board_data ADD COLUMN slug varchar;
+ALTER TABLE board_data ADD COLUMN description text NOT NULL DEFAULT '';
+UPDATE board_data SET slug = CASE WHEN id = (SELECT min(id) FROM board_data) THEN 'general' ELSE 'board-' || id END;
+ALTER TABLE board_data ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE board_data ADD CONSTRAINT board_data_slug_key UNIQUE (slug);
+ALTER TABLE board_data ADD CONSTRAINT board_data_slug_check CHECK (slug <> '');
+
+ALTER TABLE thread ADD COLUMN board_id int;
+UPDATE thread SET board_id = (SELECT min(id) FROM board_data);
+ALTER TABLE thread ALTER COLUMN board_id SET NOT NULL;
+ALTER TABLE thread ADD FOREIGN KEY (board_id) REFERENCES board_data(id);
+CREATE INDEX thread_board_id_date_last_posted_index ON thread(board_id, date_last_posted);
+
+CREATE OR REPLACE FUNCTION thread_sync() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    UPDATE member SET total_threads=total_threads-1 WHERE id=OLD.member_id;
+    UPDATE board_data SET total_threads=(total_threads::integer)-1 WHERE id=OLD.board_id;
+    RETURN OLD;
+  ELSEIF TG_OP = 'INSERT' THEN
+    UPDATE member SET total_threads=total_threads+1 WHERE id=NEW.member_id;
+    UPDATE board_data SET total_threads=(total_threads::integer)+1 WHERE id=NEW.board_id;
+    RETURN NEW;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION thread_post_sync() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    UPDATE member SET total_thread_posts=total_thread_posts-1, last_post=now() WHERE id=OLD.member_id;
+    UPDATE board_data SET total_thread_posts=(total_thread_posts::integer)-1 WHERE id=(SELECT board_id FROM thread WHERE id=OLD.thread_id);
+    IF (SELECT count(*) FROM thread_post WHERE thread_id=OLD.thread_id) > 1 THEN
+      UPDATE
+        thread
+      SET
+        posts=posts-1,
+        first_post_id=(SELECT id FROM thread_post WHERE thread_id=OLD.thread_id ORDER BY date_posted ASC LIMIT 1),
+        last_member_id=(SELECT member_id FROM thread_post WHERE thread_id=OLD.thread_id ORDER BY date_posted DESC LIMIT 1),
+        date_last_posted=(SELECT date_posted FROM thread_post WHERE thread_id=OLD.thread_id ORDER BY date_posted DESC LIMIT 1)
+      WHERE
+        id=OLD.thread_id;
+    ELSEIF (SELECT posts FROM thread WHERE id=OLD.thread_id) = 1 THEN
+      DELETE FROM thread_member WHERE thread_id=OLD.thread_id;
+      DELETE FROM favorite WHERE thread_id=OLD.thread_id;
+      DELETE FROM thread WHERE id=OLD.thread_id;
+    END IF;
+    IF (SELECT count(*) FROM thread_post WHERE member_id=OLD.member_id AND thread_id=OLD.thread_id) = 0 THEN
+      DELETE FROM thread_member WHERE member_id=OLD.member_id AND thread_id=OLD.thread_id;
+    END IF;
+    RETURN OLD;
+  ELSEIF TG_OP = 'INSERT' THEN
+    UPDATE member SET last_post=now() WHERE id=NEW.member_id;
+    UPDATE member SET total_thread_posts=total_thread_posts+1 WHERE id=NEW.member_id;
+    UPDATE board_data SET total_thread_posts=(total_thread_posts::integer)+1 WHERE id=(SELECT board_id FROM thread WHERE id=NEW.thread_id);
+    -- Held posts don't bump the thread until they are approved
+    IF NEW.held THEN
+      UPDATE
+        thread
+      SET
+        first_post_id=(SELECT id FROM thread_post WHERE thread_id=NEW.thread_id ORDER BY date_posted ASC LIMIT 1)
+      WHERE
+        id=NEW.thread_id;
+    ELSE
+      UPDATE
+        thread
+      SET
+        posts=posts+1,
+        first_post_id=(SELECT id FROM thread_post WHERE thread_id=NEW.thread_id ORDER BY date_posted ASC LIMIT 1),
+        last_member_id=(SELECT member_id FROM thread_post WHERE thread_id=NEW.thread_id ORDER BY date_posted DESC LIMIT 1),
+        date_last_posted=now()
+      WHERE
+        id=NEW.thread_id;
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM thread_member WHERE member_id=NEW.member_id AND thread_id=NEW.thread_id) THEN
+      INSERT INTO
+        thread_member (member_id,thread_id,date_posted,last_view_posts)
+      VALUES
+        (NEW.member_id,NEW.thread_id,now(),(SELECT posts FROM thread WHERE id=NEW.thread_id));
+    ELSE
+      UPDATE
+        thread_member
+      SET
+        date_posted=now(),
+        last_view_posts=(SELECT posts FROM thread WHERE id=NEW.thread_id)
+      WHERE
+        member_id=NEW.member_id
+      AND
+        thread_id=NEW.thread_id;
+    END IF;
+    RETURN NEW;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO schema_version (version) VALUES (9);
@@ -0,0 +1,17 @@
+-- Autosaved drafts of new threads and replies, one per member and thread
+CREATE TABLE draft
+(
+  id          bigserial UNIQUE PRIMARY KEY,
+  member_id   bigint NOT NULL,
+  thread_id   bigint,
+  subject     text NOT NULL DEFAULT '',
+  body        text NOT NULL DEFAULT '',
+  date_saved  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX draft_member_id_thread_id_index ON draft(member_id, COALESCE(thread_id, 0));
+CREATE INDEX draft_date_saved_index ON draft(date_saved);
+ALTER TABLE draft ADD FOREIGN KEY (member_id) REFERENCES member(id);
+ALTER TABLE draft ADD FOREIGN KEY (thread_id) REFERENCES thread(id) ON DELETE CASCADE;
+
+INSERT INTO schema_version (version) VALUES (13);
@@ -0,0 +1,5 @@
+-- Lets admins show members by name only, hiding email addresses from
+-- everyone else
+ALTER TABLE board_data ADD COLUMN hide_emails boolean NOT NULL DEFAULT false;
+
+INSERT INTO schema_version (version) VALUES (7);
@@ -0,0 +1,27 @@
+-- Link previews: OpenGraph metadata fetched for links in posts, shown as
+-- cards under the post. Posts made before this are left as they are.
+ALTER TABLE thread_post ADD COLUMN unfurled bool NOT NULL DEFAULT false;
+UPDATE thread_post SET unfurled = true;
+
+CREATE TABLE link_preview
+(
+  url           text PRIMARY KEY,
+  ok            bool NOT NULL DEFAULT false,
+  title         text NOT NULL DEFAULT '',
+  description   text NOT NULL DEFAULT '',
+  site_name     text NOT NULL DEFAULT '',
+  image_url     text NOT NULL DEFAULT '',
+  date_fetched  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE thread_post_link
+(
+  thread_post_id  bigint NOT NULL,
+  position        int NOT NULL,
+  url             text NOT NULL,
+  PRIMARY KEY (thread_post_id, url)
+);
+
+ALTER TABLE thread_post_link ADD FOREIGN KEY (thread_post_id) REFERENCES thread_post(id) ON DELETE CASCADE;
+
+INSERT INTO schema_version (version) VALUES (14);
@@ -0,0 +1,45 @@
+-- Polls started with a thread, one ballot per member
+CREATE TABLE poll
+(
+  id            bigserial UNIQUE PRIMARY KEY,
+  thread_id     bigint NOT NULL UNIQUE,
+  question      text NOT NULL CHECK(question <> ''),
+  multiple      bool NOT NULL DEFAULT false,
+  anonymous     bool NOT NULL DEFAULT false,
+  date_closes   timestamptz,
+  date_created  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE poll_option
+(
+  id        bigserial UNIQUE PRIMARY KEY,
+  poll_id   bigint NOT NULL,
+  position  int NOT NULL,
+  label     text NOT NULL CHECK(label <> ''),
+  UNIQUE (poll_id, position)
+);
+
+CREATE TABLE poll_ballot
+(
+  poll_id     bigint NOT NULL,
+  member_id   bigint NOT NULL,
+  date_voted  timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (poll_id, member_id)
+);
+
+CREATE TABLE poll_vote
+(
+  poll_option_id  bigint NOT NULL,
+  member_id       bigint
+);
+
+ALTER TABLE poll ADD FOREIGN KEY (thread_id) REFERENCES thread(id) ON DELETE CASCADE;
+CREATE INDEX poll_option_poll_id_index ON poll_option(poll_id);
+ALTER TABLE poll_option ADD FOREIGN KEY (poll_id) REFERENCES poll(id) ON DELETE CASCADE;
+ALTER TABLE poll_ballot ADD FOREIGN KEY (poll_id) REFERENCES poll(id) ON DELETE CASCADE;
+ALTER TABLE poll_ballot ADD FOREIGN KEY (member_id) REFERENCES member(id);
+CREATE INDEX poll_vote_poll_option_id_index ON poll_vote(poll_option_id);
+ALTER TABLE poll_vote ADD FOREIGN KEY (poll_option_id) REFERENCES poll_option(id) ON DELETE CASCADE;
+ALTER TABLE poll_vote ADD FOREIGN KEY (member_id) REFERENCES member(id);
+
+INSERT INTO schema_version (version) VALUES (12);
@@ -0,0 +1,15 @@
+-- Emoji short names members can react to posts with, configurable by admins
+ALTER TABLE board_data ADD COLUMN reaction_emoji text[] NOT NULL DEFAULT '{+1,tada,eyes,heart,laughing}';
+
+-- One row per member, post and emoji reaction
+CREATE TABLE post_reaction
+(
+  thread_post_id  bigint NOT NULL,
+  member_id       bigint NOT NULL,
+  emoji           varchar NOT NULL CHECK(emoji <> ''),
+  date_reacted    timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (thread_post_id, member_id, emoji)
+);
+
+ALTER TABLE post_reaction ADD FOREIGN KEY (thread_post_id) REFERENCES thread_post(id);
+ALTER TABLE post_reaction ADD FOREIGN KEY (member_id) REFERENCES member(id);
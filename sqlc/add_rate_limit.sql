@@ -0,0 +1,25 @@
+-- Per-role rate limits, editable from the admin page
+CREATE TABLE rate_limit
+(
+  role            varchar(16) PRIMARY KEY,
+  requests        int NOT NULL CHECK(requests > 0),
+  window_seconds  int NOT NULL CHECK(window_seconds > 0)
+);
+
+INSERT INTO rate_limit (role, requests, window_seconds) VALUES
+  ('admin', 1200, 60),
+  ('member', 300, 60);
+
+-- Sliding window counters shared by every replica. Counters are short lived
+-- and cheap to lose, so the table skips the WAL.
+CREATE UNLOGGED TABLE rate_limit_window
+(
+  key           varchar NOT NULL,
+  window_start  timestamptz NOT NULL,
+  count         int NOT NULL DEFAULT 0,
+  PRIMARY KEY (key, window_start)
+);
+
+CREATE INDEX rate_limit_window_window_start_index ON rate_limit_window(window_start);
+
+INSERT INTO schema_version (version) VALUES (2);
@@ -0,0 +1,4 @@
+-- Lets members see timestamps as relative times ("3 hours ago")
+ALTER TABLE member_profile ADD COLUMN relative_times boolean NOT NULL DEFAULT false;
+
+INSERT INTO schema_version (version) VALUES (6);
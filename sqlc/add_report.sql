@@ -0,0 +1,22 @@
+-- Post reports and moderator actions
+ALTER TABLE thread_post ADD COLUMN hidden bool NOT NULL DEFAULT false;
+
+CREATE TABLE report
+(
+  id              bigserial UNIQUE PRIMARY KEY,
+  thread_post_id  bigint NOT NULL,
+  member_id       bigint NOT NULL,
+  reason          text NOT NULL CHECK(reason <> ''),
+  date_reported   timestamptz NOT NULL DEFAULT now(),
+  resolution      varchar,
+  resolved_by     bigint,
+  date_resolved   timestamptz
+);
+
+-- A member can have one open report per post
+CREATE UNIQUE INDEX report_open_index ON report(thread_post_id, member_id) WHERE resolution IS NULL;
+ALTER TABLE report ADD FOREIGN KEY (thread_post_id) REFERENCES thread_post(id);
+ALTER TABLE report ADD FOREIGN KEY (member_id) REFERENCES member(id);
+ALTER TABLE report ADD FOREIGN KEY (resolved_by) REFERENCES member(id);
+
+INSERT INTO schema_version (version) VALUES (4);
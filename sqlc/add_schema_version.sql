@@ -0,0 +1,9 @@
+-- Track applied migrations so /readyz can tell when the schema is behind the
+-- binary. Version 1 is the schema with every earlier add_*.sql applied.
+CREATE TABLE schema_version
+(
+  version       int PRIMARY KEY,
+  date_applied  timestamptz NOT NULL DEFAULT now()
+);
+
+INSERT INTO schema_version (version) VALUES (1);
@@ -0,0 +1,83 @@
+-- Spam guard: duplicate detection and the moderation queue
+ALTER TABLE thread ADD COLUMN held bool NOT NULL DEFAULT false;
+
+ALTER TABLE thread_post ADD COLUMN body_hash varchar(64);
+ALTER TABLE thread_post ADD COLUMN held bool NOT NULL DEFAULT false;
+ALTER TABLE thread_post ADD COLUMN held_reason varchar;
+
+CREATE INDEX thread_member_id_date_posted_index ON thread(member_id, date_posted);
+CREATE INDEX thread_post_member_id_date_posted_index ON thread_post(member_id, date_posted);
+CREATE INDEX thread_post_member_id_body_hash_index ON thread_post(member_id, body_hash);
+CREATE INDEX thread_post_held_index ON thread_post(date_posted) WHERE held;
+
+-- Held posts no longer bump their thread when inserted
+CREATE OR REPLACE FUNCTION thread_post_sync() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    UPDATE member SET total_thread_posts=total_thread_posts-1, last_post=now() WHERE id=OLD.member_id;
+    UPDATE board_data SET total_thread_posts=(total_thread_posts::integer)-1;
+    IF (SELECT count(*) FROM thread_post WHERE thread_id=OLD.thread_id) > 1 THEN
+      UPDATE
+        thread
+      SET
+        posts=posts-1,
+        first_post_id=(SELECT id FROM thread_post WHERE thread_id=OLD.thread_id ORDER BY date_posted ASC LIMIT 1),
+        last_member_id=(SELECT member_id FROM thread_post WHERE thread_id=OLD.thread_id ORDER BY date_posted DESC LIMIT 1),
+        date_last_posted=(SELECT date_posted FROM thread_post WHERE thread_id=OLD.thread_id ORDER BY date_posted DESC LIMIT 1)
+      WHERE
+        id=OLD.thread_id;
+    ELSEIF (SELECT posts FROM thread WHERE id=OLD.thread_id) = 1 THEN
+      DELETE FROM thread_member WHERE thread_id=OLD.thread_id;
+      DELETE FROM favorite WHERE thread_id=OLD.thread_id;
+      DELETE FROM thread WHERE id=OLD.thread_id;
+    END IF;
+    IF (SELECT count(*) FROM thread_post WHERE member_id=OLD.member_id AND thread_id=OLD.thread_id) = 0 THEN
+      DELETE FROM thread_member WHERE member_id=OLD.member_id AND thread_id=OLD.thread_id;
+    END IF;
+    RETURN OLD;
+  ELSEIF TG_OP = 'INSERT' THEN
+    UPDATE member SET last_post=now() WHERE id=NEW.member_id;
+    UPDATE member SET total_thread_posts=total_thread_posts+1 WHERE id=NEW.member_id;
+    UPDATE board_data SET total_thread_posts=(total_thread_posts::integer)+1;
+    -- Held posts don't bump the thread until they are approved
+    IF NEW.held THEN
+      UPDATE
+        thread
+      SET
+        first_post_id=(SELECT id FROM thread_post WHERE thread_id=NEW.thread_id ORDER BY date_posted ASC LIMIT 1)
+      WHERE
+        id=NEW.thread_id;
+    ELSE
+      UPDATE
+        thread
+      SET
+        posts=posts+1,
+        first_post_id=(SELECT id FROM thread_post WHERE thread_id=NEW.thread_id ORDER BY date_posted ASC LIMIT 1),
+        last_member_id=(SELECT member_id FROM thread_post WHERE thread_id=NEW.thread_id ORDER BY date_posted DESC LIMIT 1),
+        date_last_posted=now()
+      WHERE
+        id=NEW.thread_id;
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM thread_member WHERE member_id=NEW.member_id AND thread_id=NEW.thread_id) THEN
+      INSERT INTO
+        thread_member (member_id,thread_id,date_posted,last_view_posts)
+      VALUES
+        (NEW.member_id,NEW.thread_id,now(),(SELECT posts FROM thread WHERE id=NEW.thread_id));
+    ELSE
+      UPDATE
+        thread_member
+      SET
+        date_posted=now(),
+        last_view_posts=(SELECT posts FROM thread WHERE id=NEW.thread_id)
+      WHERE
+        member_id=NEW.member_id
+      AND
+        thread_id=NEW.thread_id;
+    END IF;
+    RETURN NEW;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO schema_version (version) VALUES (3);
@@ -0,0 +1,10 @@
+-- Keeps member profiles in sync with their Tailscale identity, and records
+-- the machine each member was last seen on
+ALTER TABLE member ADD COLUMN last_seen_node varchar;
+ALTER TABLE member ADD COLUMN last_seen_os varchar;
+ALTER TABLE member ADD COLUMN last_seen_at timestamptz;
+
+ALTER TABLE member_profile ADD COLUMN tailscale_name varchar;
+ALTER TABLE member_profile ADD COLUMN tailscale_photo_url varchar;
+
+INSERT INTO schema_version (version) VALUES (8);
@@ -0,0 +1,17 @@
+-- Track when a post was last edited
+ALTER TABLE thread_post ADD COLUMN date_edited timestamptz;
+
+-- Keep the previous subject and body of a post every time it is edited
+CREATE TABLE thread_post_revision
+(
+  id              bigserial UNIQUE PRIMARY KEY,
+  thread_post_id  bigint NOT NULL,
+  member_id       bigint NOT NULL,
+  date_revised    timestamptz NOT NULL DEFAULT now(),
+  subject         text,
+  body            text
+);
+
+CREATE INDEX thread_post_revision_thread_post_id_index ON thread_post_revision(thread_post_id, date_revised);
+ALTER TABLE thread_post_revision ADD FOREIGN KEY (thread_post_id) REFERENCES thread_post(id);
+ALTER TABLE thread_post_revision ADD FOREIGN KEY (member_id) REFERENCES member(id);
@@ -0,0 +1,19 @@
+-- Tags label threads across boards, e.g. postmortem or rfc
+CREATE TABLE tag
+(
+  id    serial PRIMARY KEY,
+  name  varchar NOT NULL UNIQUE CHECK(name <> '')
+);
+
+CREATE TABLE thread_tag
+(
+  thread_id  bigint NOT NULL,
+  tag_id     int NOT NULL,
+  PRIMARY KEY (thread_id, tag_id)
+);
+
+CREATE INDEX thread_tag_tag_id_index ON thread_tag(tag_id);
+ALTER TABLE thread_tag ADD FOREIGN KEY (thread_id) REFERENCES thread(id) ON DELETE CASCADE;
+ALTER TABLE thread_tag ADD FOREIGN KEY (tag_id) REFERENCES tag(id) ON DELETE CASCADE;
+
+INSERT INTO schema_version (version) VALUES (11);
@@ -1,12 +1,17 @@
 -- name: CreateThread :exec
-INSERT INTO thread (subject,member_id,last_member_id) VALUES ($1,$2,$3);
+INSERT INTO thread (subject,member_id,last_member_id,held,board_id) VALUES ($1,$2,$3,$4,$5);
 
 -- name: CreateThreadPost :exec
-INSERT INTO
-  thread_post
-    (thread_id,body,member_id)
-  VALUES
-    ($1,$2,$3);
+WITH post AS (
+  INSERT INTO
+    thread_post
+      (thread_id,body,member_id,body_hash,held,held_reason)
+    VALUES
+      ($1,$2,$3,$4,$5,$6)
+  RETURNING id
+)
+INSERT INTO thread_post_attachment (thread_post_id, hash)
+SELECT post.id, a.hash FROM post, attachment a WHERE a.hash = ANY(sqlc.arg(attachments)::varchar[]);
 
 -- name: GetThreadSequenceId :one
 SELECT currval('thread_id_seq');
@@ -31,7 +36,11 @@ SELECT
   mp.proper_name,
   mp.pronouns,
   m.date_joined,
-  mp.photo_url
+  mp.photo_url,
+  mp.relative_times,
+  m.last_seen_node,
+  m.last_seen_os,
+  m.last_seen_at
 FROM
   member m
 LEFT JOIN
@@ -41,6 +50,9 @@ ON
 WHERE
   m.id = $1;
 
+-- name: GetMemberTimePreferences :one
+SELECT timezone, relative_times FROM member_profile WHERE member_id = $1;
+
 -- name: ListThreads :many
 SELECT
   t.id as thread_id,
@@ -53,21 +65,41 @@ SELECT
   t.posts,
   t.views,
   tp.body,
-  (CASE WHEN (m.email = $1 AND t.date_posted >= NOW() - INTERVAL '900 seconds') THEN 't' ELSE 'f' END)::boolean as can_edit,
+  (CASE WHEN (m.email = $1 AND t.date_posted >= NOW() - COALESCE(b.edit_window, 0) * INTERVAL '1 second') THEN 't' ELSE 'f' END)::boolean as can_edit,
   (CASE WHEN tm.last_view_posts IS null THEN 0 ELSE tm.last_view_posts END) as last_view_posts,
   (CASE WHEN tm.date_posted IS NOT null AND tm.undot IS false AND tm.member_id IS NOT null THEN 't' ELSE 'f' END)::boolean as dot,
   t.sticky,
-  t.locked
+  t.locked,
+  mp.preferred_name,
+  mp.photo_url,
+  lp.preferred_name as last_preferred_name,
+  lp.photo_url as last_photo_url,
+  b.slug as board_slug,
+  b.title as board_title,
+  b.hide_emails,
+  ARRAY(SELECT tg.name FROM thread_tag tt JOIN tag tg ON tg.id=tt.tag_id WHERE tt.thread_id=t.id ORDER BY tg.name)::varchar[] as tags
 FROM
   thread t
+JOIN
+  board_data b
+ON
+  b.id=t.board_id
 LEFT JOIN
   member m
 ON
   m.id=t.member_id
+LEFT JOIN
+  member_profile mp
+ON
+  mp.member_id=m.id
 LEFT JOIN
   member l
 ON
   l.id=t.last_member_id
+LEFT JOIN
+  member_profile lp
+ON
+  lp.member_id=l.id
 LEFT JOIN
   thread_post tp
 ON
@@ -77,6 +109,13 @@ LEFT OUTER JOIN
 ON
   (tm.member_id=$2 AND tm.thread_id=t.id)
 WHERE t.sticky IS false
+AND t.deleted IS false
+AND (t.held IS false OR t.member_id=$2)
+AND (sqlc.narg(board_id)::int IS NULL OR t.board_id = sqlc.narg(board_id))
+AND (sqlc.narg(tag)::varchar IS NULL OR EXISTS (SELECT 1 FROM thread_tag tt JOIN tag tg ON tg.id=tt.tag_id WHERE tt.thread_id=t.id AND tg.name = sqlc.narg(tag)))
+AND (cardinality(b.acl) = 0
+  OR sqlc.arg(see_all_boards)::bool
+  OR EXISTS (SELECT 1 FROM unnest(b.acl) entry WHERE entry = ANY(sqlc.arg(principals)::text[]) OR lower(entry) = lower(sqlc.arg(viewer_email)::text)))
 ORDER BY t.date_last_posted DESC
 LIMIT 100;
 
@@ -91,20 +130,38 @@ SELECT
   t.subject,
   t.posts,
   t.views,
+  tp.body,
   (CASE WHEN tm.last_view_posts IS null THEN 0 ELSE tm.last_view_posts END) as last_view_posts,
   (CASE WHEN tm.date_posted IS NOT null AND tm.undot IS false AND tm.member_id IS NOT null THEN 't' ELSE 'f' END)::boolean as dot,
   t.sticky,
-  t.locked
+  t.locked,
+  mp.preferred_name,
+  mp.photo_url,
+  lp.preferred_name as last_preferred_name,
+  lp.photo_url as last_photo_url,
+  b.hide_emails
 FROM
   thread t
+JOIN
+  board_data b
+ON
+  b.id=t.board_id
 LEFT JOIN
   member m
 ON
   m.id=t.member_id
+LEFT JOIN
+  member_profile mp
+ON
+  mp.member_id=m.id
 LEFT JOIN
   member l
 ON
   l.id=t.last_member_id
+LEFT JOIN
+  member_profile lp
+ON
+  lp.member_id=l.id
 LEFT JOIN
   thread_post tp
 ON
@@ -112,9 +169,14 @@ ON
 LEFT OUTER JOIN
   thread_member tm
 ON
-  (tm.member_id=$1 AND tm.thread_id=t.id)
+  (tm.member_id=sqlc.arg(member_id) AND tm.thread_id=t.id)
 WHERE t.sticky IS false
-AND m.id=$1
+AND t.deleted IS false
+AND t.held IS false
+AND m.id=sqlc.arg(member_id)
+AND (cardinality(b.acl) = 0
+  OR sqlc.arg(see_all_boards)::bool
+  OR EXISTS (SELECT 1 FROM unnest(b.acl) entry WHERE entry = ANY(sqlc.arg(principals)::text[]) OR lower(entry) = lower(sqlc.arg(viewer_email)::text)))
 ORDER BY t.date_last_posted DESC
 LIMIT 10;
 
@@ -128,40 +190,113 @@ SELECT
   t.subject,
   t.id as thread_id,
   m.is_admin,
-  (CASE WHEN (m.email = $2 AND t.date_posted >= NOW() - INTERVAL '900 seconds') THEN 't' ELSE 'f' END)::boolean as can_edit
+  tp.edited,
+  tp.date_edited,
+  COALESCE((
+    SELECT jsonb_agg(jsonb_build_object('emoji', r.emoji, 'count', r.count, 'reacted', r.reacted) ORDER BY r.first_reacted)
+    FROM (
+      SELECT pr.emoji, count(*) AS count, bool_or(pr.member_id = $3) AS reacted, min(pr.date_reacted) AS first_reacted
+      FROM post_reaction pr
+      WHERE pr.thread_post_id = tp.id
+      GROUP BY pr.emoji
+    ) r
+  ), '[]')::jsonb as reactions,
+  (CASE WHEN (m.email = $2 AND t.date_posted >= NOW() - sqlc.arg(edit_window)::int * INTERVAL '1 second') THEN 't' ELSE 'f' END)::boolean as can_edit,
+  tp.held,
+  tp.hidden,
+  t.locked,
+  mp.preferred_name,
+  mp.photo_url
 FROM
   thread_post tp
 LEFT JOIN
   member m
 ON
   m.id=tp.member_id
+LEFT JOIN
+  member_profile mp
+ON
+  mp.member_id=m.id
 LEFT JOIN
   thread t
 ON
   t.id = tp.thread_id
 WHERE tp.thread_id=$1
+AND tp.deleted IS false
+AND (tp.held IS false OR tp.member_id=$3)
 ORDER BY tp.date_posted ASC;
 
 -- name: GetBoardData :one
 SELECT
   id,
+  slug,
   title,
+  description,
   total_members,
   total_threads,
   total_thread_posts,
-  edit_window
-FROM board_data;
+  edit_window,
+  reaction_emoji,
+  hide_emails,
+  acl
+FROM board_data
+WHERE sqlc.narg(slug)::varchar IS NULL OR slug = sqlc.narg(slug)
+ORDER BY id
+LIMIT 1;
+
+-- name: ListBoards :many
+SELECT
+  id,
+  slug,
+  title,
+  description,
+  total_threads,
+  total_thread_posts,
+  acl
+FROM board_data
+ORDER BY id;
+
+-- name: CreateBoard :one
+INSERT INTO board_data (slug, title, description, edit_window, total_members, reaction_emoji, hide_emails)
+SELECT $1, $2, $3, edit_window, total_members, reaction_emoji, hide_emails
+FROM board_data
+ORDER BY id
+LIMIT 1
+RETURNING id;
+
+-- name: GetBoardAcl :one
+SELECT acl FROM board_data WHERE id=$1;
+
+-- name: AnyBoardHidesEmails :one
+SELECT EXISTS (SELECT 1 FROM board_data WHERE hide_emails)::boolean AS hides;
+
+-- name: GetThreadBoard :one
+SELECT b.id, b.slug, b.title, b.acl, b.edit_window, b.reaction_emoji, b.hide_emails
+FROM thread t
+JOIN board_data b ON b.id=t.board_id
+WHERE t.id=$1;
+
+-- name: GetThreadPostBoard :one
+SELECT b.id, b.slug, b.title, b.acl
+FROM thread_post tp
+JOIN thread t ON t.id=tp.thread_id
+JOIN board_data b ON b.id=t.board_id
+WHERE tp.id=$1;
 
 -- name: GetThreadSubjectById :one
-SELECT subject FROM thread WHERE id=$1;
+SELECT subject FROM thread WHERE id=$1 AND deleted IS false;
 
 -- name: GetThreadForEdit :one
 SELECT m.email AS email,
   t.id AS thread_id,
   t.subject AS subject,
   tp.id AS thread_post_id,
-  tp.body AS body
+  tp.body AS body,
+  ARRAY(SELECT tg.name FROM thread_tag tt JOIN tag tg ON tg.id=tt.tag_id WHERE tt.thread_id=t.id ORDER BY tg.name)::varchar[] AS tags,
+  b.edit_window
 FROM thread t
+JOIN board_data b
+  ON b.id=t.board_id
 LEFT JOIN thread_post tp
   ON tp.thread_id=t.id
 LEFT JOIN member m
@@ -169,18 +304,39 @@ LEFT JOIN member m
 WHERE t.id=$1 AND m.id=$2;
 
 -- name: GetThreadPostForEdit :one
-SELECT tp.id, tp.body
+SELECT tp.id, tp.body, b.edit_window
 FROM thread_post tp LEFT JOIN member m
   ON tp.member_id=m.id
+JOIN thread t
+  ON t.id=tp.thread_id
+JOIN board_data b
+  ON b.id=t.board_id
 WHERE tp.id=$1 AND m.id=$2;
 
 -- name: UpdateBoardTitle :exec
 UPDATE board_data
-SET title=$1;
+SET title=$1
+WHERE id=$2;
+
+-- name: UpdateBoardDescription :exec
+UPDATE board_data
+SET description=$1
+WHERE id=$2;
+
+-- name: UpdateBoardAcl :exec
+UPDATE board_data
+SET acl=$1
+WHERE id=$2;
 
 -- name: UpdateBoardEditWindow :exec
 UPDATE board_data
-SET edit_window=$1;
+SET edit_window=$1
+WHERE id=$2;
+
+-- name: UpdateBoardHideEmails :exec
+UPDATE board_data
+SET hide_emails=$1
+WHERE id=$2;
 
 -- name: UpdateMemberProfileByID :exec
 UPDATE member_profile SET
@@ -189,24 +345,503 @@ UPDATE member_profile SET
   bio = $4,
   timezone = $5,
   preferred_name = $6,
-  pronouns = $7
+  pronouns = $7,
+  relative_times = $8
 WHERE member_id = $1;
 
+-- name: SyncMemberTailscaleProfile :exec
+WITH seen AS (
+  UPDATE member SET
+    last_seen_node = NULLIF(sqlc.arg(node_name)::varchar, ''),
+    last_seen_os = NULLIF(sqlc.arg(node_os)::varchar, ''),
+    last_seen_at = now()
+  WHERE id = sqlc.arg(member_id)
+)
+UPDATE member_profile SET
+  preferred_name = CASE
+    WHEN COALESCE(preferred_name, '') = '' OR preferred_name = tailscale_name
+    THEN NULLIF(sqlc.arg(display_name)::varchar, '')
+    ELSE preferred_name
+  END,
+  photo_url = CASE
+    WHEN COALESCE(photo_url, '') = '' OR photo_url = tailscale_photo_url
+    THEN NULLIF(sqlc.arg(profile_pic_url)::varchar, '')
+    ELSE photo_url
+  END,
+  tailscale_name = NULLIF(sqlc.arg(display_name)::varchar, ''),
+  tailscale_photo_url = NULLIF(sqlc.arg(profile_pic_url)::varchar, '')
+WHERE member_id = sqlc.arg(member_id);
+
 -- name: UpdateThread :exec
 UPDATE thread SET
-  subject = $1
+  subject = $1,
+  edited = true
 WHERE id = $2
   AND member_id = $3
-  AND date_posted >= NOW() - INTERVAL '900 seconds';
+  AND date_posted >= NOW() - sqlc.arg(edit_window)::int * INTERVAL '1 second';
 
 -- name: UpdateThreadPost :exec
 UPDATE thread_post SET
-  body = $1
+  body = $1,
+  edited = true,
+  date_edited = now(),
+  unfurled = false
 WHERE id = $2
   AND member_id = $3
-  AND date_posted >= NOW() - INTERVAL '900 seconds';
+  AND date_posted >= NOW() - sqlc.arg(edit_window)::int * INTERVAL '1 second';
 
 -- name: BlockMember :exec
 UPDATE member SET
   is_blocked = true
 WHERE id = $1;
+
+-- name: CreateThreadPostRevision :exec
+INSERT INTO thread_post_revision (thread_post_id, member_id, subject, body)
+SELECT tp.id, tp.member_id, t.subject, tp.body
+FROM thread_post tp
+LEFT JOIN thread t
+  ON t.id=tp.thread_id AND t.first_post_id=tp.id
+WHERE tp.id = $1
+  AND tp.member_id = $2
+  AND tp.date_posted >= NOW() - sqlc.arg(edit_window)::int * INTERVAL '1 second';
+
+-- name: GetThreadPost :one
+SELECT
+  tp.id,
+  tp.thread_id,
+  tp.member_id,
+  tp.date_posted,
+  tp.edited,
+  tp.date_edited,
+  tp.body,
+  t.subject,
+  (CASE WHEN t.first_post_id=tp.id THEN 't' ELSE 'f' END)::boolean as is_first_post,
+  tp.held,
+  tp.hidden,
+  tp.deleted
+FROM thread_post tp
+LEFT JOIN thread t
+  ON t.id=tp.thread_id
+WHERE tp.id=$1;
+
+-- name: ListThreadPostRevisions :many
+SELECT
+  r.id,
+  r.thread_post_id,
+  r.member_id,
+  m.email,
+  r.date_revised,
+  r.subject,
+  r.body
+FROM thread_post_revision r
+LEFT JOIN member m
+  ON m.id=r.member_id
+WHERE r.thread_post_id=$1
+ORDER BY r.date_revised ASC, r.id ASC;
+
+-- name: CreatePostReaction :exec
+INSERT INTO post_reaction (thread_post_id, member_id, emoji)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: DeletePostReaction :execrows
+DELETE FROM post_reaction
+WHERE thread_post_id = $1
+  AND member_id = $2
+  AND emoji = $3;
+
+-- name: UpdateBoardReactionEmoji :exec
+UPDATE board_data
+SET reaction_emoji=$1
+WHERE id=$2;
+
+-- name: CreateAttachment :exec
+WITH stored AS (
+  INSERT INTO attachment (hash, content_type, size, filename, member_id)
+  VALUES ($1, $2, $3, $4, $5)
+  ON CONFLICT (hash) DO NOTHING
+)
+INSERT INTO attachment_upload (hash, member_id)
+VALUES ($1, $5)
+ON CONFLICT DO NOTHING;
+
+-- name: CanSeeAttachment :one
+SELECT (
+  sqlc.arg(see_all_boards)::bool
+  OR EXISTS (SELECT 1 FROM attachment_upload au WHERE au.hash = sqlc.arg(hash) AND au.member_id = sqlc.arg(member_id))
+  OR EXISTS (
+    SELECT 1
+    FROM thread_post_attachment pa
+    JOIN thread_post tp ON tp.id=pa.thread_post_id
+    JOIN thread t ON t.id=tp.thread_id
+    JOIN board_data b ON b.id=t.board_id
+    WHERE pa.hash = sqlc.arg(hash)
+    AND tp.deleted IS false
+    AND ((tp.held IS false AND tp.hidden IS false) OR tp.member_id = sqlc.arg(member_id))
+    AND (cardinality(b.acl) = 0
+      OR EXISTS (SELECT 1 FROM unnest(b.acl) entry WHERE entry = ANY(sqlc.arg(principals)::text[]) OR lower(entry) = lower(sqlc.arg(viewer_email)::text)))
+  )
+)::bool AS visible;
+
+-- name: LinkThreadPostAttachments :exec
+INSERT INTO thread_post_attachment (thread_post_id, hash)
+SELECT sqlc.arg(thread_post_id), a.hash FROM attachment a WHERE a.hash = ANY(sqlc.arg(hashes)::varchar[])
+ON CONFLICT DO NOTHING;
+
+-- name: GetAttachment :one
+SELECT hash, content_type, size, filename, member_id, date_uploaded
+FROM attachment
+WHERE hash = $1;
+
+-- name: GetSchemaVersion :one
+SELECT COALESCE(max(version), 0)::int AS version
+FROM schema_version;
+
+-- name: HitRateLimitWindow :one
+WITH hit AS (
+  INSERT INTO rate_limit_window (key, window_start, count)
+  VALUES (sqlc.arg(key), sqlc.arg(window_start), 1)
+  ON CONFLICT (key, window_start) DO UPDATE SET count = rate_limit_window.count + 1
+  RETURNING count
+)
+SELECT hit.count AS current_count,
+  COALESCE((
+    SELECT rate_limit_window.count
+    FROM rate_limit_window
+    WHERE rate_limit_window.key = sqlc.arg(key)
+      AND rate_limit_window.window_start = sqlc.arg(previous_window_start)
+  ), 0)::int AS previous_count
+FROM hit;
+
+-- name: DeleteExpiredRateLimitWindows :execrows
+DELETE FROM rate_limit_window
+WHERE window_start < $1;
+
+-- name: ListRateLimits :many
+SELECT role, requests, window_seconds
+FROM rate_limit
+ORDER BY role;
+
+-- name: UpsertRateLimit :exec
+INSERT INTO rate_limit (role, requests, window_seconds)
+VALUES ($1, $2, $3)
+ON CONFLICT (role) DO UPDATE SET requests = EXCLUDED.requests, window_seconds = EXCLUDED.window_seconds;
+
+-- name: GetMemberPostingActivity :one
+SELECT
+  m.date_joined,
+  (SELECT count(*) FROM thread t
+    WHERE t.member_id = m.id AND t.date_posted >= sqlc.arg(threads_since)) AS recent_threads,
+  (SELECT count(*) FROM thread_post tp
+    WHERE tp.member_id = m.id AND tp.date_posted >= sqlc.arg(posts_since)) AS recent_posts,
+  (SELECT count(*) FROM thread_post tp
+    WHERE tp.member_id = m.id AND tp.body_hash = sqlc.arg(body_hash) AND tp.date_posted >= sqlc.arg(duplicates_since)) AS duplicate_posts
+FROM member m
+WHERE m.id = sqlc.arg(member_id);
+
+-- name: ListHeldPosts :many
+SELECT
+  tp.id,
+  tp.thread_id,
+  tp.date_posted,
+  tp.body,
+  tp.held_reason,
+  m.id as member_id,
+  m.email,
+  m.date_joined,
+  t.subject,
+  (CASE WHEN t.first_post_id=tp.id THEN 't' ELSE 'f' END)::boolean as is_first_post
+FROM thread_post tp
+LEFT JOIN member m
+  ON m.id=tp.member_id
+LEFT JOIN thread t
+  ON t.id=tp.thread_id
+WHERE tp.held IS true
+  AND tp.deleted IS false
+ORDER BY tp.date_posted ASC
+LIMIT 100;
+
+-- name: ApproveHeldPost :execrows
+WITH approved AS (
+  UPDATE thread_post
+  SET held = false, held_reason = NULL
+  WHERE thread_post.id = $1 AND thread_post.held IS true AND thread_post.deleted IS false
+  RETURNING id, thread_id, member_id
+)
+UPDATE thread
+SET
+  held = (thread.held AND thread.first_post_id <> approved.id),
+  posts = thread.posts + 1,
+  last_member_id = approved.member_id,
+  date_last_posted = now()
+FROM approved
+WHERE thread.id = approved.thread_id;
+
+-- name: RejectHeldPost :execrows
+UPDATE thread_post
+SET deleted = true
+WHERE id = $1 AND held IS true AND deleted IS false;
+
+-- name: CreateReport :exec
+INSERT INTO report (thread_post_id, member_id, reason)
+VALUES ($1, $2, $3)
+ON CONFLICT (thread_post_id, member_id) WHERE resolution IS NULL DO NOTHING;
+
+-- name: ListOpenReports :many
+SELECT
+  r.id,
+  r.thread_post_id,
+  r.reason,
+  r.date_reported,
+  rm.email as reporter_email,
+  tp.thread_id,
+  tp.body,
+  tp.hidden,
+  tp.member_id as author_id,
+  am.email as author_email,
+  t.subject,
+  t.locked
+FROM report r
+JOIN thread_post tp
+  ON tp.id=r.thread_post_id
+JOIN thread t
+  ON t.id=tp.thread_id
+LEFT JOIN member rm
+  ON rm.id=r.member_id
+LEFT JOIN member am
+  ON am.id=tp.member_id
+WHERE r.resolution IS NULL
+ORDER BY r.date_reported ASC
+LIMIT 500;
+
+-- name: ResolveReports :execrows
+UPDATE report
+SET resolution = $2, resolved_by = $3, date_resolved = now()
+WHERE thread_post_id = $1
+  AND resolution IS NULL;
+
+-- name: HideThreadPost :exec
+UPDATE thread_post
+SET hidden = true
+WHERE id = $1;
+
+-- name: DeleteThreadPost :exec
+WITH deleted_post AS (
+  UPDATE thread_post
+  SET deleted = true
+  WHERE thread_post.id = $1
+  RETURNING thread_post.id, thread_post.thread_id
+)
+UPDATE thread
+SET deleted = true
+FROM deleted_post
+WHERE thread.id = deleted_post.thread_id
+  AND thread.first_post_id = deleted_post.id;
+
+-- name: LockThread :exec
+UPDATE thread
+SET locked = true
+WHERE id = $1;
+
+-- name: IsThreadLocked :one
+SELECT COALESCE(locked, false)::boolean AS locked
+FROM thread
+WHERE id = $1 AND deleted IS false;
+
+-- name: CreateAuditLog :exec
+INSERT INTO audit_log (actor_id, action, target_type, target_id, before, after, request_id, trace_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: ListAuditLog :many
+SELECT
+  a.id,
+  a.date_created,
+  a.actor_id,
+  m.email as actor_email,
+  a.action,
+  a.target_type,
+  a.target_id,
+  a.before,
+  a.after,
+  a.request_id,
+  a.trace_id
+FROM audit_log a
+LEFT JOIN member m
+  ON m.id=a.actor_id
+WHERE (sqlc.narg(actor_id)::bigint IS NULL OR a.actor_id = sqlc.narg(actor_id))
+  AND (sqlc.narg(action)::text IS NULL OR a.action = sqlc.narg(action))
+  AND (sqlc.narg(target_type)::text IS NULL OR a.target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::text IS NULL OR a.target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(since)::timestamptz IS NULL OR a.date_created >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR a.date_created < sqlc.narg(until))
+  AND (sqlc.narg(before_id)::bigint IS NULL OR a.id < sqlc.narg(before_id))
+ORDER BY a.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: CreateTags :exec
+INSERT INTO tag (name)
+SELECT unnest(sqlc.arg(names)::varchar[])
+ON CONFLICT (name) DO NOTHING;
+
+-- name: SetThreadTags :exec
+WITH removed AS (
+  DELETE FROM thread_tag
+  WHERE thread_tag.thread_id = sqlc.arg(thread_id)
+  AND thread_tag.tag_id NOT IN (SELECT id FROM tag WHERE name = ANY(sqlc.arg(names)::varchar[]))
+)
+INSERT INTO thread_tag (thread_id, tag_id)
+SELECT sqlc.arg(thread_id), id FROM tag WHERE name = ANY(sqlc.arg(names)::varchar[])
+ON CONFLICT DO NOTHING;
+
+-- name: ListTags :many
+SELECT tg.id, tg.name, count(tt.thread_id) AS threads
+FROM tag tg
+LEFT JOIN thread_tag tt ON tt.tag_id=tg.id
+GROUP BY tg.id
+ORDER BY tg.name;
+
+-- name: GetTag :one
+SELECT id, name FROM tag WHERE id=$1;
+
+-- name: RenameTag :exec
+UPDATE tag SET name=$1 WHERE id=$2;
+
+-- name: MergeThreadTags :exec
+INSERT INTO thread_tag (thread_id, tag_id)
+SELECT tt.thread_id, sqlc.arg(into_id)::int FROM thread_tag tt WHERE tt.tag_id = sqlc.arg(from_id)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteTag :exec
+DELETE FROM tag WHERE id=$1;
+
+-- name: CreatePoll :one
+INSERT INTO poll (thread_id, question, multiple, anonymous, date_closes)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: CreatePollOptions :exec
+INSERT INTO poll_option (poll_id, position, label)
+SELECT sqlc.arg(poll_id), o.position, o.label
+FROM unnest(sqlc.arg(labels)::text[]) WITH ORDINALITY AS o(label, position);
+
+-- name: GetThreadPoll :one
+SELECT id, thread_id, question, multiple, anonymous, date_closes, date_created
+FROM poll
+WHERE thread_id=$1;
+
+-- name: ListPollOptions :many
+SELECT
+  o.id,
+  o.label,
+  count(v.poll_option_id) AS votes,
+  COALESCE(bool_or(v.member_id = sqlc.arg(member_id)), false)::boolean AS chosen
+FROM poll_option o
+LEFT JOIN poll_vote v ON v.poll_option_id=o.id
+WHERE o.poll_id = sqlc.arg(poll_id)
+GROUP BY o.id
+ORDER BY o.position;
+
+-- name: ListPollVoters :many
+SELECT v.poll_option_id, m.id, m.email, mp.preferred_name, mp.photo_url
+FROM poll_vote v
+JOIN poll_option o ON o.id=v.poll_option_id
+JOIN member m ON m.id=v.member_id
+LEFT JOIN member_profile mp ON mp.member_id=m.id
+WHERE o.poll_id=$1
+ORDER BY v.poll_option_id, m.id;
+
+-- name: HasVotedInPoll :one
+SELECT EXISTS(SELECT 1 FROM poll_ballot WHERE poll_id=$1 AND member_id=$2);
+
+-- name: CreatePollBallot :execrows
+INSERT INTO poll_ballot (poll_id, member_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: CreatePollVotes :exec
+INSERT INTO poll_vote (poll_option_id, member_id)
+SELECT id, sqlc.narg(member_id)::bigint
+FROM poll_option
+WHERE poll_id = sqlc.arg(poll_id) AND id = ANY(sqlc.arg(option_ids)::bigint[]);
+
+-- name: SaveDraft :exec
+INSERT INTO draft (member_id, thread_id, subject, body)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (member_id, COALESCE(thread_id, 0)) DO UPDATE
+SET subject=EXCLUDED.subject, body=EXCLUDED.body, date_saved=now();
+
+-- name: GetDraft :one
+SELECT id, member_id, thread_id, subject, body, date_saved
+FROM draft
+WHERE member_id = sqlc.arg(member_id)
+  AND thread_id IS NOT DISTINCT FROM sqlc.narg(thread_id)::bigint
+  AND date_saved > sqlc.arg(saved_after);
+
+-- name: DeleteDraft :exec
+DELETE FROM draft
+WHERE member_id = sqlc.arg(member_id)
+  AND thread_id IS NOT DISTINCT FROM sqlc.narg(thread_id)::bigint;
+
+-- name: ListMemberDrafts :many
+SELECT
+  d.id,
+  d.thread_id,
+  d.subject,
+  d.body,
+  d.date_saved,
+  t.subject AS thread_subject
+FROM draft d
+LEFT JOIN thread t ON t.id=d.thread_id
+LEFT JOIN board_data b ON b.id=t.board_id
+WHERE d.member_id = sqlc.arg(member_id)
+  AND d.date_saved > sqlc.arg(saved_after)
+  AND (COALESCE(cardinality(b.acl), 0) = 0
+    OR sqlc.arg(see_all_boards)::bool
+    OR EXISTS (SELECT 1 FROM unnest(b.acl) entry WHERE entry = ANY(sqlc.arg(principals)::text[]) OR lower(entry) = lower(sqlc.arg(viewer_email)::text)))
+ORDER BY d.date_saved DESC;
+
+-- name: DeleteExpiredDrafts :execrows
+DELETE FROM draft
+WHERE date_saved < $1;
+
+-- name: ListPostsToUnfurl :many
+SELECT id, body
+FROM thread_post
+WHERE NOT unfurled
+ORDER BY id
+LIMIT $1;
+
+-- name: MarkThreadPostUnfurled :exec
+UPDATE thread_post SET unfurled = true
+WHERE id = $1 AND body IS NOT DISTINCT FROM $2;
+
+-- name: GetLinkPreview :one
+SELECT url, ok, title, description, site_name, image_url, date_fetched
+FROM link_preview
+WHERE url = $1;
+
+-- name: UpsertLinkPreview :exec
+INSERT INTO link_preview (url, ok, title, description, site_name, image_url)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (url) DO UPDATE
+SET ok=EXCLUDED.ok, title=EXCLUDED.title, description=EXCLUDED.description,
+  site_name=EXCLUDED.site_name, image_url=EXCLUDED.image_url, date_fetched=now();
+
+-- name: SetThreadPostLinks :exec
+WITH removed AS (
+  DELETE FROM thread_post_link
+  WHERE thread_post_link.thread_post_id = sqlc.arg(thread_post_id)
+  AND thread_post_link.url <> ALL(sqlc.arg(urls)::text[])
+)
+INSERT INTO thread_post_link (thread_post_id, position, url)
+SELECT sqlc.arg(thread_post_id), l.position, l.url
+FROM unnest(sqlc.arg(urls)::text[]) WITH ORDINALITY AS l(url, position)
+ON CONFLICT (thread_post_id, url) DO UPDATE SET position=EXCLUDED.position;
+
+-- name: ListThreadLinkPreviews :many
+SELECT l.thread_post_id, p.url, p.title, p.description, p.site_name, p.image_url
+FROM thread_post_link l
+JOIN thread_post tp ON tp.id=l.thread_post_id
+JOIN link_preview p ON p.url=l.url
+WHERE tp.thread_id=$1 AND p.ok
+ORDER BY l.thread_post_id, l.position;
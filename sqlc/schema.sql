@@ -1,16 +1,32 @@
 CREATE TABLE board_data
 (
   id      serial PRIMARY KEY,                 -- id
+  slug   varchar NOT NULL UNIQUE CHECK(slug <> ''), -- url name of board, as in /b/{slug}/
   title  varchar NOT NULL CHECK(title <> ''), -- title of board
+  description text NOT NULL DEFAULT '',       -- what the board is for, shown on its page
   allow_editing boolean DEFAULT false,        -- allow editing of posts
   allow_deleting boolean DEFAULT false,       -- allow deleting of posts
   edit_window int DEFAULT 0,                  -- time in seconds to allow editing of posts
   total_members int DEFAULT 0,                -- total members
   total_threads int DEFAULT 0,                -- total threads
-  total_thread_posts int DEFAULT 0            -- total posts in threads
+  total_thread_posts int DEFAULT 0,           -- total posts in threads
+  reaction_emoji text[] NOT NULL DEFAULT '{+1,tada,eyes,heart,laughing}', -- emoji short names members can react with
+  hide_emails boolean NOT NULL DEFAULT false, -- show members by name only, emails are for admins
+  acl text[] NOT NULL DEFAULT '{}'            -- groups, tags, capabilities and emails that can see the board, empty for everyone
 );
 
-INSERT INTO board_data (title, edit_window) VALUES ('My Board', 900);
+INSERT INTO board_data (slug, title, edit_window) VALUES ('general', 'My Board', 900);
+
+-- Migrations applied to this database. Every migration in sqlc/ inserts its
+-- version here, and /readyz reports an error until the version expected by
+-- the running binary (expectedSchemaVersion in health.go) is present.
+CREATE TABLE schema_version
+(
+  version       int PRIMARY KEY,                      -- migration number
+  date_applied  timestamptz NOT NULL DEFAULT now()    -- time the migration was applied
+);
+
+INSERT INTO schema_version (version) VALUES (1), (2), (3), (4), (5), (6), (7), (8), (9), (10), (11), (12), (13), (14), (15);
 
 CREATE TABLE member
 (
@@ -23,7 +39,10 @@ CREATE TABLE member
   last_post            timestamp,                           -- last post to board
   last_view            timestamp,                           -- last view of board
   total_thread_posts   int DEFAULT 0,                       -- member's total posts
-  total_threads        int DEFAULT 0                        -- member's total threads created
+  total_threads        int DEFAULT 0,                       -- member's total threads created
+  last_seen_node       varchar,                             -- tailscale machine last seen on
+  last_seen_os         varchar,                             -- os of that machine
+  last_seen_at         timestamptz                          -- when the member was last seen
 );
 
 CREATE TABLE member_profile
@@ -36,13 +55,17 @@ CREATE TABLE member_profile
   proper_name          varchar,                      -- proper name of member
   photo_url            varchar,                      -- url to the users photo
   timezone             varchar,                      -- timezone of member
-  bio                  text                          -- bio of member
+  relative_times       boolean NOT NULL DEFAULT false, -- show times as "3 hours ago"
+  bio                  text,                         -- bio of member
+  tailscale_name       varchar,                      -- display name last synced from tailscale
+  tailscale_photo_url  varchar                       -- profile picture last synced from tailscale
 
 );
 
 CREATE TABLE thread
 (
   id                 bigserial UNIQUE PRIMARY KEY,
+  board_id           int NOT NULL,                        -- board the thread was started in
   member_id          bigint NOT NULL,                     -- id of member who created thread
   subject            text NOT NULL CHECK(subject <> ''),  -- subject of thread
   date_posted        timestamptz not NULL DEFAULT now(),  -- date thread was created
@@ -55,7 +78,8 @@ CREATE TABLE thread
   date_last_posted   timestamptz NOT NULL DEFAULT now(),  -- time last post was entered
   indexed            bool NOT NULL DEFAULT false,         -- has been indexed: for search indexer
   edited             bool NOT NULL DEFAULT false,         -- has been edited: for search indexer
-  deleted            bool NOT NULL DEFAULT false          -- flagged for deletion: for search indexer
+  deleted            bool NOT NULL DEFAULT false,         -- flagged for deletion: for search indexer
+  held               bool NOT NULL DEFAULT false          -- first post awaiting moderation, hidden from other members
 );
 
 CREATE TABLE thread_post
@@ -67,7 +91,177 @@ CREATE TABLE thread_post
   indexed       bool NOT NULL DEFAULT false,  -- has been indexed by search indexer
   edited        bool NOT NULL DEFAULT false,  -- has been edited: for search indexer
   deleted       bool NOT NULL DEFAULT false,  -- flagged for deletion: for search indexer
-  body          text                          -- body text of post
+  date_edited   timestamptz,                  -- time this post was last edited
+  body          text,                         -- body text of post
+  body_hash     varchar(64),                  -- hex sha256 of the normalized markdown, for duplicate detection
+  held          bool NOT NULL DEFAULT false,  -- awaiting moderation, hidden from other members
+  held_reason   varchar,                      -- why the spam guard held the post
+  hidden        bool NOT NULL DEFAULT false,  -- body hidden by a moderator
+  unfurled      bool NOT NULL DEFAULT false   -- links have been unfurled into preview cards
+);
+
+CREATE TABLE thread_post_revision
+(
+  id              bigserial UNIQUE PRIMARY KEY,         -- id of revision
+  thread_post_id  bigint NOT NULL,                      -- post this revision belongs to
+  member_id       bigint NOT NULL,                      -- id of member who made the edit
+  date_revised    timestamptz NOT NULL DEFAULT now(),   -- time the edit was made
+  subject         text,                                 -- thread subject before the edit, first posts only
+  body            text                                  -- body text before the edit
+);
+
+CREATE TABLE post_reaction
+(
+  thread_post_id  bigint NOT NULL,                      -- post reacted to
+  member_id       bigint NOT NULL,                      -- member who reacted
+  emoji           varchar NOT NULL CHECK(emoji <> ''),  -- goldmark-emoji short name
+  date_reacted    timestamptz NOT NULL DEFAULT now(),   -- time of reaction
+  PRIMARY KEY (thread_post_id, member_id, emoji)
+);
+
+CREATE TABLE tag
+(
+  id    serial PRIMARY KEY,                           -- id
+  name  varchar NOT NULL UNIQUE CHECK(name <> '')     -- lowercase label, as in /tag/{name}
+);
+
+CREATE TABLE thread_tag
+(
+  thread_id  bigint NOT NULL,                         -- thread labelled
+  tag_id     int NOT NULL,                            -- tag it's labelled with
+  PRIMARY KEY (thread_id, tag_id)
+);
+
+CREATE TABLE poll
+(
+  id            bigserial UNIQUE PRIMARY KEY,           -- id of poll
+  thread_id     bigint NOT NULL UNIQUE,                 -- thread the poll was started with
+  question      text NOT NULL CHECK(question <> ''),    -- what's being asked
+  multiple      bool NOT NULL DEFAULT false,            -- members may choose more than one option
+  anonymous     bool NOT NULL DEFAULT false,            -- votes aren't linked to who cast them
+  date_closes   timestamptz,                            -- time voting ends, null for never
+  date_created  timestamptz NOT NULL DEFAULT now()      -- time the poll was created
+);
+
+CREATE TABLE poll_option
+(
+  id        bigserial UNIQUE PRIMARY KEY,               -- id of option
+  poll_id   bigint NOT NULL,                            -- poll the option belongs to
+  position  int NOT NULL,                               -- order the option is shown in
+  label     text NOT NULL CHECK(label <> ''),           -- what the option says
+  UNIQUE (poll_id, position)
+);
+
+CREATE TABLE poll_ballot
+(
+  poll_id     bigint NOT NULL,                          -- poll voted in
+  member_id   bigint NOT NULL,                          -- member who voted, once per poll
+  date_voted  timestamptz NOT NULL DEFAULT now(),       -- time of vote
+  PRIMARY KEY (poll_id, member_id)
+);
+
+CREATE TABLE poll_vote
+(
+  poll_option_id  bigint NOT NULL,                      -- option chosen
+  member_id       bigint                                -- member who chose it, null in anonymous polls
+);
+
+CREATE TABLE draft
+(
+  id          bigserial UNIQUE PRIMARY KEY,             -- id of draft
+  member_id   bigint NOT NULL,                          -- member writing it
+  thread_id   bigint,                                   -- thread being replied to, null for a new thread
+  subject     text NOT NULL DEFAULT '',                 -- subject of a new thread
+  body        text NOT NULL DEFAULT '',                 -- markdown written so far
+  date_saved  timestamptz NOT NULL DEFAULT now()        -- time last saved
+);
+
+CREATE TABLE link_preview
+(
+  url           text PRIMARY KEY,                        -- link as posted
+  ok            bool NOT NULL DEFAULT false,             -- the page had a title to show
+  title         text NOT NULL DEFAULT '',                -- og:title, or the page title
+  description   text NOT NULL DEFAULT '',                -- og:description
+  site_name     text NOT NULL DEFAULT '',                -- og:site_name
+  image_url     text NOT NULL DEFAULT '',                -- og:image
+  date_fetched  timestamptz NOT NULL DEFAULT now()       -- time the page was last fetched
+);
+
+CREATE TABLE thread_post_link
+(
+  thread_post_id  bigint NOT NULL,                       -- post the link is in
+  position        int NOT NULL,                          -- order of the link in the post
+  url             text NOT NULL,                         -- link as posted
+  PRIMARY KEY (thread_post_id, url)
+);
+
+CREATE TABLE attachment
+(
+  hash            varchar(64) PRIMARY KEY,              -- hex sha256 of the stored (metadata stripped) content
+  content_type    varchar NOT NULL,                     -- sniffed MIME type
+  size            bigint NOT NULL,                      -- size in bytes
+  filename        varchar NOT NULL DEFAULT '',          -- original filename of the first upload
+  member_id       bigint NOT NULL,                      -- member who first uploaded it
+  date_uploaded   timestamptz NOT NULL DEFAULT now()    -- time of first upload
+);
+
+CREATE TABLE attachment_upload
+(
+  hash            varchar(64) NOT NULL,                 -- file uploaded
+  member_id       bigint NOT NULL,                      -- member who uploaded it, who can always fetch it
+  PRIMARY KEY (hash, member_id)
+);
+
+CREATE TABLE thread_post_attachment
+(
+  thread_post_id  bigint NOT NULL,                      -- post linking the file
+  hash            varchar(64) NOT NULL,                 -- file linked, served to members who can see the post
+  PRIMARY KEY (thread_post_id, hash)
+);
+
+CREATE TABLE rate_limit
+(
+  role            varchar(16) PRIMARY KEY,              -- admin or member
+  requests        int NOT NULL CHECK(requests > 0),     -- requests allowed per window
+  window_seconds  int NOT NULL CHECK(window_seconds > 0) -- length of the sliding window
+);
+
+INSERT INTO rate_limit (role, requests, window_seconds) VALUES
+  ('admin', 1200, 60),
+  ('member', 300, 60);
+
+CREATE UNLOGGED TABLE rate_limit_window
+(
+  key           varchar NOT NULL,                       -- visitor and limit being counted
+  window_start  timestamptz NOT NULL,                   -- start of the fixed window
+  count         int NOT NULL DEFAULT 0,                 -- requests counted in the window
+  PRIMARY KEY (key, window_start)
+);
+
+CREATE TABLE report
+(
+  id              bigserial UNIQUE PRIMARY KEY,         -- id of report
+  thread_post_id  bigint NOT NULL,                      -- post being reported
+  member_id       bigint NOT NULL,                      -- member who reported it
+  reason          text NOT NULL CHECK(reason <> ''),    -- why it was reported
+  date_reported   timestamptz NOT NULL DEFAULT now(),   -- time of report
+  resolution      varchar,                              -- dismiss, hide, delete, lock or block; null while open
+  resolved_by     bigint,                               -- admin who resolved it
+  date_resolved   timestamptz                           -- time it was resolved
+);
+
+CREATE TABLE audit_log
+(
+  id            bigserial UNIQUE PRIMARY KEY,           -- id of entry
+  date_created  timestamptz NOT NULL DEFAULT now(),     -- time of the action
+  actor_id      bigint NOT NULL,                        -- admin who took the action
+  action        varchar NOT NULL CHECK(action <> ''),   -- what was done, e.g. block_member
+  target_type   varchar NOT NULL DEFAULT '',            -- kind of thing acted on: member, thread, post, board or rate_limits
+  target_id     varchar NOT NULL DEFAULT '',            -- id of the thing acted on
+  before        jsonb,                                  -- values before the action
+  after         jsonb,                                  -- values after the action
+  request_id    varchar NOT NULL DEFAULT '',            -- request that took the action
+  trace_id      varchar NOT NULL DEFAULT ''             -- trace of that request
 );
 
 CREATE TABLE thread_member
@@ -97,11 +291,11 @@ CREATE OR REPLACE FUNCTION thread_sync() RETURNS trigger AS $$
 BEGIN
   IF TG_OP = 'DELETE' THEN
     UPDATE member SET total_threads=total_threads-1 WHERE id=OLD.member_id;
-    UPDATE board_data SET total_threads=(total_threads::integer)-1;
+    UPDATE board_data SET total_threads=(total_threads::integer)-1 WHERE id=OLD.board_id;
     RETURN OLD;
   ELSEIF TG_OP = 'INSERT' THEN
     UPDATE member SET total_threads=total_threads+1 WHERE id=NEW.member_id;
-    UPDATE board_data SET total_threads=(total_threads::integer)+1;
+    UPDATE board_data SET total_threads=(total_threads::integer)+1 WHERE id=NEW.board_id;
     RETURN NEW;
   END IF;
   RETURN NULL;
@@ -112,7 +306,7 @@ CREATE OR REPLACE FUNCTION thread_post_sync() RETURNS trigger AS $$
 BEGIN
   IF TG_OP = 'DELETE' THEN
     UPDATE member SET total_thread_posts=total_thread_posts-1, last_post=now() WHERE id=OLD.member_id;
-    UPDATE board_data SET total_thread_posts=(total_thread_posts::integer)-1;
+    UPDATE board_data SET total_thread_posts=(total_thread_posts::integer)-1 WHERE id=(SELECT board_id FROM thread WHERE id=OLD.thread_id);
     IF (SELECT count(*) FROM thread_post WHERE thread_id=OLD.thread_id) > 1 THEN
       UPDATE
         thread
@@ -135,16 +329,26 @@ BEGIN
   ELSEIF TG_OP = 'INSERT' THEN
     UPDATE member SET last_post=now() WHERE id=NEW.member_id;
     UPDATE member SET total_thread_posts=total_thread_posts+1 WHERE id=NEW.member_id;
-    UPDATE board_data SET total_thread_posts=(total_thread_posts::integer)+1;
-    UPDATE
-      thread
-    SET
-      posts=posts+1,
-      first_post_id=(SELECT id FROM thread_post WHERE thread_id=NEW.thread_id ORDER BY date_posted ASC LIMIT 1),
-      last_member_id=(SELECT member_id FROM thread_post WHERE thread_id=NEW.thread_id ORDER BY date_posted DESC LIMIT 1),
-      date_last_posted=now()
-    WHERE
-      id=NEW.thread_id;
+    UPDATE board_data SET total_thread_posts=(total_thread_posts::integer)+1 WHERE id=(SELECT board_id FROM thread WHERE id=NEW.thread_id);
+    -- Held posts don't bump the thread until they are approved
+    IF NEW.held THEN
+      UPDATE
+        thread
+      SET
+        first_post_id=(SELECT id FROM thread_post WHERE thread_id=NEW.thread_id ORDER BY date_posted ASC LIMIT 1)
+      WHERE
+        id=NEW.thread_id;
+    ELSE
+      UPDATE
+        thread
+      SET
+        posts=posts+1,
+        first_post_id=(SELECT id FROM thread_post WHERE thread_id=NEW.thread_id ORDER BY date_posted ASC LIMIT 1),
+        last_member_id=(SELECT member_id FROM thread_post WHERE thread_id=NEW.thread_id ORDER BY date_posted DESC LIMIT 1),
+        date_last_posted=now()
+      WHERE
+        id=NEW.thread_id;
+    END IF;
     IF NOT EXISTS (SELECT 1 FROM thread_member WHERE member_id=NEW.member_id AND thread_id=NEW.thread_id) THEN
       INSERT INTO
         thread_member (member_id,thread_id,date_posted,last_view_posts)
@@ -269,8 +473,11 @@ CREATE INDEX thread_date_last_posted_index ON thread(date_last_posted);
 CREATE INDEX thread_indexed_index ON thread(indexed);
 CREATE INDEX thread_edited_index ON thread(edited);
 CREATE INDEX thread_deleted_index ON thread(deleted);
+CREATE INDEX thread_member_id_date_posted_index ON thread(member_id, date_posted);
+CREATE INDEX thread_board_id_date_last_posted_index ON thread(board_id, date_last_posted);
 CLUSTER thread_date_last_posted_index ON thread;
 
+ALTER TABLE thread ADD FOREIGN KEY (board_id) REFERENCES board_data(id);
 ALTER TABLE thread ADD FOREIGN KEY (member_id) REFERENCES member(id);
 ALTER TABLE thread ADD FOREIGN KEY (last_member_id) REFERENCES member(id);
 
@@ -286,6 +493,9 @@ CREATE INDEX thread_post_indexed_index ON thread_post(indexed);
 CREATE INDEX thread_post_edited_index ON thread_post(edited);
 CREATE INDEX thread_post_deleted_index ON thread_post(deleted);
 CREATE INDEX thread_post_thread_id_date_posted_index ON thread_post(thread_id, date_posted);
+CREATE INDEX thread_post_member_id_date_posted_index ON thread_post(member_id, date_posted);
+CREATE INDEX thread_post_member_id_body_hash_index ON thread_post(member_id, body_hash);
+CREATE INDEX thread_post_held_index ON thread_post(date_posted) WHERE held;
 ALTER TABLE thread_post ADD FOREIGN KEY (member_id) REFERENCES member(id);
 ALTER TABLE thread_post ADD FOREIGN KEY (thread_id) REFERENCES thread(id);
 
@@ -293,6 +503,74 @@ CREATE TRIGGER thread_post_sync AFTER INSERT OR DELETE ON thread_post
   FOR EACH ROW EXECUTE PROCEDURE thread_post_sync();
 -- end thread_post
 
+-- start thread_post_revision
+CREATE INDEX thread_post_revision_thread_post_id_index ON thread_post_revision(thread_post_id, date_revised);
+ALTER TABLE thread_post_revision ADD FOREIGN KEY (thread_post_id) REFERENCES thread_post(id);
+ALTER TABLE thread_post_revision ADD FOREIGN KEY (member_id) REFERENCES member(id);
+-- end thread_post_revision
+
+-- start post_reaction
+ALTER TABLE post_reaction ADD FOREIGN KEY (thread_post_id) REFERENCES thread_post(id);
+ALTER TABLE post_reaction ADD FOREIGN KEY (member_id) REFERENCES member(id);
+-- end post_reaction
+
+-- start thread_tag
+CREATE INDEX thread_tag_tag_id_index ON thread_tag(tag_id);
+ALTER TABLE thread_tag ADD FOREIGN KEY (thread_id) REFERENCES thread(id) ON DELETE CASCADE;
+ALTER TABLE thread_tag ADD FOREIGN KEY (tag_id) REFERENCES tag(id) ON DELETE CASCADE;
+-- end thread_tag
+
+-- start poll
+ALTER TABLE poll ADD FOREIGN KEY (thread_id) REFERENCES thread(id) ON DELETE CASCADE;
+CREATE INDEX poll_option_poll_id_index ON poll_option(poll_id);
+ALTER TABLE poll_option ADD FOREIGN KEY (poll_id) REFERENCES poll(id) ON DELETE CASCADE;
+ALTER TABLE poll_ballot ADD FOREIGN KEY (poll_id) REFERENCES poll(id) ON DELETE CASCADE;
+ALTER TABLE poll_ballot ADD FOREIGN KEY (member_id) REFERENCES member(id);
+CREATE INDEX poll_vote_poll_option_id_index ON poll_vote(poll_option_id);
+ALTER TABLE poll_vote ADD FOREIGN KEY (poll_option_id) REFERENCES poll_option(id) ON DELETE CASCADE;
+ALTER TABLE poll_vote ADD FOREIGN KEY (member_id) REFERENCES member(id);
+-- end poll
+
+-- start draft
+CREATE UNIQUE INDEX draft_member_id_thread_id_index ON draft(member_id, COALESCE(thread_id, 0));
+CREATE INDEX draft_date_saved_index ON draft(date_saved);
+ALTER TABLE draft ADD FOREIGN KEY (member_id) REFERENCES member(id);
+ALTER TABLE draft ADD FOREIGN KEY (thread_id) REFERENCES thread(id) ON DELETE CASCADE;
+-- end draft
+
+-- start link_preview
+ALTER TABLE thread_post_link ADD FOREIGN KEY (thread_post_id) REFERENCES thread_post(id) ON DELETE CASCADE;
+-- end link_preview
+
+-- start attachment
+CREATE INDEX attachment_member_id_index ON attachment(member_id);
+ALTER TABLE attachment ADD FOREIGN KEY (member_id) REFERENCES member(id);
+ALTER TABLE attachment_upload ADD FOREIGN KEY (hash) REFERENCES attachment(hash);
+ALTER TABLE attachment_upload ADD FOREIGN KEY (member_id) REFERENCES member(id);
+CREATE INDEX thread_post_attachment_hash_index ON thread_post_attachment(hash);
+ALTER TABLE thread_post_attachment ADD FOREIGN KEY (thread_post_id) REFERENCES thread_post(id) ON DELETE CASCADE;
+ALTER TABLE thread_post_attachment ADD FOREIGN KEY (hash) REFERENCES attachment(hash);
+-- end attachment
+
+-- start report
+CREATE UNIQUE INDEX report_open_index ON report(thread_post_id, member_id) WHERE resolution IS NULL;
+ALTER TABLE report ADD FOREIGN KEY (thread_post_id) REFERENCES thread_post(id);
+ALTER TABLE report ADD FOREIGN KEY (member_id) REFERENCES member(id);
+ALTER TABLE report ADD FOREIGN KEY (resolved_by) REFERENCES member(id);
+-- end report
+
+-- start audit_log
+CREATE INDEX audit_log_date_created_index ON audit_log(date_created);
+CREATE INDEX audit_log_actor_id_index ON audit_log(actor_id);
+CREATE INDEX audit_log_action_index ON audit_log(action);
+CREATE INDEX audit_log_target_index ON audit_log(target_type, target_id);
+ALTER TABLE audit_log ADD FOREIGN KEY (actor_id) REFERENCES member(id);
+-- end audit_log
+
+-- start rate_limit_window
+CREATE INDEX rate_limit_window_window_start_index ON rate_limit_window(window_start);
+-- end rate_limit_window
+
 -- start thread_member
 CREATE UNIQUE INDEX tm_mi_mi_lvr ON thread_member(member_id,thread_id,last_view_posts);
 CREATE INDEX thread_member_member_id_date_posted ON thread_member(member_id,date_posted);
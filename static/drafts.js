@@ -0,0 +1,60 @@
+// Draft autosave: a form with class="draft-form" saves what's been written
+// to /draft every few seconds while it changes, so it can be restored when
+// the form is reopened. data-draft-thread is the thread a reply belongs to,
+// empty for a new thread.
+
+const draftSaveInterval = 10000;
+
+function draftFields(form) {
+    const subject = form.querySelector('#subject');
+    const body = form.querySelector('#thread_body');
+    return {
+        subject: subject ? subject.value : '',
+        body: body ? body.value : '',
+    };
+}
+
+function saveDraft(form, fields) {
+    const status = form.querySelector('.draft-status');
+    const data = new FormData();
+    data.append('thread_id', form.getAttribute('data-draft-thread'));
+    data.append('subject', fields.subject);
+    data.append('body', fields.body);
+
+    return fetch('/draft', { method: 'POST', body: data, credentials: 'same-origin' })
+        .then(resp => {
+            if (!resp.ok) {
+                return Promise.reject(resp.status);
+            }
+            if (status) {
+                status.textContent = fields.subject || fields.body ? 'draft saved' : '';
+            }
+        })
+        .catch(() => {
+            if (status) {
+                status.textContent = 'draft not saved';
+            }
+        });
+}
+
+document.addEventListener('DOMContentLoaded', function() {
+    document.querySelectorAll('form.draft-form').forEach(form => {
+        let saved = draftFields(form);
+        let submitted = false;
+
+        const timer = setInterval(() => {
+            const fields = draftFields(form);
+            if (submitted || (fields.subject === saved.subject && fields.body === saved.body)) {
+                return;
+            }
+            saved = fields;
+            saveDraft(form, fields);
+        }, draftSaveInterval);
+
+        // Posting discards the draft server-side; don't save it again
+        form.addEventListener('submit', () => {
+            submitted = true;
+            clearInterval(timer);
+        });
+    });
+});
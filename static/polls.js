@@ -0,0 +1,49 @@
+// Live poll tallies: while a thread with a poll is open, its tallies are
+// refreshed from the server-rendered poll without touching the voting form.
+
+const pollRefreshInterval = 15000;
+
+function refreshPoll(poll) {
+    if (document.hidden) {
+        return;
+    }
+
+    fetch(poll.getAttribute('data-poll-url'), { credentials: 'same-origin' })
+        .then(resp => resp.ok ? resp.text() : Promise.reject(resp.status))
+        .then(html => {
+            const fresh = new DOMParser().parseFromString(html, 'text/html').querySelector('.poll');
+            if (!fresh) {
+                return;
+            }
+
+            const total = fresh.querySelector('.poll-total');
+            if (total) {
+                poll.querySelector('.poll-total').textContent = total.textContent;
+            }
+
+            fresh.querySelectorAll('.poll-option').forEach(option => {
+                const current = poll.querySelector(`.poll-option[data-option-id="${option.getAttribute('data-option-id')}"]`);
+                if (!current) {
+                    return;
+                }
+                current.querySelector('.poll-votes').textContent = option.querySelector('.poll-votes').textContent;
+                current.querySelector('.poll-bar').value = option.querySelector('.poll-bar').value;
+
+                // Voters are only listed once the member has voted
+                const voters = option.querySelector('.poll-voters');
+                const currentVoters = current.querySelector('.poll-voters');
+                if (voters && currentVoters) {
+                    currentVoters.replaceWith(voters);
+                } else if (voters) {
+                    current.appendChild(voters);
+                }
+            });
+        })
+        .catch(() => {});
+}
+
+document.addEventListener('DOMContentLoaded', function() {
+    document.querySelectorAll('.poll[data-poll-url]').forEach(poll => {
+        setInterval(() => refreshPoll(poll), pollRefreshInterval);
+    });
+});
@@ -0,0 +1,90 @@
+// Quote-reply and ">>1234" post reference previews
+
+function quoteText(bubble) {
+    const body = bubble.querySelector('.threadpost-body').cloneNode(true);
+    body.querySelectorAll('.post-edit-link').forEach(el => el.remove());
+    return body.innerText.trim();
+}
+
+function quotePost(btn) {
+    const textarea = document.getElementById('thread_body');
+    const bubble = btn.closest('.threadpost-bubble');
+    if (!textarea || !bubble) {
+        return;
+    }
+
+    const postId = btn.getAttribute('data-post-id');
+    const threadId = btn.getAttribute('data-thread-id');
+    const author = btn.getAttribute('data-author');
+
+    const lines = quoteText(bubble).split('\n').map(line => '> ' + line);
+    const quote = `> [${author}](/thread/${threadId}#post-${postId}) wrote:\n${lines.join('\n')}\n\n`;
+
+    if (textarea.value && !textarea.value.endsWith('\n')) {
+        textarea.value += '\n\n';
+    }
+    textarea.value += quote;
+    textarea.focus();
+    textarea.setSelectionRange(textarea.value.length, textarea.value.length);
+    textarea.scrollIntoView({ behavior: 'smooth', block: 'center' });
+}
+
+const previewCache = new Map();
+let activePreview = null;
+
+function fetchPreview(href) {
+    if (!previewCache.has(href)) {
+        const request = fetch(href + '/preview', { credentials: 'same-origin' })
+            .then(resp => resp.ok ? resp.text() : Promise.reject(resp.status))
+            .catch(() => {
+                previewCache.delete(href);
+                return null;
+            });
+        previewCache.set(href, request);
+    }
+    return previewCache.get(href);
+}
+
+function hidePreview() {
+    if (activePreview) {
+        activePreview.remove();
+        activePreview = null;
+    }
+}
+
+function showPreview(link) {
+    const href = link.getAttribute('href');
+    fetchPreview(href).then(html => {
+        if (!html || !link.matches(':hover')) {
+            return;
+        }
+        hidePreview();
+
+        // The fragment is rendered server-side from the sanitized post body
+        const popup = document.createElement('div');
+        popup.className = 'post-preview-popup';
+        popup.innerHTML = html;
+
+        const rect = link.getBoundingClientRect();
+        popup.style.left = `${rect.left + window.scrollX}px`;
+        popup.style.top = `${rect.bottom + window.scrollY + 4}px`;
+
+        document.body.appendChild(popup);
+        activePreview = popup;
+    });
+}
+
+document.addEventListener('DOMContentLoaded', function() {
+    document.querySelectorAll('.quote-btn').forEach(btn => {
+        btn.addEventListener('click', function() {
+            quotePost(this);
+        });
+    });
+
+    document.querySelectorAll('a.post-ref').forEach(link => {
+        link.addEventListener('mouseenter', function() {
+            showPreview(this);
+        });
+        link.addEventListener('mouseleave', hidePreview);
+    });
+});
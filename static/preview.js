@@ -0,0 +1,72 @@
+// Post previews: the write/preview tabs above a post's textarea swap it for
+// the post rendered by /preview, exactly as posting it would.
+
+function showPreview(tabs, textarea) {
+    let preview = tabs.parentElement.querySelector('.compose-preview');
+    if (!preview) {
+        preview = document.createElement('div');
+        preview.className = 'compose-preview threadpost-body';
+        textarea.after(preview);
+    }
+
+    const body = new FormData();
+    body.append('thread_body', textarea.value);
+
+    preview.textContent = 'rendering preview...';
+    textarea.hidden = true;
+    preview.hidden = false;
+
+    fetch('/preview', { method: 'POST', body: body, credentials: 'same-origin' })
+        .then(resp => {
+            if (resp.status === 429) {
+                return Promise.reject('too many previews, try again in a moment');
+            }
+            if (resp.status === 400) {
+                return resp.text().then(text => Promise.reject(text.trim()));
+            }
+            return resp.ok ? resp.text() : Promise.reject('preview failed');
+        })
+        .then(html => {
+            // The fragment has been through the same sanitizer as posts
+            preview.innerHTML = html || '<p class="compose-preview-empty">nothing to preview</p>';
+        })
+        .catch(err => {
+            preview.textContent = typeof err === 'string' ? err : 'preview failed';
+        });
+}
+
+function showWrite(tabs, textarea) {
+    const preview = tabs.parentElement.querySelector('.compose-preview');
+    if (preview) {
+        preview.hidden = true;
+    }
+    textarea.hidden = false;
+    textarea.focus();
+}
+
+document.addEventListener('DOMContentLoaded', function() {
+    document.querySelectorAll('.compose-tabs').forEach(tabs => {
+        const textarea = tabs.parentElement.querySelector('textarea');
+        if (!textarea) {
+            return;
+        }
+
+        // A required textarea hidden behind the preview can't be focused by
+        // the browser's validation, so go back to writing
+        textarea.addEventListener('invalid', () => {
+            tabs.querySelectorAll('.compose-tab').forEach(t => t.classList.toggle('active', t.getAttribute('data-compose-tab') === 'write'));
+            showWrite(tabs, textarea);
+        });
+
+        tabs.querySelectorAll('.compose-tab').forEach(tab => {
+            tab.addEventListener('click', () => {
+                tabs.querySelectorAll('.compose-tab').forEach(t => t.classList.toggle('active', t === tab));
+                if (tab.getAttribute('data-compose-tab') === 'preview') {
+                    showPreview(tabs, textarea);
+                } else {
+                    showWrite(tabs, textarea);
+                }
+            });
+        });
+    });
+});
@@ -0,0 +1,71 @@
+// Attachment uploads: an <input type="file" class="attach-input"> uploads the
+// chosen file and either inserts its Markdown into the textarea named by
+// data-target, or, with data-mode="url", sets the target input to its URL.
+
+function insertAtCursor(textarea, text) {
+    const start = textarea.selectionStart;
+    const end = textarea.selectionEnd;
+    const before = textarea.value.slice(0, start);
+    const after = textarea.value.slice(end);
+    const prefix = before && !before.endsWith('\n') ? '\n' : '';
+
+    textarea.value = before + prefix + text + '\n' + after;
+    const cursor = (before + prefix + text + '\n').length;
+    textarea.focus();
+    textarea.setSelectionRange(cursor, cursor);
+}
+
+function uploadAttachment(input) {
+    const target = document.getElementById(input.getAttribute('data-target'));
+    const status = input.parentElement.querySelector('.attach-status');
+    const file = input.files[0];
+    if (!target || !file) {
+        return;
+    }
+
+    const body = new FormData();
+    body.append('file', file);
+
+    if (status) {
+        status.textContent = `uploading ${file.name}...`;
+    }
+    input.disabled = true;
+
+    fetch('/upload', { method: 'POST', body: body, credentials: 'same-origin' })
+        .then(resp => {
+            if (resp.status === 413) {
+                return Promise.reject('file is too large');
+            }
+            if (resp.status === 415) {
+                return Promise.reject('file type is not allowed');
+            }
+            return resp.ok ? resp.json() : Promise.reject('upload failed');
+        })
+        .then(result => {
+            if (input.getAttribute('data-mode') === 'url') {
+                target.value = result.url;
+            } else {
+                insertAtCursor(target, result.markdown);
+            }
+            if (status) {
+                status.textContent = '';
+            }
+        })
+        .catch(err => {
+            if (status) {
+                status.textContent = typeof err === 'string' ? err : 'upload failed';
+            }
+        })
+        .finally(() => {
+            input.disabled = false;
+            input.value = '';
+        });
+}
+
+document.addEventListener('DOMContentLoaded', function() {
+    document.querySelectorAll('.attach-input').forEach(input => {
+        input.addEventListener('change', function() {
+            uploadAttachment(this);
+        });
+    });
+});
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// tagPath is where the threads with a tag are listed.
+func tagPath(tag string) string {
+	return "/tag/" + tag
+}
+
+// saveThreadTags replaces a thread's tags, creating any tag that doesn't
+// exist yet. Tags must already be validated by ValidateThreadTags.
+func saveThreadTags(ctx context.Context, q Querier, threadID int64, tags []string) error {
+	if len(tags) > 0 {
+		if err := q.CreateTags(ctx, tags); err != nil {
+			return err
+		}
+	}
+	return q.SetThreadTags(ctx, SetThreadTagsParams{
+		ThreadID: threadID,
+		Names:    tags,
+	})
+}
+
+// formatTags is the text form of a thread's tags, as shown in the tags
+// input of the thread forms.
+func formatTags(tags []string) string {
+	return strings.Join(tags, ", ")
+}
+
+// adminTag looks up the tag whose ID is in the named form field of an admin
+// request, writing an error response and reporting false if there's none.
+func (s *DiscussService) adminTag(w http.ResponseWriter, r *http.Request, field string) (Tag, bool) {
+	id, err := strconv.ParseInt(r.Form.Get(field), 10, 32)
+	if err != nil {
+		http.Error(w, field+": must be a tag ID", http.StatusBadRequest)
+		return Tag{}, false
+	}
+
+	tag, err := s.queries.GetTag(r.Context(), int32(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, field+": unknown tag", http.StatusBadRequest)
+			return Tag{}, false
+		}
+		s.logger.ErrorContext(r.Context(), "error getting tag", slog.String("error", err.Error()))
+		s.renderError(w, http.StatusInternalServerError)
+		return Tag{}, false
+	}
+	return tag, true
+}
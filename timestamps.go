@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// timestampFormat is how absolute times are shown on the page
+	timestampFormat = "2006-01-02 15:04:05"
+
+	// timestampTitleFormat is shown on hover, and names the timezone
+	timestampTitleFormat = "2006-01-02 15:04:05 MST"
+
+	// relativeTimeLimit is the oldest a timestamp can be and still be shown
+	// relative to now; anything older is shown as an absolute time
+	relativeTimeLimit = 7 * 24 * time.Hour
+)
+
+// Clock formats timestamps for the member viewing a page: in their
+// timezone, and relative to Now if they've asked for relative times.
+type Clock struct {
+	Location *time.Location
+	Relative bool
+	Now      time.Time
+}
+
+// loadTimezone looks up an IANA timezone name such as "Europe/Berlin".
+// "Local" is refused since it means whatever the server happens to use.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	return time.LoadLocation(name)
+}
+
+// viewerClock returns the Clock for whoever made the request. Visitors, and
+// members who haven't set a valid timezone, see server-local times.
+func (s *DiscussService) viewerClock(r *http.Request) Clock {
+	clock := Clock{Location: time.Local, Now: time.Now()}
+
+	user, err := GetUser(r)
+	if err != nil {
+		return clock
+	}
+
+	prefs, err := s.queries.GetMemberTimePreferences(r.Context(), user.ID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			s.logger.WarnContext(r.Context(), "GetMemberTimePreferences", slog.String("error", err.Error()))
+		}
+		return clock
+	}
+
+	if loc, err := loadTimezone(prefs.Timezone.String); err == nil {
+		clock.Location = loc
+	}
+	clock.Relative = prefs.RelativeTimes
+	return clock
+}
+
+// formatTimestamp renders t as a <time> element in the clock's timezone. The
+// element's text is the absolute time, or for recent times when the clock
+// is relative, how long ago it was; hovering shows the absolute time with
+// its timezone.
+func formatTimestamp(c Clock, t time.Time) template.HTML {
+	if t.IsZero() {
+		return ""
+	}
+
+	loc := c.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	local := t.In(loc)
+
+	text := local.Format(timestampFormat)
+	if c.Relative {
+		if ago, ok := relativeTime(c.Now, t); ok {
+			text = ago
+		}
+	}
+
+	return template.HTML(fmt.Sprintf(`<time datetime="%s" title="%s">%s</time>`,
+		template.HTMLEscapeString(t.UTC().Format(time.RFC3339)),
+		template.HTMLEscapeString(local.Format(timestampTitleFormat)),
+		template.HTMLEscapeString(text)))
+}
+
+// relativeTime describes t relative to now, e.g. "3 hours ago". It reports
+// false for times older than relativeTimeLimit. Times slightly in the
+// future, from clock skew, are "just now".
+func relativeTime(now, t time.Time) (string, bool) {
+	d := now.Sub(t)
+	switch {
+	case d > relativeTimeLimit:
+		return "", false
+	case d < time.Minute:
+		return "just now", true
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute") + " ago", true
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour") + " ago", true
+	default:
+		return plural(int(d/(24*time.Hour)), "day") + " ago", true
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
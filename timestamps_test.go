@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTimezone(t *testing.T) {
+	loc, err := loadTimezone("Europe/Berlin")
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", loc.String())
+
+	for _, name := range []string{"", "Local", "Not/AZone", "../etc/passwd"} {
+		_, err := loadTimezone(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		ago    time.Duration
+		want   string
+		wantOK bool
+	}{
+		{-time.Minute, "just now", true},
+		{30 * time.Second, "just now", true},
+		{time.Minute, "1 minute ago", true},
+		{59 * time.Minute, "59 minutes ago", true},
+		{time.Hour, "1 hour ago", true},
+		{3*time.Hour + 20*time.Minute, "3 hours ago", true},
+		{24 * time.Hour, "1 day ago", true},
+		{6 * 24 * time.Hour, "6 days ago", true},
+		{7 * 24 * time.Hour, "7 days ago", true},
+		{8 * 24 * time.Hour, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := relativeTime(now, now.Add(-tt.ago))
+		assert.Equal(t, tt.wantOK, ok, tt.ago)
+		assert.Equal(t, tt.want, got, tt.ago)
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	posted := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		clock Clock
+		t     time.Time
+		want  string
+	}{
+		{
+			name:  "absolute in viewer's timezone",
+			clock: Clock{Location: berlin},
+			t:     posted,
+			want:  `<time datetime="2025-06-01T09:00:00Z" title="2025-06-01 11:00:00 CEST">2025-06-01 11:00:00</time>`,
+		},
+		{
+			name:  "relative",
+			clock: Clock{Location: berlin, Relative: true, Now: posted.Add(3 * time.Hour)},
+			t:     posted,
+			want:  `<time datetime="2025-06-01T09:00:00Z" title="2025-06-01 11:00:00 CEST">3 hours ago</time>`,
+		},
+		{
+			name:  "relative but too old",
+			clock: Clock{Location: time.UTC, Relative: true, Now: posted.AddDate(0, 1, 0)},
+			t:     posted,
+			want:  `<time datetime="2025-06-01T09:00:00Z" title="2025-06-01 09:00:00 UTC">2025-06-01 09:00:00</time>`,
+		},
+		{
+			name:  "zero time",
+			clock: Clock{Location: time.UTC},
+			t:     time.Time{},
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(formatTimestamp(tt.clock, tt.t)))
+		})
+	}
+}
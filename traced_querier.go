@@ -128,12 +128,12 @@ func (t *TracedQueriesWrapper) CreateThreadPost(ctx context.Context, arg CreateT
 }
 
 // GetBoardData implements the Querier interface with tracing
-func (t *TracedQueriesWrapper) GetBoardData(ctx context.Context) (GetBoardDataRow, error) {
+func (t *TracedQueriesWrapper) GetBoardData(ctx context.Context, slug pgtype.Text) (GetBoardDataRow, error) {
 	ctx, span := t.telemetry.Tracer.Start(ctx, "GetBoardData(query)")
 	defer span.End()
 
 	start := time.Now()
-	row, err := t.wrapped.GetBoardData(ctx)
+	row, err := t.wrapped.GetBoardData(ctx, slug)
 	duration := time.Since(start).Seconds()
 
 	if err != nil {
@@ -144,6 +144,7 @@ func (t *TracedQueriesWrapper) GetBoardData(ctx context.Context) (GetBoardDataRo
 
 	span.SetAttributes(
 		attribute.Int("board.id", int(row.ID)),
+		attribute.String("board.slug", row.Slug),
 		attribute.String("board.title", row.Title),
 		attribute.Float64("request.duration", duration),
 	)
@@ -341,12 +342,12 @@ func (t *TracedQueriesWrapper) GetThreadSubjectById(ctx context.Context, id int6
 }
 
 // ListMemberThreads implements the Querier interface with tracing
-func (t *TracedQueriesWrapper) ListMemberThreads(ctx context.Context, memberID int64) ([]ListMemberThreadsRow, error) {
+func (t *TracedQueriesWrapper) ListMemberThreads(ctx context.Context, arg ListMemberThreadsParams) ([]ListMemberThreadsRow, error) {
 	ctx, span := t.telemetry.Tracer.Start(ctx, "ListMemberThreads(query)")
 	defer span.End()
 
 	start := time.Now()
-	rows, err := t.wrapped.ListMemberThreads(ctx, memberID)
+	rows, err := t.wrapped.ListMemberThreads(ctx, arg)
 	duration := time.Since(start).Seconds()
 
 	if err != nil {
@@ -356,7 +357,7 @@ func (t *TracedQueriesWrapper) ListMemberThreads(ctx context.Context, memberID i
 	}
 
 	span.SetAttributes(
-		attribute.Int64("member.id", memberID),
+		attribute.Int64("member.id", arg.MemberID),
 		attribute.Int("result.count", len(rows)),
 		attribute.Float64("request.duration", duration),
 	)
@@ -424,12 +425,12 @@ func (t *TracedQueriesWrapper) ListThreads(ctx context.Context, arg ListThreadsP
 }
 
 // UpdateBoardEditWindow implements the Querier interface with tracing
-func (t *TracedQueriesWrapper) UpdateBoardEditWindow(ctx context.Context, editWindow pgtype.Int4) error {
+func (t *TracedQueriesWrapper) UpdateBoardEditWindow(ctx context.Context, arg UpdateBoardEditWindowParams) error {
 	ctx, span := t.telemetry.Tracer.Start(ctx, "UpdateBoardEditWindow(query)")
 	defer span.End()
 
 	start := time.Now()
-	err := t.wrapped.UpdateBoardEditWindow(ctx, editWindow)
+	err := t.wrapped.UpdateBoardEditWindow(ctx, arg)
 	duration := time.Since(start).Seconds()
 
 	if err != nil {
@@ -439,7 +440,8 @@ func (t *TracedQueriesWrapper) UpdateBoardEditWindow(ctx context.Context, editWi
 	}
 
 	span.SetAttributes(
-		attribute.Int("board.edit_window", int(editWindow.Int32)),
+		attribute.Int("board.id", int(arg.ID)),
+		attribute.Int("board.edit_window", int(arg.EditWindow.Int32)),
 		attribute.Float64("request.duration", duration),
 	)
 
@@ -450,12 +452,12 @@ func (t *TracedQueriesWrapper) UpdateBoardEditWindow(ctx context.Context, editWi
 }
 
 // UpdateBoardTitle implements the Querier interface with tracing
-func (t *TracedQueriesWrapper) UpdateBoardTitle(ctx context.Context, title string) error {
+func (t *TracedQueriesWrapper) UpdateBoardTitle(ctx context.Context, arg UpdateBoardTitleParams) error {
 	ctx, span := t.telemetry.Tracer.Start(ctx, "UpdateBoardTitle(query)")
 	defer span.End()
 
 	start := time.Now()
-	err := t.wrapped.UpdateBoardTitle(ctx, title)
+	err := t.wrapped.UpdateBoardTitle(ctx, arg)
 	duration := time.Since(start).Seconds()
 
 	if err != nil {
@@ -465,7 +467,8 @@ func (t *TracedQueriesWrapper) UpdateBoardTitle(ctx context.Context, title strin
 	}
 
 	span.SetAttributes(
-		attribute.String("board.title", title),
+		attribute.Int("board.id", int(arg.ID)),
+		attribute.String("board.title", arg.Title),
 		attribute.Float64("request.duration", duration),
 	)
 
@@ -581,3 +584,1748 @@ func (t *TracedQueriesWrapper) BlockMember(ctx context.Context, id int64) error
 
 	return nil
 }
+
+// CreateThreadPostRevision implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) CreateThreadPostRevision(ctx context.Context, arg CreateThreadPostRevisionParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "CreateThreadPostRevision(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.CreateThreadPostRevision(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("threadpost.id", arg.ID),
+		attribute.Int64("member.id", arg.MemberID),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "CreateThreadPostRevision", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// GetThreadPost implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) GetThreadPost(ctx context.Context, id int64) (GetThreadPostRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "GetThreadPost(query)")
+	defer span.End()
+
+	start := time.Now()
+	row, err := t.wrapped.GetThreadPost(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return row, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("threadpost.id", id),
+		attribute.Int64("thread.id", row.ThreadID),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "GetThreadPost", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return row, nil
+}
+
+// ListThreadPostRevisions implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) ListThreadPostRevisions(ctx context.Context, threadPostID int64) ([]ListThreadPostRevisionsRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "ListThreadPostRevisions(query)")
+	defer span.End()
+
+	start := time.Now()
+	rows, err := t.wrapped.ListThreadPostRevisions(ctx, threadPostID)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return rows, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("threadpost.id", threadPostID),
+		attribute.Int("result.count", len(rows)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "ListThreadPostRevisions", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return rows, nil
+}
+
+// CreatePostReaction implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) CreatePostReaction(ctx context.Context, arg CreatePostReactionParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "CreatePostReaction(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.CreatePostReaction(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("threadpost.id", arg.ThreadPostID),
+		attribute.Int64("member.id", arg.MemberID),
+		attribute.String("reaction.emoji", arg.Emoji),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "CreatePostReaction", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// DeletePostReaction implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) DeletePostReaction(ctx context.Context, arg DeletePostReactionParams) (int64, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "DeletePostReaction(query)")
+	defer span.End()
+
+	start := time.Now()
+	count, err := t.wrapped.DeletePostReaction(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return count, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("threadpost.id", arg.ThreadPostID),
+		attribute.Int64("member.id", arg.MemberID),
+		attribute.String("reaction.emoji", arg.Emoji),
+		attribute.Int64("result.count", count),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "DeletePostReaction", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return count, nil
+}
+
+// UpdateBoardReactionEmoji implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) UpdateBoardReactionEmoji(ctx context.Context, arg UpdateBoardReactionEmojiParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "UpdateBoardReactionEmoji(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.UpdateBoardReactionEmoji(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("board.id", int(arg.ID)),
+		attribute.StringSlice("board.reaction_emoji", arg.ReactionEmoji),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "UpdateBoardReactionEmoji", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// CreateAttachment implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) CreateAttachment(ctx context.Context, arg CreateAttachmentParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "CreateAttachment(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.CreateAttachment(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.String("attachment.hash", arg.Hash),
+		attribute.String("attachment.content_type", arg.ContentType),
+		attribute.Int64("attachment.size", arg.Size),
+		attribute.Int64("member.id", arg.MemberID),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "CreateAttachment", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// GetAttachment implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) GetAttachment(ctx context.Context, hash string) (Attachment, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "GetAttachment(query)")
+	defer span.End()
+
+	start := time.Now()
+	row, err := t.wrapped.GetAttachment(ctx, hash)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return row, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.String("attachment.hash", hash),
+		attribute.String("attachment.content_type", row.ContentType),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "GetAttachment", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return row, nil
+}
+
+// CanSeeAttachment implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) CanSeeAttachment(ctx context.Context, arg CanSeeAttachmentParams) (bool, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "CanSeeAttachment(query)")
+	defer span.End()
+
+	start := time.Now()
+	visible, err := t.wrapped.CanSeeAttachment(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return visible, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.String("attachment.hash", arg.Hash),
+		attribute.Int64("member.id", arg.MemberID),
+		attribute.Bool("attachment.visible", visible),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "CanSeeAttachment", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return visible, nil
+}
+
+// LinkThreadPostAttachments implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) LinkThreadPostAttachments(ctx context.Context, arg LinkThreadPostAttachmentsParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "LinkThreadPostAttachments(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.LinkThreadPostAttachments(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("threadpost.id", arg.ThreadPostID),
+		attribute.Int("attachment.count", len(arg.Hashes)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "LinkThreadPostAttachments", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// GetSchemaVersion implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) GetSchemaVersion(ctx context.Context) (int32, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "GetSchemaVersion(query)")
+	defer span.End()
+
+	start := time.Now()
+	version, err := t.wrapped.GetSchemaVersion(ctx)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return version, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("schema.version", int(version)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "GetSchemaVersion", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return version, nil
+}
+
+// DeleteExpiredRateLimitWindows implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) DeleteExpiredRateLimitWindows(ctx context.Context, windowStart pgtype.Timestamptz) (int64, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "DeleteExpiredRateLimitWindows(query)")
+	defer span.End()
+
+	start := time.Now()
+	deleted, err := t.wrapped.DeleteExpiredRateLimitWindows(ctx, windowStart)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return deleted, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("rate_limit.windows_deleted", deleted),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "DeleteExpiredRateLimitWindows", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return deleted, nil
+}
+
+// HitRateLimitWindow implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) HitRateLimitWindow(ctx context.Context, arg HitRateLimitWindowParams) (HitRateLimitWindowRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "HitRateLimitWindow(query)")
+	defer span.End()
+
+	start := time.Now()
+	row, err := t.wrapped.HitRateLimitWindow(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return row, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("rate_limit.current_count", int(row.CurrentCount)),
+		attribute.Int("rate_limit.previous_count", int(row.PreviousCount)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "HitRateLimitWindow", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return row, nil
+}
+
+// ListRateLimits implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) ListRateLimits(ctx context.Context) ([]RateLimit, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "ListRateLimits(query)")
+	defer span.End()
+
+	start := time.Now()
+	limits, err := t.wrapped.ListRateLimits(ctx)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return limits, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("rate_limit.roles", len(limits)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "ListRateLimits", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return limits, nil
+}
+
+// UpsertRateLimit implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) UpsertRateLimit(ctx context.Context, arg UpsertRateLimitParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "UpsertRateLimit(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.UpsertRateLimit(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.String("rate_limit.role", arg.Role),
+		attribute.Int("rate_limit.requests", int(arg.Requests)),
+		attribute.Int("rate_limit.window_seconds", int(arg.WindowSeconds)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "UpsertRateLimit", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// GetMemberPostingActivity implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) GetMemberPostingActivity(ctx context.Context, arg GetMemberPostingActivityParams) (GetMemberPostingActivityRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "GetMemberPostingActivity(query)")
+	defer span.End()
+
+	start := time.Now()
+	row, err := t.wrapped.GetMemberPostingActivity(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return row, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("member.id", arg.MemberID),
+		attribute.Int64("member.recent_threads", row.RecentThreads),
+		attribute.Int64("member.recent_posts", row.RecentPosts),
+		attribute.Int64("member.duplicate_posts", row.DuplicatePosts),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "GetMemberPostingActivity", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return row, nil
+}
+
+// ListHeldPosts implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) ListHeldPosts(ctx context.Context) ([]ListHeldPostsRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "ListHeldPosts(query)")
+	defer span.End()
+
+	start := time.Now()
+	posts, err := t.wrapped.ListHeldPosts(ctx)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return posts, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("result.count", len(posts)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "ListHeldPosts", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return posts, nil
+}
+
+// ApproveHeldPost implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) ApproveHeldPost(ctx context.Context, id int64) (int64, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "ApproveHeldPost(query)")
+	defer span.End()
+
+	start := time.Now()
+	rows, err := t.wrapped.ApproveHeldPost(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return rows, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("threadpost.id", id),
+		attribute.Int64("threadpost.moderated", rows),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "ApproveHeldPost", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return rows, nil
+}
+
+// RejectHeldPost implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) RejectHeldPost(ctx context.Context, id int64) (int64, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "RejectHeldPost(query)")
+	defer span.End()
+
+	start := time.Now()
+	rows, err := t.wrapped.RejectHeldPost(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return rows, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("threadpost.id", id),
+		attribute.Int64("threadpost.moderated", rows),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "RejectHeldPost", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return rows, nil
+}
+
+// CreateReport implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) CreateReport(ctx context.Context, arg CreateReportParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "CreateReport(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.CreateReport(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("threadpost.id", arg.ThreadPostID),
+		attribute.Int64("member.id", arg.MemberID),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "CreateReport", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// ListOpenReports implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) ListOpenReports(ctx context.Context) ([]ListOpenReportsRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "ListOpenReports(query)")
+	defer span.End()
+
+	start := time.Now()
+	rows, err := t.wrapped.ListOpenReports(ctx)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return rows, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("result.count", len(rows)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "ListOpenReports", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return rows, nil
+}
+
+// ResolveReports implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) ResolveReports(ctx context.Context, arg ResolveReportsParams) (int64, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "ResolveReports(query)")
+	defer span.End()
+
+	start := time.Now()
+	resolved, err := t.wrapped.ResolveReports(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resolved, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("threadpost.id", arg.ThreadPostID),
+		attribute.String("report.resolution", arg.Resolution.String),
+		attribute.Int64("report.resolved", resolved),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "ResolveReports", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return resolved, nil
+}
+
+// HideThreadPost implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) HideThreadPost(ctx context.Context, id int64) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "HideThreadPost(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.HideThreadPost(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("threadpost.id", id),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "HideThreadPost", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// DeleteThreadPost implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) DeleteThreadPost(ctx context.Context, id int64) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "DeleteThreadPost(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.DeleteThreadPost(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("threadpost.id", id),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "DeleteThreadPost", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// LockThread implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) LockThread(ctx context.Context, id int64) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "LockThread(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.LockThread(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("thread.id", id),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "LockThread", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// IsThreadLocked implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) IsThreadLocked(ctx context.Context, id int64) (bool, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "IsThreadLocked(query)")
+	defer span.End()
+
+	start := time.Now()
+	locked, err := t.wrapped.IsThreadLocked(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return locked, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("thread.id", id),
+		attribute.Bool("thread.locked", locked),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "IsThreadLocked", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return locked, nil
+}
+
+// CreateAuditLog implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "CreateAuditLog(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.CreateAuditLog(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("member.id", arg.ActorID),
+		attribute.String("audit.action", arg.Action),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "CreateAuditLog", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// ListAuditLog implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) ListAuditLog(ctx context.Context, arg ListAuditLogParams) ([]ListAuditLogRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "ListAuditLog(query)")
+	defer span.End()
+
+	start := time.Now()
+	rows, err := t.wrapped.ListAuditLog(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return rows, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("result.count", len(rows)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "ListAuditLog", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return rows, nil
+}
+
+// GetMemberTimePreferences implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) GetMemberTimePreferences(ctx context.Context, memberID int64) (GetMemberTimePreferencesRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "GetMemberTimePreferences(query)")
+	defer span.End()
+
+	start := time.Now()
+	row, err := t.wrapped.GetMemberTimePreferences(ctx, memberID)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return row, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("member.id", memberID),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "GetMemberTimePreferences", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return row, nil
+}
+
+// UpdateBoardHideEmails implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) UpdateBoardHideEmails(ctx context.Context, arg UpdateBoardHideEmailsParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "UpdateBoardHideEmails(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.UpdateBoardHideEmails(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("board.id", int(arg.ID)),
+		attribute.Bool("board.hide_emails", arg.HideEmails),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "UpdateBoardHideEmails", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// SyncMemberTailscaleProfile implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) SyncMemberTailscaleProfile(ctx context.Context, arg SyncMemberTailscaleProfileParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "SyncMemberTailscaleProfile(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.SyncMemberTailscaleProfile(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("member.id", arg.MemberID),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "SyncMemberTailscaleProfile", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// CreateBoard implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) CreateBoard(ctx context.Context, arg CreateBoardParams) (int32, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "CreateBoard(query)")
+	defer span.End()
+
+	start := time.Now()
+	id, err := t.wrapped.CreateBoard(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return id, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.String("board.slug", arg.Slug),
+		attribute.Int("board.id", int(id)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "CreateBoard", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return id, nil
+}
+
+// GetBoardAcl implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) GetBoardAcl(ctx context.Context, id int32) ([]string, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "GetBoardAcl(query)")
+	defer span.End()
+
+	start := time.Now()
+	acl, err := t.wrapped.GetBoardAcl(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return acl, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("board.id", int(id)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "GetBoardAcl", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return acl, nil
+}
+
+// AnyBoardHidesEmails implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) AnyBoardHidesEmails(ctx context.Context) (bool, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "AnyBoardHidesEmails(query)")
+	defer span.End()
+
+	start := time.Now()
+	hides, err := t.wrapped.AnyBoardHidesEmails(ctx)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return hides, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Bool("board.hide_emails", hides),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "AnyBoardHidesEmails", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return hides, nil
+}
+
+// GetThreadBoard implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) GetThreadBoard(ctx context.Context, id int64) (GetThreadBoardRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "GetThreadBoard(query)")
+	defer span.End()
+
+	start := time.Now()
+	row, err := t.wrapped.GetThreadBoard(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return row, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("thread.id", id),
+		attribute.Int("board.id", int(row.ID)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "GetThreadBoard", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return row, nil
+}
+
+// ListBoards implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) ListBoards(ctx context.Context) ([]ListBoardsRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "ListBoards(query)")
+	defer span.End()
+
+	start := time.Now()
+	rows, err := t.wrapped.ListBoards(ctx)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return rows, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("boards.count", len(rows)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "ListBoards", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return rows, nil
+}
+
+// UpdateBoardDescription implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) UpdateBoardDescription(ctx context.Context, arg UpdateBoardDescriptionParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "UpdateBoardDescription(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.UpdateBoardDescription(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("board.id", int(arg.ID)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "UpdateBoardDescription", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// GetThreadPostBoard implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) GetThreadPostBoard(ctx context.Context, id int64) (GetThreadPostBoardRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "GetThreadPostBoard(query)")
+	defer span.End()
+
+	start := time.Now()
+	row, err := t.wrapped.GetThreadPostBoard(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return row, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("post.id", id),
+		attribute.Int("board.id", int(row.ID)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "GetThreadPostBoard", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return row, nil
+}
+
+// UpdateBoardAcl implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) UpdateBoardAcl(ctx context.Context, arg UpdateBoardAclParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "UpdateBoardAcl(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.UpdateBoardAcl(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("board.id", int(arg.ID)),
+		attribute.Int("board.acl_entries", len(arg.Acl)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "UpdateBoardAcl", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// CreateTags implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) CreateTags(ctx context.Context, names []string) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "CreateTags(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.CreateTags(ctx, names)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("tags.count", len(names)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "CreateTags", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// SetThreadTags implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) SetThreadTags(ctx context.Context, arg SetThreadTagsParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "SetThreadTags(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.SetThreadTags(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("thread.id", arg.ThreadID),
+		attribute.Int("tags.count", len(arg.Names)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "SetThreadTags", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// ListTags implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) ListTags(ctx context.Context) ([]ListTagsRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "ListTags(query)")
+	defer span.End()
+
+	start := time.Now()
+	rows, err := t.wrapped.ListTags(ctx)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return rows, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("tags.count", len(rows)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "ListTags", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return rows, nil
+}
+
+// GetTag implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) GetTag(ctx context.Context, id int32) (Tag, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "GetTag(query)")
+	defer span.End()
+
+	start := time.Now()
+	tag, err := t.wrapped.GetTag(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return tag, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("tag.id", int(id)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "GetTag", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return tag, nil
+}
+
+// RenameTag implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) RenameTag(ctx context.Context, arg RenameTagParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "RenameTag(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.RenameTag(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("tag.id", int(arg.ID)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "RenameTag", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// MergeThreadTags implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) MergeThreadTags(ctx context.Context, arg MergeThreadTagsParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "MergeThreadTags(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.MergeThreadTags(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("tag.from_id", int(arg.FromID)),
+		attribute.Int("tag.into_id", int(arg.IntoID)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "MergeThreadTags", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// DeleteTag implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) DeleteTag(ctx context.Context, id int32) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "DeleteTag(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.DeleteTag(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("tag.id", int(id)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "DeleteTag", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// CreatePoll implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) CreatePoll(ctx context.Context, arg CreatePollParams) (int64, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "CreatePoll(query)")
+	defer span.End()
+
+	start := time.Now()
+	id, err := t.wrapped.CreatePoll(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return id, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("thread.id", arg.ThreadID),
+		attribute.Int64("poll.id", id),
+		attribute.Bool("poll.multiple", arg.Multiple),
+		attribute.Bool("poll.anonymous", arg.Anonymous),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "CreatePoll", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return id, nil
+}
+
+// CreatePollOptions implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) CreatePollOptions(ctx context.Context, arg CreatePollOptionsParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "CreatePollOptions(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.CreatePollOptions(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("poll.id", arg.PollID),
+		attribute.Int("poll.options", len(arg.Labels)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "CreatePollOptions", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// GetThreadPoll implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) GetThreadPoll(ctx context.Context, threadID int64) (Poll, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "GetThreadPoll(query)")
+	defer span.End()
+
+	start := time.Now()
+	poll, err := t.wrapped.GetThreadPoll(ctx, threadID)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return poll, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("thread.id", threadID),
+		attribute.Int64("poll.id", poll.ID),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "GetThreadPoll", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return poll, nil
+}
+
+// ListPollOptions implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) ListPollOptions(ctx context.Context, arg ListPollOptionsParams) ([]ListPollOptionsRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "ListPollOptions(query)")
+	defer span.End()
+
+	start := time.Now()
+	rows, err := t.wrapped.ListPollOptions(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return rows, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("poll.id", arg.PollID),
+		attribute.Int("result.count", len(rows)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "ListPollOptions", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return rows, nil
+}
+
+// ListPollVoters implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) ListPollVoters(ctx context.Context, pollID int64) ([]ListPollVotersRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "ListPollVoters(query)")
+	defer span.End()
+
+	start := time.Now()
+	rows, err := t.wrapped.ListPollVoters(ctx, pollID)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return rows, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("poll.id", pollID),
+		attribute.Int("result.count", len(rows)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "ListPollVoters", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return rows, nil
+}
+
+// HasVotedInPoll implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) HasVotedInPoll(ctx context.Context, arg HasVotedInPollParams) (bool, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "HasVotedInPoll(query)")
+	defer span.End()
+
+	start := time.Now()
+	voted, err := t.wrapped.HasVotedInPoll(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return voted, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("poll.id", arg.PollID),
+		attribute.Int64("member.id", arg.MemberID),
+		attribute.Bool("poll.voted", voted),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "HasVotedInPoll", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return voted, nil
+}
+
+// CreatePollBallot implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) CreatePollBallot(ctx context.Context, arg CreatePollBallotParams) (int64, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "CreatePollBallot(query)")
+	defer span.End()
+
+	start := time.Now()
+	rows, err := t.wrapped.CreatePollBallot(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return rows, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("poll.id", arg.PollID),
+		attribute.Int64("member.id", arg.MemberID),
+		attribute.Int64("result.rows", rows),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "CreatePollBallot", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return rows, nil
+}
+
+// CreatePollVotes implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) CreatePollVotes(ctx context.Context, arg CreatePollVotesParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "CreatePollVotes(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.CreatePollVotes(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("poll.id", arg.PollID),
+		attribute.Int("poll.votes", len(arg.OptionIds)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "CreatePollVotes", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// SaveDraft implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) SaveDraft(ctx context.Context, arg SaveDraftParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "SaveDraft(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.SaveDraft(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("member.id", arg.MemberID),
+		attribute.Int64("thread.id", arg.ThreadID.Int64),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "SaveDraft", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// GetDraft implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) GetDraft(ctx context.Context, arg GetDraftParams) (Draft, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "GetDraft(query)")
+	defer span.End()
+
+	start := time.Now()
+	draft, err := t.wrapped.GetDraft(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return draft, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("member.id", arg.MemberID),
+		attribute.Int64("thread.id", arg.ThreadID.Int64),
+		attribute.Int64("draft.id", draft.ID),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "GetDraft", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return draft, nil
+}
+
+// DeleteDraft implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) DeleteDraft(ctx context.Context, arg DeleteDraftParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "DeleteDraft(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.DeleteDraft(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("member.id", arg.MemberID),
+		attribute.Int64("thread.id", arg.ThreadID.Int64),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "DeleteDraft", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// ListMemberDrafts implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) ListMemberDrafts(ctx context.Context, arg ListMemberDraftsParams) ([]ListMemberDraftsRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "ListMemberDrafts(query)")
+	defer span.End()
+
+	start := time.Now()
+	rows, err := t.wrapped.ListMemberDrafts(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return rows, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("member.id", arg.MemberID),
+		attribute.Int("result.count", len(rows)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "ListMemberDrafts", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return rows, nil
+}
+
+// DeleteExpiredDrafts implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) DeleteExpiredDrafts(ctx context.Context, dateSaved pgtype.Timestamptz) (int64, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "DeleteExpiredDrafts(query)")
+	defer span.End()
+
+	start := time.Now()
+	rows, err := t.wrapped.DeleteExpiredDrafts(ctx, dateSaved)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return rows, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("result.rows", rows),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "DeleteExpiredDrafts", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return rows, nil
+}
+
+// ListPostsToUnfurl implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) ListPostsToUnfurl(ctx context.Context, limit int32) ([]ListPostsToUnfurlRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "ListPostsToUnfurl(query)")
+	defer span.End()
+
+	start := time.Now()
+	rows, err := t.wrapped.ListPostsToUnfurl(ctx, limit)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return rows, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("result.count", len(rows)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "ListPostsToUnfurl", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return rows, nil
+}
+
+// MarkThreadPostUnfurled implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) MarkThreadPostUnfurled(ctx context.Context, arg MarkThreadPostUnfurledParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "MarkThreadPostUnfurled(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.MarkThreadPostUnfurled(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("post.id", arg.ID),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "MarkThreadPostUnfurled", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// GetLinkPreview implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) GetLinkPreview(ctx context.Context, url string) (LinkPreview, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "GetLinkPreview(query)")
+	defer span.End()
+
+	start := time.Now()
+	preview, err := t.wrapped.GetLinkPreview(ctx, url)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return preview, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Bool("link_preview.ok", preview.Ok),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "GetLinkPreview", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return preview, nil
+}
+
+// UpsertLinkPreview implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) UpsertLinkPreview(ctx context.Context, arg UpsertLinkPreviewParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "UpsertLinkPreview(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.UpsertLinkPreview(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Bool("link_preview.ok", arg.Ok),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "UpsertLinkPreview", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// SetThreadPostLinks implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) SetThreadPostLinks(ctx context.Context, arg SetThreadPostLinksParams) error {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "SetThreadPostLinks(query)")
+	defer span.End()
+
+	start := time.Now()
+	err := t.wrapped.SetThreadPostLinks(ctx, arg)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("post.id", arg.ThreadPostID),
+		attribute.Int("post.links", len(arg.Urls)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "SetThreadPostLinks", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+// ListThreadLinkPreviews implements the Querier interface with tracing
+func (t *TracedQueriesWrapper) ListThreadLinkPreviews(ctx context.Context, threadID int64) ([]ListThreadLinkPreviewsRow, error) {
+	ctx, span := t.telemetry.Tracer.Start(ctx, "ListThreadLinkPreviews(query)")
+	defer span.End()
+
+	start := time.Now()
+	rows, err := t.wrapped.ListThreadLinkPreviews(ctx, threadID)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return rows, fmt.Errorf("query error: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("thread.id", threadID),
+		attribute.Int("result.count", len(rows)),
+		attribute.Float64("request.duration", duration),
+	)
+
+	t.recordMetrics(ctx, "ListThreadLinkPreviews", duration)
+	span.SetStatus(codes.Ok, "")
+
+	return rows, nil
+}
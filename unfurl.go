@@ -0,0 +1,482 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	// unfurlInterval is how often new and edited posts are checked for
+	// links to unfurl
+	unfurlInterval = 30 * time.Second
+
+	// unfurlBatchSize is how many posts are unfurled each run
+	unfurlBatchSize = 20
+
+	// maxPostLinks is how many links in one post get a card
+	maxPostLinks = 3
+
+	// linkPreviewTTL is how long a fetched page, or a failure to fetch it,
+	// is reused before the page is fetched again
+	linkPreviewTTL = 24 * time.Hour
+
+	// unfurlTimeout bounds fetching one page, redirects included
+	unfurlTimeout = 5 * time.Second
+
+	// maxUnfurlBytes is how much of a page is read looking for its metadata
+	maxUnfurlBytes = 512 * 1024
+
+	// maxUnfurlRedirects is how many redirects are followed
+	maxUnfurlRedirects = 3
+
+	maxLinkTitleLength       = 200
+	maxLinkDescriptionLength = 300
+)
+
+// errBlockedAddress is returned when a link in a post resolves to an address
+// the server may not connect to on a member's behalf.
+var errBlockedAddress = errors.New("address not allowed")
+
+// blockedPrefixes are ranges outside those netip already classifies that
+// mustn't be fetched from on behalf of a post: the Tailscale CGNAT range,
+// "this network" and the IETF protocol assignments.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+}
+
+// publicAddr reports whether ip is a globally routable unicast address.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// LinkMetadata is what a page says about itself in its OpenGraph tags,
+// falling back to its <title> and meta description.
+type LinkMetadata struct {
+	Title       string
+	Description string
+	SiteName    string
+	ImageURL    string
+}
+
+// Unfurler fetches the pages linked to from posts so they can be shown as
+// cards. Only hosts on its allowlist are fetched: listed hosts may be
+// internal and resolve to private or tailnet addresses, while "*" allows any
+// other host as long as it resolves to a public address.
+type Unfurler struct {
+	hosts   []string
+	anyHost bool
+	// internal fetches from listed hosts, public from any other host
+	internal *http.Client
+	public   *http.Client
+}
+
+// NewUnfurler creates an Unfurler for a comma separated list of hosts. An
+// entry "*.example.com" matches the subdomains of example.com and "*" matches
+// any host. It returns nil if the list is empty, as unfurling is opt-in.
+func NewUnfurler(hosts string) *Unfurler {
+	u := &Unfurler{}
+	for _, host := range strings.Split(hosts, ",") {
+		host = strings.ToLower(strings.TrimSpace(host))
+		switch host {
+		case "":
+		case "*":
+			u.anyHost = true
+		default:
+			u.hosts = append(u.hosts, host)
+		}
+	}
+	if !u.anyHost && len(u.hosts) == 0 {
+		return nil
+	}
+
+	u.internal = u.newClient(true)
+	u.public = u.newClient(false)
+	return u
+}
+
+// listed reports whether host is on the allowlist by name.
+func (u *Unfurler) listed(host string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range u.hosts {
+		if entry == host {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(entry, "*"); ok && strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed reports whether a link may be unfurled.
+func (u *Unfurler) allowed(link *url.URL) bool {
+	if link.Scheme != "http" && link.Scheme != "https" || link.User != nil || link.Hostname() == "" {
+		return false
+	}
+	if u.listed(link.Hostname()) {
+		return true
+	}
+	// Any other host only on the standard ports
+	return u.anyHost && (link.Port() == "" || link.Port() == "80" || link.Port() == "443")
+}
+
+// guardedDialer dials on behalf of members, to hosts they named in a post.
+// Loopback and link-local addresses are never dialed, and unless internal is
+// set neither is anything but a public address. The check is made on the
+// address actually dialed, after DNS, so a name can't be pointed somewhere
+// else between the check and the request.
+func guardedDialer(internal bool) *net.Dialer {
+	return &net.Dialer{
+		Timeout: unfurlTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if ip.IsLoopback() || ip.IsUnspecified() || ip.IsMulticast() || ip.IsLinkLocalUnicast() {
+				return errBlockedAddress
+			}
+			if !internal && !publicAddr(ip) {
+				return errBlockedAddress
+			}
+			return nil
+		},
+	}
+}
+
+// newClient creates the HTTP client for listed hosts, which may connect to
+// private addresses, or for any other host, which may only connect to public
+// ones.
+func (u *Unfurler) newClient(internal bool) *http.Client {
+	dialer := guardedDialer(internal)
+
+	return &http.Client{
+		Timeout: unfurlTimeout,
+		Transport: &http.Transport{
+			// Never through a proxy from the environment, which would dial
+			// on our behalf
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   unfurlTimeout,
+			ResponseHeaderTimeout: unfurlTimeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       time.Minute,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxUnfurlRedirects {
+				return errors.New("unfurl: too many redirects")
+			}
+			// A listed host can only redirect to another listed host, or
+			// the internal client would reach hosts that weren't listed
+			if internal && !u.listed(req.URL.Hostname()) {
+				return fmt.Errorf("unfurl: redirect to %s not allowed", req.URL.Hostname())
+			}
+			if !u.allowed(req.URL) {
+				return fmt.Errorf("unfurl: redirect to %s not allowed", req.URL.Hostname())
+			}
+			return nil
+		},
+	}
+}
+
+// Fetch gets a page and reads its metadata.
+func (u *Unfurler) Fetch(ctx context.Context, link string) (LinkMetadata, error) {
+	target, err := url.Parse(link)
+	if err != nil {
+		return LinkMetadata{}, err
+	}
+	if !u.allowed(target) {
+		return LinkMetadata{}, fmt.Errorf("unfurl: %s not allowed", target.Hostname())
+	}
+
+	client := u.public
+	if u.listed(target.Hostname()) {
+		client = u.internal
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return LinkMetadata{}, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("User-Agent", "tdiscuss-unfurler/"+version)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return LinkMetadata{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return LinkMetadata{}, fmt.Errorf("unfurl: %s", resp.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return LinkMetadata{}, fmt.Errorf("unfurl: not a page: %q", mediaType)
+	}
+
+	return parseLinkMetadata(io.LimitReader(resp.Body, maxUnfurlBytes), resp.Request.URL), nil
+}
+
+// parseLinkMetadata reads a page's OpenGraph tags from its <head>. The image
+// is only kept if it's served over https; it's shown through the image proxy.
+func parseLinkMetadata(r io.Reader, base *url.URL) LinkMetadata {
+	var meta LinkMetadata
+	var title, description string
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+
+		token := z.Token()
+		if tt == html.EndTagToken && token.DataAtom == atom.Head || tt == html.StartTagToken && token.DataAtom == atom.Body {
+			break
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+
+		switch token.DataAtom {
+		case atom.Title:
+			if z.Next() == html.TextToken && title == "" {
+				title = string(z.Text())
+			}
+		case atom.Meta:
+			var key, content string
+			for _, attr := range token.Attr {
+				switch attr.Key {
+				case "property", "name":
+					if key == "" {
+						key = strings.ToLower(attr.Val)
+					}
+				case "content":
+					content = attr.Val
+				}
+			}
+			switch key {
+			case "og:title":
+				meta.Title = content
+			case "og:description":
+				meta.Description = content
+			case "og:site_name":
+				meta.SiteName = content
+			case "og:image":
+				meta.ImageURL = content
+			case "description":
+				description = content
+			}
+		}
+	}
+
+	if meta.Title == "" {
+		meta.Title = title
+	}
+	if meta.Description == "" {
+		meta.Description = description
+	}
+	meta.Title = truncateRunes(strings.Join(strings.Fields(meta.Title), " "), maxLinkTitleLength)
+	meta.Description = truncateRunes(strings.Join(strings.Fields(meta.Description), " "), maxLinkDescriptionLength)
+	meta.SiteName = truncateRunes(strings.TrimSpace(meta.SiteName), maxLinkTitleLength)
+
+	if meta.ImageURL != "" {
+		image, err := base.Parse(meta.ImageURL)
+		if err != nil || image.Scheme != "https" {
+			meta.ImageURL = ""
+		} else {
+			meta.ImageURL = image.String()
+		}
+	}
+
+	return meta
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with "…".
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
+
+// postLinks finds the links in a rendered post that may be unfurled, in
+// order and without repeats. Post references and other local links are left
+// out.
+func (u *Unfurler) postLinks(body string) []string {
+	var links []string
+
+	z := html.NewTokenizer(strings.NewReader(body))
+	for len(links) < maxPostLinks {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		if tt != html.StartTagToken {
+			continue
+		}
+		token := z.Token()
+		if token.DataAtom != atom.A {
+			continue
+		}
+		for _, attr := range token.Attr {
+			if attr.Key != "href" {
+				continue
+			}
+			link, err := url.Parse(attr.Val)
+			if err != nil || !link.IsAbs() || !u.allowed(link) {
+				continue
+			}
+			link.Fragment = ""
+			if href := link.String(); !slices.Contains(links, href) {
+				links = append(links, href)
+			}
+		}
+	}
+
+	return links
+}
+
+// LinkCardTemplateData is a link preview shown under a post.
+type LinkCardTemplateData struct {
+	URL         string
+	Title       string
+	Description string
+	SiteName    string
+	ImageURL    string
+}
+
+// groupLinkCards sorts a thread's link previews by post.
+func groupLinkCards(rows []ListThreadLinkPreviewsRow) map[int64][]LinkCardTemplateData {
+	cards := make(map[int64][]LinkCardTemplateData)
+	for _, row := range rows {
+		siteName := row.SiteName
+		if siteName == "" {
+			if link, err := url.Parse(row.Url); err == nil {
+				siteName = link.Hostname()
+			}
+		}
+		cards[row.ThreadPostID] = append(cards[row.ThreadPostID], LinkCardTemplateData{
+			URL:         row.Url,
+			Title:       row.Title,
+			Description: row.Description,
+			SiteName:    siteName,
+			ImageURL:    proxiedImageSrc(row.ImageUrl),
+		})
+	}
+	return cards
+}
+
+// threadLinkCards loads the link cards of a thread's posts. With unfurling
+// turned off there are none, and cards failing to load are only logged.
+func (s *DiscussService) threadLinkCards(r *http.Request, threadID int64) map[int64][]LinkCardTemplateData {
+	if s.unfurler == nil {
+		return nil
+	}
+
+	rows, err := s.queries.ListThreadLinkPreviews(r.Context(), threadID)
+	if err != nil {
+		s.logger.WarnContext(r.Context(), "error listing link previews", slog.Int64("thread_id", threadID), slog.String("error", err.Error()))
+		return nil
+	}
+	return groupLinkCards(rows)
+}
+
+// unfurlPosts fetches previews for the links in new and edited posts. Pages
+// fetched, or failed, within linkPreviewTTL aren't fetched again.
+func (s *DiscussService) unfurlPosts(ctx context.Context) error {
+	posts, err := s.queries.ListPostsToUnfurl(ctx, unfurlBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		links := s.unfurler.postLinks(post.Body.String)
+		for _, link := range links {
+			if err := s.unfurlLink(ctx, link); err != nil {
+				return err
+			}
+		}
+
+		// An empty, not nil, list so an edit that removed every link
+		// removes their cards too
+		if err := s.queries.SetThreadPostLinks(ctx, SetThreadPostLinksParams{
+			ThreadPostID: post.ID,
+			Urls:         append([]string{}, links...),
+		}); err != nil {
+			return err
+		}
+		// An edit since the post was listed leaves it to be unfurled again
+		if err := s.queries.MarkThreadPostUnfurled(ctx, MarkThreadPostUnfurledParams{
+			ID:   post.ID,
+			Body: post.Body,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if len(posts) > 0 {
+		s.logger.DebugContext(ctx, "unfurled posts", slog.Int("posts", len(posts)))
+	}
+	return nil
+}
+
+// unfurlLink fetches a page's metadata unless it's cached. A page that can't
+// be fetched is cached too, so it isn't retried with every post linking it.
+func (s *DiscussService) unfurlLink(ctx context.Context, link string) error {
+	cached, err := s.queries.GetLinkPreview(ctx, link)
+	if err == nil && cached.DateFetched.Valid && time.Since(cached.DateFetched.Time) < linkPreviewTTL {
+		return nil
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	meta, err := s.unfurler.Fetch(ctx, link)
+	if err != nil {
+		s.logger.DebugContext(ctx, "error unfurling link", slog.String("url", link), slog.String("error", err.Error()))
+	}
+
+	return s.queries.UpsertLinkPreview(ctx, UpsertLinkPreviewParams{
+		Url:         link,
+		Ok:          err == nil && meta.Title != "",
+		Title:       meta.Title,
+		Description: meta.Description,
+		SiteName:    meta.SiteName,
+		ImageUrl:    meta.ImageURL,
+	})
+}
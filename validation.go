@@ -2,9 +2,13 @@ package main
 
 import (
 	"fmt"
+	"net/mail"
 	"net/url"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -179,6 +183,20 @@ const (
 	MaxPronounsLength = 50
 	MinEditWindow     = 0
 	MaxEditWindow     = 86400 // 24 hours in seconds
+	MaxReactionEmoji  = 12
+
+	MaxRateLimitRequests      = 100000
+	MaxRateLimitWindowSeconds = 86400 // 24 hours
+	MaxReportReasonLength     = 500
+	MaxBoardSlugLength        = 32
+	MaxBoardDescriptionLength = 500
+	MaxBoardACLEntries        = 100
+	MaxTagLength              = 32
+	MaxThreadTags             = 5
+	MaxPollQuestionLength     = 255
+	MaxPollOptionLength       = 200
+	MinPollOptions            = 2
+	MaxPollOptions            = 10
 )
 
 // ValidateThreadForm validates new thread creation form
@@ -213,12 +231,27 @@ func ValidateThreadPostForm(body string) ValidationErrors {
 	return v.Errors()
 }
 
+// ValidateDraftForm validates an autosaved draft. Either field may be empty,
+// a draft is whatever has been written so far.
+func ValidateDraftForm(subject, body string) ValidationErrors {
+	v := NewValidator()
+
+	v.ValidateMaxLength("subject", subject, MaxSubjectLength)
+	v.ValidateMaxLength("body", body, MaxBodyLength)
+
+	return v.Errors()
+}
+
+// attachmentPathPattern matches the local URL of an uploaded attachment
+var attachmentPathPattern = regexp.MustCompile(`^/file/[0-9a-f]{64}$`)
+
 // ValidateProfileForm validates member profile edit form
-func ValidateProfileForm(photoURL, location, preferredName, bio, pronouns string) ValidationErrors {
+func ValidateProfileForm(photoURL, location, preferredName, bio, pronouns, timezone string) ValidationErrors {
 	v := NewValidator()
 
-	// Photo URL is optional but must be valid if provided
-	if photoURL != "" {
+	// Photo URL is optional but must be valid if provided. Uploaded
+	// attachments are referenced by their local /file/ path.
+	if photoURL != "" && !attachmentPathPattern.MatchString(photoURL) {
 		v.ValidateURL("photo_url", photoURL)
 		v.ValidateMaxLength("photo_url", photoURL, MaxURLLength)
 	}
@@ -229,6 +262,13 @@ func ValidateProfileForm(photoURL, location, preferredName, bio, pronouns string
 	v.ValidateMaxLength("bio", bio, MaxBioLength)
 	v.ValidateMaxLength("pronouns", pronouns, MaxPronounsLength)
 
+	// Timezone is optional but must be in the IANA database, e.g. "Europe/Berlin"
+	if timezone != "" {
+		if _, err := loadTimezone(timezone); err != nil {
+			v.AddError("timezone", "must be an IANA timezone such as Europe/Berlin")
+		}
+	}
+
 	return v.Errors()
 }
 
@@ -253,6 +293,209 @@ func ValidateAdminForm(boardTitle, editWindowStr string) (string, int64, Validat
 	return boardTitle, editWindow, v.Errors()
 }
 
+// boardSlugPattern matches a board's URL name, e.g. "eng" or "on-call"
+var boardSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ValidateBoardForm validates a new board's URL name, title and description
+func ValidateBoardForm(slug, title, description string) ValidationErrors {
+	v := NewValidator()
+
+	if v.ValidateRequired("board_slug", slug) {
+		v.ValidateMaxLength("board_slug", slug, MaxBoardSlugLength)
+		if !boardSlugPattern.MatchString(slug) {
+			v.AddError("board_slug", "must be lowercase letters, digits and dashes, e.g. on-call")
+		}
+	}
+
+	if v.ValidateRequired("board_title", title) {
+		v.ValidateMaxLength("board_title", title, MaxTitleLength)
+	}
+
+	v.ValidateMaxLength("board_description", description, MaxBoardDescriptionLength)
+
+	return v.Errors()
+}
+
+// ValidateReactionEmoji parses the admin's reaction set, a list of emoji short
+// names separated by spaces or commas, e.g. "+1 :tada: heart".
+func ValidateReactionEmoji(value string) ([]string, ValidationErrors) {
+	v := NewValidator()
+
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+
+	var names []string
+	for _, field := range fields {
+		name := strings.Trim(field, ":")
+		if _, ok := emojiUnicode(name); !ok {
+			v.AddError("reaction_emoji", fmt.Sprintf("unknown emoji %q", field))
+			continue
+		}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	if len(fields) == 0 {
+		v.AddError("reaction_emoji", "is required")
+	}
+	if len(names) > MaxReactionEmoji {
+		v.AddError("reaction_emoji", fmt.Sprintf("must not exceed %d emoji", MaxReactionEmoji))
+	}
+
+	return names, v.Errors()
+}
+
+// boardACLPrefixes are the kinds of access list entry other than an email
+var boardACLPrefixes = []string{"group:", "tag:", "cap:"}
+
+// ValidateBoardACL parses a board's access list, entries separated by spaces
+// or commas. Each entry is a Tailscale group ("group:hr"), a machine tag
+// ("tag:security"), a capability ("cap:example.com/cap/oncall") or a
+// member's email. An empty list makes the board visible to everyone.
+func ValidateBoardACL(value string) ([]string, ValidationErrors) {
+	v := NewValidator()
+
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+
+	// Never nil, which would be stored as NULL rather than an empty list
+	entries := []string{}
+	for _, field := range fields {
+		if !validBoardACLEntry(field) {
+			v.AddError("board_acl", fmt.Sprintf("%q is not a group:, tag: or cap: entry or an email", field))
+			continue
+		}
+		if !slices.Contains(entries, field) {
+			entries = append(entries, field)
+		}
+	}
+
+	if len(entries) > MaxBoardACLEntries {
+		v.AddError("board_acl", fmt.Sprintf("must not exceed %d entries", MaxBoardACLEntries))
+	}
+
+	return entries, v.Errors()
+}
+
+func validBoardACLEntry(entry string) bool {
+	for _, prefix := range boardACLPrefixes {
+		if name, ok := strings.CutPrefix(entry, prefix); ok {
+			return name != ""
+		}
+	}
+	addr, err := mail.ParseAddress(entry)
+	return err == nil && addr.Address == entry
+}
+
+// ValidateThreadTags parses a thread's tags, separated by spaces or commas,
+// e.g. "rfc, #postmortem". Tags are lowercased and a leading # is dropped;
+// what's left follows the same rules as a board's URL name.
+func ValidateThreadTags(value string) ([]string, ValidationErrors) {
+	v := NewValidator()
+
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+
+	// Never nil, which would be stored as NULL rather than an empty list
+	tags := []string{}
+	for _, field := range fields {
+		tag := strings.ToLower(strings.TrimPrefix(field, "#"))
+		if len(tag) > MaxTagLength || !boardSlugPattern.MatchString(tag) {
+			v.AddError("tags", fmt.Sprintf("%q must be lowercase letters, digits and dashes, at most %d characters", field, MaxTagLength))
+			continue
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	if len(tags) > MaxThreadTags {
+		v.AddError("tags", fmt.Sprintf("must not exceed %d tags", MaxThreadTags))
+	}
+
+	slices.Sort(tags)
+	return tags, v.Errors()
+}
+
+// pollClosesFormat is the value of a datetime-local input
+const pollClosesFormat = "2006-01-02T15:04"
+
+// ValidatePollForm validates the poll started with a thread. Options are
+// given one per line. closes is when voting ends, from a datetime-local
+// input in the member's timezone, and must be in the future; empty means
+// the poll never closes and gives a zero time.
+func ValidatePollForm(question, options, closes string, loc *time.Location, now time.Time) ([]string, time.Time, ValidationErrors) {
+	v := NewValidator()
+
+	v.ValidateMaxLength("poll_question", question, MaxPollQuestionLength)
+
+	var labels []string
+	for _, line := range strings.Split(options, "\n") {
+		label := strings.TrimSpace(line)
+		if label == "" {
+			continue
+		}
+		if len(label) > MaxPollOptionLength {
+			v.AddError("poll_options", fmt.Sprintf("%q must not exceed %d characters", label, MaxPollOptionLength))
+			continue
+		}
+		if slices.Contains(labels, label) {
+			v.AddError("poll_options", fmt.Sprintf("%q is listed more than once", label))
+			continue
+		}
+		labels = append(labels, label)
+	}
+	if len(labels) < MinPollOptions || len(labels) > MaxPollOptions {
+		v.AddError("poll_options", fmt.Sprintf("must have between %d and %d options, one per line", MinPollOptions, MaxPollOptions))
+	}
+
+	var closesAt time.Time
+	if closes != "" {
+		t, err := time.ParseInLocation(pollClosesFormat, closes, loc)
+		switch {
+		case err != nil:
+			v.AddError("poll_closes", "must be a date and time")
+		case !t.After(now):
+			v.AddError("poll_closes", "must be in the future")
+		default:
+			closesAt = t
+		}
+	}
+
+	return labels, closesAt, v.Errors()
+}
+
+// ValidateRateLimit validates a role's rate limit from the admin form: the
+// number of requests allowed per sliding window of windowStr seconds.
+func ValidateRateLimit(role, requestsStr, windowStr string) (int32, int32, ValidationErrors) {
+	v := NewValidator()
+
+	var requests, window int64
+	if v.ValidateRequired(role+"_requests", requestsStr) {
+		requests, _ = v.ValidateInteger(role+"_requests", requestsStr, 1, MaxRateLimitRequests)
+	}
+	if v.ValidateRequired(role+"_window", windowStr) {
+		window, _ = v.ValidateInteger(role+"_window", windowStr, 1, MaxRateLimitWindowSeconds)
+	}
+
+	return int32(requests), int32(window), v.Errors()
+}
+
+// ValidateReportForm validates the reason given when reporting a post
+func ValidateReportForm(reason string) ValidationErrors {
+	v := NewValidator()
+
+	if v.ValidateRequired("reason", reason) {
+		v.ValidateMaxLength("reason", reason, MaxReportReasonLength)
+	}
+
+	return v.Errors()
+}
+
 // SanitizeInput performs basic input sanitization
 func SanitizeInput(input string) string {
 	// Normalize line endings: CRLF -> LF, standalone CR -> LF
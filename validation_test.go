@@ -3,6 +3,7 @@ package main
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -151,6 +152,7 @@ func TestValidateProfileForm(t *testing.T) {
 		preferredName string
 		bio           string
 		pronouns      string
+		timezone      string
 		wantError     bool
 	}{
 		{
@@ -169,8 +171,27 @@ func TestValidateProfileForm(t *testing.T) {
 			preferredName: "John",
 			bio:           "Software developer",
 			pronouns:      "he/him",
+			timezone:      "America/Los_Angeles",
 			wantError:     false,
 		},
+		{
+			name:          "uploaded attachment",
+			photoURL:      "/file/" + strings.Repeat("ab", 32),
+			location:      "",
+			preferredName: "",
+			bio:           "",
+			pronouns:      "",
+			wantError:     false,
+		},
+		{
+			name:          "other relative path",
+			photoURL:      "/file/../etc/passwd",
+			location:      "",
+			preferredName: "",
+			bio:           "",
+			pronouns:      "",
+			wantError:     true,
+		},
 		{
 			name:          "invalid URL",
 			photoURL:      "not a url",
@@ -189,11 +210,31 @@ func TestValidateProfileForm(t *testing.T) {
 			pronouns:      "",
 			wantError:     true,
 		},
+		{
+			name:      "UTC timezone",
+			timezone:  "UTC",
+			wantError: false,
+		},
+		{
+			name:      "unknown timezone",
+			timezone:  "Mars/Olympus_Mons",
+			wantError: true,
+		},
+		{
+			name:      "server local timezone",
+			timezone:  "Local",
+			wantError: true,
+		},
+		{
+			name:      "timezone path traversal",
+			timezone:  "../../etc/passwd",
+			wantError: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			errors := ValidateProfileForm(tt.photoURL, tt.location, tt.preferredName, tt.bio, tt.pronouns)
+			errors := ValidateProfileForm(tt.photoURL, tt.location, tt.preferredName, tt.bio, tt.pronouns, tt.timezone)
 			if tt.wantError {
 				assert.NotEmpty(t, errors)
 			} else {
@@ -320,3 +361,301 @@ func TestSanitizeInput(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateReactionEmoji(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		want      []string
+		wantError bool
+	}{
+		{
+			name:  "space separated",
+			value: "+1 tada heart",
+			want:  []string{"+1", "tada", "heart"},
+		},
+		{
+			name:  "commas and colons",
+			value: ":+1:, :eyes:,laughing",
+			want:  []string{"+1", "eyes", "laughing"},
+		},
+		{
+			name:  "duplicates removed",
+			value: "tada tada :tada:",
+			want:  []string{"tada"},
+		},
+		{
+			name:      "unknown emoji",
+			value:     "tada notanemoji",
+			wantError: true,
+		},
+		{
+			name:      "empty",
+			value:     " , ",
+			wantError: true,
+		},
+		{
+			name:      "too many",
+			value:     "+1 -1 tada eyes heart laughing smile rocket fire star wave clap pray",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errors := ValidateReactionEmoji(tt.value)
+			if tt.wantError {
+				assert.NotEmpty(t, errors)
+			} else {
+				assert.Empty(t, errors)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateBoardACL(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		want      []string
+		wantError bool
+	}{
+		{
+			name:  "empty is public",
+			value: " ",
+			want:  []string{},
+		},
+		{
+			name:  "every kind of entry",
+			value: "group:hr, tag:security\ncap:example.com/cap/oncall alice@example.com",
+			want:  []string{"group:hr", "tag:security", "cap:example.com/cap/oncall", "alice@example.com"},
+		},
+		{
+			name:  "duplicates removed",
+			value: "group:hr group:hr",
+			want:  []string{"group:hr"},
+		},
+		{
+			name:      "missing name",
+			value:     "group:",
+			wantError: true,
+		},
+		{
+			name:      "not an email",
+			value:     "alice",
+			wantError: true,
+		},
+		{
+			name:      "display name is not an email",
+			value:     "Alice<alice@example.com>",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errors := ValidateBoardACL(tt.value)
+			if tt.wantError {
+				assert.NotEmpty(t, errors)
+			} else {
+				assert.Empty(t, errors)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateRateLimit(t *testing.T) {
+	tests := []struct {
+		name         string
+		requests     string
+		window       string
+		wantRequests int32
+		wantWindow   int32
+		wantErr      bool
+	}{
+		{name: "valid", requests: "300", window: "60", wantRequests: 300, wantWindow: 60},
+		{name: "missing requests", requests: "", window: "60", wantErr: true},
+		{name: "missing window", requests: "300", window: "", wantErr: true},
+		{name: "zero requests", requests: "0", window: "60", wantErr: true},
+		{name: "not a number", requests: "lots", window: "60", wantErr: true},
+		{name: "window too long", requests: "300", window: "86401", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, window, errs := ValidateRateLimit("member", tt.requests, tt.window)
+			if tt.wantErr {
+				assert.NotEmpty(t, errs)
+				return
+			}
+			assert.Empty(t, errs)
+			assert.Equal(t, tt.wantRequests, requests)
+			assert.Equal(t, tt.wantWindow, window)
+		})
+	}
+}
+
+func TestValidateReportForm(t *testing.T) {
+	assert.Empty(t, ValidateReportForm("spam"))
+	assert.NotEmpty(t, ValidateReportForm(""))
+	assert.NotEmpty(t, ValidateReportForm("   "))
+	assert.NotEmpty(t, ValidateReportForm(strings.Repeat("a", MaxReportReasonLength+1)))
+}
+
+func TestValidateDraftForm(t *testing.T) {
+	assert.Empty(t, ValidateDraftForm("", ""))
+	assert.Empty(t, ValidateDraftForm("half a subject", "half a thought"))
+	assert.NotEmpty(t, ValidateDraftForm(strings.Repeat("a", MaxSubjectLength+1), ""))
+	assert.NotEmpty(t, ValidateDraftForm("", strings.Repeat("a", MaxBodyLength+1)))
+}
+
+func TestValidateBoardForm(t *testing.T) {
+	tests := []struct {
+		name        string
+		slug        string
+		title       string
+		description string
+		wantError   bool
+	}{
+		{"valid", "eng", "Engineering", "", false},
+		{"dashed slug", "on-call", "On call", "Pages and handoffs", false},
+		{"empty slug", "", "Engineering", "", true},
+		{"uppercase slug", "Eng", "Engineering", "", true},
+		{"slug with spaces", "on call", "On call", "", true},
+		{"slug with trailing dash", "eng-", "Engineering", "", true},
+		{"slug too long", strings.Repeat("a", MaxBoardSlugLength+1), "Engineering", "", true},
+		{"empty title", "eng", "", "", true},
+		{"description too long", "eng", "Engineering", strings.Repeat("a", MaxBoardDescriptionLength+1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateBoardForm(tt.slug, tt.title, tt.description)
+			assert.Equal(t, tt.wantError, len(errs) > 0, errs)
+		})
+	}
+}
+
+func TestValidateThreadTags(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		want      []string
+		wantError bool
+	}{
+		{
+			name:  "empty",
+			value: "",
+			want:  []string{},
+		},
+		{
+			name:  "sorted and lowercased",
+			value: "RFC, #postmortem\nannouncement",
+			want:  []string{"announcement", "postmortem", "rfc"},
+		},
+		{
+			name:  "duplicates removed",
+			value: "rfc #rfc Rfc",
+			want:  []string{"rfc"},
+		},
+		{
+			name:      "bad characters",
+			value:     "post_mortem",
+			wantError: true,
+		},
+		{
+			name:      "too long",
+			value:     strings.Repeat("a", MaxTagLength+1),
+			wantError: true,
+		},
+		{
+			name:      "too many",
+			value:     "a b c d e f",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errors := ValidateThreadTags(tt.value)
+			if tt.wantError {
+				assert.NotEmpty(t, errors)
+			} else {
+				assert.Empty(t, errors)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidatePollForm(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		options    string
+		closes     string
+		wantLabels []string
+		wantCloses time.Time
+		wantError  bool
+	}{
+		{
+			name:       "options one per line",
+			options:    "Yes\n\n  No  \r\nMaybe",
+			wantLabels: []string{"Yes", "No", "Maybe"},
+		},
+		{
+			name:       "closes in the member's timezone",
+			options:    "Yes\nNo",
+			closes:     "2024-05-02T09:30",
+			wantLabels: []string{"Yes", "No"},
+			wantCloses: time.Date(2024, 5, 2, 7, 30, 0, 0, time.UTC),
+		},
+		{
+			name:      "too few options",
+			options:   "Yes",
+			wantError: true,
+		},
+		{
+			name:      "duplicate options",
+			options:   "Yes\nYes",
+			wantError: true,
+		},
+		{
+			name:      "too many options",
+			options:   "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk",
+			wantError: true,
+		},
+		{
+			name:      "closes in the past",
+			options:   "Yes\nNo",
+			closes:    "2024-05-01T13:00",
+			wantError: true,
+		},
+		{
+			name:      "closes is not a time",
+			options:   "Yes\nNo",
+			closes:    "tomorrow",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels, closes, errors := ValidatePollForm("Ship it?", tt.options, tt.closes, berlin, now)
+			if tt.wantError {
+				assert.NotEmpty(t, errors)
+				return
+			}
+			assert.Empty(t, errors)
+			assert.Equal(t, tt.wantLabels, labels)
+			assert.True(t, tt.wantCloses.Equal(closes), "closes = %v", closes)
+		})
+	}
+}